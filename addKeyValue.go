@@ -3,8 +3,18 @@ package main
 import (
 	"context"
 	kvpb "github.com/kv-storage/proto/kv"
+	"errors"
+	"github.com/kv-storage/audit"
+	cacheModule "github.com/kv-storage/cache"
+	"github.com/kv-storage/changefeed"
+	"github.com/kv-storage/cluster"
+	"github.com/kv-storage/leases"
 	"github.com/kv-storage/model"
+	"github.com/kv-storage/replicas"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"strings"
+	"time"
     // "log"
 )
 func (KvServerManager *KvService) SetKeyValue(
@@ -15,31 +25,237 @@ func (KvServerManager *KvService) SetKeyValue(
     key := request.Key
     value := request.Value
     // log.Printf("Received SetKeyValue request - Key: %s, Value: %s", key, value)
-    if key == "" || value == "" {
+    // The validator has checked the key, mode and TTL
+    mode := request.Mode
+    if mode == "" {
+        mode = SetModeCreate
+    }
+
+    // Leases live in this node's database, which cluster and sharded modes do not share
+    if request.LeaseId != 0 && leaseManager == nil {
         return &kvpb.SetKeyValueResponse{
-            Message:    "Either key or value missing",
+            Message:    "Leases are not available in cluster or sharded mode",
             StatusCode: int64(StatusBadRequest),
         }, nil
     }
 
-    kv := model.KV{Key: key, Value: value}
-     
-    if err := kvDbConnector.Create(&kv).Error; err != nil {
-        if strings.Contains(err.Error(), "Duplicate entry") {
+    // In cluster mode the write is committed through Raft instead
+    if clusterNode != nil {
+        // Expiry is judged by each node's clock, which would let replicas diverge
+        if request.TtlMilliseconds != 0 {
+            return &kvpb.SetKeyValueResponse{
+                Message:    "Key TTLs are not available in cluster mode",
+                StatusCode: int64(StatusBadRequest),
+            }, nil
+        }
+        if !clusterNode.IsLeader() {
+            connection, forwardCtx, err := clusterNode.ForwardToLeader(ctx)
+            if err != nil {
+                return &kvpb.SetKeyValueResponse{
+                    Message:    err.Error(),
+                    StatusCode: int64(StatusServiceUnavailable),
+                }, nil
+            }
+            return kvpb.NewKeyValueStoreClient(connection).SetKeyValue(forwardCtx, request)
+        }
+        result, err := clusterNode.Propose(&cluster.Command{
+            Op:              cluster.OpSet,
+            Key:             key,
+            Value:           value,
+            Mode:            mode,
+            Flags:           request.Flags,
+            ExpectedVersion: request.ExpectedVersion,
+        })
+        if err != nil {
             return &kvpb.SetKeyValueResponse{
-                Message:    "Key already exists",
-                StatusCode: int64(StatusConflict),
+                Message:    err.Error(),
+                StatusCode: int64(StatusServiceUnavailable),
             }, nil
         }
+        audit.Replaced(ctx, result.Previous)
         return &kvpb.SetKeyValueResponse{
-            Message:    "Database error",
-            StatusCode: int64(StatusInternalServerError),
+            Message:    result.Message,
+            StatusCode: result.StatusCode,
+            Version:    result.Version,
         }, nil
     }
-    cache.Put(key, value)
+
+    write := keyWrite{
+        key:             key,
+        value:           value,
+        mode:            mode,
+        flags:           request.Flags,
+        expectedVersion: request.ExpectedVersion,
+        leaseID:         request.LeaseId,
+        leaseOwner:      leases.Owner(ctx),
+    }
+    if request.TtlMilliseconds > 0 {
+        expiresAt := time.Now().Add(time.Duration(request.TtlMilliseconds) * time.Millisecond)
+        write.expiresAt = &expiresAt
+    }
+    statusCode, message, version, previous := writeKeyValue(kvDbConnector, write)
+    audit.Replaced(ctx, previous)
+    sessionToken := ""
+    if statusCode == StatusCreated || statusCode == StatusOK {
+        // The cache cannot expire entries, so keys with a TTL stay out of it
+        if write.expiresAt != nil {
+            cache.DeleteKey(key)
+        } else {
+            cache.Put(key, cacheModule.Entry{Value: value, Version: version, Flags: request.Flags})
+        }
+        invalidationBus.Publish(key)
+        sessionToken = replicas.NewSessionToken()
+    }
     // log.Printf("Key-Value pair set successfully - Key: %s, Value: %s", key, value)
     return &kvpb.SetKeyValueResponse{
-        Message:    "Key-value pair successfully created",
-        StatusCode: int64(StatusCreated),
+        Message:      message,
+        StatusCode:   statusCode,
+        SessionToken: sessionToken,
+        Version:      version,
     }, nil
 }
+
+const (
+    SetModeCreate = "create"
+    SetModeUpdate = "update"
+    SetModeUpsert = "upsert"
+)
+
+// keyWrite is one validated SetKeyValue.
+type keyWrite struct {
+    key             string
+    value           string
+    mode            string
+    flags           uint32
+    expectedVersion uint64
+    leaseID         uint64
+    // leaseOwner is who the write is made by, which must be who holds the lease
+    leaseOwner      string
+    expiresAt       *time.Time
+    // version, when set, is the key's new version instead of the change's sequence
+    version         uint64
+}
+
+// writeKeyValue stores the value and its change log entry in one transaction
+// and returns the key's new version and the string it replaced, nil if none;
+// the caller updates the cache once it is committed. Creating answers 201,
+// replacing 200. Two writes racing to create the same key conflict in InnoDB,
+// so the loser retries, and then replaces the winner's row or, when it may
+// only create, answers 409.
+func writeKeyValue(db *gorm.DB, write keyWrite) (int64, string, uint64, *string) {
+    var replaced *model.KV
+    var version uint64
+    var err error
+    for attempt := 0; attempt < writeRetries; attempt++ {
+        err = db.Transaction(func(tx *gorm.DB) error {
+            var stepErr error
+            replaced, version, stepErr = writeStep(tx, write)
+            return stepErr
+        })
+        if err == nil || !retryableWriteError(err) || (write.mode != SetModeUpsert && !strings.Contains(err.Error(), "Deadlock found")) {
+            break
+        }
+    }
+    switch {
+    case err == nil && replaced == nil:
+        return StatusCreated, "Key-value pair successfully created", version, nil
+    case err == nil:
+        return StatusOK, "Key-value pair successfully updated", version, stringValue(*replaced)
+    case err == leases.ErrLeaseNotFound:
+        return StatusNotFound, "Lease not found or expired", 0, nil
+    case err == errKeyExists || strings.Contains(err.Error(), "Duplicate entry"):
+        return StatusConflict, "Key already exists", 0, nil
+    case err == errVersionMismatch:
+        return StatusConflict, "Key has changed since expectedVersion", 0, nil
+    case err == gorm.ErrRecordNotFound:
+        return StatusNotFound, "Key not found", 0, nil
+    default:
+        return StatusInternalServerError, "Database error", 0, nil
+    }
+}
+
+// stringValue is a row's value when it holds a string, nil for a collection.
+func stringValue(kv model.KV) *string {
+    if kv.Type != model.TypeString {
+        return nil
+    }
+    return &kv.Value
+}
+
+// recordWrite logs a write in the change log and returns the key's new
+// version. Cluster mode passes the Raft log index, which every replica
+// agrees on; otherwise the write's place in the change log is its version.
+func recordWrite(tx *gorm.DB, version uint64, op, key, value string) (uint64, error) {
+    sequence, err := changefeed.Record(tx, op, key, value)
+    if version != 0 {
+        return version, err
+    }
+    return sequence, err
+}
+
+var (
+    errKeyExists       = errors.New("key already exists")
+    errVersionMismatch = errors.New("key version does not match")
+)
+
+// writeStep returns the row it replaced, nil when it created the key, and the key's new version.
+func writeStep(tx *gorm.DB, write keyWrite) (*model.KV, uint64, error) {
+    if write.leaseID != 0 {
+        if err := leases.Attach(tx, write.leaseID, write.leaseOwner); err != nil {
+            return nil, 0, err
+        }
+    }
+    // A key whose TTL passed no longer exists, even if it has not been reaped yet
+    if _, err := dropExpired(tx, write.key, time.Now()); err != nil {
+        return nil, 0, err
+    }
+    var leaseID *uint64
+    if write.leaseID != 0 {
+        leaseID = &write.leaseID
+    }
+
+    var existing model.KV
+    if write.mode != SetModeCreate {
+        if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key_name = ?", write.key).Limit(1).Find(&existing).Error; err != nil {
+            return nil, 0, err
+        }
+    }
+    switch {
+    case existing.ID == 0 && (write.mode == SetModeUpdate || write.expectedVersion != 0):
+        return nil, 0, gorm.ErrRecordNotFound
+    case existing.ID != 0 && write.expectedVersion != 0 && existing.Version != write.expectedVersion:
+        return nil, 0, errVersionMismatch
+    }
+    version, err := recordWrite(tx, write.version, model.ChangeSet, write.key, write.value)
+    if err != nil {
+        return nil, 0, err
+    }
+    if existing.ID == 0 {
+        kv := model.KV{Key: write.key, Value: write.value, Type: model.TypeString, Version: version, Flags: write.flags, LeaseID: leaseID, ExpiresAt: write.expiresAt}
+        if err := tx.Create(&kv).Error; err != nil {
+            if write.mode == SetModeCreate && strings.Contains(err.Error(), "Duplicate entry") {
+                return nil, 0, errKeyExists
+            }
+            return nil, 0, err
+        }
+        return nil, version, nil
+    }
+    // Like a Redis SET, replacing a value also replaces its type, lease and TTL
+    if err := deleteElements(tx, existing); err != nil {
+        return nil, 0, err
+    }
+    // Updates writes the new values into existing as well
+    replaced := existing
+    err = tx.Model(&existing).Updates(map[string]any{
+        "value":      write.value,
+        "type":       model.TypeString,
+        "version":    version,
+        "flags":      write.flags,
+        "lease_id":   leaseID,
+        "expires_at": write.expiresAt,
+    }).Error
+    if err != nil {
+        return nil, 0, err
+    }
+    return &replaced, version, nil
+}
@@ -0,0 +1,470 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kv-storage/auth"
+	"github.com/kv-storage/rbac"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// maxLineLength bounds one entry when reading a log back; keys are at most
+// 255 bytes and values appear only as hashes, so entries stay far below it.
+const maxLineLength = 1 << 20
+
+// Entry is one line of the audit log. Hash is the SHA-256 of the entry's
+// JSON with Hash left empty, and that JSON includes Prev, the hash of the
+// entry before, so changing, removing or reordering any entry breaks every
+// hash after it. With a key the hash is an HMAC-SHA256 instead, so only a
+// holder of the key can rewrite the chain from an altered entry onwards.
+type Entry struct {
+	Sequence   uint64    `json:"seq"`
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	Key        string    `json:"key,omitempty"`
+	Subject    string    `json:"subject,omitempty"`
+	AuthMethod string    `json:"authMethod,omitempty"`
+	Peer       string    `json:"peer"`
+	// OldValueSHA256 is what a set replaced or a delete removed, as the
+	// handler read it inside its write. Both are empty where there is no
+	// value, and a collection's members are not hashed.
+	OldValueSHA256 string `json:"oldValueSha256,omitempty"`
+	NewValueSHA256 string `json:"newValueSha256,omitempty"`
+	// Code is the gRPC status, empty for a BulkSet record; StatusCode and
+	// Message are the service's own result, where most failures are reported
+	Code       string `json:"code,omitempty"`
+	StatusCode int64  `json:"statusCode,omitempty"`
+	Message    string `json:"message,omitempty"`
+	Prev       string `json:"prev"`
+	Hash       string `json:"hash,omitempty"`
+}
+
+func (e *Entry) digest(key []byte) (string, error) {
+	unhashed := *e
+	unhashed.Hash = ""
+	data, err := json.Marshal(unhashed)
+	if err != nil {
+		return "", err
+	}
+	if len(key) == 0 {
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:]), nil
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// ReadKey reads the key a log's chain is made with from file, leaving out
+// trailing whitespace such as a final newline.
+func ReadKey(file string) ([]byte, error) {
+	key, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	key = bytes.TrimRight(key, " \t\r\n")
+	if len(key) == 0 {
+		return nil, fmt.Errorf("%s: the audit key is empty", file)
+	}
+	return key, nil
+}
+
+func hashValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// Log appends entries to a file, rotating it once it reaches maxBytes. The
+// rotated file keeps its name with a timestamp appended and is never
+// written again; the chain carries on into the new file.
+type Log struct {
+	path     string
+	maxBytes int64
+	key      []byte
+	proxied  func(ctx context.Context, method string, message any) bool
+	logger   *zap.Logger
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	sequence uint64
+	last     string
+	// torn is set once a partial entry cannot be taken back, and fails
+	// every later entry rather than chaining it onto the fragment
+	torn error
+}
+
+// NewLog opens path for appending, picking the chain up from its last
+// entry, or the newest rotated file's when it is empty. Entries are hashed
+// with key when it is set. proxied reports whether this node hands a call,
+// or one record of a stream, on to another node, which audits it instead so
+// it is recorded once.
+func NewLog(path string, maxBytes int64, key []byte, proxied func(ctx context.Context, method string, message any) bool, logger *zap.Logger) (*Log, error) {
+	l := &Log{path: path, maxBytes: maxBytes, key: key, proxied: proxied, logger: logger.Named("audit")}
+	last, complete, err := lastEntry(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.quarantine(complete); err != nil {
+		return nil, err
+	}
+	if last == nil {
+		// Rotated names sort oldest first
+		rotated, err := filepath.Glob(path + ".*")
+		if err != nil {
+			return nil, err
+		}
+		if len(rotated) > 0 {
+			if last, _, err = lastEntry(rotated[len(rotated)-1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if last != nil {
+		l.sequence, l.last = last.Sequence, last.Hash
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// lastEntry returns the last entry of the log at path and the length of its
+// complete lines, which falls short of the file's size when a crash tore
+// the last write.
+func lastEntry(path string) (*Entry, int64, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+	var last []byte
+	var complete int64
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		complete += int64(len(line))
+		if len(bytes.TrimSpace(line)) > 0 {
+			last = line
+		}
+	}
+	if last == nil {
+		return nil, complete, nil
+	}
+	var entry Entry
+	if err := json.Unmarshal(last, &entry); err != nil {
+		return nil, 0, fmt.Errorf("%s: last entry: %w", path, err)
+	}
+	return &entry, complete, nil
+}
+
+// quarantine moves whatever follows the last complete line of the log, left
+// by a torn write, into a file of its own beside it, and truncates the log
+// so the chain carries on from the last whole entry.
+func (l *Log) quarantine(complete int64) error {
+	info, err := os.Stat(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Size() <= complete {
+		return nil
+	}
+	file, err := os.OpenFile(l.path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	torn := make([]byte, info.Size()-complete)
+	if _, err := file.ReadAt(torn, complete); err != nil {
+		return err
+	}
+	// Named apart from rotated files, which share the log's name as a prefix
+	name := filepath.Join(filepath.Dir(l.path), "torn-"+filepath.Base(l.path)+"."+time.Now().UTC().Format("20060102T150405.000000000Z"))
+	if err := os.WriteFile(name, torn, 0o600); err != nil {
+		return err
+	}
+	if err := file.Truncate(complete); err != nil {
+		return err
+	}
+	l.logger.Error("The audit log ended in a torn entry, moved aside; the chain continues from the last whole entry",
+		zap.String("log", l.path), zap.String("file", name), zap.Int("bytes", len(torn)))
+	return nil
+}
+
+func (l *Log) open() error {
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	l.file, l.size = file, info.Size()
+	return nil
+}
+
+func (l *Log) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	rotated := l.path + "." + time.Now().UTC().Format("20060102T150405.000000000Z")
+	if err := os.Rename(l.path, rotated); err != nil {
+		return err
+	}
+	l.logger.Info("Rotated the audit log", zap.String("file", rotated))
+	return l.open()
+}
+
+// Append chains entry onto the log and writes it.
+func (l *Log) Append(entry *Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.torn != nil {
+		return l.torn
+	}
+	if l.maxBytes > 0 && l.size >= l.maxBytes {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	entry.Sequence, entry.Prev, entry.Hash = l.sequence+1, l.last, ""
+	hash, err := entry.digest(l.key)
+	if err != nil {
+		return err
+	}
+	entry.Hash = hash
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	n, err := l.file.Write(append(data, '\n'))
+	if err != nil {
+		// Take a partial entry back, so the next starts on a line of its own
+		if n > 0 {
+			if truncateErr := l.file.Truncate(l.size); truncateErr != nil {
+				l.torn = fmt.Errorf("the audit log ends in a partial entry that cannot be removed: %w", truncateErr)
+				return errors.Join(err, l.torn)
+			}
+		}
+		return err
+	}
+	l.size += int64(n)
+	l.sequence, l.last = entry.Sequence, hash
+	return nil
+}
+
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+type entryKey struct{}
+
+// Replaced records the value a call's write replaced or deleted in the call's
+// audit entry, if it has one. Only the handler can read it, inside its write,
+// so it reports it here; nil is a key that held no string.
+func Replaced(ctx context.Context, value *string) {
+	if entry, ok := ctx.Value(entryKey{}).(*Entry); ok && value != nil {
+		entry.OldValueSHA256 = hashValue(*value)
+	}
+}
+
+// audited reports whether a call changes data. Leases, locks and admin calls
+// are left to the service logs.
+func audited(method string) bool {
+	operation, _, ok := rbac.Classify(method, nil)
+	return ok && (operation == rbac.OpWrite || operation == rbac.OpDelete)
+}
+
+// start fills in who is making a call and on what.
+func (l *Log) start(ctx context.Context, method string, request any) *Entry {
+	entry := &Entry{Time: time.Now().UTC(), Method: method, Peer: auth.Address(ctx)}
+	if principal, ok := auth.FromContext(ctx); ok {
+		entry.Subject, entry.AuthMethod = principal.Subject, principal.Method
+	}
+	if keyed, ok := request.(interface{ GetKey() string }); ok {
+		entry.Key = keyed.GetKey()
+	}
+	if valued, ok := request.(interface{ GetValue() string }); ok {
+		entry.NewValueSHA256 = hashValue(valued.GetValue())
+	}
+	return entry
+}
+
+// finish records the outcome and appends the entry. An entry that cannot be
+// written is logged as an error, since the call has already happened.
+func (l *Log) finish(entry *Entry, response any, err error) {
+	entry.Code = status.Code(err).String()
+	if err != nil {
+		entry.Message = status.Convert(err).Message()
+	}
+	if result, ok := response.(interface {
+		GetStatusCode() int64
+		GetMessage() string
+	}); ok && err == nil {
+		entry.StatusCode, entry.Message = result.GetStatusCode(), result.GetMessage()
+	}
+	if err := l.Append(entry); err != nil {
+		l.logger.Error("Failed to write the audit log", zap.String("method", entry.Method), zap.String("key", entry.Key), zap.Error(err))
+	}
+}
+
+func (l *Log) UnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if !audited(info.FullMethod) || l.proxied(ctx, info.FullMethod, req) {
+		return handler(ctx, req)
+	}
+	entry := l.start(ctx, info.FullMethod, req)
+	response, err := handler(context.WithValue(ctx, entryKey{}, entry), req)
+	l.finish(entry, response, err)
+	return response, err
+}
+
+// StreamInterceptor records each record of a BulkSet as it arrives, without
+// a code since records have no result of their own, then one entry for the
+// whole stream with its outcome. Records relayed to their shard's owner are
+// left to that node.
+func (l *Log) StreamInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !audited(info.FullMethod) || l.proxied(stream.Context(), info.FullMethod, nil) {
+		return handler(srv, stream)
+	}
+	wrapped := &auditedStream{ServerStream: stream, log: l, method: info.FullMethod}
+	err := handler(srv, wrapped)
+	summary := l.start(stream.Context(), info.FullMethod, nil)
+	l.finish(summary, wrapped.response, err)
+	return err
+}
+
+type auditedStream struct {
+	grpc.ServerStream
+	log      *Log
+	method   string
+	response any
+}
+
+func (s *auditedStream) RecvMsg(message any) error {
+	if err := s.ServerStream.RecvMsg(message); err != nil {
+		return err
+	}
+	if s.log.proxied(s.Context(), s.method, message) {
+		return nil
+	}
+	entry := s.log.start(s.Context(), s.method, message)
+	entry.Message = "received"
+	if err := s.log.Append(entry); err != nil {
+		s.log.logger.Error("Failed to write the audit log", zap.String("method", s.method), zap.String("key", entry.Key), zap.Error(err))
+	}
+	return nil
+}
+
+func (s *auditedStream) SendMsg(message any) error {
+	s.response = message
+	return s.ServerStream.SendMsg(message)
+}
+
+// Verification is what Verify found in an intact chain.
+type Verification struct {
+	Entries uint64 `json:"entries"`
+	// First is the sequence the files start at, above 1 once earlier files are left out
+	First uint64 `json:"firstSequence"`
+	Last  string `json:"lastHash"`
+}
+
+// Verify checks that files, oldest first, hold one unbroken chain: every
+// hash matches its entry, and every entry follows on from the one before.
+// key is the one the log was written with, nil if none. A chain that starts
+// part-way, after older files were archived, is taken on trust from its
+// first entry.
+func Verify(files []string, key []byte) (*Verification, error) {
+	result := &Verification{}
+	var previous *Entry
+	for _, name := range files {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), maxLineLength)
+		for line := 1; scanner.Scan(); line++ {
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+			var entry Entry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				file.Close()
+				return nil, fmt.Errorf("%s:%d: %w", name, line, err)
+			}
+			if err := check(&entry, previous, key); err != nil {
+				file.Close()
+				return nil, fmt.Errorf("%s:%d: %w", name, line, err)
+			}
+			if previous == nil {
+				result.First = entry.Sequence
+			}
+			previous = &entry
+			result.Entries++
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if previous != nil {
+		result.Last = previous.Hash
+	}
+	return result, nil
+}
+
+func check(entry, previous *Entry, key []byte) error {
+	hash, err := entry.digest(key)
+	if err != nil {
+		return err
+	}
+	if hash != entry.Hash {
+		return fmt.Errorf("entry %d has been altered: its hash is %s, not %s", entry.Sequence, hash, entry.Hash)
+	}
+	if previous == nil {
+		if entry.Sequence == 1 && entry.Prev != "" {
+			return errors.New("the first entry must not follow another")
+		}
+		return nil
+	}
+	if entry.Sequence != previous.Sequence+1 {
+		return fmt.Errorf("entry %d follows entry %d: entries are missing or out of order", entry.Sequence, previous.Sequence)
+	}
+	if entry.Prev != previous.Hash {
+		return fmt.Errorf("entry %d does not follow on from entry %d", entry.Sequence, previous.Sequence)
+	}
+	return nil
+}
@@ -0,0 +1,355 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	kvpb "github.com/kv-storage/proto/kv"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+func never(ctx context.Context, method string, message any) bool { return false }
+
+// written opens a log in a directory of its own and appends an entry for
+// each key.
+func written(t *testing.T, maxBytes int64, keys ...string) (*Log, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := NewLog(path, maxBytes, nil, never, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendKeys(t, l, keys...)
+	return l, path
+}
+
+func appendKeys(t *testing.T, l *Log, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		if err := l.Append(&Entry{Method: kvpb.KeyValueStore_SetKeyValue_FullMethodName, Key: key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// files lists a log's files oldest first, as Verify takes them.
+func files(t *testing.T, path string) []string {
+	t.Helper()
+	rotated, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(rotated, path)
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func writeLines(t *testing.T, path string, lines []string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// rekeyed changes an entry's key, rehashing it when rehash is set.
+func rekeyed(t *testing.T, line string, rehash bool) string {
+	t.Helper()
+	var entry Entry
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		t.Fatal(err)
+	}
+	entry.Key = "forged"
+	if rehash {
+		hash, err := entry.digest(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry.Hash = hash
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name        string
+		tamper      func(t *testing.T, lines []string) []string
+		wantErr     string
+		wantEntries uint64
+		wantFirst   uint64
+	}{
+		{
+			name:        "intact",
+			tamper:      func(t *testing.T, lines []string) []string { return lines },
+			wantEntries: 4,
+			wantFirst:   1,
+		},
+		{
+			name:    "an altered entry",
+			tamper:  func(t *testing.T, lines []string) []string { lines[1] = rekeyed(t, lines[1], false); return lines },
+			wantErr: "entry 2 has been altered",
+		},
+		{
+			name:    "an altered entry given a fresh hash",
+			tamper:  func(t *testing.T, lines []string) []string { lines[1] = rekeyed(t, lines[1], true); return lines },
+			wantErr: "entry 3 does not follow on from entry 2",
+		},
+		{
+			name:    "a removed entry",
+			tamper:  func(t *testing.T, lines []string) []string { return append(lines[:1], lines[2:]...) },
+			wantErr: "entry 3 follows entry 1",
+		},
+		{
+			name: "reordered entries",
+			tamper: func(t *testing.T, lines []string) []string {
+				lines[1], lines[2] = lines[2], lines[1]
+				return lines
+			},
+			wantErr: "entry 3 follows entry 1",
+		},
+		{
+			name:        "the last entry removed",
+			tamper:      func(t *testing.T, lines []string) []string { return lines[:3] },
+			wantEntries: 3,
+			wantFirst:   1,
+		},
+		{
+			name:        "earlier entries archived",
+			tamper:      func(t *testing.T, lines []string) []string { return lines[2:] },
+			wantEntries: 2,
+			wantFirst:   3,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l, path := written(t, 0, "a", "b", "c", "d")
+			l.Close()
+			writeLines(t, path, test.tamper(t, readLines(t, path)))
+			result, err := Verify([]string{path}, nil)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Entries != test.wantEntries || result.First != test.wantFirst {
+				t.Fatalf("got %+v, want %d entries from %d", result, test.wantEntries, test.wantFirst)
+			}
+		})
+	}
+}
+
+func TestKeyedChain(t *testing.T) {
+	key := []byte("secret")
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := NewLog(path, 0, key, never, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendKeys(t, l, "a", "b", "c")
+	l.Close()
+	if _, err := Verify([]string{path}, key); err != nil {
+		t.Fatalf("verifying with the key: %v", err)
+	}
+	if _, err := Verify([]string{path}, nil); err == nil {
+		t.Fatal("a keyed chain verified without its key")
+	}
+
+	// Without the key, an altered entry cannot be given a hash that verifies
+	lines := readLines(t, path)
+	lines[1] = rekeyed(t, lines[1], true)
+	writeLines(t, path, lines)
+	if _, err := Verify([]string{path}, key); err == nil || !strings.Contains(err.Error(), "entry 2 has been altered") {
+		t.Fatalf("got %v, want entry 2 reported as altered", err)
+	}
+}
+
+func TestReadKey(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "audit.key")
+	if err := os.WriteFile(keyFile, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if key, err := ReadKey(keyFile); err != nil || string(key) != "secret" {
+		t.Fatalf("ReadKey = %q, %v, want secret", key, err)
+	}
+	emptyFile := filepath.Join(dir, "empty.key")
+	if err := os.WriteFile(emptyFile, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadKey(emptyFile); err == nil {
+		t.Fatal("an empty key was accepted")
+	}
+}
+
+func TestRotationContinuesTheChain(t *testing.T) {
+	// Every entry is over a byte, so each Append after the first rotates
+	l, path := written(t, 1, "a", "b", "c")
+	defer l.Close()
+	logs := files(t, path)
+	if len(logs) != 3 {
+		t.Fatalf("%d files, want one per entry", len(logs))
+	}
+	result, err := Verify(logs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Entries != 3 {
+		t.Fatalf("verified %d entries, want 3", result.Entries)
+	}
+	if _, err := Verify(logs[1:], nil); err != nil {
+		t.Fatalf("a chain whose oldest file was archived: %v", err)
+	}
+	if _, err := Verify([]string{logs[0], logs[2]}, nil); err == nil {
+		t.Fatal("a chain missing a file in the middle verified")
+	}
+}
+
+func TestNewLogResumesTheChain(t *testing.T) {
+	tests := []struct {
+		name string
+		// reopen prepares the files of a closed log holding a and b
+		reopen func(t *testing.T, path string)
+	}{
+		{"from the log", func(t *testing.T, path string) {}},
+		{"from the newest rotated file", func(t *testing.T, path string) {
+			if err := os.Rename(path, path+".20260101T000000.000000000Z"); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"after a torn entry", func(t *testing.T, path string) {
+			file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			if _, err := file.WriteString(`{"seq":3,"time":"2026-`); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l, path := written(t, 0, "a", "b")
+			l.Close()
+			test.reopen(t, path)
+
+			l, err := NewLog(path, 0, nil, never, zap.NewNop())
+			if err != nil {
+				t.Fatal(err)
+			}
+			appendKeys(t, l, "c")
+			l.Close()
+
+			lines := readLines(t, path)
+			var entry Entry
+			if err := json.Unmarshal([]byte(lines[len(lines)-1]), &entry); err != nil {
+				t.Fatal(err)
+			}
+			if entry.Sequence != 3 || entry.Key != "c" {
+				t.Fatalf("appended entry %d for %q, want entry 3 for c", entry.Sequence, entry.Key)
+			}
+			if _, err := Verify(files(t, path), nil); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestTornEntriesAreKept(t *testing.T) {
+	l, path := written(t, 0, "a")
+	l.Close()
+	fragment := `{"seq":2,"time":"2026-`
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = file.WriteString(fragment)
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err = NewLog(path, 0, nil, never, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+	torn, err := filepath.Glob(filepath.Join(filepath.Dir(path), "torn-*"))
+	if err != nil || len(torn) != 1 {
+		t.Fatalf("torn files %v, %v, want one", torn, err)
+	}
+	if data, err := os.ReadFile(torn[0]); err != nil || string(data) != fragment {
+		t.Fatalf("torn file holds %q, %v, want %q", data, err, fragment)
+	}
+	if lines := readLines(t, path); len(lines) != 1 {
+		t.Fatalf("the log holds %d lines, want the one whole entry", len(lines))
+	}
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		proxied bool
+		want    int
+	}{
+		{"a write", kvpb.KeyValueStore_SetKeyValue_FullMethodName, false, 1},
+		{"a delete", kvpb.KeyValueStore_DeleteKeyValue_FullMethodName, false, 1},
+		{"a read", kvpb.KeyValueStore_GetKeyValue_FullMethodName, false, 0},
+		{"a write proxied to another node", kvpb.KeyValueStore_SetKeyValue_FullMethodName, true, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.log")
+			l, err := NewLog(path, 0, nil, func(ctx context.Context, method string, message any) bool { return test.proxied }, zap.NewNop())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer l.Close()
+			request := &kvpb.SetKeyValueRequest{Key: "k", Value: "v"}
+			_, err = l.UnaryInterceptor(context.Background(), request, &grpc.UnaryServerInfo{FullMethod: test.method}, func(ctx context.Context, req any) (any, error) {
+				previous := "old"
+				Replaced(ctx, &previous)
+				return &kvpb.SetKeyValueResponse{StatusCode: 200, Message: "stored"}, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := Verify([]string{path}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Entries != uint64(test.want) {
+				t.Fatalf("%d entries, want %d", result.Entries, test.want)
+			}
+			if test.want == 1 {
+				var entry Entry
+				if err := json.Unmarshal([]byte(readLines(t, path)[0]), &entry); err != nil {
+					t.Fatal(err)
+				}
+				if entry.Key != "k" || entry.OldValueSHA256 != hashValue("old") || entry.NewValueSHA256 != hashValue("v") || entry.StatusCode != 200 || entry.Code != "OK" {
+					t.Fatalf("entry %+v does not record the call", entry)
+				}
+			}
+		})
+	}
+}
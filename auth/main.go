@@ -0,0 +1,319 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	MethodAPIKey      = "api-key"
+	MethodJWT         = "jwt"
+	MethodCertificate = "certificate"
+
+	// APIKeyHeader carries an API key for clients that keep Authorization for something else
+	APIKeyHeader = "x-api-key"
+
+	forwardedForHeader = "x-forwarded-for"
+)
+
+// exemptPrefixes are the methods anyone may call: load balancers probe
+// health without credentials, and reflection only describes the API.
+var exemptPrefixes = []string{"/grpc.health.v1.Health/", "/grpc.reflection."}
+
+// Principal is who a call was made by.
+type Principal struct {
+	Subject string
+	Method  string
+	Roles   []string
+}
+
+type principalKey struct{}
+
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the principal an authenticated call was made by.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
+
+// Address is the host a call came from. Calls the gateway, RESP and
+// memcached listeners make in-process carry the address they were made for
+// in x-forwarded-for.
+func Address(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+	address := p.Addr.String()
+	if p.Addr.Network() == "bufconn" {
+		// Only the last entry was added by us; the ones before came from the client
+		md, _ := metadata.FromIncomingContext(ctx)
+		if values := md.Get(forwardedForHeader); len(values) > 0 {
+			hops := strings.Split(values[len(values)-1], ",")
+			address = strings.TrimSpace(hops[len(hops)-1])
+		}
+	}
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		address = host
+	}
+	return address
+}
+
+// apiKey is one entry of the API key file, a JSON array such as
+// [{"key": "...", "subject": "billing", "roles": ["reader"]}].
+type apiKey struct {
+	Key     string   `json:"key"`
+	Subject string   `json:"subject"`
+	Roles   []string `json:"roles"`
+}
+
+// jwk is the subset of RFC 7517 we accept: RSA keys for RS256 and
+// symmetric "oct" keys for HS256.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+// Authenticator accepts static API keys, JWTs signed by a key in a local
+// JWKS, and client certificates verified by the TLS handshake.
+type Authenticator struct {
+	// keys is indexed by the SHA-256 of each key, so lookups do not leak
+	// how much of a guessed key matched
+	keys    map[[sha256.Size]byte]*Principal
+	jwtKeys map[string]any
+	parser  *jwt.Parser
+}
+
+// NewAuthenticator loads either file or both; an empty name skips it.
+func NewAuthenticator(apiKeysFile, jwksFile, issuer, audience string) (*Authenticator, error) {
+	a := &Authenticator{keys: make(map[[sha256.Size]byte]*Principal), jwtKeys: make(map[string]any)}
+	if apiKeysFile != "" {
+		var entries []apiKey
+		if err := readJSON(apiKeysFile, &entries); err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.Key == "" || entry.Subject == "" {
+				return nil, fmt.Errorf("%s: every API key needs a key and a subject", apiKeysFile)
+			}
+			a.keys[sha256.Sum256([]byte(entry.Key))] = &Principal{Subject: entry.Subject, Method: MethodAPIKey, Roles: entry.Roles}
+		}
+	}
+	if jwksFile != "" {
+		var set struct {
+			Keys []jwk `json:"keys"`
+		}
+		if err := readJSON(jwksFile, &set); err != nil {
+			return nil, err
+		}
+		for _, key := range set.Keys {
+			parsed, err := key.parse()
+			if err != nil {
+				return nil, fmt.Errorf("%s: key %q: %w", jwksFile, key.Kid, err)
+			}
+			a.jwtKeys[key.Kid] = parsed
+		}
+	}
+	options := []jwt.ParserOption{jwt.WithValidMethods([]string{"RS256", "HS256"}), jwt.WithExpirationRequired()}
+	if issuer != "" {
+		options = append(options, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+	a.parser = jwt.NewParser(options...)
+	return a, nil
+}
+
+func readJSON(file string, value any) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
+
+func (k jwk) parse() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return nil, err
+		}
+		return secret, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// keyFor picks the verification key by the token's kid, or the only key
+// there is when the token names none. The key type must match the
+// algorithm, so an RSA public key can never be used as an HMAC secret.
+func (a *Authenticator) keyFor(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := a.jwtKeys[kid]
+	if !ok && kid == "" && len(a.jwtKeys) == 1 {
+		for _, only := range a.jwtKeys {
+			key, ok = only, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	switch key.(type) {
+	case *rsa.PublicKey:
+		if token.Method.Alg() != "RS256" {
+			return nil, errors.New("RSA keys only verify RS256")
+		}
+	case []byte:
+		if token.Method.Alg() != "HS256" {
+			return nil, errors.New("symmetric keys only verify HS256")
+		}
+	}
+	return key, nil
+}
+
+// claims adds the roles claim, or an OAuth space-separated scope, to the
+// registered ones.
+type claims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles"`
+	Scope string   `json:"scope"`
+}
+
+func (a *Authenticator) parseJWT(raw string) (*Principal, error) {
+	var parsed claims
+	if _, err := a.parser.ParseWithClaims(raw, &parsed, a.keyFor); err != nil {
+		return nil, err
+	}
+	if parsed.Subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+	roles := parsed.Roles
+	if len(roles) == 0 && parsed.Scope != "" {
+		roles = strings.Fields(parsed.Scope)
+	}
+	return &Principal{Subject: parsed.Subject, Method: MethodJWT, Roles: roles}, nil
+}
+
+// Authenticate finds who made the call: a bearer JWT or API key in the
+// authorization metadata, an API key in x-api-key, or else a verified
+// client certificate, whose organizational units become its roles.
+func (a *Authenticator) Authenticate(ctx context.Context) (*Principal, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		scheme, credential, _ := strings.Cut(values[0], " ")
+		if !strings.EqualFold(scheme, "Bearer") || credential == "" {
+			return nil, status.Error(codes.Unauthenticated, "authorization must be a Bearer token")
+		}
+		return a.Check(credential)
+	}
+	if values := md.Get(APIKeyHeader); len(values) > 0 {
+		return a.lookupKey(values[0])
+	}
+	// The TLS handshake only completes with a certificate that verified against our CA bundle
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.PeerCertificates) > 0 {
+			subject := info.State.PeerCertificates[0].Subject
+			return &Principal{Subject: subject.CommonName, Method: MethodCertificate, Roles: subject.OrganizationalUnit}, nil
+		}
+	}
+	return nil, status.Error(codes.Unauthenticated, "missing credentials")
+}
+
+// Check finds who a bearer credential, a JWT or an API key, belongs to.
+func (a *Authenticator) Check(credential string) (*Principal, error) {
+	// JWTs are three dot-separated parts; API keys are opaque
+	if strings.Count(credential, ".") == 2 && len(a.jwtKeys) > 0 {
+		principal, err := a.parseJWT(credential)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid token: "+err.Error())
+		}
+		return principal, nil
+	}
+	return a.lookupKey(credential)
+}
+
+func (a *Authenticator) lookupKey(key string) (*Principal, error) {
+	if principal, ok := a.keys[sha256.Sum256([]byte(key))]; ok {
+		return principal, nil
+	}
+	return nil, status.Error(codes.Unauthenticated, "invalid API key")
+}
+
+// Exempt reports whether anyone may call method without credentials.
+func Exempt(method string) bool {
+	for _, prefix := range exemptPrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Authenticator) UnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if Exempt(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	principal, err := a.Authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(NewContext(ctx, principal), req)
+}
+
+func (a *Authenticator) StreamInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if Exempt(info.FullMethod) {
+		return handler(srv, stream)
+	}
+	principal, err := a.Authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: NewContext(stream.Context(), principal)})
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	issuer   = "https://issuer.example"
+	audience = "kv"
+)
+
+var secret = []byte("a shared secret of thirty-two by")
+
+// keys writes an API key file and a JWKS holding an RSA key, kid "rsa", and
+// a symmetric one, kid "hmac", or when only is set just the key it names.
+func keys(t *testing.T, private *rsa.PrivateKey, only string) (string, string) {
+	t.Helper()
+	encode := base64.RawURLEncoding.EncodeToString
+	set := []jwk{
+		{Kty: "RSA", Kid: "rsa", N: encode(private.N.Bytes()), E: encode(big.NewInt(int64(private.E)).Bytes())},
+		{Kty: "oct", Kid: "hmac", K: encode(secret)},
+	}
+	if only != "" {
+		set = slices.DeleteFunc(set, func(key jwk) bool { return key.Kid != only })
+	}
+	dir := t.TempDir()
+	write := func(name string, value any) string {
+		data, err := json.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	return write("keys.json", []apiKey{{Key: "secret-key", Subject: "billing", Roles: []string{"reader"}}}),
+		write("jwks.json", map[string]any{"keys": set})
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func valid(overrides jwt.MapClaims) jwt.MapClaims {
+	claims := jwt.MapClaims{"sub": "alice", "iss": issuer, "aud": audience, "exp": time.Now().Add(time.Hour).Unix(), "roles": []string{"writer"}}
+	for name, value := range overrides {
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+	}
+	return claims
+}
+
+func TestCheck(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public})
+
+	tests := []struct {
+		name       string
+		only       string
+		credential func(t *testing.T) string
+		wantRoles  []string
+	}{
+		{
+			name:       "RS256 token",
+			credential: func(t *testing.T) string { return sign(t, jwt.SigningMethodRS256, "rsa", private, valid(nil)) },
+			wantRoles:  []string{"writer"},
+		},
+		{
+			name:       "HS256 token",
+			credential: func(t *testing.T) string { return sign(t, jwt.SigningMethodHS256, "hmac", secret, valid(nil)) },
+			wantRoles:  []string{"writer"},
+		},
+		{
+			name: "scope as roles",
+			credential: func(t *testing.T) string {
+				return sign(t, jwt.SigningMethodRS256, "rsa", private, valid(jwt.MapClaims{"roles": nil, "scope": "reader admin"}))
+			},
+			wantRoles: []string{"reader", "admin"},
+		},
+		{
+			name:       "the only key, without a kid",
+			only:       "rsa",
+			credential: func(t *testing.T) string { return sign(t, jwt.SigningMethodRS256, "", private, valid(nil)) },
+			wantRoles:  []string{"writer"},
+		},
+		{
+			name:       "API key",
+			credential: func(t *testing.T) string { return "secret-key" },
+			wantRoles:  []string{"reader"},
+		},
+		{
+			name: "HS256 signed with the RSA public key",
+			credential: func(t *testing.T) string {
+				return sign(t, jwt.SigningMethodHS256, "rsa", publicPEM, valid(nil))
+			},
+		},
+		{
+			name: "HS256 signed with the RSA modulus",
+			credential: func(t *testing.T) string {
+				return sign(t, jwt.SigningMethodHS256, "rsa", private.N.Bytes(), valid(nil))
+			},
+		},
+		{
+			name:       "RS256 naming the symmetric key",
+			credential: func(t *testing.T) string { return sign(t, jwt.SigningMethodRS256, "hmac", private, valid(nil)) },
+		},
+		{
+			name: "unsigned",
+			credential: func(t *testing.T) string {
+				return sign(t, jwt.SigningMethodNone, "rsa", jwt.UnsafeAllowNoneSignatureType, valid(nil))
+			},
+		},
+		{
+			name:       "unknown kid",
+			credential: func(t *testing.T) string { return sign(t, jwt.SigningMethodRS256, "other", private, valid(nil)) },
+		},
+		{
+			name:       "no kid among several keys",
+			credential: func(t *testing.T) string { return sign(t, jwt.SigningMethodRS256, "", private, valid(nil)) },
+		},
+		{
+			name:       "wrong secret",
+			credential: func(t *testing.T) string { return sign(t, jwt.SigningMethodHS256, "hmac", []byte("guess"), valid(nil)) },
+		},
+		{
+			name: "expired",
+			credential: func(t *testing.T) string {
+				return sign(t, jwt.SigningMethodRS256, "rsa", private, valid(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}))
+			},
+		},
+		{
+			name: "no expiry",
+			credential: func(t *testing.T) string {
+				return sign(t, jwt.SigningMethodRS256, "rsa", private, valid(jwt.MapClaims{"exp": nil}))
+			},
+		},
+		{
+			name: "another issuer",
+			credential: func(t *testing.T) string {
+				return sign(t, jwt.SigningMethodRS256, "rsa", private, valid(jwt.MapClaims{"iss": "https://elsewhere.example"}))
+			},
+		},
+		{
+			name: "another audience",
+			credential: func(t *testing.T) string {
+				return sign(t, jwt.SigningMethodRS256, "rsa", private, valid(jwt.MapClaims{"aud": "billing"}))
+			},
+		},
+		{
+			name: "no subject",
+			credential: func(t *testing.T) string {
+				return sign(t, jwt.SigningMethodRS256, "rsa", private, valid(jwt.MapClaims{"sub": nil}))
+			},
+		},
+		{
+			name:       "unknown API key",
+			credential: func(t *testing.T) string { return "guessed-key" },
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			apiKeysFile, jwksFile := keys(t, private, test.only)
+			a, err := NewAuthenticator(apiKeysFile, jwksFile, issuer, audience)
+			if err != nil {
+				t.Fatal(err)
+			}
+			principal, err := a.Check(test.credential(t))
+			if test.wantRoles == nil {
+				if status.Code(err) != codes.Unauthenticated {
+					t.Fatalf("got %+v, %v, want Unauthenticated", principal, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(principal.Roles, test.wantRoles) {
+				t.Fatalf("roles %v, want %v", principal.Roles, test.wantRoles)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiKeysFile, jwksFile := keys(t, private, "")
+	a, err := NewAuthenticator(apiKeysFile, jwksFile, issuer, audience)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		md          metadata.MD
+		wantSubject string
+	}{
+		{"bearer API key", metadata.Pairs("authorization", "Bearer secret-key"), "billing"},
+		{"lower-case scheme", metadata.Pairs("authorization", "bearer secret-key"), "billing"},
+		{"API key header", metadata.Pairs(APIKeyHeader, "secret-key"), "billing"},
+		{"another scheme", metadata.Pairs("authorization", "Basic c2VjcmV0LWtleQ=="), ""},
+		{"empty bearer", metadata.Pairs("authorization", "Bearer "), ""},
+		{"nothing", metadata.MD{}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			principal, err := a.Authenticate(metadata.NewIncomingContext(context.Background(), test.md))
+			if test.wantSubject == "" {
+				if status.Code(err) != codes.Unauthenticated {
+					t.Fatalf("got %+v, %v, want Unauthenticated", principal, err)
+				}
+				return
+			}
+			if err != nil || principal.Subject != test.wantSubject || principal.Method != MethodAPIKey {
+				t.Fatalf("got %+v, %v, want %s by API key", principal, err, test.wantSubject)
+			}
+		})
+	}
+}
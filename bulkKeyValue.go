@@ -0,0 +1,452 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/kv-storage/changefeed"
+	"github.com/kv-storage/cluster"
+	"github.com/kv-storage/model"
+	kvpb "github.com/kv-storage/proto/kv"
+	"github.com/kv-storage/rbac"
+	"github.com/kv-storage/replicas"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	BulkPolicySkip      = "skip"
+	BulkPolicyOverwrite = "overwrite"
+
+	bulkBatch           = 500
+	maxReportedFailures = 1000
+)
+
+type bulkRecord struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Flags uint32 `json:"flags,omitempty"`
+}
+
+// bulkWrite is one batch of a BulkSet stream, written in a single
+// transaction. It is also the payload of the replicated command in cluster mode.
+type bulkWrite struct {
+	Overwrite bool         `json:"overwrite"`
+	Records   []bulkRecord `json:"records"`
+	// version, when set, is the new version of every key written instead of each change's sequence
+	version uint64
+}
+
+// BulkSet writes a stream of records in multi-row batches. Each batch is
+// committed before the next one is read, so a client sending faster than the
+// database keeps up is held back by the stream's flow control. In sharded
+// mode records owned by other nodes are relayed to them on a stream per owner.
+func (KvServerManager *KvService) BulkSet(stream grpc.ClientStreamingServer[kvpb.BulkSetRequest, kvpb.BulkSetResponse]) error {
+	if clusterNode != nil && !clusterNode.IsLeader() {
+		return forwardBulkSet(stream)
+	}
+
+	ingest := bulkIngest{
+		ctx:      stream.Context(),
+		pending:  make(map[string]bool),
+		shards:   make(map[string]*shardStream),
+		response: &kvpb.BulkSetResponse{},
+	}
+	for index := int64(0); ; index++ {
+		request, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		request, rejected := rejectedRecord(request, err)
+		if request == nil {
+			return err
+		}
+		// The validator fails a first record with an unknown policy, and the
+		// stream goes on skipping existing keys
+		if index == 0 && request.Policy == BulkPolicyOverwrite {
+			ingest.write.Overwrite = true
+			ingest.policy = request.Policy
+		}
+		if rejected != nil {
+			ingest.fail(index, request.Key, recordStatus(rejected), status.Convert(rejected).Message())
+			continue
+		}
+		ingest.add(index, request)
+	}
+	ingest.flush()
+	ingest.closeShards()
+
+	response := ingest.response
+	response.Message = "Bulk set finished"
+	response.StatusCode = int64(StatusOK)
+	if ingest.written {
+		response.SessionToken = replicas.NewSessionToken()
+	}
+	return stream.SendAndClose(response)
+}
+
+// rejectedRecord sorts out what Recv returned: a record, with the reason it
+// was rejected when an interceptor turned it down, or nil when the stream
+// itself failed.
+func rejectedRecord(request *kvpb.BulkSetRequest, err error) (*kvpb.BulkSetRequest, error) {
+	var rejected *rbac.RecordError
+	if errors.As(err, &rejected) {
+		request, _ := rejected.Message.(*kvpb.BulkSetRequest)
+		return request, rejected.Err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// recordStatus is the status code reported for a record an interceptor rejected.
+func recordStatus(err error) int64 {
+	switch status.Code(err) {
+	case codes.Unauthenticated:
+		return StatusUnauthorized
+	case codes.PermissionDenied:
+		return StatusForbidden
+	case codes.ResourceExhausted:
+		return StatusTooManyRequests
+	default:
+		return StatusBadRequest
+	}
+}
+
+// forwardBulkSet relays a follower's stream to the leader, which batches it
+// as usual. Records rejected here are not sent, and are reported alongside
+// the leader's failures at their index in the client's stream.
+func forwardBulkSet(stream grpc.ClientStreamingServer[kvpb.BulkSetRequest, kvpb.BulkSetResponse]) error {
+	connection, forwardCtx, err := clusterNode.ForwardToLeader(stream.Context())
+	if err != nil {
+		return stream.SendAndClose(&kvpb.BulkSetResponse{
+			Message:    err.Error(),
+			StatusCode: int64(StatusServiceUnavailable),
+		})
+	}
+	leader, err := kvpb.NewKeyValueStoreClient(connection).BulkSet(forwardCtx)
+	if err != nil {
+		return err
+	}
+	// sent maps the leader's indexes back to the client's
+	var sent []int64
+	var failures []*kvpb.BulkSetFailure
+	var policy string
+	for index := int64(0); ; index++ {
+		request, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		request, rejected := rejectedRecord(request, err)
+		if request == nil {
+			return err
+		}
+		if index == 0 {
+			policy = request.Policy
+		}
+		if rejected != nil {
+			failures = append(failures, &kvpb.BulkSetFailure{
+				Index:      index,
+				Key:        request.Key,
+				StatusCode: recordStatus(rejected),
+				Message:    status.Convert(rejected).Message(),
+			})
+			continue
+		}
+		// The leader reads the policy from the first record it gets
+		if len(sent) == 0 {
+			request.Policy = policy
+		}
+		// A failed send ends the leader's stream; CloseAndRecv reports why
+		if err := leader.Send(request); err != nil {
+			break
+		}
+		sent = append(sent, index)
+	}
+	response, err := leader.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+	for _, failure := range response.Failures {
+		failure.Index = sent[failure.Index]
+	}
+	response.Failed += int64(len(failures))
+	response.Failures = append(response.Failures, failures[:min(len(failures), max(0, maxReportedFailures-len(response.Failures)))]...)
+	return stream.SendAndClose(response)
+}
+
+// bulkIngest collects records into batches and tallies their outcomes.
+type bulkIngest struct {
+	ctx     context.Context
+	policy  string
+	write   bulkWrite
+	indexes []int64
+	// pending holds the batch's keys; a key seen again starts a new batch, so
+	// each batch writes a key at most once.
+	pending  map[string]bool
+	response *kvpb.BulkSetResponse
+	written  bool
+	// shards relay records to their owners in sharded mode, by address
+	shards map[string]*shardStream
+}
+
+// shardStream is a BulkSet stream to the owner of some of the records, with
+// the index each record it was sent had in the client's stream.
+type shardStream struct {
+	client  grpc.ClientStreamingClient[kvpb.BulkSetRequest, kvpb.BulkSetResponse]
+	indexes []int64
+	// err is why the stream could not be opened, or why a send failed
+	err error
+}
+
+func (b *bulkIngest) add(index int64, request *kvpb.BulkSetRequest) {
+	// Caught here, one bad record would otherwise fail its whole batch, or the
+	// whole stream had an interceptor rejected it
+	if err := validator.Validate(kvpb.KeyValueStore_BulkSet_FullMethodName, request); err != nil {
+		b.fail(index, request.Key, StatusBadRequest, status.Convert(err).Message())
+		return
+	}
+	if shardRouter != nil {
+		if address := shardRouter.Owner(b.ctx, request.Key); address != "" {
+			b.relay(address, index, request)
+			return
+		}
+	}
+	if b.pending[request.Key] {
+		b.flush()
+	}
+	b.pending[request.Key] = true
+	b.indexes = append(b.indexes, index)
+	b.write.Records = append(b.write.Records, bulkRecord{Key: request.Key, Value: request.Value, Flags: request.Flags})
+	if len(b.write.Records) == bulkBatch {
+		b.flush()
+	}
+}
+
+// relay sends a record to the node at address, opening its stream on the
+// first record, which carries the policy as the client's first record did.
+func (b *bulkIngest) relay(address string, index int64, request *kvpb.BulkSetRequest) {
+	shard, ok := b.shards[address]
+	if !ok {
+		shard = &shardStream{}
+		b.shards[address] = shard
+		connection, forwardCtx, err := shardRouter.Forward(b.ctx, address)
+		if err == nil {
+			shard.client, err = kvpb.NewKeyValueStoreClient(connection).BulkSet(forwardCtx)
+		}
+		shard.err = err
+	}
+	if shard.err != nil {
+		b.fail(index, request.Key, StatusServiceUnavailable, shard.err.Error())
+		return
+	}
+	record := &kvpb.BulkSetRequest{Key: request.Key, Value: request.Value, Flags: request.Flags}
+	if len(shard.indexes) == 0 {
+		record.Policy = b.policy
+	}
+	if err := shard.client.Send(record); err != nil {
+		// The owner ended the stream; closeShards reports why for the records already sent
+		shard.err = err
+		b.fail(index, request.Key, StatusServiceUnavailable, err.Error())
+		return
+	}
+	shard.indexes = append(shard.indexes, index)
+}
+
+// closeShards ends the streams to other owners and folds their outcomes in,
+// failing every record of a stream that did not finish.
+func (b *bulkIngest) closeShards() {
+	for _, shard := range b.shards {
+		if shard.client == nil {
+			continue
+		}
+		response, err := shard.client.CloseAndRecv()
+		if err == nil && response.StatusCode != StatusOK {
+			err = errors.New(response.Message)
+		}
+		if err != nil {
+			for _, index := range shard.indexes {
+				b.fail(index, "", StatusServiceUnavailable, err.Error())
+			}
+			continue
+		}
+		b.response.Created += response.Created
+		b.response.Updated += response.Updated
+		b.response.Skipped += response.Skipped
+		b.response.Failed += response.Failed
+		b.written = b.written || response.Created+response.Updated > 0
+		for _, failure := range response.Failures {
+			if len(b.response.Failures) >= maxReportedFailures {
+				break
+			}
+			failure.Index = shard.indexes[failure.Index]
+			b.response.Failures = append(b.response.Failures, failure)
+		}
+	}
+}
+
+func (b *bulkIngest) fail(index int64, key string, statusCode int64, message string) {
+	b.response.Failed++
+	if len(b.response.Failures) < maxReportedFailures {
+		b.response.Failures = append(b.response.Failures, &kvpb.BulkSetFailure{
+			Index:      index,
+			Key:        key,
+			StatusCode: statusCode,
+			Message:    message,
+		})
+	}
+}
+
+func (b *bulkIngest) flush() {
+	if len(b.write.Records) == 0 {
+		return
+	}
+	statuses, statusCode, message := commitBulk(b.write)
+	for i, record := range b.write.Records {
+		if statuses == nil {
+			b.fail(b.indexes[i], record.Key, statusCode, message)
+			continue
+		}
+		switch statuses[i] {
+		case StatusCreated:
+			b.response.Created++
+		case StatusOK:
+			b.response.Updated++
+		default:
+			b.response.Skipped++
+			continue
+		}
+		b.written = true
+		// In cluster mode Committed has already dropped the key on every node
+		if clusterNode == nil {
+			// A bulk load would flush the hot keys out of the cache, so written keys are only invalidated
+			cache.DeleteKey(record.Key)
+			invalidationBus.Publish(record.Key)
+		}
+	}
+	b.write.Records = nil
+	b.indexes = nil
+	clear(b.pending)
+}
+
+// commitBulk writes a batch locally, or through Raft in cluster mode. When
+// the batch fails as a whole the statuses are nil and the status code and
+// message say why.
+func commitBulk(write bulkWrite) ([]int64, int64, string) {
+	if clusterNode != nil {
+		payload, err := json.Marshal(write)
+		if err != nil {
+			return nil, StatusInternalServerError, err.Error()
+		}
+		result, err := clusterNode.Propose(&cluster.Command{Op: cluster.OpBulkSet, Bulk: payload})
+		if err != nil {
+			return nil, StatusServiceUnavailable, err.Error()
+		}
+		if result.StatusCode != StatusOK {
+			return nil, result.StatusCode, result.Message
+		}
+		return result.Statuses, StatusOK, result.Message
+	}
+	statuses, err := applyBulk(kvDbConnector, write)
+	if err != nil {
+		return nil, StatusInternalServerError, "Database error"
+	}
+	return statuses, StatusOK, "Batch written"
+}
+
+// applyBulk writes a batch in one transaction and returns each record's
+// outcome: 201 created, 200 overwritten or 409 skipped as existing.
+func applyBulk(db *gorm.DB, write bulkWrite) ([]int64, error) {
+	var statuses []int64
+	var err error
+	for attempt := 0; attempt < writeRetries; attempt++ {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var stepErr error
+			statuses, stepErr = bulkStep(tx, write, time.Now())
+			return stepErr
+		})
+		if err == nil || !retryableWriteError(err) {
+			break
+		}
+	}
+	return statuses, err
+}
+
+func bulkStep(tx *gorm.DB, write bulkWrite, now time.Time) ([]int64, error) {
+	keys := make([]string, len(write.Records))
+	for i, record := range write.Records {
+		keys[i] = record.Key
+	}
+	// Locking the keys, present or not, keeps concurrent writers out until we commit
+	var existing []model.KV
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key_name IN ?", keys).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	live := make(map[string]model.KV, len(existing))
+	for _, kv := range existing {
+		if kv.ExpiresAt != nil && !kv.ExpiresAt.After(now) {
+			if _, err := dropExpired(tx, kv.Key, now); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		live[kv.Key] = kv
+	}
+
+	statuses := make([]int64, len(write.Records))
+	rows := make([]model.KV, 0, len(write.Records))
+	changes := make([]model.Change, 0, len(write.Records))
+	for i, record := range write.Records {
+		kv, exists := live[record.Key]
+		switch {
+		case exists && !write.Overwrite:
+			statuses[i] = StatusConflict
+			continue
+		case exists:
+			statuses[i] = StatusOK
+			if err := deleteElements(tx, kv); err != nil {
+				return nil, err
+			}
+		default:
+			statuses[i] = StatusCreated
+		}
+		rows = append(rows, model.KV{Key: record.Key, Value: record.Value, Type: model.TypeString, Flags: record.Flags})
+		changes = append(changes, model.Change{Op: model.ChangeSet, Key: record.Key, Value: record.Value})
+	}
+	if len(rows) == 0 {
+		return statuses, nil
+	}
+	// Each write's place in the change log is its key's new version
+	if err := changefeed.RecordAll(tx, changes); err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].Version = changes[i].Sequence
+		if write.version != 0 {
+			rows[i].Version = write.version
+		}
+	}
+
+	insert := tx
+	if write.Overwrite {
+		// Like a Redis SET, overwriting also replaces the key's type, lease and TTL
+		insert = tx.Clauses(clause.OnConflict{
+			DoUpdates: append(clause.AssignmentColumns([]string{"value", "flags", "version"}), clause.Assignments(map[string]any{
+				"type":       model.TypeString,
+				"lease_id":   nil,
+				"expires_at": nil,
+			})...),
+		})
+	}
+	if err := insert.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
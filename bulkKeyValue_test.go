@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	kvpb "github.com/kv-storage/proto/kv"
+	"github.com/kv-storage/validation"
+	"google.golang.org/grpc"
+)
+
+// bulkStream feeds BulkSet its records and keeps the response.
+type bulkStream struct {
+	grpc.ServerStream
+	records  []*kvpb.BulkSetRequest
+	response *kvpb.BulkSetResponse
+}
+
+func (s *bulkStream) Context() context.Context { return context.Background() }
+
+func (s *bulkStream) Recv() (*kvpb.BulkSetRequest, error) {
+	if len(s.records) == 0 {
+		return nil, io.EOF
+	}
+	record := s.records[0]
+	s.records = s.records[1:]
+	return record, nil
+}
+
+func (s *bulkStream) SendAndClose(response *kvpb.BulkSetResponse) error {
+	s.response = response
+	return nil
+}
+
+func TestBulkSet(t *testing.T) {
+	tests := []struct {
+		name                               string
+		policy                             string
+		wantCreated, wantUpdated, wantSkip int64
+		wantValue                          string
+	}{
+		{"existing keys are skipped by default", "", 1, 0, 2, "old"},
+		{"skipping existing keys", BulkPolicySkip, 1, 0, 2, "old"},
+		{"overwriting existing keys", BulkPolicyOverwrite, 1, 2, 0, "second"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useStore(t)
+			var err error
+			if validator, err = validation.NewValidator(255, 0, ""); err != nil {
+				t.Fatal(err)
+			}
+			set(t, "kept", "old")
+			stream := &bulkStream{records: []*kvpb.BulkSetRequest{
+				{Key: "kept", Value: "first", Policy: test.policy},
+				{Key: "new", Value: "v"},
+				// A key seen twice is written in order, in a batch of its own
+				{Key: "kept", Value: "second"},
+				{Key: "", Value: "no key"},
+			}}
+			if err := (&KvService{}).BulkSet(stream); err != nil {
+				t.Fatal(err)
+			}
+			response := stream.response
+			if response.StatusCode != 200 || response.Created != test.wantCreated || response.Updated != test.wantUpdated || response.Skipped != test.wantSkip {
+				t.Fatalf("got %v", response)
+			}
+			if response.Failed != 1 || len(response.Failures) != 1 || response.Failures[0].Index != 3 {
+				t.Fatalf("failures %v, want the record without a key", response.Failures)
+			}
+			got, _ := (&KvService{}).GetKeyValue(context.Background(), &kvpb.GetKVRequest{Key: "kept"})
+			if got.Value != test.wantValue {
+				t.Fatalf("kept holds %q, want %q", got.Value, test.wantValue)
+			}
+		})
+	}
+}
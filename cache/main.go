@@ -1,9 +1,36 @@
 package cache
 
-import "sync"
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+const (
+	// generationStripes buckets keys for invalidation tracking. Two keys sharing a
+	// stripe only cost each other an occasional dropped fill, never a stale read.
+	generationStripes = 256
+	// tombstoneTTL is how long a delete keeps older writes out of the cache. A
+	// write reaches Put well within it of committing.
+	tombstoneTTL = 5 * time.Second
+)
+
+// Entry is a cached value with the metadata reads return alongside it.
+type Entry struct {
+	Value   string
+	Version uint64
+	Flags   uint32
+}
+
+// tombstone remembers the version a key was deleted at.
+type tombstone struct {
+	version uint64
+	expires time.Time
+}
 
 type node struct {
-	key, value string
+	key        string
+	entry      Entry
 	prev, next *node
 }
 
@@ -32,8 +59,8 @@ func (l *dll) moveToFront(n *node) {
 	l.head.next = n
 }
 
-func (l *dll) addToFront(key, value string) *node {
-	n := &node{key: key, value: value}
+func (l *dll) addToFront(key string, entry Entry) *node {
+	n := &node{key: key, entry: entry}
 	n.next = l.head.next
 	n.prev = l.head
 	l.head.next.prev = n
@@ -53,27 +80,37 @@ func (l *dll) removeLast() {
 }
 
 type LRUCache struct {
-	mu       sync.RWMutex
-	capacity int
-	list     *dll
+	mu          sync.RWMutex
+	capacity    int
+	list        *dll
+	tombstones  map[string]tombstone
+	generations [generationStripes]uint64
+}
+
+func stripe(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % generationStripes)
 }
 
 func NewLRUCache(cap int) *LRUCache {
-	return &LRUCache{capacity: cap, list: newDLL()}
+	return &LRUCache{capacity: cap, list: newDLL(), tombstones: make(map[string]tombstone)}
 }
 
 // Highly Optimized GET
-func (c *LRUCache) Get(key string) (string, bool) {
+func (c *LRUCache) Get(key string) (Entry, bool) {
 	// First do fast path under read lock
 	c.mu.RLock()
 	n, ok := c.list.m[key]
+	var val Entry
+	if ok {
+		val = n.entry // Put may rewrite it in place, so copy under the lock
+	}
 	c.mu.RUnlock()
 	if !ok {
-		return "", false // miss → no write-lock needed
+		return Entry{}, false // miss → no write-lock needed
 	}
 
-	val := n.value
-
 	/*
 		Key already exists → must move it to front
 		Requires write lock
@@ -88,12 +125,62 @@ func (c *LRUCache) Get(key string) (string, bool) {
 	return val, true
 }
 
-func (c *LRUCache) Put(key, value string) {
+// Put stores an authoritative value, i.e. one just written to the database.
+// Values read back from the database must go through Fill instead. Writes
+// can reach Put out of order, so an entry older than the cached one, or than
+// a recent Delete, is ignored.
+func (c *LRUCache) Put(key string, entry Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generations[stripe(key)]++
+	if c.superseded(key, entry.Version) {
+		return
+	}
+	c.put(key, entry)
+}
+
+// superseded reports whether the cache has seen key at a later version than
+// version, written or deleted.
+func (c *LRUCache) superseded(key string, version uint64) bool {
+	if n, ok := c.list.m[key]; ok && n.entry.Version > version {
+		return true
+	}
+	t, ok := c.tombstones[key]
+	if !ok {
+		return false
+	}
+	if time.Now().After(t.expires) {
+		delete(c.tombstones, key)
+		return false
+	}
+	return t.version >= version
+}
+
+// Generation must be read before querying the database for a value that will later be passed to Fill.
+func (c *LRUCache) Generation(key string) uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.generations[stripe(key)]
+}
+
+// Fill caches a value read from the database, unless a Put, DeleteKey or Flush
+// touched the key since generation was taken: the value may predate that write.
+func (c *LRUCache) Fill(key string, entry Entry, generation uint64) bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if c.generations[stripe(key)] != generation {
+		return false
+	}
+	c.put(key, entry)
+	return true
+}
+
+func (c *LRUCache) put(key string, entry Entry) {
 	if n, ok := c.list.m[key]; ok {
-		n.value = value
+		n.entry = entry
 		c.list.moveToFront(n)
 		return
 	}
@@ -102,16 +189,51 @@ func (c *LRUCache) Put(key, value string) {
 		c.list.removeLast()
 	}
 
-	c.list.addToFront(key, value)
+	c.list.addToFront(key, entry)
 }
 
 func (c *LRUCache) DeleteKey(key string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	c.generations[stripe(key)]++
+	if n, ok := c.list.m[key]; ok {
+		n.prev.next = n.next
+		n.next.prev = n.prev
+		delete(c.list.m, key)
+	}
+}
+
+// Delete removes a key deleted from the database at version, and leaves a
+// tombstone so a slower write of an earlier version cannot cache it again.
+func (c *LRUCache) Delete(key string, version uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generations[stripe(key)]++
 	if n, ok := c.list.m[key]; ok {
 		n.prev.next = n.next
 		n.next.prev = n.prev
 		delete(c.list.m, key)
 	}
+	now := time.Now()
+	if len(c.tombstones) >= c.capacity {
+		for k, t := range c.tombstones {
+			if now.After(t.expires) {
+				delete(c.tombstones, k)
+			}
+		}
+	}
+	c.tombstones[key] = tombstone{version: version, expires: now.Add(tombstoneTTL)}
+}
+
+// Flush drops every entry, used when this node can no longer trust its contents.
+func (c *LRUCache) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.list = newDLL()
+	for i := range c.generations {
+		c.generations[i]++
+	}
 }
@@ -0,0 +1,208 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// store stands in for MySQL: the source of truth the cache sits in front of.
+type store struct {
+	mu   sync.Mutex
+	rows map[string]string
+}
+
+func (s *store) get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.rows[key]
+	return value, ok
+}
+
+func (s *store) set(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rows[key] = value
+}
+
+func (s *store) delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.rows, key)
+}
+
+// The interleaving GetKeyValue used to allow: the read sees the row, a delete
+// commits and invalidates, then the read's unconditional Put restores the row.
+func TestUnconditionalFillResurrectsDeletedKey(t *testing.T) {
+	c := NewLRUCache(10)
+	db := &store{rows: map[string]string{"k": "v1"}}
+
+	value, _ := db.get("k")         // GetKeyValue reads the row...
+	db.delete("k")                  // ...DeleteKeyValue commits...
+	c.DeleteKey("k")                // ...and invalidates...
+	c.Put("k", Entry{Value: value}) // ...then the stale fill lands.
+
+	if got, ok := c.Get("k"); !ok || got.Value != "v1" {
+		t.Fatalf("expected the anomaly to serve the deleted value, got %q, %v", got.Value, ok)
+	}
+}
+
+func TestFillAfterDeleteIsDropped(t *testing.T) {
+	c := NewLRUCache(10)
+	db := &store{rows: map[string]string{"k": "v1"}}
+
+	generation := c.Generation("k")
+	value, _ := db.get("k")
+	db.delete("k")
+	c.DeleteKey("k")
+
+	if c.Fill("k", Entry{Value: value}, generation) {
+		t.Fatal("fill older than the delete was accepted")
+	}
+	if got, ok := c.Get("k"); ok {
+		t.Fatalf("deleted key is still served: %q", got.Value)
+	}
+}
+
+func TestFillAfterOverwriteIsDropped(t *testing.T) {
+	c := NewLRUCache(10)
+	db := &store{rows: map[string]string{"k": "v1"}}
+
+	generation := c.Generation("k")
+	value, _ := db.get("k")
+	db.set("k", "v2")
+	c.Put("k", Entry{Value: "v2"})
+
+	if c.Fill("k", Entry{Value: value}, generation) {
+		t.Fatal("fill older than the overwrite was accepted")
+	}
+	if got, _ := c.Get("k"); got.Value != "v2" {
+		t.Fatalf("expected v2, got %q", got.Value)
+	}
+}
+
+func TestFillAfterFlushIsDropped(t *testing.T) {
+	c := NewLRUCache(10)
+
+	generation := c.Generation("k")
+	c.Flush()
+
+	if c.Fill("k", Entry{Value: "v1"}, generation) {
+		t.Fatal("fill older than the flush was accepted")
+	}
+}
+
+func TestFillWithoutInterveningWriteIsCached(t *testing.T) {
+	c := NewLRUCache(10)
+
+	if !c.Fill("k", Entry{Value: "v1"}, c.Generation("k")) {
+		t.Fatal("uncontended fill was dropped")
+	}
+	if got, ok := c.Get("k"); !ok || got.Value != "v1" {
+		t.Fatalf("expected v1, got %q, %v", got.Value, ok)
+	}
+}
+
+// Hammers the read-through path against writers and deleters. Once everyone
+// stops, every cached entry must match the store; run with -race. Writers are
+// serialized among themselves so only fills can race with them.
+func TestConcurrentFillsNeverOutliveWrites(t *testing.T) {
+	const (
+		keys       = 8
+		iterations = 2000
+	)
+	c := NewLRUCache(keys)
+	db := &store{rows: make(map[string]string)}
+
+	var writes sync.Mutex
+	var wg sync.WaitGroup
+	for worker := 0; worker < 4; worker++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				key := strconv.Itoa(i % keys)
+				if _, ok := c.Get(key); ok {
+					continue
+				}
+				generation := c.Generation(key)
+				if value, ok := db.get(key); ok {
+					c.Fill(key, Entry{Value: value}, generation)
+				}
+			}
+		}()
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				key := strconv.Itoa(i % keys)
+				value := strconv.Itoa(worker*iterations + i)
+				writes.Lock()
+				db.set(key, value)
+				c.Put(key, Entry{Value: value})
+				writes.Unlock()
+			}
+		}(worker)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				key := strconv.Itoa((i * 3) % keys)
+				writes.Lock()
+				db.delete(key)
+				c.DeleteKey(key)
+				writes.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < keys; i++ {
+		key := strconv.Itoa(i)
+		cached, inCache := c.Get(key)
+		stored, inStore := db.get(key)
+		if inCache && (!inStore || cached.Value != stored) {
+			t.Errorf("key %s: cache has %q, store has %q (present=%v)", key, cached.Value, stored, inStore)
+		}
+	}
+}
+
+func TestPutKeepsTheLatestVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		steps     func(c *LRUCache)
+		wantValue string
+		wantOK    bool
+	}{
+		{"a newer write replaces an older one", func(c *LRUCache) {
+			c.Put("k", Entry{Value: "v1", Version: 1})
+			c.Put("k", Entry{Value: "v2", Version: 2})
+		}, "v2", true},
+		{"an older write arriving late is ignored", func(c *LRUCache) {
+			c.Put("k", Entry{Value: "v2", Version: 2})
+			c.Put("k", Entry{Value: "v1", Version: 1})
+		}, "v2", true},
+		{"a write older than a delete is ignored", func(c *LRUCache) {
+			c.Delete("k", 3)
+			c.Put("k", Entry{Value: "v2", Version: 2})
+		}, "", false},
+		{"a write after a delete is cached", func(c *LRUCache) {
+			c.Delete("k", 3)
+			c.Put("k", Entry{Value: "v4", Version: 4})
+		}, "v4", true},
+		{"an expired tombstone no longer holds writes back", func(c *LRUCache) {
+			c.Delete("k", 3)
+			c.tombstones["k"] = tombstone{version: 3, expires: time.Now().Add(-time.Second)}
+			c.Put("k", Entry{Value: "v2", Version: 2})
+		}, "v2", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewLRUCache(10)
+			test.steps(c)
+			got, ok := c.Get("k")
+			if ok != test.wantOK || got.Value != test.wantValue {
+				t.Fatalf("got %q, %v, want %q, %v", got.Value, ok, test.wantValue, test.wantOK)
+			}
+		})
+	}
+}
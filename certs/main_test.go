@@ -0,0 +1,212 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// authority signs certificates for localhost.
+type authority struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	serial      int64
+}
+
+func newAuthority(t *testing.T, name string) *authority {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &authority{certificate: certificate, key: key, serial: 1}
+}
+
+// write saves the CA bundle and a fresh node certificate it signed under dir.
+func (a *authority) write(t *testing.T, dir, name string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.serial++
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(a.serial),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.certificate, &key.PublicKey, a.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []struct {
+		name  string
+		block *pem.Block
+	}{
+		{"ca.crt", &pem.Block{Type: "CERTIFICATE", Bytes: a.certificate.Raw}},
+		{"node.crt", &pem.Block{Type: "CERTIFICATE", Bytes: der}},
+		{"node.key", &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}},
+	}
+	// Each file is newer than the last and than any earlier write, so a
+	// reload part way through is followed by another once all are written
+	modified := time.Now().Add(time.Duration(a.serial) * time.Second)
+	for i, file := range files {
+		path := filepath.Join(dir, file.name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(file.block), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		at := modified.Add(time.Duration(i) * time.Millisecond)
+		if err := os.Chtimes(path, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// handshake connects client to server and returns the name on the certificate the server presented.
+func handshake(server, client *tls.Config) (string, error) {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", server)
+	if err != nil {
+		return "", err
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// A byte the client can only read once both sides accepted the handshake
+		if conn.(*tls.Conn).Handshake() == nil {
+			conn.Write([]byte{1})
+		}
+	}()
+	client = client.Clone()
+	client.ServerName = "localhost"
+	conn, err := tls.Dial("tcp", listener.Addr().String(), client)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if _, err := conn.Read(make([]byte, 1)); err != nil {
+		return "", err
+	}
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName, nil
+}
+
+func TestReloaderPicksUpRotatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	first := newAuthority(t, "first CA")
+	first.write(t, dir, "node 1")
+	reloader, err := NewReloader(filepath.Join(dir, "node.crt"), filepath.Join(dir, "node.key"), filepath.Join(dir, "ca.crt"), ClientAuthRequire, 10*time.Millisecond, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, err := handshake(reloader.ServerConfig(), reloader.ClientConfig()); err != nil || name != "node 1" {
+		t.Fatalf("handshake = %q, %v", name, err)
+	}
+
+	// A client holding a certificate from another CA is turned away
+	stranger := newAuthority(t, "stranger CA")
+	strangerDir := t.TempDir()
+	stranger.write(t, strangerDir, "stranger")
+	strangerCertificate, err := tls.LoadX509KeyPair(filepath.Join(strangerDir, "node.crt"), filepath.Join(strangerDir, "node.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	strangerClient := reloader.ClientConfig()
+	strangerClient.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) { return &strangerCertificate, nil }
+	if _, err := handshake(reloader.ServerConfig(), strangerClient); err == nil {
+		t.Fatal("accepted a client certificate from another CA")
+	}
+
+	second := newAuthority(t, "second CA")
+	second.write(t, dir, "node 2")
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		name, err := handshake(reloader.ServerConfig(), reloader.ClientConfig())
+		if err == nil && name == "node 2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("still serving %q, %v after rotating the certificate", name, err)
+		}
+	}
+}
+
+func TestReloaderKeepsTheLastGoodFiles(t *testing.T) {
+	dir := t.TempDir()
+	newAuthority(t, "CA").write(t, dir, "node 1")
+	reloader, err := NewReloader(filepath.Join(dir, "node.crt"), filepath.Join(dir, "node.key"), filepath.Join(dir, "ca.crt"), ClientAuthNone, 10*time.Millisecond, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	broken := time.Now().Add(time.Minute)
+	if err := os.WriteFile(filepath.Join(dir, "node.crt"), []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(dir, "node.crt"), broken, broken); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if name, err := handshake(reloader.ServerConfig(), reloader.ClientConfig()); err != nil || name != "node 1" {
+		t.Fatalf("handshake = %q, %v, want the last good certificate", name, err)
+	}
+}
+
+func TestNewReloaderChecksClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	newAuthority(t, "CA").write(t, dir, "node")
+	certFile, keyFile := filepath.Join(dir, "node.crt"), filepath.Join(dir, "node.key")
+	tests := []struct {
+		name       string
+		caFile     string
+		clientAuth string
+		wantErr    bool
+	}{
+		{"no client certificates", "", ClientAuthNone, false},
+		{"required client certificates", filepath.Join(dir, "ca.crt"), ClientAuthRequire, false},
+		{"client certificates without a CA", "", ClientAuthOptional, true},
+		{"an unknown mode", filepath.Join(dir, "ca.crt"), "always", true},
+		{"a CA file that is missing", filepath.Join(dir, "missing.crt"), ClientAuthRequire, true},
+	}
+	for _, test := range tests {
+		if _, err := NewReloader(certFile, keyFile, test.caFile, test.clientAuth, time.Hour, zap.NewNop()); (err != nil) != test.wantErr {
+			t.Errorf("%s: got %v, want an error %v", test.name, err, test.wantErr)
+		}
+	}
+}
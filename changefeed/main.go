@@ -0,0 +1,287 @@
+package changefeed
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/kv-storage/model"
+	kvpb "github.com/kv-storage/proto/kv"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const (
+	readBatch     = 500
+	pollInterval  = 200 * time.Millisecond
+	pruneInterval = time.Minute
+	pruneBatch    = 10000
+	// gapWait is how long a reader waits on a missing sequence before taking
+	// it for a rolled back write and moving past it.
+	gapWait = 5 * time.Second
+)
+
+// Record appends a mutation to the change log and returns its sequence
+// number. It must run inside the transaction making the mutation, so the two
+// commit or roll back together.
+func Record(tx *gorm.DB, op, key, value string) (uint64, error) {
+	change := model.Change{Op: op, Key: key, Value: value, CreatedAt: time.Now()}
+	if err := tx.Create(&change).Error; err != nil {
+		return 0, err
+	}
+	return change.Sequence, nil
+}
+
+// RecordAll appends several mutations in one insert, filling in their
+// sequences. Like Record it must run inside their transaction.
+func RecordAll(tx *gorm.DB, changes []model.Change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for i := range changes {
+		changes[i].CreatedAt = now
+	}
+	return tx.Create(&changes).Error
+}
+
+// Seed makes sure the sequences handed out from now on are above floor, and
+// marks everything up to floor as gone from the log.
+func Seed(db *gorm.DB, floor uint64) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var last uint64
+		if err := tx.Model(&model.Change{}).Select("COALESCE(MAX(sequence), 0)").Scan(&last).Error; err != nil {
+			return err
+		}
+		if last >= floor {
+			return nil
+		}
+		// Writing a change at floor moves the auto increment counter past it
+		placeholder := model.Change{Sequence: floor, Op: model.ChangeSet, CreatedAt: time.Now()}
+		if err := tx.Create(&placeholder).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&placeholder).Error; err != nil {
+			return err
+		}
+		return raiseWatermark(tx, floor)
+	})
+}
+
+// Reset marks every change logged so far as gone, for when the data they led
+// up to was replaced wholesale. Consumers resuming from them start over.
+func Reset(db *gorm.DB) error {
+	var last uint64
+	if err := db.Model(&model.Change{}).Select("COALESCE(MAX(sequence), 0)").Scan(&last).Error; err != nil {
+		return err
+	}
+	return Seed(db, last+1)
+}
+
+func raiseWatermark(db *gorm.DB, pruned uint64) error {
+	return db.Model(&model.ChangeWatermark{}).Where("id = ? AND pruned < ?", 1, pruned).Update("pruned", pruned).Error
+}
+
+// Feed streams the change log to consumers and prunes it by age and size.
+type Feed struct {
+	kvpb.UnimplementedChangeFeedServer
+
+	db        *gorm.DB
+	retention time.Duration
+	maxRows   int
+	gapWait   time.Duration
+	logger    *zap.Logger
+}
+
+func NewFeed(db *gorm.DB, retention time.Duration, maxRows int, logger *zap.Logger) (*Feed, error) {
+	if err := db.FirstOrCreate(&model.ChangeWatermark{}, model.ChangeWatermark{ID: 1}).Error; err != nil {
+		return nil, err
+	}
+	if err := dropSequenceRow(db); err != nil {
+		return nil, err
+	}
+	feed := &Feed{db: db, retention: retention, maxRows: maxRows, gapWait: gapWait, logger: logger}
+	if retention > 0 || maxRows > 0 {
+		go func() {
+			for range time.Tick(pruneInterval) {
+				feed.prune()
+			}
+		}()
+	}
+	return feed, nil
+}
+
+// dropSequenceRow retires the row sequences used to be taken from, carrying
+// its last sequence over so none is handed out twice.
+func dropSequenceRow(db *gorm.DB) error {
+	if !db.Migrator().HasTable("change_sequences") {
+		return nil
+	}
+	var last uint64
+	if err := db.Table("change_sequences").Select("COALESCE(MAX(last), 0)").Scan(&last).Error; err != nil {
+		return err
+	}
+	if err := Seed(db, last); err != nil {
+		return err
+	}
+	return db.Migrator().DropTable("change_sequences")
+}
+
+func (f *Feed) pruned(ctx context.Context) (uint64, error) {
+	var watermark model.ChangeWatermark
+	err := f.db.WithContext(ctx).First(&watermark, 1).Error
+	return watermark.Pruned, err
+}
+
+func (f *Feed) ReadChanges(request *kvpb.ReadChangesRequest, stream grpc.ServerStreamingServer[kvpb.ChangeEvent]) error {
+	ctx := stream.Context()
+	after := request.AfterSequence
+	if after == 0 && request.FromNow {
+		if err := f.db.Model(&model.Change{}).Select("COALESCE(MAX(sequence), 0)").Scan(&after).Error; err != nil {
+			return status.Error(codes.Internal, "Database error")
+		}
+	} else if after == 0 && request.ConsumerId != "" {
+		var checkpoint model.ConsumerCheckpoint
+		if err := f.db.Limit(1).Find(&checkpoint, "consumer_id = ?", request.ConsumerId).Error; err != nil {
+			return status.Error(codes.Internal, "Database error")
+		}
+		after = checkpoint.Sequence
+	}
+	// Only a reader resuming where it left off can have missed pruned changes
+	resuming := after > 0 && !request.FromNow
+
+	for {
+		var batch []model.Change
+		if err := f.db.WithContext(ctx).Where("sequence > ?", after).Order("sequence").Limit(readBatch).Find(&batch).Error; err != nil {
+			if ctx.Err() != nil {
+				return status.FromContextError(ctx.Err()).Err()
+			}
+			return status.Error(codes.Internal, "Database error")
+		}
+		// Pruning raises the watermark before it deletes, so reading it after
+		// the batch catches every change the batch is missing for that reason
+		pruned, err := f.pruned(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return status.FromContextError(ctx.Err()).Err()
+			}
+			return status.Error(codes.Internal, "Database error")
+		}
+		if pruned > after && resuming {
+			return status.Errorf(codes.OutOfRange, "changes after %d were pruned, the log now starts after %d", after, pruned)
+		}
+		after = max(after, pruned)
+
+		waiting := false
+		for _, change := range batch {
+			if change.Sequence <= after {
+				continue
+			}
+			// A missing sequence is a write still committing or one that rolled
+			// back. Moving past it early would lose the write if it commits.
+			if change.Sequence != after+1 && time.Since(change.CreatedAt) < f.gapWait {
+				waiting = true
+				break
+			}
+			resuming = true
+			if !strings.HasPrefix(change.Key, request.Prefix) {
+				after = change.Sequence
+				continue
+			}
+			err := stream.Send(&kvpb.ChangeEvent{
+				Sequence:  change.Sequence,
+				Op:        change.Op,
+				Key:       change.Key,
+				Value:     change.Value,
+				Timestamp: change.CreatedAt.UnixMilli(),
+			})
+			if err != nil {
+				return err
+			}
+			after = change.Sequence
+		}
+		if len(batch) == readBatch && !waiting {
+			continue
+		}
+		if !request.Follow && !waiting {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func (f *Feed) CommitCheckpoint(ctx context.Context, request *kvpb.CommitCheckpointRequest) (*kvpb.CommitCheckpointResponse, error) {
+	if request.ConsumerId == "" {
+		return &kvpb.CommitCheckpointResponse{
+			Message:    "Consumer id missing",
+			StatusCode: 400,
+		}, nil
+	}
+	checkpoint := model.ConsumerCheckpoint{ConsumerID: request.ConsumerId, Sequence: request.Sequence}
+	if err := f.db.WithContext(ctx).Save(&checkpoint).Error; err != nil {
+		return &kvpb.CommitCheckpointResponse{
+			Message:    "Database error",
+			StatusCode: 500,
+		}, nil
+	}
+	return &kvpb.CommitCheckpointResponse{
+		Message:    "Checkpoint committed",
+		StatusCode: 200,
+	}, nil
+}
+
+// prune drops changes past the retention period and beyond the newest maxRows.
+func (f *Feed) prune() {
+	var bound uint64
+	if f.retention > 0 {
+		cutoff := time.Now().Add(-f.retention)
+		if err := f.db.Model(&model.Change{}).Where("created_at < ?", cutoff).Select("COALESCE(MAX(sequence), 0)").Scan(&bound).Error; err != nil {
+			f.logger.Warn("Change log prune failed", zap.Error(err))
+			return
+		}
+	}
+	if f.maxRows > 0 {
+		var sequences []uint64
+		if err := f.db.Model(&model.Change{}).Order("sequence DESC").Offset(f.maxRows).Limit(1).Pluck("sequence", &sequences).Error; err != nil {
+			f.logger.Warn("Change log prune failed", zap.Error(err))
+			return
+		}
+		if len(sequences) > 0 {
+			bound = max(bound, sequences[0])
+		}
+	}
+	if bound == 0 {
+		return
+	}
+	if err := f.pruneThrough(bound); err != nil {
+		f.logger.Warn("Change log prune failed", zap.Error(err))
+	}
+}
+
+// pruneThrough deletes every change up to bound, in bounded ranges so it never
+// holds long locks on the log. It raises the watermark first, so readers are
+// told about what they missed.
+func (f *Feed) pruneThrough(bound uint64) error {
+	if err := raiseWatermark(f.db, bound); err != nil {
+		return err
+	}
+	var oldest uint64
+	if err := f.db.Model(&model.Change{}).Select("COALESCE(MIN(sequence), 0)").Scan(&oldest).Error; err != nil {
+		return err
+	}
+	for oldest != 0 && oldest <= bound {
+		upper := min(bound, oldest+pruneBatch-1)
+		if err := f.db.Where("sequence <= ?", upper).Delete(&model.Change{}).Error; err != nil {
+			return err
+		}
+		oldest = upper + 1
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package changefeed
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/kv-storage/model"
+	kvpb "github.com/kv-storage/proto/kv"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newFeed(t *testing.T) *Feed {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "changes.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.Change{}, &model.ChangeWatermark{}, &model.ConsumerCheckpoint{}); err != nil {
+		t.Fatal(err)
+	}
+	feed, err := NewFeed(db, 0, 0, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed.gapWait = time.Minute
+	return feed
+}
+
+// record writes one change per key, each in a transaction of its own.
+func record(t *testing.T, f *Feed, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		err := f.db.Transaction(func(tx *gorm.DB) error {
+			_, err := Record(tx, model.ChangeSet, key, "v")
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// stream collects what ReadChanges sends.
+type stream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []*kvpb.ChangeEvent
+}
+
+func (s *stream) Context() context.Context { return s.ctx }
+
+func (s *stream) Send(event *kvpb.ChangeEvent) error {
+	s.sent = append(s.sent, event)
+	return nil
+}
+
+func read(f *Feed, request *kvpb.ReadChangesRequest) ([]uint64, error) {
+	s := &stream{ctx: context.Background()}
+	err := f.ReadChanges(request, s)
+	var sequences []uint64
+	for _, event := range s.sent {
+		sequences = append(sequences, event.Sequence)
+	}
+	return sequences, err
+}
+
+func TestRecordNumbersChangesInOrder(t *testing.T) {
+	f := newFeed(t)
+	record(t, f, "a", "b")
+	changes := []model.Change{{Op: model.ChangeSet, Key: "c"}, {Op: model.ChangeDelete, Key: "d"}}
+	if err := f.db.Transaction(func(tx *gorm.DB) error { return RecordAll(tx, changes) }); err != nil {
+		t.Fatal(err)
+	}
+	record(t, f, "e")
+	// A rolled back write leaves nothing behind, though MySQL does not reuse its sequence
+	err := f.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := Record(tx, model.ChangeSet, "lost", "v"); err != nil {
+			return err
+		}
+		return gorm.ErrInvalidTransaction
+	})
+	if err != gorm.ErrInvalidTransaction {
+		t.Fatalf("got %v, want the write rolled back", err)
+	}
+	record(t, f, "f")
+
+	var logged []model.Change
+	if err := f.db.Order("sequence").Find(&logged).Error; err != nil {
+		t.Fatal(err)
+	}
+	keys := ""
+	for i, change := range logged {
+		if i > 0 && change.Sequence <= logged[i-1].Sequence {
+			t.Fatalf("change %q has sequence %d, not above %d", change.Key, change.Sequence, logged[i-1].Sequence)
+		}
+		keys += change.Key
+	}
+	if keys != "abcdef" {
+		t.Fatalf("logged %q, want abcdef", keys)
+	}
+	if changes[0].Sequence != 3 || changes[1].Sequence != 4 {
+		t.Fatalf("RecordAll filled in %d and %d, want 3 and 4", changes[0].Sequence, changes[1].Sequence)
+	}
+}
+
+func TestReadChanges(t *testing.T) {
+	tests := []struct {
+		name       string
+		checkpoint uint64
+		pruned     uint64
+		request    *kvpb.ReadChangesRequest
+		want       []uint64
+		wantCode   codes.Code
+	}{
+		{"from the start", 0, 0, &kvpb.ReadChangesRequest{}, []uint64{1, 2, 3, 4}, codes.OK},
+		{"after a sequence", 0, 0, &kvpb.ReadChangesRequest{AfterSequence: 2}, []uint64{3, 4}, codes.OK},
+		{"with a prefix", 0, 0, &kvpb.ReadChangesRequest{Prefix: "billing/"}, []uint64{2, 4}, codes.OK},
+		{"from now", 0, 0, &kvpb.ReadChangesRequest{FromNow: true}, nil, codes.OK},
+		{"from a checkpoint", 3, 0, &kvpb.ReadChangesRequest{ConsumerId: "indexer"}, []uint64{4}, codes.OK},
+		{"an explicit sequence wins over the checkpoint", 3, 0, &kvpb.ReadChangesRequest{ConsumerId: "indexer", AfterSequence: 1}, []uint64{2, 3, 4}, codes.OK},
+		{"a new consumer starts at the oldest change", 0, 2, &kvpb.ReadChangesRequest{ConsumerId: "indexer"}, []uint64{3, 4}, codes.OK},
+		{"right after what was pruned", 0, 2, &kvpb.ReadChangesRequest{AfterSequence: 2}, []uint64{3, 4}, codes.OK},
+		{"past what was pruned", 0, 2, &kvpb.ReadChangesRequest{AfterSequence: 1}, nil, codes.OutOfRange},
+		{"a checkpoint past what was pruned", 1, 2, &kvpb.ReadChangesRequest{ConsumerId: "indexer"}, nil, codes.OutOfRange},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFeed(t)
+			record(t, f, "users/1", "billing/1", "users/2", "billing/2")
+			if test.checkpoint != 0 {
+				if response, err := f.CommitCheckpoint(context.Background(), &kvpb.CommitCheckpointRequest{ConsumerId: "indexer", Sequence: test.checkpoint}); err != nil || response.StatusCode != 200 {
+					t.Fatalf("CommitCheckpoint = %v, %v", response, err)
+				}
+			}
+			if test.pruned != 0 {
+				if err := f.pruneThrough(test.pruned); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := read(f, test.request)
+			if status.Code(err) != test.wantCode {
+				t.Fatalf("got %v, want %v", err, test.wantCode)
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("read %v, want %v", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Fatalf("read %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadChangesAfterAnEmptiedLog(t *testing.T) {
+	f := newFeed(t)
+	record(t, f, "a", "b")
+	if err := f.pruneThrough(2); err != nil {
+		t.Fatal(err)
+	}
+	// With every change pruned, a consumer that saw them all is up to date, one that did not is not
+	if _, err := read(f, &kvpb.ReadChangesRequest{AfterSequence: 2}); err != nil {
+		t.Fatalf("reading on from the last change: %v", err)
+	}
+	if _, err := read(f, &kvpb.ReadChangesRequest{AfterSequence: 1}); status.Code(err) != codes.OutOfRange {
+		t.Fatalf("got %v, want OutOfRange for a change that was pruned unread", err)
+	}
+}
+
+func TestReadChangesAcrossAGap(t *testing.T) {
+	tests := []struct {
+		name string
+		// age is how long ago the change after the gap was recorded
+		age  time.Duration
+		fill bool
+		want []uint64
+	}{
+		{"a gap that fills is read in order", 0, true, []uint64{1, 2, 3}},
+		{"an old gap is a rolled back write", time.Hour, false, []uint64{1, 3}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFeed(t)
+			record(t, f, "a")
+			// Sequence 2 is still committing when 3 lands
+			if err := f.db.Create(&model.Change{Sequence: 3, Op: model.ChangeSet, Key: "c", CreatedAt: time.Now().Add(-test.age)}).Error; err != nil {
+				t.Fatal(err)
+			}
+			done := make(chan struct{})
+			var got []uint64
+			var err error
+			go func() {
+				got, err = read(f, &kvpb.ReadChangesRequest{})
+				close(done)
+			}()
+			if test.fill {
+				time.Sleep(2 * pollInterval)
+				if err := f.db.Create(&model.Change{Sequence: 2, Op: model.ChangeSet, Key: "b", CreatedAt: time.Now()}).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+			<-done
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Fatalf("read %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSeedStartsSequencesAboveTheFloor(t *testing.T) {
+	f := newFeed(t)
+	record(t, f, "a")
+	if err := Seed(f.db, 10); err != nil {
+		t.Fatal(err)
+	}
+	record(t, f, "b")
+	got, err := read(f, &kvpb.ReadChangesRequest{})
+	if err != nil || fmt.Sprint(got) != "[11]" {
+		t.Fatalf("read %v, %v, want [11]", got, err)
+	}
+	if _, err := read(f, &kvpb.ReadChangesRequest{AfterSequence: 1}); status.Code(err) != codes.OutOfRange {
+		t.Fatalf("got %v, want OutOfRange below the seeded floor", err)
+	}
+}
+
+func TestResetStartsConsumersOver(t *testing.T) {
+	f := newFeed(t)
+	record(t, f, "a", "b")
+	if err := Reset(f.db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := read(f, &kvpb.ReadChangesRequest{AfterSequence: 2}); status.Code(err) != codes.OutOfRange {
+		t.Fatalf("got %v, want OutOfRange for a consumer of the replaced log", err)
+	}
+	record(t, f, "c")
+	got, err := read(f, &kvpb.ReadChangesRequest{})
+	if err != nil || fmt.Sprint(got) != "[4]" {
+		t.Fatalf("read %v, %v, want [4]", got, err)
+	}
+}
+
+func TestPruneKeepsTheNewestRows(t *testing.T) {
+	f := newFeed(t)
+	f.maxRows = 2
+	record(t, f, "a", "b", "c", "d")
+	f.prune()
+	got, err := read(f, &kvpb.ReadChangesRequest{})
+	if err != nil || fmt.Sprint(got) != "[3 4]" {
+		t.Fatalf("read %v, %v, want [3 4]", got, err)
+	}
+	if pruned, err := f.pruned(context.Background()); err != nil || pruned != 2 {
+		t.Fatalf("watermark %d, %v, want 2", pruned, err)
+	}
+}
+
+func TestCommitCheckpointNeedsAConsumer(t *testing.T) {
+	f := newFeed(t)
+	response, err := f.CommitCheckpoint(context.Background(), &kvpb.CommitCheckpointRequest{Sequence: 3})
+	if err != nil || response.StatusCode != 400 {
+		t.Fatalf("got %v, %v, want 400", response, err)
+	}
+}
+
+func BenchmarkRecord(b *testing.B) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(b.TempDir(), "changes.db")+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.Change{}, &model.ChangeWatermark{}, &model.ConsumerCheckpoint{}); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := NewFeed(db, 0, 0, zap.NewNop()); err != nil {
+		b.Fatal(err)
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			err := db.Transaction(func(tx *gorm.DB) error {
+				_, err := Record(tx, model.ChangeSet, "k", "v")
+				return err
+			})
+			if err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,356 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	kvpb "github.com/kv-storage/proto/kv"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrNotFound        = errors.New("kv: key not found")
+	ErrExists          = errors.New("kv: key already exists")
+	ErrVersionMismatch = errors.New("kv: key has changed since the expected version")
+	ErrWrongType       = errors.New("kv: key holds a collection")
+)
+
+// StatusError is a failure the service reported that has no error of its own.
+type StatusError struct {
+	StatusCode int64
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("kv: %d %s", e.StatusCode, e.Message)
+}
+
+// Item is a key's value together with its version and flags.
+type Item struct {
+	Value   string
+	Version uint64
+	Flags   uint32
+}
+
+type options struct {
+	timeout     time.Duration
+	retries     int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	dialOptions []grpc.DialOption
+}
+
+type Option func(*options)
+
+// WithTimeout sets the deadline of each attempt; 0 leaves only the caller's.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) { o.timeout = timeout }
+}
+
+// WithRetries sets how many times a failed idempotent call is retried.
+func WithRetries(retries int) Option {
+	return func(o *options) { o.retries = retries }
+}
+
+// WithBackoff sets the first retry delay and the cap it doubles up to.
+func WithBackoff(initial, max time.Duration) Option {
+	return func(o *options) { o.backoff, o.maxBackoff = initial, max }
+}
+
+// WithDialOptions adds gRPC dial options, e.g. transport credentials in
+// place of the default plaintext.
+func WithDialOptions(dialOptions ...grpc.DialOption) Option {
+	return func(o *options) { o.dialOptions = append(o.dialOptions, dialOptions...) }
+}
+
+// WithToken authenticates every call with token, an API key or a JWT.
+func WithToken(token string) Option {
+	return func(o *options) {
+		o.dialOptions = append(o.dialOptions, grpc.WithPerRPCCredentials(bearer(token)))
+	}
+}
+
+type bearer string
+
+func (b bearer) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(b)}, nil
+}
+
+// RequireTransportSecurity allows plaintext, as the server does; use TLS to keep tokens private.
+func (bearer) RequireTransportSecurity() bool {
+	return false
+}
+
+// Client wraps the KeyValueStore service, turning its status codes into
+// errors. It is safe for concurrent use. Reads carry the session token of the
+// client's latest write, so a replica never hides that write from it.
+type Client struct {
+	connection   *grpc.ClientConn
+	kv           kvpb.KeyValueStoreClient
+	options      options
+	sessionToken atomic.Value
+}
+
+// New connects to one or more server addresses, balancing calls across them
+// round robin. Connections are made lazily and re-established as needed.
+func New(addresses []string, opts ...Option) (*Client, error) {
+	if len(addresses) == 0 {
+		return nil, errors.New("kv: no server address")
+	}
+	o := options{timeout: 5 * time.Second, retries: 3, backoff: 50 * time.Millisecond, maxBackoff: 2 * time.Second}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	targets := make([]resolver.Address, len(addresses))
+	for i, address := range addresses {
+		targets[i] = resolver.Address{Addr: address}
+	}
+	builder := manual.NewBuilderWithScheme("kv")
+	builder.InitialState(resolver.State{Addresses: targets})
+	dialOptions := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithResolvers(builder),
+		grpc.WithDefaultServiceConfig(`{"loadBalancingConfig": [{"round_robin": {}}]}`),
+	}, o.dialOptions...)
+	connection, err := grpc.NewClient(builder.Scheme()+":///kv-storage", dialOptions...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{connection: connection, kv: kvpb.NewKeyValueStoreClient(connection), options: o}, nil
+}
+
+func (c *Client) Close() error {
+	return c.connection.Close()
+}
+
+// KeyValueStore is the generated client, for calls this package does not wrap.
+func (c *Client) KeyValueStore() kvpb.KeyValueStoreClient {
+	return c.kv
+}
+
+// Connection is the underlying connection, for the server's other services
+// such as the change feed or health checks.
+func (c *Client) Connection() *grpc.ClientConn {
+	return c.connection
+}
+
+// call runs attempt with a deadline per try. Idempotent calls are retried
+// with jittered exponential backoff while the server is unreachable or has
+// no leader.
+func (c *Client) call(ctx context.Context, idempotent bool, attempt func(ctx context.Context) (int64, error)) (int64, error) {
+	delay := c.options.backoff
+	for try := 0; ; try++ {
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if c.options.timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, c.options.timeout)
+		}
+		statusCode, err := attempt(attemptCtx)
+		cancel()
+		if !idempotent || try >= c.options.retries || !retryable(statusCode, err) {
+			return statusCode, err
+		}
+		select {
+		case <-ctx.Done():
+			return statusCode, err
+		case <-time.After(time.Duration(rand.Int63n(int64(delay) + 1))):
+		}
+		delay = min(2*delay, c.options.maxBackoff)
+	}
+}
+
+func retryable(statusCode int64, err error) bool {
+	if err != nil {
+		switch status.Code(err) {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+			return true
+		}
+		return false
+	}
+	return statusCode == 503
+}
+
+func (c *Client) remember(sessionToken string) {
+	if sessionToken != "" {
+		c.sessionToken.Store(sessionToken)
+	}
+}
+
+func (c *Client) lastSessionToken() string {
+	token, _ := c.sessionToken.Load().(string)
+	return token
+}
+
+// Get returns a key's value, or ErrNotFound.
+func (c *Client) Get(ctx context.Context, key string) (string, error) {
+	item, err := c.GetItem(ctx, key)
+	return item.Value, err
+}
+
+// GetItem returns a key's value along with its version, for a later IfVersion write.
+func (c *Client) GetItem(ctx context.Context, key string) (Item, error) {
+	var response *kvpb.GetKVResponse
+	statusCode, err := c.call(ctx, true, func(ctx context.Context) (int64, error) {
+		var err error
+		response, err = c.kv.GetKeyValue(ctx, &kvpb.GetKVRequest{Key: key, SessionToken: c.lastSessionToken()})
+		if err != nil {
+			return 0, err
+		}
+		return response.StatusCode, nil
+	})
+	if err != nil {
+		return Item{}, err
+	}
+	switch statusCode {
+	case 200:
+		return Item{Value: response.Value, Version: response.Version, Flags: response.Flags}, nil
+	case 404:
+		return Item{}, ErrNotFound
+	case 409:
+		return Item{}, ErrWrongType
+	}
+	return Item{}, &StatusError{StatusCode: statusCode, Message: response.Message}
+}
+
+type writeOptions struct {
+	mode    string
+	version uint64
+	ttl     time.Duration
+	flags   uint32
+}
+
+type WriteOption func(*writeOptions)
+
+// IfNotExists only creates the key, failing with ErrExists otherwise.
+func IfNotExists() WriteOption {
+	return func(o *writeOptions) { o.mode = "create" }
+}
+
+// IfExists only replaces the key, failing with ErrNotFound otherwise.
+func IfExists() WriteOption {
+	return func(o *writeOptions) { o.mode = "update" }
+}
+
+// IfVersion only writes or deletes the key at version, failing with
+// ErrVersionMismatch once it has changed.
+func IfVersion(version uint64) WriteOption {
+	return func(o *writeOptions) { o.mode, o.version = "update", version }
+}
+
+// WithTTL makes the key expire; it has no effect on Delete.
+func WithTTL(ttl time.Duration) WriteOption {
+	return func(o *writeOptions) { o.ttl = ttl }
+}
+
+// WithFlags stores opaque flags alongside the value, as memcached clients do.
+func WithFlags(flags uint32) WriteOption {
+	return func(o *writeOptions) { o.flags = flags }
+}
+
+// Set writes a key, creating or replacing it unless an option says
+// otherwise, and returns its new version. Only unconditional writes are
+// retried: repeating a conditional one could fail on its own first attempt.
+func (c *Client) Set(ctx context.Context, key, value string, opts ...WriteOption) (uint64, error) {
+	o := writeOptions{mode: "upsert"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	request := &kvpb.SetKeyValueRequest{
+		Key:             key,
+		Value:           value,
+		Mode:            o.mode,
+		Flags:           o.flags,
+		ExpectedVersion: o.version,
+		TtlMilliseconds: o.ttl.Milliseconds(),
+	}
+	var response *kvpb.SetKeyValueResponse
+	idempotent := o.mode == "upsert" || (o.mode == "update" && o.version == 0)
+	statusCode, err := c.call(ctx, idempotent, func(ctx context.Context) (int64, error) {
+		var err error
+		response, err = c.kv.SetKeyValue(ctx, request)
+		if err != nil {
+			return 0, err
+		}
+		return response.StatusCode, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case statusCode == 200 || statusCode == 201:
+		c.remember(response.SessionToken)
+		return response.Version, nil
+	case statusCode == 404:
+		return 0, ErrNotFound
+	case statusCode == 409 && o.version != 0:
+		return 0, ErrVersionMismatch
+	case statusCode == 409:
+		return 0, ErrExists
+	}
+	return 0, &StatusError{StatusCode: statusCode, Message: response.Message}
+}
+
+// Delete removes a key, or fails with ErrNotFound. It is not retried, since
+// a repeat after a lost reply would report ErrNotFound for a delete that worked.
+func (c *Client) Delete(ctx context.Context, key string, opts ...WriteOption) error {
+	var o writeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var response *kvpb.DeleteKeyValueResponse
+	statusCode, err := c.call(ctx, false, func(ctx context.Context) (int64, error) {
+		var err error
+		response, err = c.kv.DeleteKeyValue(ctx, &kvpb.DeleteKeyValueRequest{Key: key, ExpectedVersion: o.version})
+		if err != nil {
+			return 0, err
+		}
+		return response.StatusCode, nil
+	})
+	if err != nil {
+		return err
+	}
+	switch statusCode {
+	case 200:
+		c.remember(response.SessionToken)
+		return nil
+	case 404:
+		return ErrNotFound
+	case 409:
+		return ErrVersionMismatch
+	}
+	return &StatusError{StatusCode: statusCode, Message: response.Message}
+}
+
+// Increment adds delta to a counter, starting missing ones at 0, and returns
+// its new value. Like Delete it is never retried.
+func (c *Client) Increment(ctx context.Context, key string, delta int64) (int64, error) {
+	var response *kvpb.CounterResponse
+	statusCode, err := c.call(ctx, false, func(ctx context.Context) (int64, error) {
+		var err error
+		response, err = c.kv.Increment(ctx, &kvpb.CounterRequest{Key: key, Delta: delta})
+		if err != nil {
+			return 0, err
+		}
+		return response.StatusCode, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	switch statusCode {
+	case 200:
+		c.remember(response.SessionToken)
+		return response.Value, nil
+	case 409:
+		return 0, ErrWrongType
+	}
+	return 0, &StatusError{StatusCode: statusCode, Message: response.Message}
+}
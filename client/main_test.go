@@ -0,0 +1,180 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	kvpb "github.com/kv-storage/proto/kv"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int64
+		err        error
+		want       bool
+	}{
+		{"no leader", 503, nil, true},
+		{"unreachable", 0, status.Error(codes.Unavailable, "down"), true},
+		{"timed out", 0, status.Error(codes.DeadlineExceeded, "slow"), true},
+		{"rate limited", 0, status.Error(codes.ResourceExhausted, "slow down"), true},
+		{"bad request", 0, status.Error(codes.InvalidArgument, "bad key"), false},
+		{"refused", 0, status.Error(codes.PermissionDenied, "no"), false},
+		{"conflict", 409, nil, false},
+		{"done", 200, nil, false},
+	}
+	for _, test := range tests {
+		if got := retryable(test.statusCode, test.err); got != test.want {
+			t.Errorf("%s: retryable = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestCall(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "down")
+	tests := []struct {
+		name       string
+		idempotent bool
+		retries    int
+		// outcomes are the status code each attempt returns, 0 for unavailable
+		outcomes     []int64
+		wantAttempts int
+		wantStatus   int64
+		wantErr      bool
+	}{
+		{name: "succeeds first time", idempotent: true, retries: 3, outcomes: []int64{200}, wantAttempts: 1, wantStatus: 200},
+		{name: "retries until it succeeds", idempotent: true, retries: 3, outcomes: []int64{0, 503, 200}, wantAttempts: 3, wantStatus: 200},
+		{name: "gives up after its retries", idempotent: true, retries: 2, outcomes: []int64{503, 503, 503, 200}, wantAttempts: 3, wantStatus: 503},
+		{name: "never retries a non-idempotent call", idempotent: false, retries: 3, outcomes: []int64{0, 200}, wantAttempts: 1, wantErr: true},
+		{name: "does not retry a failure that would repeat", idempotent: true, retries: 3, outcomes: []int64{409, 200}, wantAttempts: 1, wantStatus: 409},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Client{options: options{retries: test.retries, backoff: time.Millisecond, maxBackoff: 2 * time.Millisecond}}
+			attempts := 0
+			statusCode, err := c.call(context.Background(), test.idempotent, func(ctx context.Context) (int64, error) {
+				outcome := test.outcomes[attempts]
+				attempts++
+				if outcome == 0 {
+					return 0, unavailable
+				}
+				return outcome, nil
+			})
+			if attempts != test.wantAttempts {
+				t.Errorf("%d attempts, want %d", attempts, test.wantAttempts)
+			}
+			if (err != nil) != test.wantErr || statusCode != test.wantStatus {
+				t.Errorf("got %d, %v, want %d, error %v", statusCode, err, test.wantStatus, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestCallStopsWhenTheContextEnds(t *testing.T) {
+	c := &Client{options: options{retries: 100, backoff: 10 * time.Millisecond, maxBackoff: 10 * time.Millisecond}}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	attempts := 0
+	start := time.Now()
+	_, err := c.call(ctx, true, func(ctx context.Context) (int64, error) {
+		attempts++
+		return 503, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second || attempts >= 100 {
+		t.Fatalf("kept retrying for %v and %d attempts after the context ended", elapsed, attempts)
+	}
+}
+
+func TestCallTimesOutEachAttempt(t *testing.T) {
+	c := &Client{options: options{timeout: 5 * time.Millisecond, retries: 1, backoff: time.Millisecond, maxBackoff: time.Millisecond}}
+	attempts := 0
+	_, err := c.call(context.Background(), true, func(ctx context.Context) (int64, error) {
+		attempts++
+		<-ctx.Done()
+		return 0, status.FromContextError(ctx.Err()).Err()
+	})
+	if status.Code(err) != codes.DeadlineExceeded || attempts != 2 {
+		t.Fatalf("got %v after %d attempts, want DeadlineExceeded after 2", err, attempts)
+	}
+}
+
+// server answers SetKeyValue with each of statuses in turn.
+type server struct {
+	kvpb.UnimplementedKeyValueStoreServer
+
+	mu       sync.Mutex
+	statuses []int64
+	requests int
+}
+
+func (s *server) SetKeyValue(ctx context.Context, request *kvpb.SetKeyValueRequest) (*kvpb.SetKeyValueResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statusCode := s.statuses[min(s.requests, len(s.statuses)-1)]
+	s.requests++
+	return &kvpb.SetKeyValueResponse{StatusCode: statusCode, Message: "status", Version: 4, SessionToken: "token"}, nil
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []WriteOption
+		statuses     []int64
+		wantRequests int
+		wantErr      error
+	}{
+		{name: "an upsert is retried", statuses: []int64{503, 200}, wantRequests: 2},
+		{name: "an update is retried", opts: []WriteOption{IfExists()}, statuses: []int64{503, 200}, wantRequests: 2},
+		{name: "a create is not retried", opts: []WriteOption{IfNotExists()}, statuses: []int64{503, 201}, wantRequests: 1, wantErr: &StatusError{}},
+		{name: "a versioned write is not retried", opts: []WriteOption{IfVersion(3)}, statuses: []int64{503, 200}, wantRequests: 1, wantErr: &StatusError{}},
+		{name: "an existing key", opts: []WriteOption{IfNotExists()}, statuses: []int64{409}, wantRequests: 1, wantErr: ErrExists},
+		{name: "a changed key", opts: []WriteOption{IfVersion(3)}, statuses: []int64{409}, wantRequests: 1, wantErr: ErrVersionMismatch},
+		{name: "a missing key", opts: []WriteOption{IfExists()}, statuses: []int64{404}, wantRequests: 1, wantErr: ErrNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := &server{statuses: test.statuses}
+			grpcServer := grpc.NewServer()
+			kvpb.RegisterKeyValueStoreServer(grpcServer, s)
+			go grpcServer.Serve(listener)
+			defer grpcServer.Stop()
+
+			c, err := New([]string{listener.Addr().String()}, WithBackoff(time.Millisecond, time.Millisecond))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+			version, err := c.Set(context.Background(), "k", "v", test.opts...)
+			var statusError *StatusError
+			switch {
+			case test.wantErr == nil && (err != nil || version != 4):
+				t.Errorf("got version %d, %v, want version 4", version, err)
+			case test.wantErr == nil && c.lastSessionToken() != "token":
+				t.Errorf("session token %q was not kept", c.lastSessionToken())
+			case errors.As(test.wantErr, &statusError) && !errors.As(err, &statusError):
+				t.Errorf("got %v, want a StatusError", err)
+			case test.wantErr != nil && !errors.As(test.wantErr, &statusError) && !errors.Is(err, test.wantErr):
+				t.Errorf("got %v, want %v", err, test.wantErr)
+			}
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.requests != test.wantRequests {
+				t.Errorf("%d requests, want %d", s.requests, test.wantRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+#!/bin/bash
+
+# Starts a local Raft cluster of kv-storage processes, one MySQL database each.
+# Usage: ./cluster.sh [nodes]   (default 3, CTRL+C stops them all)
+
+NODES=${1:-3}
+MYSQL_USER=${MYSQL_USER:-root}
+: "${MYSQL_PASSWORD:?set MYSQL_PASSWORD to the MySQL password}"
+
+go build -o kv-storage . || exit 1
+
+pids=()
+trap 'kill ${pids[@]} 2>/dev/null' EXIT
+
+for i in $(seq 1 $NODES); do
+    mysql -u$MYSQL_USER -p$MYSQL_PASSWORD -e "CREATE DATABASE IF NOT EXISTS kvstoredb$i"
+
+    join=""
+    bootstrap="false"
+    if [ $i -eq 1 ]; then bootstrap="true"; else join="localhost:50051"; fi
+
+    MYSQL_DATABASE=kvstoredb$i \
+    GRPC_ADDRESS=localhost:$((50050 + i)) \
+    GATEWAY_ADDRESS=:$((8090 + i)) \
+    PPROF_ADDRESS=:$((6060 + i)) \
+    RAFT_NODE_ID=node$i \
+    RAFT_ADDRESS=127.0.0.1:$((7000 + i)) \
+    RAFT_BOOTSTRAP=$bootstrap \
+    RAFT_JOIN=$join \
+    ./kv-storage > node$i.log 2>&1 &
+    pids+=($!)
+    echo "node$i: grpc localhost:$((50050 + i)), http :$((8090 + i)), log node$i.log"
+    sleep 1
+done
+
+wait
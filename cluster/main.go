@@ -0,0 +1,784 @@
+package cluster
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	raftboltdb "github.com/hashicorp/raft-boltdb/v2"
+	"github.com/kv-storage/model"
+	kvpb "github.com/kv-storage/proto/kv"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"gorm.io/gorm"
+)
+
+const (
+	OpSet        = "set"
+	OpDelete     = "delete"
+	OpIncrement  = "increment"
+	OpCollection = "collection"
+	OpBulkSet    = "bulkset"
+	opMember     = "member"
+	opForget     = "forget"
+
+	applyTimeout   = 5 * time.Second
+	forwardedKey   = "x-kv-forwarded"
+	pollInterval   = 2 * time.Millisecond
+	snapshotRetain = 2
+	restoreBatch   = 500
+)
+
+var (
+	ErrNoLeader       = errors.New("no cluster leader")
+	ErrLeaderNotReady = errors.New("leader has not caught up with its term yet")
+	ErrForwardLoop    = errors.New("forwarded request reached a non-leader")
+)
+
+// Command is one entry of the replicated log. ExpectedVersion makes a Set or
+// Delete conditional on the key's current version, Collection carries the
+// field-level operation of an OpCollection command and Bulk the records of
+// an OpBulkSet command.
+type Command struct {
+	Op              string          `json:"op"`
+	Key             string          `json:"key,omitempty"`
+	Value           string          `json:"value,omitempty"`
+	Mode            string          `json:"mode,omitempty"`
+	Flags           uint32          `json:"flags,omitempty"`
+	ExpectedVersion uint64          `json:"expectedVersion,omitempty"`
+	Delta           int64           `json:"delta,omitempty"`
+	Initial         int64           `json:"initial,omitempty"`
+	Min             *int64          `json:"min,omitempty"`
+	Max             *int64          `json:"max,omitempty"`
+	Saturate        bool            `json:"saturate,omitempty"`
+	RequireExisting bool            `json:"requireExisting,omitempty"`
+	Collection      json.RawMessage `json:"collection,omitempty"`
+	Bulk            json.RawMessage `json:"bulk,omitempty"`
+	NodeID          string          `json:"nodeId,omitempty"`
+	RaftAddress     string          `json:"raftAddress,omitempty"`
+	GrpcAddress     string          `json:"grpcAddress,omitempty"`
+	// Index is the command's place in the replicated log, the same on every
+	// replica, so writes take it as the key's new version.
+	Index uint64 `json:"-"`
+}
+
+// Result is what applying a command produced, handed back to the proposer.
+type Result struct {
+	StatusCode int64
+	Message    string
+	// Value, Version and Flags are what the key holds afterwards, for commands that compute them.
+	// A delete's Version is the one the key was deleted at.
+	Value   string
+	Version uint64
+	Flags   uint32
+	// Count and Values report what a collection command added, removed or popped.
+	Count  int64
+	Values []string
+	// Statuses is the outcome of each record of a bulk command.
+	Statuses []int64
+	// Previous is the string a set replaced or a delete removed, nil if none.
+	Previous *string
+}
+
+// Store applies committed key-value commands to this node's own database.
+type Store interface {
+	// Apply runs inside the transaction that also records the log index.
+	// Outcomes every replica reaches alike, such as a version conflict, go in
+	// the Result; an error rolls the transaction back and the entry is retried.
+	Apply(tx *gorm.DB, command *Command) (Result, error)
+	// Committed runs once that transaction has committed, e.g. to update caches.
+	Committed(command *Command, result Result)
+	// Restored runs after a snapshot replaced the local state wholesale.
+	Restored()
+}
+
+type Config struct {
+	NodeID      string
+	RaftAddress string
+	GrpcAddress string
+	DataDir     string
+	Bootstrap   bool
+	JoinAddress string
+	// PeerCredentials secure the gRPC calls this node makes to other members
+	PeerCredentials credentials.TransportCredentials
+}
+
+// Node is one member of a Raft group replicating Set/Delete commands.
+type Node struct {
+	kvpb.UnimplementedClusterAdminServer
+
+	config   Config
+	raft     *raft.Raft
+	logStore *raftboltdb.BoltStore
+	fsm      *fsm
+	logger   *zap.Logger
+	ready    atomic.Bool
+
+	mu          sync.Mutex
+	connections map[string]*grpc.ClientConn
+}
+
+func NewNode(config Config, db *gorm.DB, store Store, logger *zap.Logger) (*Node, error) {
+	if err := os.MkdirAll(config.DataDir, 0o755); err != nil {
+		return nil, err
+	}
+	var state model.RaftState
+	if err := db.FirstOrCreate(&state, model.RaftState{ID: 1}).Error; err != nil {
+		return nil, err
+	}
+
+	node := &Node{
+		config:      config,
+		logger:      logger,
+		fsm:         &fsm{db: db, store: store, logger: logger},
+		connections: make(map[string]*grpc.ClientConn),
+	}
+	node.fsm.applied.Store(state.AppliedIndex)
+
+	raftConfig := raft.DefaultConfig()
+	raftConfig.LocalID = raft.ServerID(config.NodeID)
+	raftConfig.Logger = hclog.New(&hclog.LoggerOptions{Name: "raft", Level: hclog.Warn})
+
+	logStore, err := raftboltdb.NewBoltStore(filepath.Join(config.DataDir, "raft.db"))
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := raft.NewFileSnapshotStore(config.DataDir, snapshotRetain, os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := raft.NewTCPTransport(config.RaftAddress, nil, 3, 10*time.Second, os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := raft.HasExistingState(logStore, logStore, snapshots)
+	if err != nil {
+		return nil, err
+	}
+	node.logStore = logStore
+	node.raft, err = raft.NewRaft(raftConfig, node.fsm, logStore, logStore, snapshots, transport)
+	if err != nil {
+		return nil, err
+	}
+	if config.Bootstrap && !existing {
+		err := node.raft.BootstrapCluster(raft.Configuration{
+			Servers: []raft.Server{{ID: raftConfig.LocalID, Address: transport.LocalAddr()}},
+		}).Error()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	go node.watchLeadership()
+	if config.JoinAddress != "" && !existing {
+		go node.join()
+	}
+	return node, nil
+}
+
+// watchLeadership commits a barrier whenever we win an election, so the
+// commit index covers every earlier term before we serve read-index requests,
+// and records our own gRPC address for followers to forward to.
+func (n *Node) watchLeadership() {
+	for leader := range n.raft.LeaderCh() {
+		n.ready.Store(false)
+		if !leader {
+			continue
+		}
+		if err := n.raft.Barrier(applyTimeout).Error(); err != nil {
+			n.logger.Warn("Leader barrier failed", zap.Error(err))
+			continue
+		}
+		n.ready.Store(true)
+		n.logger.Info("Became cluster leader", zap.String("node", n.config.NodeID))
+		_, err := n.apply(&Command{Op: opMember, NodeID: n.config.NodeID, RaftAddress: n.config.RaftAddress, GrpcAddress: n.config.GrpcAddress})
+		if err != nil {
+			n.logger.Warn("Failed to record leader address", zap.Error(err))
+		}
+	}
+}
+
+// join asks an existing member to admit us, retrying until the cluster answers.
+func (n *Node) join() {
+	connection, err := grpc.NewClient(n.config.JoinAddress, grpc.WithTransportCredentials(n.config.PeerCredentials))
+	if err != nil {
+		n.logger.Error("Invalid join address", zap.String("address", n.config.JoinAddress), zap.Error(err))
+		return
+	}
+	defer connection.Close()
+	client := kvpb.NewClusterAdminClient(connection)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), applyTimeout)
+		response, err := client.AddMember(ctx, &kvpb.AddMemberRequest{
+			NodeId:      n.config.NodeID,
+			RaftAddress: n.config.RaftAddress,
+			GrpcAddress: n.config.GrpcAddress,
+		})
+		cancel()
+		if err == nil && response.StatusCode == 200 {
+			n.logger.Info("Joined cluster", zap.String("via", n.config.JoinAddress))
+			return
+		}
+		n.logger.Info("Waiting to join cluster", zap.String("via", n.config.JoinAddress), zap.Error(err))
+		time.Sleep(time.Second)
+	}
+}
+
+func (n *Node) IsLeader() bool {
+	return n.raft.State() == raft.Leader
+}
+
+// Propose replicates command and returns the result of applying it on the leader.
+func (n *Node) Propose(command *Command) (Result, error) {
+	return n.apply(command)
+}
+
+func (n *Node) apply(command *Command) (Result, error) {
+	data, err := json.Marshal(command)
+	if err != nil {
+		return Result{}, err
+	}
+	future := n.raft.Apply(data, applyTimeout)
+	if err := future.Error(); err != nil {
+		return Result{}, err
+	}
+	result, _ := future.Response().(Result)
+	return result, nil
+}
+
+// ForwardToLeader returns a connection to the leader's gRPC endpoint and a
+// context carrying the caller's metadata plus a marker that stops a request
+// bouncing between nodes while leadership changes.
+func (n *Node) ForwardToLeader(ctx context.Context) (*grpc.ClientConn, context.Context, error) {
+	if Forwarded(ctx) {
+		return nil, nil, ErrForwardLoop
+	}
+	_, leaderID := n.raft.LeaderWithID()
+	if leaderID == "" {
+		return nil, nil, ErrNoLeader
+	}
+	var member model.ClusterMember
+	if err := n.fsm.db.First(&member, "node_id = ?", string(leaderID)).Error; err != nil {
+		return nil, nil, fmt.Errorf("leader %s has no known gRPC address: %w", leaderID, err)
+	}
+	connection, err := n.connection(member.GrpcAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+	incoming, _ := metadata.FromIncomingContext(ctx)
+	md := metadata.MD{}
+	for key, values := range incoming {
+		if !strings.HasPrefix(key, ":") {
+			md[key] = values
+		}
+	}
+	md.Set(forwardedKey, n.config.NodeID)
+	return connection, metadata.NewOutgoingContext(ctx, md), nil
+}
+
+func (n *Node) connection(address string) (*grpc.ClientConn, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if connection, ok := n.connections[address]; ok {
+		return connection, nil
+	}
+	connection, err := grpc.NewClient(address, grpc.WithTransportCredentials(n.config.PeerCredentials))
+	if err != nil {
+		return nil, err
+	}
+	n.connections[address] = connection
+	return connection, nil
+}
+
+// Forwarded reports whether another node already forwarded this request.
+func Forwarded(ctx context.Context) bool {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return len(md.Get(forwardedKey)) > 0
+}
+
+// WaitReadIndex blocks until this node has applied every command committed
+// before the call, so a subsequent local read is linearizable.
+func (n *Node) WaitReadIndex(ctx context.Context) error {
+	index, err := n.leaderReadIndex(ctx)
+	if err != nil {
+		return err
+	}
+	return n.waitApplied(ctx, index)
+}
+
+func (n *Node) leaderReadIndex(ctx context.Context) (uint64, error) {
+	if n.IsLeader() {
+		if !n.ready.Load() {
+			return 0, ErrLeaderNotReady
+		}
+		index := n.raft.CommitIndex()
+		if err := n.raft.VerifyLeader().Error(); err != nil {
+			return 0, err
+		}
+		return index, nil
+	}
+	connection, ctx, err := n.ForwardToLeader(ctx)
+	if err != nil {
+		return 0, err
+	}
+	response, err := kvpb.NewClusterAdminClient(connection).ReadIndex(ctx, &kvpb.ReadIndexRequest{})
+	if err != nil {
+		return 0, err
+	}
+	if response.StatusCode != 200 {
+		return 0, errors.New(response.Message)
+	}
+	return response.Index, nil
+}
+
+// waitApplied waits for Raft to process index and for our FSM to apply the
+// last command at or before it. Barriers and no-ops never reach the FSM, so
+// its own counter can legitimately stop short of index.
+func (n *Node) waitApplied(ctx context.Context, index uint64) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for n.raft.AppliedIndex() < index {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	target := n.lastCommandAtOrBefore(index)
+	for n.fsm.applied.Load() < target {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+func (n *Node) lastCommandAtOrBefore(index uint64) uint64 {
+	first, err := n.logStore.FirstIndex()
+	if err != nil {
+		return index
+	}
+	for ; index >= first && index > 0; index-- {
+		var entry raft.Log
+		if err := n.logStore.GetLog(index, &entry); err != nil {
+			// Compacted into a snapshot, which Raft restores before moving on.
+			return 0
+		}
+		if entry.Type == raft.LogCommand {
+			return index
+		}
+	}
+	return 0
+}
+
+func (n *Node) Shutdown() error {
+	return n.raft.Shutdown().Error()
+}
+
+func (n *Node) AddMember(ctx context.Context, request *kvpb.AddMemberRequest) (*kvpb.AddMemberResponse, error) {
+	if request.NodeId == "" || request.RaftAddress == "" || request.GrpcAddress == "" {
+		return &kvpb.AddMemberResponse{
+			Message:    "nodeId, raftAddress and grpcAddress are required",
+			StatusCode: 400,
+		}, nil
+	}
+	if !n.IsLeader() {
+		connection, ctx, err := n.ForwardToLeader(ctx)
+		if err != nil {
+			return &kvpb.AddMemberResponse{Message: err.Error(), StatusCode: 503}, nil
+		}
+		return kvpb.NewClusterAdminClient(connection).AddMember(ctx, request)
+	}
+
+	id, address := raft.ServerID(request.NodeId), raft.ServerAddress(request.RaftAddress)
+	var err error
+	if request.NonVoter {
+		err = n.raft.AddNonvoter(id, address, 0, applyTimeout).Error()
+	} else {
+		err = n.raft.AddVoter(id, address, 0, applyTimeout).Error()
+	}
+	if err == nil {
+		_, err = n.apply(&Command{Op: opMember, NodeID: request.NodeId, RaftAddress: request.RaftAddress, GrpcAddress: request.GrpcAddress})
+	}
+	if err != nil {
+		return &kvpb.AddMemberResponse{Message: err.Error(), StatusCode: 500}, nil
+	}
+	n.logger.Info("Cluster member added", zap.String("node", request.NodeId), zap.Bool("voter", !request.NonVoter))
+	return &kvpb.AddMemberResponse{Message: "Member added", StatusCode: 200}, nil
+}
+
+func (n *Node) RemoveMember(ctx context.Context, request *kvpb.RemoveMemberRequest) (*kvpb.RemoveMemberResponse, error) {
+	if request.NodeId == "" {
+		return &kvpb.RemoveMemberResponse{Message: "nodeId is required", StatusCode: 400}, nil
+	}
+	if !n.IsLeader() {
+		connection, ctx, err := n.ForwardToLeader(ctx)
+		if err != nil {
+			return &kvpb.RemoveMemberResponse{Message: err.Error(), StatusCode: 503}, nil
+		}
+		return kvpb.NewClusterAdminClient(connection).RemoveMember(ctx, request)
+	}
+
+	err := n.raft.RemoveServer(raft.ServerID(request.NodeId), 0, applyTimeout).Error()
+	if err == nil {
+		_, err = n.apply(&Command{Op: opForget, NodeID: request.NodeId})
+	}
+	if err != nil {
+		return &kvpb.RemoveMemberResponse{Message: err.Error(), StatusCode: 500}, nil
+	}
+	n.logger.Info("Cluster member removed", zap.String("node", request.NodeId))
+	return &kvpb.RemoveMemberResponse{Message: "Member removed", StatusCode: 200}, nil
+}
+
+func (n *Node) ListMembers(ctx context.Context, request *kvpb.ListMembersRequest) (*kvpb.ListMembersResponse, error) {
+	future := n.raft.GetConfiguration()
+	if err := future.Error(); err != nil {
+		return &kvpb.ListMembersResponse{Message: err.Error(), StatusCode: 500}, nil
+	}
+	var known []model.ClusterMember
+	if err := n.fsm.db.Find(&known).Error; err != nil {
+		return &kvpb.ListMembersResponse{Message: "Database error", StatusCode: 500}, nil
+	}
+	grpcAddresses := make(map[string]string, len(known))
+	for _, member := range known {
+		grpcAddresses[member.NodeID] = member.GrpcAddress
+	}
+
+	_, leaderID := n.raft.LeaderWithID()
+	var members []*kvpb.Member
+	for _, server := range future.Configuration().Servers {
+		members = append(members, &kvpb.Member{
+			NodeId:      string(server.ID),
+			RaftAddress: string(server.Address),
+			GrpcAddress: grpcAddresses[string(server.ID)],
+			Voter:       server.Suffrage == raft.Voter,
+			Leader:      server.ID == leaderID,
+		})
+	}
+	return &kvpb.ListMembersResponse{Message: "Members listed", StatusCode: 200, Members: members}, nil
+}
+
+func (n *Node) ReadIndex(ctx context.Context, request *kvpb.ReadIndexRequest) (*kvpb.ReadIndexResponse, error) {
+	if !n.IsLeader() {
+		return &kvpb.ReadIndexResponse{Message: "Not the leader", StatusCode: 503}, nil
+	}
+	index, err := n.leaderReadIndex(ctx)
+	if err != nil {
+		return &kvpb.ReadIndexResponse{Message: err.Error(), StatusCode: 503}, nil
+	}
+	return &kvpb.ReadIndexResponse{Message: "Read index confirmed", StatusCode: 200, Index: index}, nil
+}
+
+// fsm applies the replicated log to MySQL. The applied index is stored in the
+// same transaction as each command, so entries replayed after a restart are
+// recognised and skipped instead of being applied twice.
+type fsm struct {
+	db      *gorm.DB
+	store   Store
+	logger  *zap.Logger
+	applied atomic.Uint64
+}
+
+func (f *fsm) Apply(entry *raft.Log) interface{} {
+	if entry.Index <= f.applied.Load() {
+		return nil
+	}
+	var command Command
+	if err := json.Unmarshal(entry.Data, &command); err != nil {
+		f.logger.Error("Undecodable log entry", zap.Uint64("index", entry.Index), zap.Error(err))
+		f.applied.Store(entry.Index)
+		return Result{StatusCode: 400, Message: "Undecodable command"}
+	}
+	command.Index = entry.Index
+
+	var result Result
+	backoff := 10 * time.Millisecond
+	for {
+		err := f.db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			if result, err = f.applyCommand(tx, &command); err != nil {
+				return err
+			}
+			return tx.Save(&model.RaftState{ID: 1, AppliedIndex: entry.Index}).Error
+		})
+		if err == nil {
+			break
+		}
+		// Skipping an entry would silently diverge from the other replicas.
+		f.logger.Error("Failed to apply log entry, retrying", zap.Uint64("index", entry.Index), zap.Error(err))
+		time.Sleep(backoff)
+		if backoff < time.Second {
+			backoff *= 2
+		}
+	}
+	f.applied.Store(entry.Index)
+	f.store.Committed(&command, result)
+	return result
+}
+
+func (f *fsm) applyCommand(tx *gorm.DB, command *Command) (Result, error) {
+	switch command.Op {
+	case opMember:
+		member := model.ClusterMember{NodeID: command.NodeID, RaftAddress: command.RaftAddress, GrpcAddress: command.GrpcAddress}
+		if err := tx.Save(&member).Error; err != nil {
+			return Result{}, err
+		}
+		return Result{StatusCode: 200, Message: "Member recorded"}, nil
+	case opForget:
+		if err := tx.Delete(&model.ClusterMember{}, "node_id = ?", command.NodeID).Error; err != nil {
+			return Result{}, err
+		}
+		return Result{StatusCode: 200, Message: "Member forgotten"}, nil
+	default:
+		return f.store.Apply(tx, command)
+	}
+}
+
+// Snapshot opens a consistent read view now, while no command is being
+// applied; Persist then streams it out concurrently with later applies.
+func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	tx := f.db.Begin(&sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	// InnoDB fixes the read view at the first plain read of the transaction.
+	var state model.RaftState
+	if err := tx.First(&state, 1).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return &snapshot{tx: tx, appliedIndex: state.AppliedIndex}, nil
+}
+
+type snapshotHeader struct {
+	AppliedIndex uint64                `json:"appliedIndex"`
+	Members      []model.ClusterMember `json:"members"`
+}
+
+// snapshotRow is one line after the header: a key row, or when Collection
+// is set, one element of the collection whose key row has ID Owner.
+type snapshotRow struct {
+	ID         uint    `json:"i,omitempty"`
+	Key        string  `json:"k,omitempty"`
+	Value      string  `json:"v,omitempty"`
+	Type       string  `json:"t,omitempty"`
+	Version    uint64  `json:"ver,omitempty"`
+	Flags      uint32  `json:"fl,omitempty"`
+	Collection string  `json:"c,omitempty"`
+	Owner      uint    `json:"o,omitempty"`
+	Name       string  `json:"n,omitempty"`
+	Position   int64   `json:"p,omitempty"`
+	Score      float64 `json:"s,omitempty"`
+}
+
+// restoreBatches buffers restored rows per table.
+type restoreBatches struct {
+	keys             []model.KV
+	hashFields       []model.HashField
+	listItems        []model.ListItem
+	setMembers       []model.SetMember
+	sortedSetMembers []model.SortedSetMember
+}
+
+func (b *restoreBatches) add(tx *gorm.DB, row snapshotRow) error {
+	switch row.Collection {
+	case "":
+		keyType := row.Type
+		if keyType == "" {
+			keyType = model.TypeString
+		}
+		version := row.Version
+		if version == 0 {
+			version = 1
+		}
+		b.keys = append(b.keys, model.KV{ID: row.ID, Key: row.Key, Value: row.Value, Type: keyType, Version: version, Flags: row.Flags})
+	case model.TypeHash:
+		b.hashFields = append(b.hashFields, model.HashField{KVID: row.Owner, Field: row.Name, Value: row.Value})
+	case model.TypeList:
+		b.listItems = append(b.listItems, model.ListItem{KVID: row.Owner, Position: row.Position, Value: row.Value})
+	case model.TypeSet:
+		b.setMembers = append(b.setMembers, model.SetMember{KVID: row.Owner, Member: row.Name})
+	case model.TypeSortedSet:
+		b.sortedSetMembers = append(b.sortedSetMembers, model.SortedSetMember{KVID: row.Owner, Member: row.Name, Score: row.Score})
+	default:
+		return fmt.Errorf("unknown collection %q in snapshot", row.Collection)
+	}
+	if len(b.keys)+len(b.hashFields)+len(b.listItems)+len(b.setMembers)+len(b.sortedSetMembers) >= restoreBatch {
+		return b.flush(tx)
+	}
+	return nil
+}
+
+func (b *restoreBatches) flush(tx *gorm.DB) error {
+	if err := createBatch(tx, &b.keys); err != nil {
+		return err
+	}
+	if err := createBatch(tx, &b.hashFields); err != nil {
+		return err
+	}
+	if err := createBatch(tx, &b.listItems); err != nil {
+		return err
+	}
+	if err := createBatch(tx, &b.setMembers); err != nil {
+		return err
+	}
+	return createBatch(tx, &b.sortedSetMembers)
+}
+
+func createBatch[T any](tx *gorm.DB, batch *[]T) error {
+	if len(*batch) == 0 {
+		return nil
+	}
+	if err := tx.Create(batch).Error; err != nil {
+		return err
+	}
+	*batch = (*batch)[:0]
+	return nil
+}
+
+func (f *fsm) Restore(reader io.ReadCloser) error {
+	defer reader.Close()
+	decoder := json.NewDecoder(reader)
+
+	var header snapshotHeader
+	if err := decoder.Decode(&header); err != nil {
+		return err
+	}
+	if header.AppliedIndex <= f.applied.Load() {
+		// Our database already reflects everything in the snapshot.
+		return nil
+	}
+
+	err := f.db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range []any{&model.KV{}, &model.HashField{}, &model.ListItem{}, &model.SetMember{}, &model.SortedSetMember{}} {
+			if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(table).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.ClusterMember{}).Error; err != nil {
+			return err
+		}
+		if len(header.Members) > 0 {
+			if err := tx.Create(&header.Members).Error; err != nil {
+				return err
+			}
+		}
+		var batches restoreBatches
+		for {
+			var row snapshotRow
+			err := decoder.Decode(&row)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if err := batches.add(tx, row); err != nil {
+				return err
+			}
+		}
+		if err := batches.flush(tx); err != nil {
+			return err
+		}
+		return tx.Save(&model.RaftState{ID: 1, AppliedIndex: header.AppliedIndex}).Error
+	})
+	if err != nil {
+		return err
+	}
+	f.applied.Store(header.AppliedIndex)
+	f.store.Restored()
+	return nil
+}
+
+type snapshot struct {
+	tx           *gorm.DB
+	appliedIndex uint64
+}
+
+func (s *snapshot) Persist(sink raft.SnapshotSink) error {
+	if err := s.write(sink); err != nil {
+		sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+func (s *snapshot) write(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	header := snapshotHeader{AppliedIndex: s.appliedIndex}
+	if err := s.tx.Find(&header.Members).Error; err != nil {
+		return err
+	}
+	if err := encoder.Encode(&header); err != nil {
+		return err
+	}
+	// Key rows keep their IDs so collection elements still point at them after a restore.
+	err := writeRows(s.tx, encoder, "id", func(kv model.KV) snapshotRow {
+		return snapshotRow{ID: kv.ID, Key: kv.Key, Value: kv.Value, Type: kv.Type, Version: kv.Version, Flags: kv.Flags}
+	})
+	if err != nil {
+		return err
+	}
+	err = writeRows(s.tx, encoder, "kv_id, field", func(field model.HashField) snapshotRow {
+		return snapshotRow{Collection: model.TypeHash, Owner: field.KVID, Name: field.Field, Value: field.Value}
+	})
+	if err != nil {
+		return err
+	}
+	err = writeRows(s.tx, encoder, "kv_id, position", func(item model.ListItem) snapshotRow {
+		return snapshotRow{Collection: model.TypeList, Owner: item.KVID, Position: item.Position, Value: item.Value}
+	})
+	if err != nil {
+		return err
+	}
+	err = writeRows(s.tx, encoder, "kv_id, member", func(member model.SetMember) snapshotRow {
+		return snapshotRow{Collection: model.TypeSet, Owner: member.KVID, Name: member.Member}
+	})
+	if err != nil {
+		return err
+	}
+	return writeRows(s.tx, encoder, "kv_id, member", func(member model.SortedSetMember) snapshotRow {
+		return snapshotRow{Collection: model.TypeSortedSet, Owner: member.KVID, Name: member.Member, Score: member.Score}
+	})
+}
+
+// writeRows streams one table out of the snapshot's read view.
+func writeRows[T any](tx *gorm.DB, encoder *json.Encoder, order string, toRow func(T) snapshotRow) error {
+	rows, err := tx.Model(new(T)).Order(order).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item T
+		if err := tx.ScanRows(rows, &item); err != nil {
+			return err
+		}
+		if err := encoder.Encode(toRow(item)); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *snapshot) Release() {
+	s.tx.Rollback()
+}
@@ -0,0 +1,153 @@
+package cluster
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/hashicorp/raft"
+	"github.com/kv-storage/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// store stands in for the key-value store. It records a member row for each
+// command, so a rolled back attempt is visible, and fails the first failures
+// attempts at each command.
+type store struct {
+	failures  int
+	attempts  map[string]int
+	committed []Result
+}
+
+func (s *store) Apply(tx *gorm.DB, command *Command) (Result, error) {
+	s.attempts[command.Key]++
+	if err := tx.Create(&model.ClusterMember{NodeID: command.Key + "-" + strconv.Itoa(s.attempts[command.Key])}).Error; err != nil {
+		return Result{}, err
+	}
+	if s.attempts[command.Key] <= s.failures {
+		return Result{}, errors.New("deadlock")
+	}
+	return Result{StatusCode: 200, Message: command.Value}, nil
+}
+
+func (s *store) Committed(command *Command, result Result) {
+	s.committed = append(s.committed, result)
+}
+
+func (s *store) Restored() {}
+
+func newFSM(t *testing.T, failures int) (*fsm, *store) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "fsm.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.RaftState{}, &model.ClusterMember{}); err != nil {
+		t.Fatal(err)
+	}
+	s := &store{failures: failures, attempts: map[string]int{}}
+	return &fsm{db: db, store: s, logger: zap.NewNop()}, s
+}
+
+func entry(t *testing.T, index uint64, command Command) *raft.Log {
+	t.Helper()
+	data, err := json.Marshal(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &raft.Log{Index: index, Data: data}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		applied uint64
+		entry   func(t *testing.T) *raft.Log
+		// want is nil for an entry that is skipped
+		want      *Result
+		rows      int64
+		appliedTo uint64
+	}{
+		{
+			name:      "applies a command and records its index",
+			entry:     func(t *testing.T) *raft.Log { return entry(t, 1, Command{Op: "set", Key: "k", Value: "done"}) },
+			want:      &Result{StatusCode: 200, Message: "done"},
+			rows:      1,
+			appliedTo: 1,
+		},
+		{
+			name:      "skips an entry replayed after a restart",
+			applied:   5,
+			entry:     func(t *testing.T) *raft.Log { return entry(t, 5, Command{Op: "set", Key: "k", Value: "done"}) },
+			rows:      0,
+			appliedTo: 5,
+		},
+		{
+			name:      "moves past an undecodable entry",
+			entry:     func(t *testing.T) *raft.Log { return &raft.Log{Index: 1, Data: []byte("{")} },
+			want:      &Result{StatusCode: 400, Message: "Undecodable command"},
+			rows:      0,
+			appliedTo: 1,
+		},
+		{
+			name: "records a member",
+			entry: func(t *testing.T) *raft.Log {
+				return entry(t, 1, Command{Op: opMember, NodeID: "node-2", RaftAddress: "r", GrpcAddress: "g"})
+			},
+			want:      &Result{StatusCode: 200, Message: "Member recorded"},
+			rows:      1,
+			appliedTo: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, _ := newFSM(t, 0)
+			f.applied.Store(test.applied)
+			got := f.Apply(test.entry(t))
+			result, applied := got.(Result)
+			switch {
+			case test.want == nil && got != nil:
+				t.Fatalf("got %+v, want the entry skipped", got)
+			case test.want != nil && (!applied || result.StatusCode != test.want.StatusCode || result.Message != test.want.Message):
+				t.Fatalf("got %+v, want %+v", got, *test.want)
+			}
+			var rows int64
+			f.db.Model(&model.ClusterMember{}).Count(&rows)
+			if rows != test.rows {
+				t.Fatalf("%d member rows, want %d", rows, test.rows)
+			}
+			if f.applied.Load() != test.appliedTo {
+				t.Fatalf("applied index %d, want %d", f.applied.Load(), test.appliedTo)
+			}
+		})
+	}
+}
+
+func TestApplyRetriesUntilTheCommandCommits(t *testing.T) {
+	f, s := newFSM(t, 2)
+	got := f.Apply(entry(t, 7, Command{Op: "set", Key: "k", Value: "done"}))
+
+	if result, ok := got.(Result); !ok || result.StatusCode != 200 {
+		t.Fatalf("got %+v, want the result of the third attempt", got)
+	}
+	if s.attempts["k"] != 3 {
+		t.Fatalf("%d attempts, want 3", s.attempts["k"])
+	}
+	// The failed attempts rolled back their writes along with the index
+	var members []model.ClusterMember
+	f.db.Find(&members)
+	if len(members) != 1 || members[0].NodeID != "k-3" {
+		t.Fatalf("members %+v, want only the third attempt's", members)
+	}
+	var state model.RaftState
+	if err := f.db.First(&state, 1).Error; err != nil || state.AppliedIndex != 7 {
+		t.Fatalf("stored applied index %d (%v), want 7", state.AppliedIndex, err)
+	}
+	if len(s.committed) != 1 {
+		t.Fatalf("committed %d times, want once", len(s.committed))
+	}
+}
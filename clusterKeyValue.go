@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	cacheModule "github.com/kv-storage/cache"
+	"github.com/kv-storage/changefeed"
+	"github.com/kv-storage/cluster"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// kvStateMachine applies replicated key-value commands to this node's own database.
+type kvStateMachine struct{}
+
+// errDatabase stands for the database error behind a 500 from the shared
+// write paths, which report it as a status rather than returning it.
+var errDatabase = errors.New("database error")
+
+func (kvStateMachine) Apply(tx *gorm.DB, command *cluster.Command) (cluster.Result, error) {
+	result := applyCommand(tx, command)
+	if result.StatusCode == StatusInternalServerError {
+		// The transaction must roll back, or the entry counts as applied on this replica alone
+		return cluster.Result{}, errDatabase
+	}
+	return result, nil
+}
+
+func applyCommand(tx *gorm.DB, command *cluster.Command) cluster.Result {
+	var statusCode int64
+	var message string
+	switch command.Op {
+	case cluster.OpSet:
+		mode := command.Mode
+		if mode == "" {
+			mode = SetModeCreate
+		}
+		write := keyWrite{
+			key:             command.Key,
+			value:           command.Value,
+			mode:            mode,
+			flags:           command.Flags,
+			expectedVersion: command.ExpectedVersion,
+			version:         command.Index,
+		}
+		var version uint64
+		var previous *string
+		statusCode, message, version, previous = writeKeyValue(tx, write)
+		return cluster.Result{StatusCode: statusCode, Message: message, Version: version, Previous: previous}
+	case cluster.OpDelete:
+		var version uint64
+		var previous *string
+		statusCode, message, version, previous = removeKeyValue(tx, command.Key, command.ExpectedVersion, command.Index)
+		return cluster.Result{StatusCode: statusCode, Message: message, Version: version, Previous: previous}
+	case cluster.OpIncrement:
+		c := counter{
+			key:             command.Key,
+			delta:           command.Delta,
+			initial:         command.Initial,
+			min:             command.Min,
+			max:             command.Max,
+			saturate:        command.Saturate,
+			requireExisting: command.RequireExisting,
+			version:         command.Index,
+		}
+		var stored counterValue
+		statusCode, message, stored, _ = updateCounter(tx, c, time.Now())
+		return cluster.Result{
+			StatusCode: statusCode,
+			Message:    message,
+			Value:      strconv.FormatInt(stored.value, 10),
+			Version:    stored.version,
+			Flags:      stored.flags,
+		}
+	case cluster.OpCollection:
+		var op collectionOp
+		if err := json.Unmarshal(command.Collection, &op); err != nil {
+			return cluster.Result{StatusCode: StatusBadRequest, Message: "Undecodable collection operation"}
+		}
+		op.Key = command.Key
+		result := applyCollection(tx, op)
+		return cluster.Result{StatusCode: result.StatusCode, Message: result.Message, Count: result.Count, Values: result.Values}
+	case cluster.OpBulkSet:
+		var write bulkWrite
+		if err := json.Unmarshal(command.Bulk, &write); err != nil {
+			return cluster.Result{StatusCode: StatusBadRequest, Message: "Undecodable bulk write"}
+		}
+		write.version = command.Index
+		statuses, err := applyBulk(tx, write)
+		if err != nil {
+			return cluster.Result{StatusCode: StatusInternalServerError, Message: "Database error"}
+		}
+		return cluster.Result{StatusCode: StatusOK, Message: "Batch written", Statuses: statuses}
+	default:
+		statusCode, message = StatusBadRequest, "Unknown command"
+	}
+	return cluster.Result{StatusCode: statusCode, Message: message}
+}
+
+func (kvStateMachine) Committed(command *cluster.Command, result cluster.Result) {
+	switch {
+	case command.Op == cluster.OpSet && (result.StatusCode == StatusCreated || result.StatusCode == StatusOK):
+		cache.Put(command.Key, cacheModule.Entry{Value: command.Value, Version: result.Version, Flags: command.Flags})
+	case command.Op == cluster.OpDelete && result.StatusCode == StatusOK:
+		cache.Delete(command.Key, result.Version)
+	case command.Op == cluster.OpIncrement && result.StatusCode == StatusOK:
+		cache.Put(command.Key, cacheModule.Entry{Value: result.Value, Version: result.Version, Flags: result.Flags})
+	case command.Op == cluster.OpBulkSet && result.StatusCode == StatusOK:
+		var write bulkWrite
+		if json.Unmarshal(command.Bulk, &write) != nil {
+			cache.Flush()
+			return
+		}
+		for i, record := range write.Records {
+			if result.Statuses[i] != StatusConflict {
+				cache.DeleteKey(record.Key)
+			}
+		}
+	}
+}
+
+func (kvStateMachine) Restored() {
+	cache.Flush()
+	// This node's change log does not lead up to the restored data
+	if err := changefeed.Reset(kvDbConnector); err != nil {
+		logger.Warn("Failed to reset the change log after restoring a snapshot", zap.Error(err))
+	}
+}
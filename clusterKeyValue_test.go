@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kv-storage/cluster"
+	"github.com/kv-storage/model"
+	"gorm.io/gorm"
+)
+
+func TestReplicatedWritesAreVersionedByLogIndex(t *testing.T) {
+	bulk, err := json.Marshal(bulkWrite{Overwrite: true, Records: []bulkRecord{{Key: "b", Value: "1"}, {Key: "c", Value: "2"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		command cluster.Command
+		keys    []string
+	}{
+		{"a set", cluster.Command{Op: cluster.OpSet, Key: "a", Value: "v", Mode: SetModeUpsert}, []string{"a"}},
+		{"an increment", cluster.Command{Op: cluster.OpIncrement, Key: "hits", Delta: 1}, []string{"hits"}},
+		{"a bulk write", cluster.Command{Op: cluster.OpBulkSet, Bulk: bulk}, []string{"b", "c"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useStore(t)
+			// The next change sequence is 3, which only this replica would hand out
+			set(t, "x", "1")
+			set(t, "y", "1")
+			test.command.Index = 2
+			var result cluster.Result
+			err := kvDbConnector.Transaction(func(tx *gorm.DB) error {
+				var err error
+				result, err = kvStateMachine{}.Apply(tx, &test.command)
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.command.Op != cluster.OpBulkSet && result.Version != 2 {
+				t.Fatalf("result version %d, want the log index 2", result.Version)
+			}
+			for _, key := range test.keys {
+				var kv model.KV
+				if err := kvDbConnector.First(&kv, "key_name = ?", key).Error; err != nil {
+					t.Fatal(err)
+				}
+				if kv.Version != 2 {
+					t.Fatalf("%s stored at version %d, want the log index 2", key, kv.Version)
+				}
+			}
+		})
+	}
+}
+
+func TestReplicatedDeleteIsVersionedByLogIndex(t *testing.T) {
+	useStore(t)
+	set(t, "a", "v")
+	var result cluster.Result
+	err := kvDbConnector.Transaction(func(tx *gorm.DB) error {
+		var err error
+		result, err = kvStateMachine{}.Apply(tx, &cluster.Command{Op: cluster.OpDelete, Key: "a", Index: 7})
+		return err
+	})
+	if err != nil || result.StatusCode != StatusOK || result.Version != 7 {
+		t.Fatalf("got %v, %v, want a delete at version 7", result, err)
+	}
+}
@@ -0,0 +1,802 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kv-storage/audit"
+	"github.com/kv-storage/client"
+	kvpb "github.com/kv-storage/proto/kv"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultAddress = "localhost:50051"
+
+// profile is one named set of connection settings in the config file.
+type profile struct {
+	Addresses []string      `yaml:"addresses"`
+	Timeout   time.Duration `yaml:"timeout"`
+	Retries   *int          `yaml:"retries"`
+	// Token is an API key or JWT
+	Token string `yaml:"token"`
+	// TLS is switched on by tls: true or by any of the files below
+	TLS        bool   `yaml:"tls"`
+	CAFile     string `yaml:"caFile"`
+	CertFile   string `yaml:"certFile"`
+	KeyFile    string `yaml:"keyFile"`
+	ServerName string `yaml:"serverName"`
+}
+
+// configFile is kvctl's config, by default ~/.config/kvctl/config.yaml:
+//
+//	current: local
+//	profiles:
+//	  local:
+//	    addresses: [localhost:50051]
+//	  prod:
+//	    addresses: [kv-1:50051, kv-2:50051]
+//	    timeout: 2s
+//	    caFile: /etc/kv/ca.pem
+//	    certFile: /etc/kv/client.pem
+//	    keyFile: /etc/kv/client-key.pem
+type configFile struct {
+	Current  string             `yaml:"current"`
+	Profiles map[string]profile `yaml:"profiles"`
+}
+
+var global struct {
+	configPath  string
+	profileName string
+	addresses   []string
+	timeout     time.Duration
+	token       string
+	output      string
+}
+
+func main() {
+	root := &cobra.Command{
+		Use:           "kvctl",
+		Short:         "Command-line client for the kv-storage gRPC API",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			switch global.output {
+			case "plain", "json", "table":
+				return nil
+			}
+			return fmt.Errorf("unknown output format %q, expected plain, json or table", global.output)
+		},
+	}
+	flags := root.PersistentFlags()
+	flags.StringVar(&global.configPath, "config", "", "config file (default $KVCTL_CONFIG or ~/.config/kvctl/config.yaml)")
+	flags.StringVarP(&global.profileName, "profile", "p", "", "connection profile (default $KVCTL_PROFILE or the config's current)")
+	flags.StringSliceVarP(&global.addresses, "address", "a", nil, "server addresses, overriding the profile")
+	flags.DurationVar(&global.timeout, "timeout", 0, "per-call timeout, overriding the profile")
+	flags.StringVar(&global.token, "token", "", "API key or JWT (default $KVCTL_TOKEN or the profile's token)")
+	flags.StringVarP(&global.output, "output", "o", "plain", "output format: plain, json or table")
+
+	root.AddCommand(getCommand(), setCommand(), delCommand(), scanCommand(), watchCommand(), statsCommand(), benchCommand(), auditCommand())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := root.ExecuteContext(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "kvctl:", err)
+		os.Exit(1)
+	}
+}
+
+// loadProfile picks the profile named by the flags, the environment or the
+// config file, in that order. A missing default config file is not an error.
+func loadProfile() (profile, error) {
+	path := global.configPath
+	if path == "" {
+		path = os.Getenv("KVCTL_CONFIG")
+	}
+	explicit := path != ""
+	if !explicit {
+		dir, err := os.UserConfigDir()
+		if err == nil {
+			path = filepath.Join(dir, "kvctl", "config.yaml")
+		}
+	}
+	var config configFile
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := yaml.Unmarshal(data, &config); err != nil {
+				return profile{}, fmt.Errorf("reading %s: %w", path, err)
+			}
+		case explicit || !errors.Is(err, os.ErrNotExist):
+			return profile{}, err
+		}
+	}
+
+	name := global.profileName
+	if name == "" {
+		name = os.Getenv("KVCTL_PROFILE")
+	}
+	if name == "" {
+		name = config.Current
+	}
+	var selected profile
+	if name != "" {
+		var ok bool
+		if selected, ok = config.Profiles[name]; !ok {
+			return profile{}, fmt.Errorf("no profile %q in %s", name, path)
+		}
+	}
+	if len(global.addresses) > 0 {
+		selected.Addresses = global.addresses
+	}
+	if len(selected.Addresses) == 0 {
+		selected.Addresses = []string{defaultAddress}
+	}
+	if global.timeout > 0 {
+		selected.Timeout = global.timeout
+	}
+	if global.token != "" {
+		selected.Token = global.token
+	} else if token := os.Getenv("KVCTL_TOKEN"); token != "" {
+		selected.Token = token
+	}
+	return selected, nil
+}
+
+func connect() (*client.Client, error) {
+	selected, err := loadProfile()
+	if err != nil {
+		return nil, err
+	}
+	var opts []client.Option
+	if selected.Timeout > 0 {
+		opts = append(opts, client.WithTimeout(selected.Timeout))
+	}
+	if selected.Retries != nil {
+		opts = append(opts, client.WithRetries(*selected.Retries))
+	}
+	if selected.Token != "" {
+		opts = append(opts, client.WithToken(selected.Token))
+	}
+	if selected.TLS || selected.CAFile != "" || selected.CertFile != "" {
+		config, err := tlsConfig(selected)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.WithDialOptions(grpc.WithTransportCredentials(credentials.NewTLS(config))))
+	}
+	return client.New(selected.Addresses, opts...)
+}
+
+// tlsConfig verifies the server against the profile's CA bundle, or the
+// system roots without one, and presents its client certificate for mTLS.
+func tlsConfig(selected profile) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: selected.ServerName}
+	if selected.CAFile != "" {
+		pem, err := os.ReadFile(selected.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", selected.CAFile)
+		}
+	}
+	if selected.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(selected.CertFile, selected.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	return config, nil
+}
+
+// render prints a result in the chosen format: plain writes what a shell
+// script wants to consume, json the value itself, and table the rows.
+func render(w io.Writer, value any, header []string, rows [][]string, plain func(io.Writer)) error {
+	switch global.output {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case "table":
+		table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, strings.Join(header, "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(table, strings.Join(row, "\t"))
+		}
+		return table.Flush()
+	}
+	plain(w)
+	return nil
+}
+
+type item struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Version uint64 `json:"version"`
+	Flags   uint32 `json:"flags"`
+}
+
+func getCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get KEY...",
+		Short: "Print the value of one or more keys",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kv, err := connect()
+			if err != nil {
+				return err
+			}
+			defer kv.Close()
+			items := make([]item, 0, len(args))
+			for _, key := range args {
+				got, err := kv.GetItem(cmd.Context(), key)
+				if err != nil {
+					return fmt.Errorf("%s: %w", key, err)
+				}
+				items = append(items, item{Key: key, Value: got.Value, Version: got.Version, Flags: got.Flags})
+			}
+			rows := make([][]string, len(items))
+			for i, it := range items {
+				rows[i] = []string{it.Key, it.Value, strconv.FormatUint(it.Version, 10), strconv.FormatUint(uint64(it.Flags), 10)}
+			}
+			var value any = items
+			if len(items) == 1 {
+				value = items[0]
+			}
+			return render(cmd.OutOrStdout(), value, []string{"KEY", "VALUE", "VERSION", "FLAGS"}, rows, func(w io.Writer) {
+				for _, it := range items {
+					fmt.Fprintln(w, it.Value)
+				}
+			})
+		},
+	}
+}
+
+func setCommand() *cobra.Command {
+	var (
+		file        string
+		ttl         time.Duration
+		flags       uint32
+		ifNotExists bool
+		ifExists    bool
+		ifVersion   uint64
+	)
+	cmd := &cobra.Command{
+		Use:   "set KEY [VALUE|-]",
+		Short: "Write a key, taking the value from the argument, --file or stdin",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var value string
+			switch {
+			case len(args) == 2 && args[1] != "-":
+				if file != "" {
+					return errors.New("give the value either as an argument or with --file")
+				}
+				value = args[1]
+			case file != "" && file != "-":
+				data, err := os.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				value = string(data)
+			default:
+				data, err := io.ReadAll(cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+				value = string(data)
+			}
+
+			var opts []client.WriteOption
+			switch {
+			case ifVersion != 0:
+				opts = append(opts, client.IfVersion(ifVersion))
+			case ifNotExists && ifExists:
+				return errors.New("--if-not-exists and --if-exists cannot be combined")
+			case ifNotExists:
+				opts = append(opts, client.IfNotExists())
+			case ifExists:
+				opts = append(opts, client.IfExists())
+			}
+			if ttl > 0 {
+				opts = append(opts, client.WithTTL(ttl))
+			}
+			if flags != 0 {
+				opts = append(opts, client.WithFlags(flags))
+			}
+
+			kv, err := connect()
+			if err != nil {
+				return err
+			}
+			defer kv.Close()
+			version, err := kv.Set(cmd.Context(), args[0], value, opts...)
+			if err != nil {
+				return err
+			}
+			result := struct {
+				Key     string `json:"key"`
+				Version uint64 `json:"version"`
+			}{args[0], version}
+			return render(cmd.OutOrStdout(), result, []string{"KEY", "VERSION"}, [][]string{{args[0], strconv.FormatUint(version, 10)}}, func(w io.Writer) {
+				fmt.Fprintln(w, version)
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "read the value from a file, - for stdin")
+	cmd.Flags().DurationVar(&ttl, "ttl", 0, "expire the key after this long")
+	cmd.Flags().Uint32Var(&flags, "flags", 0, "opaque flags stored with the value")
+	cmd.Flags().BoolVar(&ifNotExists, "if-not-exists", false, "only create the key")
+	cmd.Flags().BoolVar(&ifExists, "if-exists", false, "only replace an existing key")
+	cmd.Flags().Uint64Var(&ifVersion, "if-version", 0, "only write while the key is at this version")
+	return cmd
+}
+
+func delCommand() *cobra.Command {
+	var ifVersion uint64
+	cmd := &cobra.Command{
+		Use:   "del KEY...",
+		Short: "Delete one or more keys",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if ifVersion != 0 && len(args) > 1 {
+				return errors.New("--if-version takes a single key")
+			}
+			var opts []client.WriteOption
+			if ifVersion != 0 {
+				opts = append(opts, client.IfVersion(ifVersion))
+			}
+			kv, err := connect()
+			if err != nil {
+				return err
+			}
+			defer kv.Close()
+			for _, key := range args {
+				if err := kv.Delete(cmd.Context(), key, opts...); err != nil {
+					return fmt.Errorf("%s: %w", key, err)
+				}
+			}
+			return render(cmd.OutOrStdout(), map[string][]string{"deleted": args}, []string{"DELETED"}, columns(args), func(io.Writer) {})
+		},
+	}
+	cmd.Flags().Uint64Var(&ifVersion, "if-version", 0, "only delete while the key is at this version")
+	return cmd
+}
+
+func columns(values []string) [][]string {
+	rows := make([][]string, len(values))
+	for i, value := range values {
+		rows[i] = []string{value}
+	}
+	return rows
+}
+
+func scanCommand() *cobra.Command {
+	var (
+		match  string
+		limit  int
+		values bool
+	)
+	cmd := &cobra.Command{
+		Use:   "scan",
+		Short: "List keys, optionally matching a glob pattern",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kv, err := connect()
+			if err != nil {
+				return err
+			}
+			defer kv.Close()
+			keys, err := scanKeys(cmd.Context(), kv, match, limit)
+			if err != nil {
+				return err
+			}
+			if !values {
+				return render(cmd.OutOrStdout(), keys, []string{"KEY"}, columns(keys), func(w io.Writer) {
+					for _, key := range keys {
+						fmt.Fprintln(w, key)
+					}
+				})
+			}
+
+			items := make([]item, 0, len(keys))
+			for _, key := range keys {
+				got, err := kv.GetItem(cmd.Context(), key)
+				if errors.Is(err, client.ErrNotFound) || errors.Is(err, client.ErrWrongType) {
+					// Deleted since the scan, or a collection without a single value
+					continue
+				}
+				if err != nil {
+					return fmt.Errorf("%s: %w", key, err)
+				}
+				items = append(items, item{Key: key, Value: got.Value, Version: got.Version, Flags: got.Flags})
+			}
+			rows := make([][]string, len(items))
+			for i, it := range items {
+				rows[i] = []string{it.Key, it.Value, strconv.FormatUint(it.Version, 10)}
+			}
+			return render(cmd.OutOrStdout(), items, []string{"KEY", "VALUE", "VERSION"}, rows, func(w io.Writer) {
+				for _, it := range items {
+					fmt.Fprintf(w, "%s\t%s\n", it.Key, it.Value)
+				}
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&match, "match", "m", "", "only keys matching this glob, e.g. user:*")
+	cmd.Flags().IntVarP(&limit, "limit", "n", 0, "stop after this many keys, 0 for all")
+	cmd.Flags().BoolVar(&values, "values", false, "also fetch each key's value")
+	return cmd
+}
+
+// scanKeys pages through Scan until the cursor runs out or limit keys are found.
+func scanKeys(ctx context.Context, kv *client.Client, match string, limit int) ([]string, error) {
+	keys := []string{}
+	var cursor uint64
+	for {
+		response, err := kv.KeyValueStore().Scan(ctx, &kvpb.ScanRequest{Cursor: cursor, Match: match, Count: 1000})
+		if err != nil {
+			return nil, err
+		}
+		if response.StatusCode != 200 {
+			return nil, &client.StatusError{StatusCode: response.StatusCode, Message: response.Message}
+		}
+		keys = append(keys, response.Keys...)
+		if limit > 0 && len(keys) >= limit {
+			return keys[:limit], nil
+		}
+		if cursor = response.Cursor; cursor == 0 {
+			return keys, nil
+		}
+	}
+}
+
+func watchCommand() *cobra.Command {
+	var (
+		prefix string
+		after  uint64
+	)
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Stream key changes as they commit",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kv, err := connect()
+			if err != nil {
+				return err
+			}
+			defer kv.Close()
+			feed := kvpb.NewChangeFeedClient(kv.Connection())
+			stream, err := feed.ReadChanges(cmd.Context(), &kvpb.ReadChangesRequest{
+				AfterSequence: after,
+				Prefix:        prefix,
+				Follow:        true,
+				FromNow:       after == 0,
+			})
+			if err != nil {
+				return err
+			}
+
+			w := cmd.OutOrStdout()
+			encoder := json.NewEncoder(w)
+			// Rows are printed as they arrive, so the table cannot size its columns up front
+			table := tabwriter.NewWriter(w, 12, 4, 2, ' ', 0)
+			if global.output == "table" {
+				fmt.Fprintln(table, "SEQUENCE\tTIME\tOP\tKEY\tVALUE")
+				table.Flush()
+			}
+			for {
+				change, err := stream.Recv()
+				if err != nil {
+					if cmd.Context().Err() != nil {
+						return nil
+					}
+					return err
+				}
+				at := time.UnixMilli(change.Timestamp).Format(time.RFC3339)
+				switch global.output {
+				case "json":
+					// One object per line, so the output can be piped into jq as it streams
+					encoder.Encode(struct {
+						Sequence  uint64 `json:"sequence"`
+						Op        string `json:"op"`
+						Key       string `json:"key"`
+						Value     string `json:"value,omitempty"`
+						Timestamp int64  `json:"timestamp"`
+					}{change.Sequence, change.Op, change.Key, change.Value, change.Timestamp})
+				case "table":
+					fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%s\n", change.Sequence, at, change.Op, change.Key, change.Value)
+					table.Flush()
+				default:
+					fmt.Fprintf(w, "%d %s %s %s\n", change.Sequence, change.Op, change.Key, change.Value)
+				}
+			}
+		},
+	}
+	cmd.Flags().StringVar(&prefix, "prefix", "", "only keys starting with this prefix")
+	cmd.Flags().Uint64Var(&after, "after", 0, "resume after this change sequence instead of starting from now")
+	return cmd
+}
+
+type stats struct {
+	Health  string        `json:"health"`
+	Latency time.Duration `json:"latencyNanoseconds"`
+	Keys    *int          `json:"keys,omitempty"`
+	Members []string      `json:"members,omitempty"`
+	Leader  string        `json:"leader,omitempty"`
+	Ring    []string      `json:"ring,omitempty"`
+}
+
+func statsCommand() *cobra.Command {
+	var countKeys bool
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show server health, key count and cluster or ring membership",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kv, err := connect()
+			if err != nil {
+				return err
+			}
+			defer kv.Close()
+			ctx := cmd.Context()
+
+			var result stats
+			started := time.Now()
+			health, err := healthpb.NewHealthClient(kv.Connection()).Check(ctx, &healthpb.HealthCheckRequest{})
+			result.Latency = time.Since(started)
+			if err != nil {
+				return err
+			}
+			result.Health = health.Status.String()
+			if countKeys {
+				keys, err := scanKeys(ctx, kv, "", 0)
+				if err != nil {
+					return err
+				}
+				count := len(keys)
+				result.Keys = &count
+			}
+			// Each node only registers the admin service of the mode it runs in
+			members, err := kvpb.NewClusterAdminClient(kv.Connection()).ListMembers(ctx, &kvpb.ListMembersRequest{})
+			if err != nil && status.Code(err) != codes.Unimplemented {
+				return err
+			}
+			for _, member := range members.GetMembers() {
+				result.Members = append(result.Members, member.NodeId+"="+member.GrpcAddress)
+				if member.Leader {
+					result.Leader = member.NodeId
+				}
+			}
+			ring, err := kvpb.NewShardRingClient(kv.Connection()).GetRing(ctx, &kvpb.GetRingRequest{})
+			if err != nil && status.Code(err) != codes.Unimplemented {
+				return err
+			}
+			for _, node := range ring.GetNodes() {
+				result.Ring = append(result.Ring, node.NodeId+"="+node.Address)
+			}
+
+			rows := [][]string{{"health", result.Health}, {"latency", result.Latency.String()}}
+			if result.Keys != nil {
+				rows = append(rows, []string{"keys", strconv.Itoa(*result.Keys)})
+			}
+			if result.Members != nil {
+				rows = append(rows, []string{"members", strings.Join(result.Members, ",")}, []string{"leader", result.Leader})
+			}
+			if result.Ring != nil {
+				rows = append(rows, []string{"ring", strings.Join(result.Ring, ",")})
+			}
+			return render(cmd.OutOrStdout(), result, []string{"STAT", "VALUE"}, rows, func(w io.Writer) {
+				for _, row := range rows {
+					fmt.Fprintf(w, "%s: %s\n", row[0], row[1])
+				}
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&countKeys, "keys", true, "count keys with a full scan")
+	return cmd
+}
+
+type benchResult struct {
+	Operations int64   `json:"operations"`
+	Errors     int64   `json:"errors"`
+	Seconds    float64 `json:"seconds"`
+	Throughput float64 `json:"operationsPerSecond"`
+	Reads      latency `json:"reads"`
+	Writes     latency `json:"writes"`
+}
+
+type latency struct {
+	Count int64         `json:"count"`
+	P50   time.Duration `json:"p50Nanoseconds"`
+	P95   time.Duration `json:"p95Nanoseconds"`
+	P99   time.Duration `json:"p99Nanoseconds"`
+	Max   time.Duration `json:"maxNanoseconds"`
+}
+
+func summarize(samples []time.Duration) latency {
+	if len(samples) == 0 {
+		return latency{}
+	}
+	slices.Sort(samples)
+	at := func(q float64) time.Duration { return samples[int(q*float64(len(samples)-1))] }
+	return latency{Count: int64(len(samples)), P50: at(0.50), P95: at(0.95), P99: at(0.99), Max: samples[len(samples)-1]}
+}
+
+func benchCommand() *cobra.Command {
+	var (
+		duration    time.Duration
+		requests    int64
+		concurrency int
+		keyspace    int
+		valueSize   int
+		readRatio   float64
+		prefix      string
+	)
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Measure throughput and latency with a mix of gets and sets",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if concurrency < 1 || keyspace < 1 || readRatio < 0 || readRatio > 1 {
+				return errors.New("--concurrency and --keys must be positive and --reads between 0 and 1")
+			}
+			kv, err := connect()
+			if err != nil {
+				return err
+			}
+			defer kv.Close()
+			ctx, cancel := context.WithTimeout(cmd.Context(), duration)
+			defer cancel()
+			value := strings.Repeat("x", valueSize)
+
+			var (
+				mu               sync.Mutex
+				reads, writes    []time.Duration
+				issued, failures int64
+				wg               sync.WaitGroup
+			)
+			started := time.Now()
+			for worker := 0; worker < concurrency; worker++ {
+				wg.Add(1)
+				go func(seed int64) {
+					defer wg.Done()
+					random := rand.New(rand.NewSource(seed))
+					var myReads, myWrites []time.Duration
+					var myErrors int64
+					for ctx.Err() == nil {
+						mu.Lock()
+						if requests > 0 && issued >= requests {
+							mu.Unlock()
+							break
+						}
+						issued++
+						mu.Unlock()
+
+						key := prefix + strconv.Itoa(random.Intn(keyspace))
+						at := time.Now()
+						var err error
+						read := random.Float64() < readRatio
+						if read {
+							_, err = kv.Get(ctx, key)
+							if errors.Is(err, client.ErrNotFound) {
+								err = nil
+							}
+						} else {
+							_, err = kv.Set(ctx, key, value)
+						}
+						took := time.Since(at)
+						switch {
+						case err != nil && ctx.Err() != nil:
+							// Cut short by the deadline, not a failure
+						case err != nil:
+							myErrors++
+						case read:
+							myReads = append(myReads, took)
+						default:
+							myWrites = append(myWrites, took)
+						}
+					}
+					mu.Lock()
+					reads, writes = append(reads, myReads...), append(writes, myWrites...)
+					failures += myErrors
+					mu.Unlock()
+				}(time.Now().UnixNano() + int64(worker))
+			}
+			wg.Wait()
+			elapsed := time.Since(started)
+
+			result := benchResult{
+				Operations: int64(len(reads) + len(writes)),
+				Errors:     failures,
+				Seconds:    elapsed.Seconds(),
+				Reads:      summarize(reads),
+				Writes:     summarize(writes),
+			}
+			result.Throughput = float64(result.Operations) / elapsed.Seconds()
+			row := func(name string, l latency) []string {
+				return []string{name, strconv.FormatInt(l.Count, 10), l.P50.String(), l.P95.String(), l.P99.String(), l.Max.String()}
+			}
+			rows := [][]string{row("get", result.Reads), row("set", result.Writes)}
+			return render(cmd.OutOrStdout(), result, []string{"OP", "COUNT", "P50", "P95", "P99", "MAX"}, rows, func(w io.Writer) {
+				fmt.Fprintf(w, "%d operations, %d errors in %.1fs: %.0f ops/s\n", result.Operations, result.Errors, result.Seconds, result.Throughput)
+				for _, r := range rows {
+					fmt.Fprintf(w, "%s: count=%s p50=%s p95=%s p99=%s max=%s\n", r[0], r[1], r[2], r[3], r[4], r[5])
+				}
+			})
+		},
+	}
+	cmd.Flags().DurationVarP(&duration, "duration", "d", 10*time.Second, "how long to run")
+	cmd.Flags().Int64VarP(&requests, "requests", "n", 0, "stop after this many requests, 0 for no limit")
+	cmd.Flags().IntVarP(&concurrency, "concurrency", "c", 16, "concurrent workers")
+	cmd.Flags().IntVar(&keyspace, "keys", 1000, "number of distinct keys")
+	cmd.Flags().IntVar(&valueSize, "value-size", 64, "bytes per value written")
+	cmd.Flags().Float64Var(&readRatio, "reads", 0.8, "fraction of operations that are gets")
+	cmd.Flags().StringVar(&prefix, "prefix", "bench:", "prefix of the keys used")
+	return cmd
+}
+
+func auditCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Work with a server's audit log files",
+	}
+	var keyFile string
+	verify := &cobra.Command{
+		Use:   "verify FILE...",
+		Short: "Check that audit log files, oldest first, form one untampered hash chain",
+		Long: "Check that audit log files, oldest first, form one untampered hash chain.\n\n" +
+			"Rotated files are named after the log with a timestamp appended, so\n" +
+			"  kvctl audit verify --key-file audit.key audit.log.* audit.log\n" +
+			"checks a whole log in order. Any altered, missing or reordered entry fails.\n" +
+			"A log the server wrote with AUDIT_KEY_FILE only verifies with the same key.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var key []byte
+			if keyFile != "" {
+				var err error
+				if key, err = audit.ReadKey(keyFile); err != nil {
+					return err
+				}
+			}
+			result, err := audit.Verify(args, key)
+			if err != nil {
+				return err
+			}
+			rows := [][]string{
+				{"entries", strconv.FormatUint(result.Entries, 10)},
+				{"first", strconv.FormatUint(result.First, 10)},
+				{"last hash", result.Last},
+			}
+			return render(cmd.OutOrStdout(), result, []string{"CHAIN", "VALUE"}, rows, func(w io.Writer) {
+				fmt.Fprintf(w, "ok: %d entries from sequence %d, last hash %s\n", result.Entries, result.First, result.Last)
+			})
+		},
+	}
+	verify.Flags().StringVar(&keyFile, "key-file", "", "file holding the key the log was written with")
+	cmd.AddCommand(verify)
+	return cmd
+}
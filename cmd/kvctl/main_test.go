@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	kvpb "github.com/kv-storage/proto/kv"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+const config = `current: local
+profiles:
+  local:
+    addresses: [localhost:1]
+  prod:
+    addresses: [kv-1:50051, kv-2:50051]
+    timeout: 2s
+    token: prod-key
+`
+
+func TestLoadProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name          string
+		configPath    string
+		env           map[string]string
+		profileName   string
+		addresses     []string
+		token         string
+		wantAddresses []string
+		wantTimeout   time.Duration
+		wantToken     string
+		wantErr       bool
+	}{
+		{"the current profile", path, nil, "", nil, "", []string{"localhost:1"}, 0, "", false},
+		{"a named profile", path, nil, "prod", nil, "", []string{"kv-1:50051", "kv-2:50051"}, 2 * time.Second, "prod-key", false},
+		{"a profile from the environment", "", map[string]string{"KVCTL_CONFIG": path, "KVCTL_PROFILE": "prod"}, "", nil, "", []string{"kv-1:50051", "kv-2:50051"}, 2 * time.Second, "prod-key", false},
+		{"flags override the profile", path, map[string]string{"KVCTL_TOKEN": "env-key"}, "prod", []string{"kv-3:50051"}, "flag-key", []string{"kv-3:50051"}, 2 * time.Second, "flag-key", false},
+		{"the token from the environment", path, map[string]string{"KVCTL_TOKEN": "env-key"}, "prod", nil, "", []string{"kv-1:50051", "kv-2:50051"}, 2 * time.Second, "env-key", false},
+		{"no config file", "", map[string]string{"XDG_CONFIG_HOME": t.TempDir(), "HOME": t.TempDir()}, "", nil, "", []string{defaultAddress}, 0, "", false},
+		{"a missing config file named", filepath.Join(t.TempDir(), "missing.yaml"), nil, "", nil, "", nil, 0, "", true},
+		{"an unknown profile", path, nil, "staging", nil, "", nil, 0, "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, name := range []string{"KVCTL_CONFIG", "KVCTL_PROFILE", "KVCTL_TOKEN"} {
+				t.Setenv(name, test.env[name])
+			}
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+			global.configPath, global.profileName, global.addresses, global.token, global.timeout = test.configPath, test.profileName, test.addresses, test.token, 0
+			selected, err := loadProfile()
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got %v, want an error %v", err, test.wantErr)
+			}
+			if !slices.Equal(selected.Addresses, test.wantAddresses) || selected.Timeout != test.wantTimeout || selected.Token != test.wantToken {
+				t.Fatalf("got %+v", selected)
+			}
+		})
+	}
+}
+
+// store serves the KeyValueStore calls kvctl get and set make.
+type store struct {
+	kvpb.UnimplementedKeyValueStoreServer
+
+	mu     sync.Mutex
+	values map[string]*kvpb.GetKVResponse
+}
+
+func (s *store) GetKeyValue(ctx context.Context, request *kvpb.GetKVRequest) (*kvpb.GetKVResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if response, ok := s.values[request.Key]; ok {
+		return response, nil
+	}
+	return &kvpb.GetKVResponse{StatusCode: 404, Message: "Key not found"}, nil
+}
+
+func (s *store) SetKeyValue(ctx context.Context, request *kvpb.SetKeyValueRequest) (*kvpb.SetKeyValueResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	version := uint64(len(s.values) + 1)
+	s.values[request.Key] = &kvpb.GetKVResponse{StatusCode: 200, Value: request.Value, Version: version, Flags: request.Flags}
+	return &kvpb.SetKeyValueResponse{StatusCode: 201, Version: version}, nil
+}
+
+func TestSetAndGet(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	kvpb.RegisterKeyValueStoreServer(server, &store{values: map[string]*kvpb.GetKVResponse{}})
+	go server.Serve(listener)
+	defer server.Stop()
+	t.Setenv("KVCTL_CONFIG", "")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	global.configPath, global.profileName, global.token = "", "", ""
+	global.addresses = []string{listener.Addr().String()}
+
+	tests := []struct {
+		name    string
+		command func() *cobra.Command
+		args    []string
+		stdin   string
+		output  string
+		want    string
+		wantErr bool
+	}{
+		{"set from an argument", setCommand, []string{"a", "1", "--flags", "7"}, "", "plain", "1\n", false},
+		{"set from stdin", setCommand, []string{"b"}, "two", "json", "{\n  \"key\": \"b\",\n  \"version\": 2\n}\n", false},
+		{"get", getCommand, []string{"a", "b"}, "", "plain", "1\ntwo\n", false},
+		{"get as a table", getCommand, []string{"a"}, "", "table", "KEY  VALUE  VERSION  FLAGS\na    1      1        7\n", false},
+		{"get a missing key", getCommand, []string{"missing"}, "", "plain", "", true},
+		{"conflicting conditions", setCommand, []string{"a", "1", "--if-exists", "--if-not-exists"}, "", "plain", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			global.output = test.output
+			cmd := test.command()
+			var out bytes.Buffer
+			cmd.SetArgs(test.args)
+			cmd.SetIn(strings.NewReader(test.stdin))
+			cmd.SetOut(&out)
+			cmd.SetErr(&out)
+			cmd.SilenceUsage, cmd.SilenceErrors = true, true
+			err := cmd.ExecuteContext(context.Background())
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got %v, want an error %v", err, test.wantErr)
+			}
+			if !test.wantErr && out.String() != test.want {
+				t.Fatalf("printed %q, want %q", out.String(), test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kv-storage/changefeed"
+	"github.com/kv-storage/cluster"
+	"github.com/kv-storage/model"
+	kvpb "github.com/kv-storage/proto/kv"
+	"github.com/kv-storage/replicas"
+	"google.golang.org/grpc"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	opHashSet         = "hset"
+	opHashDelete      = "hdel"
+	opListPushHead    = "lpush"
+	opListPushTail    = "rpush"
+	opListPopHead     = "lpop"
+	opListPopTail     = "rpop"
+	opSetAdd          = "sadd"
+	opSetRemove       = "srem"
+	opSortedSetAdd    = "zadd"
+	opSortedSetRemove = "zrem"
+)
+
+// collectionOp is one write to a collection key. It is also the payload of
+// the replicated command in cluster mode and of the change log entry.
+type collectionOp struct {
+	Op     string            `json:"op"`
+	Key    string            `json:"-"`
+	Fields map[string]string `json:"fields,omitempty"`
+	// Values are pushed or popped list items, or the hash fields and set members to remove.
+	Values  []string       `json:"values,omitempty"`
+	Members []scoredMember `json:"members,omitempty"`
+	Count   int64          `json:"count,omitempty"`
+}
+
+type scoredMember struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+func (op collectionOp) keyType() string {
+	switch op.Op {
+	case opHashSet, opHashDelete:
+		return model.TypeHash
+	case opListPushHead, opListPushTail, opListPopHead, opListPopTail:
+		return model.TypeList
+	case opSetAdd, opSetRemove:
+		return model.TypeSet
+	default:
+		return model.TypeSortedSet
+	}
+}
+
+// removes reports whether op only takes elements away, so it never creates its key.
+func (op collectionOp) removes() bool {
+	switch op.Op {
+	case opHashDelete, opListPopHead, opListPopTail, opSetRemove, opSortedSetRemove:
+		return true
+	}
+	return false
+}
+
+type collectionResult struct {
+	StatusCode int64
+	Message    string
+	Count      int64
+	Values     []string
+}
+
+func wrongType(actual, wanted string) collectionResult {
+	return collectionResult{
+		StatusCode: StatusConflict,
+		Message:    fmt.Sprintf("Key holds a %s value, not a %s", actual, wanted),
+	}
+}
+
+// elementModel is the backing table of a collection type, nil for strings.
+func elementModel(keyType string) any {
+	switch keyType {
+	case model.TypeHash:
+		return &model.HashField{}
+	case model.TypeList:
+		return &model.ListItem{}
+	case model.TypeSet:
+		return &model.SetMember{}
+	case model.TypeSortedSet:
+		return &model.SortedSetMember{}
+	}
+	return nil
+}
+
+// deleteElements removes a collection's contents along with its key row.
+func deleteElements(tx *gorm.DB, kv model.KV) error {
+	elements := elementModel(kv.Type)
+	if elements == nil {
+		return nil
+	}
+	return tx.Where("kv_id = ?", kv.ID).Delete(elements).Error
+}
+
+// mutateCollection runs a collection write locally, or through Raft in
+// cluster mode. request is the original RPC request, for forwarding.
+func mutateCollection(ctx context.Context, request any, op collectionOp) (*kvpb.CollectionResponse, error) {
+	// In cluster mode the write is committed through Raft instead
+	if clusterNode != nil {
+		if !clusterNode.IsLeader() {
+			connection, forwardCtx, err := clusterNode.ForwardToLeader(ctx)
+			if err != nil {
+				return &kvpb.CollectionResponse{
+					Message:    err.Error(),
+					StatusCode: int64(StatusServiceUnavailable),
+				}, nil
+			}
+			method, _ := grpc.Method(ctx)
+			response := &kvpb.CollectionResponse{}
+			return response, connection.Invoke(forwardCtx, method, request, response)
+		}
+		payload, err := json.Marshal(op)
+		if err != nil {
+			return nil, err
+		}
+		result, err := clusterNode.Propose(&cluster.Command{Op: cluster.OpCollection, Key: op.Key, Collection: payload})
+		if err != nil {
+			return &kvpb.CollectionResponse{
+				Message:    err.Error(),
+				StatusCode: int64(StatusServiceUnavailable),
+			}, nil
+		}
+		return &kvpb.CollectionResponse{
+			Message:    result.Message,
+			StatusCode: result.StatusCode,
+			Count:      result.Count,
+			Values:     result.Values,
+		}, nil
+	}
+
+	result := applyCollection(kvDbConnector, op)
+	sessionToken := ""
+	if result.StatusCode == StatusOK {
+		sessionToken = replicas.NewSessionToken()
+	}
+	return &kvpb.CollectionResponse{
+		Message:      result.Message,
+		StatusCode:   result.StatusCode,
+		Count:        result.Count,
+		Values:       result.Values,
+		SessionToken: sessionToken,
+	}, nil
+}
+
+// applyCollection runs op in a transaction that holds the key row's lock, so
+// writes to one collection are serialized while other keys proceed.
+func applyCollection(db *gorm.DB, op collectionOp) collectionResult {
+	var result collectionResult
+	var err error
+	for attempt := 0; attempt < writeRetries; attempt++ {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var stepErr error
+			result, stepErr = collectionStep(tx, op)
+			return stepErr
+		})
+		if err == nil || !retryableWriteError(err) {
+			break
+		}
+	}
+	if err != nil {
+		return collectionResult{StatusCode: StatusInternalServerError, Message: "Database error"}
+	}
+	return result
+}
+
+func collectionStep(tx *gorm.DB, op collectionOp) (collectionResult, error) {
+	if _, err := dropExpired(tx, op.Key, time.Now()); err != nil {
+		return collectionResult{}, err
+	}
+	var kv model.KV
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key_name = ?", op.Key).Limit(1).Find(&kv).Error; err != nil {
+		return collectionResult{}, err
+	}
+	keyType := op.keyType()
+	if kv.ID != 0 && kv.Type != keyType {
+		return wrongType(kv.Type, keyType), nil
+	}
+	if kv.ID == 0 {
+		if op.removes() {
+			return collectionResult{StatusCode: StatusNotFound, Message: "Key not found"}, nil
+		}
+		kv = model.KV{Key: op.Key, Type: keyType}
+		if err := tx.Create(&kv).Error; err != nil {
+			return collectionResult{}, err
+		}
+	}
+
+	var err error
+	result := collectionResult{StatusCode: StatusOK, Message: "Collection updated"}
+	switch op.Op {
+	case opHashSet:
+		result.Count, err = hashSet(tx, kv.ID, op.Fields)
+	case opHashDelete:
+		result.Count, err = deleteElementsIn(tx, &model.HashField{}, "field", kv.ID, op.Values)
+	case opListPushHead, opListPushTail:
+		result.Count, err = listPush(tx, kv.ID, op.Values, op.Op == opListPushTail)
+	case opListPopHead, opListPopTail:
+		result.Values, err = listPop(tx, kv.ID, op.Count, op.Op == opListPopTail)
+		result.Count = int64(len(result.Values))
+	case opSetAdd:
+		result.Count, err = setAdd(tx, kv.ID, op.Values)
+	case opSetRemove:
+		result.Count, err = deleteElementsIn(tx, &model.SetMember{}, "member", kv.ID, op.Values)
+	case opSortedSetAdd:
+		result.Count, err = sortedSetAdd(tx, kv.ID, op.Members)
+	case opSortedSetRemove:
+		result.Count, err = deleteElementsIn(tx, &model.SortedSetMember{}, "member", kv.ID, op.Values)
+	default:
+		return collectionResult{StatusCode: StatusBadRequest, Message: "Unknown collection operation"}, nil
+	}
+	if err != nil {
+		return collectionResult{}, err
+	}
+
+	// A collection exists only while it has elements
+	if op.removes() {
+		var remaining int64
+		if err := tx.Model(elementModel(keyType)).Where("kv_id = ?", kv.ID).Count(&remaining).Error; err != nil {
+			return collectionResult{}, err
+		}
+		if remaining == 0 {
+			if err := tx.Delete(&kv).Error; err != nil {
+				return collectionResult{}, err
+			}
+		}
+	}
+
+	if result.Count > 0 || op.Op == opHashSet || op.Op == opSortedSetAdd {
+		logged := op
+		if op.removes() && result.Values != nil {
+			// Log what was popped, not how many were asked for
+			logged.Values, logged.Count = result.Values, 0
+		}
+		payload, err := json.Marshal(logged)
+		if err != nil {
+			return collectionResult{}, err
+		}
+		if _, err := changefeed.Record(tx, model.ChangeCollection, op.Key, string(payload)); err != nil {
+			return collectionResult{}, err
+		}
+	}
+	return result, nil
+}
+
+// readCollection looks up a collection key for a read, checking its type.
+// A nil response means kv is ready to read.
+func readCollection(ctx context.Context, key, keyType string) (model.KV, *kvpb.CollectionResponse) {
+	var kv model.KV
+	// In cluster mode confirm with the leader that we are not serving a stale replica
+	if clusterNode != nil {
+		if err := clusterNode.WaitReadIndex(ctx); err != nil {
+			return kv, &kvpb.CollectionResponse{
+				Message:    err.Error(),
+				StatusCode: int64(StatusServiceUnavailable),
+			}
+		}
+	}
+	err := kvDbConnector.Where("key_name = ? AND (expires_at IS NULL OR expires_at > ?)", key, time.Now()).Limit(1).Find(&kv).Error
+	if err != nil {
+		return kv, collectionError(StatusInternalServerError, "Database error")
+	}
+	if kv.ID == 0 {
+		return kv, collectionError(StatusNotFound, "Key not found")
+	}
+	if kv.Type != keyType {
+		result := wrongType(kv.Type, keyType)
+		return kv, collectionError(result.StatusCode, result.Message)
+	}
+	return kv, nil
+}
+
+func collectionError(statusCode int64, message string) *kvpb.CollectionResponse {
+	return &kvpb.CollectionResponse{Message: message, StatusCode: statusCode}
+}
+
+// deleteElementsIn removes the named elements of one collection and reports how many existed.
+func deleteElementsIn(tx *gorm.DB, elements any, column string, kvID uint, names []string) (int64, error) {
+	if len(names) == 0 {
+		return 0, nil
+	}
+	result := tx.Where("kv_id = ? AND "+column+" IN ?", kvID, names).Delete(elements)
+	return result.RowsAffected, result.Error
+}
+
+// existingNames returns which of names are already present in one collection.
+func existingNames(tx *gorm.DB, elements any, column string, kvID uint, names []string) (map[string]bool, error) {
+	var found []string
+	if err := tx.Model(elements).Where("kv_id = ? AND "+column+" IN ?", kvID, names).Pluck(column, &found).Error; err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(found))
+	for _, name := range found {
+		existing[name] = true
+	}
+	return existing, nil
+}
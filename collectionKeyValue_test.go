@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	kvpb "github.com/kv-storage/proto/kv"
+)
+
+func TestList(t *testing.T) {
+	useStore(t)
+	server := &KvService{}
+	ctx := context.Background()
+	steps := []struct {
+		name      string
+		call      func() (*kvpb.CollectionResponse, error)
+		wantCode  int64
+		wantCount int64
+		want      []string
+	}{
+		{"push to the head", func() (*kvpb.CollectionResponse, error) {
+			return server.ListPush(ctx, &kvpb.ListPushRequest{Key: "queue", Values: []string{"b", "a"}})
+		}, 200, 2, nil},
+		{"push to the tail", func() (*kvpb.CollectionResponse, error) {
+			return server.ListPush(ctx, &kvpb.ListPushRequest{Key: "queue", Values: []string{"c", "d"}, Tail: true})
+		}, 200, 4, nil},
+		{"the whole range", func() (*kvpb.CollectionResponse, error) {
+			return server.ListRange(ctx, &kvpb.ListRangeRequest{Key: "queue", Start: 0, Stop: -1})
+		}, 200, 4, []string{"a", "b", "c", "d"}},
+		{"a range from the end", func() (*kvpb.CollectionResponse, error) {
+			return server.ListRange(ctx, &kvpb.ListRangeRequest{Key: "queue", Start: -2, Stop: -1})
+		}, 200, 2, []string{"c", "d"}},
+		{"pop from the tail", func() (*kvpb.CollectionResponse, error) {
+			return server.ListPop(ctx, &kvpb.ListPopRequest{Key: "queue", Count: 2, Tail: true})
+		}, 200, 2, []string{"d", "c"}},
+		{"pop more than is left", func() (*kvpb.CollectionResponse, error) {
+			return server.ListPop(ctx, &kvpb.ListPopRequest{Key: "queue", Count: 5})
+		}, 200, 2, []string{"a", "b"}},
+		{"an emptied list is gone", func() (*kvpb.CollectionResponse, error) {
+			return server.ListRange(ctx, &kvpb.ListRangeRequest{Key: "queue", Start: 0, Stop: -1})
+		}, 404, 0, nil},
+	}
+	for _, step := range steps {
+		response, err := step.call()
+		if err != nil || response.StatusCode != step.wantCode || response.Count != step.wantCount {
+			t.Fatalf("%s: got %v, %v, want %d with count %d", step.name, response, err, step.wantCode, step.wantCount)
+		}
+		if step.want != nil && !slices.Equal(response.Values, step.want) {
+			t.Fatalf("%s: got %q, want %q", step.name, response.Values, step.want)
+		}
+	}
+}
+
+func TestHashAndSets(t *testing.T) {
+	useStore(t)
+	server := &KvService{}
+	ctx := context.Background()
+
+	response, _ := server.HashSet(ctx, &kvpb.HashSetRequest{Key: "user", Fields: map[string]string{"name": "ada", "lang": "go"}})
+	if response.Count != 2 {
+		t.Fatalf("HashSet = %v, want two new fields", response)
+	}
+	response, _ = server.HashSet(ctx, &kvpb.HashSetRequest{Key: "user", Fields: map[string]string{"name": "grace"}})
+	if response.StatusCode != 200 || response.Count != 0 {
+		t.Fatalf("overwriting a field = %v, want no new fields", response)
+	}
+	if response, _ := server.HashGet(ctx, &kvpb.HashGetRequest{Key: "user", Field: "name"}); !slices.Equal(response.Values, []string{"grace"}) {
+		t.Fatalf("HashGet = %v", response)
+	}
+
+	response, _ = server.SetAdd(ctx, &kvpb.SetMembersRequest{Key: "tags", Members: []string{"a", "b", "a"}})
+	if response.Count != 2 {
+		t.Fatalf("SetAdd = %v, want two new members", response)
+	}
+
+	_, _ = server.SortedSetAdd(ctx, &kvpb.SortedSetAddRequest{Key: "board", Members: []*kvpb.ScoredMember{{Member: "x", Score: 3}, {Member: "y", Score: 1}, {Member: "z", Score: 2}}})
+	low, high := 1.5, 3.0
+	response, _ = server.SortedSetRangeByScore(ctx, &kvpb.SortedSetRangeRequest{Key: "board", Min: &low, Max: &high})
+	if len(response.Members) != 2 || response.Members[0].Member != "z" || response.Members[1].Member != "x" {
+		t.Fatalf("SortedSetRangeByScore = %v, want z then x", response)
+	}
+}
+
+func TestCollectionTypes(t *testing.T) {
+	useStore(t)
+	server := &KvService{}
+	ctx := context.Background()
+	set(t, "plain", "v")
+	if response, _ := server.SetAdd(ctx, &kvpb.SetMembersRequest{Key: "tags", Members: []string{"a"}}); response.StatusCode != 200 {
+		t.Fatalf("SetAdd = %v", response)
+	}
+
+	if response, _ := server.ListPush(ctx, &kvpb.ListPushRequest{Key: "plain", Values: []string{"a"}}); response.StatusCode != 409 {
+		t.Errorf("pushing to a string = %v, want 409", response)
+	}
+	if response, _ := server.HashGetAll(ctx, &kvpb.CollectionKeyRequest{Key: "tags"}); response.StatusCode != 409 {
+		t.Errorf("reading a set as a hash = %v, want 409", response)
+	}
+	if response, _ := server.Increment(ctx, &kvpb.CounterRequest{Key: "tags", Delta: 1}); response.StatusCode != 409 {
+		t.Errorf("incrementing a set = %v, want 409", response)
+	}
+	if response, _ := server.SetRemove(ctx, &kvpb.SetMembersRequest{Key: "missing", Members: []string{"a"}}); response.StatusCode != 404 {
+		t.Errorf("removing from a missing set = %v, want 404", response)
+	}
+}
@@ -0,0 +1,249 @@
+package conditional
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	kvpb "github.com/kv-storage/proto/kv"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	keyPrefix = "/api/kv/"
+	setPath   = "/api/kv"
+)
+
+// unmatchable stands for an entity tag no key can have. Versions are change
+// log sequence numbers, which never get this far.
+const unmatchable = uint64(math.MaxUint64)
+
+var (
+	errWeakTag   = errors.New("If-Match needs a strong entity tag")
+	errTagList   = errors.New("If-Match supports a single entity tag")
+	errNoneMatch = errors.New("If-None-Match on writes only supports *")
+	errBothTags  = errors.New("If-Match and If-None-Match cannot be combined")
+)
+
+type preconditionKey struct{}
+
+// precondition is what the middleware learned from a request's headers, for
+// ForwardResponse to act on once the service has answered.
+type precondition struct {
+	// ifNoneMatch holds the tags a GET already has.
+	ifNoneMatch []string
+	// conditional writes fail with 412 when the key changed or existed.
+	conditional bool
+	// missingFails is set when the write also needed the key to exist.
+	missingFails bool
+}
+
+// ETag is the entity tag of a key at version. A key never has the same
+// version twice, even once deleted and created again, so a tag cannot match a
+// different incarnation of the key.
+func ETag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+// Middleware turns If-Match and If-None-Match on the gateway's kv routes
+// into version-checked service calls: If-Match "<version>" becomes an update
+// with expectedVersion and If-None-Match: * a create.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var cond *precondition
+		var err error
+		switch {
+		case r.Method == http.MethodGet && isKeyPath(r.URL.Path):
+			if header := r.Header.Get("If-None-Match"); header != "" {
+				cond = &precondition{ifNoneMatch: splitTags(header)}
+			}
+		case r.Method == http.MethodPost && r.URL.Path == setPath:
+			cond, err = conditionSet(r)
+		case r.Method == http.MethodDelete && isKeyPath(r.URL.Path):
+			cond, err = conditionDelete(r)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if cond != nil {
+			r = r.WithContext(context.WithValue(r.Context(), preconditionKey{}, cond))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isKeyPath matches /api/kv/{key} but not its sub-resources.
+func isKeyPath(path string) bool {
+	key, found := strings.CutPrefix(path, keyPrefix)
+	return found && key != "" && !strings.Contains(key, "/")
+}
+
+func splitTags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// parseVersion reads a strong entity tag. A tag we never issued cannot
+// match, so it comes back as unmatchable rather than an error.
+func parseVersion(tag string) (uint64, error) {
+	if strings.HasPrefix(tag, "W/") {
+		return 0, errWeakTag
+	}
+	version, err := strconv.ParseUint(strings.Trim(tag, `"`), 10, 64)
+	if err != nil || version == 0 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		return unmatchable, nil
+	}
+	return version, nil
+}
+
+// readPreconditions returns the If-Match tag, or "" without one, and whether If-None-Match: * was sent.
+func readPreconditions(r *http.Request) (string, bool, error) {
+	ifMatch := splitTags(r.Header.Get("If-Match"))
+	ifNoneMatch := r.Header.Get("If-None-Match")
+	switch {
+	case len(ifMatch) > 0 && ifNoneMatch != "":
+		return "", false, errBothTags
+	case len(ifMatch) > 1:
+		return "", false, errTagList
+	case ifNoneMatch != "" && strings.TrimSpace(ifNoneMatch) != "*":
+		return "", false, errNoneMatch
+	case len(ifMatch) == 1:
+		return ifMatch[0], false, nil
+	}
+	return "", ifNoneMatch != "", nil
+}
+
+// conditionSet rewrites the SetKeyValue body to carry the preconditions.
+func conditionSet(r *http.Request) (*precondition, error) {
+	ifMatch, noneMatch, err := readPreconditions(r)
+	if err != nil || (ifMatch == "" && !noneMatch) {
+		return nil, err
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]any{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, err
+	}
+
+	cond := &precondition{conditional: true}
+	delete(fields, "expectedVersion")
+	switch {
+	case noneMatch:
+		fields["mode"] = "create"
+	case ifMatch == "*":
+		fields["mode"] = "update"
+		cond.missingFails = true
+	default:
+		version, err := parseVersion(ifMatch)
+		if err != nil {
+			return nil, err
+		}
+		fields["mode"] = "update"
+		fields["expectedVersion"] = strconv.FormatUint(version, 10)
+		cond.missingFails = true
+	}
+
+	body, err = json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return cond, nil
+}
+
+// conditionDelete passes the precondition to DeleteKeyValue as a query parameter.
+func conditionDelete(r *http.Request) (*precondition, error) {
+	ifMatch, noneMatch, err := readPreconditions(r)
+	if err != nil || (ifMatch == "" && !noneMatch) {
+		return nil, err
+	}
+	cond := &precondition{conditional: true, missingFails: ifMatch != ""}
+	version := uint64(0)
+	switch {
+	case noneMatch:
+		// Only a missing key passes, and deleting that answers 404 as usual
+		version = unmatchable
+	case ifMatch != "*":
+		if version, err = parseVersion(ifMatch); err != nil {
+			return nil, err
+		}
+	}
+	if version != 0 {
+		query := r.URL.Query()
+		query.Set("expectedVersion", strconv.FormatUint(version, 10))
+		r.URL.RawQuery = query.Encode()
+	}
+	return cond, nil
+}
+
+// failed reports whether statusCode means a precondition did not hold.
+func (c *precondition) failed(statusCode int64) bool {
+	if c == nil || !c.conditional {
+		return false
+	}
+	return statusCode == http.StatusConflict || (statusCode == http.StatusNotFound && c.missingFails)
+}
+
+// notModified reports whether a GET already holds the key at version.
+func (c *precondition) notModified(version uint64) bool {
+	if c == nil {
+		return false
+	}
+	for _, tag := range c.ifNoneMatch {
+		// If-None-Match compares weakly
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == ETag(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// ForwardResponse is the gateway's forward response option. It tags kv
+// reads and writes with the key's ETag and answers 304 and 412 where the
+// request's preconditions call for them.
+func ForwardResponse(ctx context.Context, w http.ResponseWriter, message proto.Message) error {
+	cond, _ := ctx.Value(preconditionKey{}).(*precondition)
+	switch response := message.(type) {
+	case *kvpb.GetKVResponse:
+		if response.StatusCode != http.StatusOK {
+			return nil
+		}
+		w.Header().Set("ETag", ETag(response.Version))
+		if cond.notModified(response.Version) {
+			// The gateway's body write is then refused, leaving a bare 304
+			w.Header().Del("Content-Type")
+			w.WriteHeader(http.StatusNotModified)
+		}
+	case *kvpb.SetKeyValueResponse:
+		switch {
+		case response.StatusCode == http.StatusOK || response.StatusCode == http.StatusCreated:
+			w.Header().Set("ETag", ETag(response.Version))
+		case cond.failed(response.StatusCode):
+			w.WriteHeader(http.StatusPreconditionFailed)
+		}
+	case *kvpb.DeleteKeyValueResponse:
+		if cond.failed(response.StatusCode) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+		}
+	}
+	return nil
+}
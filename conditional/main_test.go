@@ -0,0 +1,255 @@
+package conditional
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	kvpb "github.com/kv-storage/proto/kv"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		tag     string
+		want    uint64
+		wantErr error
+	}{
+		{tag: `"42"`, want: 42},
+		{tag: `W/"42"`, wantErr: errWeakTag},
+		{tag: `42`, want: unmatchable},
+		{tag: `"0"`, want: unmatchable},
+		{tag: `"abc"`, want: unmatchable},
+		{tag: `"42`, want: unmatchable},
+	}
+	for _, test := range tests {
+		got, err := parseVersion(test.tag)
+		if err != test.wantErr || (err == nil && got != test.want) {
+			t.Errorf("parseVersion(%s) = %d, %v, want %d, %v", test.tag, got, err, test.want, test.wantErr)
+		}
+	}
+}
+
+// served runs request through the middleware and returns what the service
+// would have been handed, or the middleware's own response.
+func served(t *testing.T, r *http.Request) (*http.Request, string, *httptest.ResponseRecorder) {
+	t.Helper()
+	var got *http.Request
+	var body string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, body = r, string(data)
+	})
+	recorder := httptest.NewRecorder()
+	Middleware(next).ServeHTTP(recorder, r)
+	return got, body, recorder
+}
+
+func TestMiddlewareSet(t *testing.T) {
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantStatus int
+		// wantFields are the body's fields after rewriting, absent ones as nil
+		wantFields map[string]any
+	}{
+		{
+			name:       "an unconditional write is untouched",
+			wantFields: map[string]any{"mode": "upsert", "expectedVersion": "9"},
+		},
+		{
+			name:       "If-Match a version updates at that version",
+			headers:    map[string]string{"If-Match": `"7"`},
+			wantFields: map[string]any{"mode": "update", "expectedVersion": "7"},
+		},
+		{
+			name:       "If-Match * updates whatever version",
+			headers:    map[string]string{"If-Match": "*"},
+			wantFields: map[string]any{"mode": "update", "expectedVersion": nil},
+		},
+		{
+			name:       "If-None-Match * creates",
+			headers:    map[string]string{"If-None-Match": "*"},
+			wantFields: map[string]any{"mode": "create", "expectedVersion": nil},
+		},
+		{
+			name:       "a tag never issued cannot match",
+			headers:    map[string]string{"If-Match": `"nope"`},
+			wantFields: map[string]any{"mode": "update", "expectedVersion": "18446744073709551615"},
+		},
+		{name: "weak tag", headers: map[string]string{"If-Match": `W/"7"`}, wantStatus: http.StatusBadRequest},
+		{name: "tag list", headers: map[string]string{"If-Match": `"7", "8"`}, wantStatus: http.StatusBadRequest},
+		{name: "If-None-Match a tag", headers: map[string]string{"If-None-Match": `"7"`}, wantStatus: http.StatusBadRequest},
+		{name: "both", headers: map[string]string{"If-Match": `"7"`, "If-None-Match": "*"}, wantStatus: http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, setPath, strings.NewReader(`{"key":"k","value":"v","mode":"upsert","expectedVersion":"9"}`))
+			for name, value := range test.headers {
+				r.Header.Set(name, value)
+			}
+			got, body, recorder := served(t, r)
+			if test.wantStatus != 0 {
+				if recorder.Code != test.wantStatus || got != nil {
+					t.Fatalf("status %d, served %v, want %d and not served", recorder.Code, got != nil, test.wantStatus)
+				}
+				return
+			}
+			fields := map[string]any{}
+			if err := json.Unmarshal([]byte(body), &fields); err != nil {
+				t.Fatal(err)
+			}
+			if fields["key"] != "k" || fields["value"] != "v" {
+				t.Fatalf("body %s lost the key or value", body)
+			}
+			for name, want := range test.wantFields {
+				if fields[name] != want {
+					t.Errorf("%s = %v, want %v", name, fields[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestMiddlewareDelete(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{name: "unconditional", want: ""},
+		{name: "If-Match a version", headers: map[string]string{"If-Match": `"7"`}, want: "7"},
+		{name: "If-Match *", headers: map[string]string{"If-Match": "*"}, want: ""},
+		{name: "If-None-Match *", headers: map[string]string{"If-None-Match": "*"}, want: "18446744073709551615"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodDelete, keyPrefix+"k", nil)
+			for name, value := range test.headers {
+				r.Header.Set(name, value)
+			}
+			got, _, _ := served(t, r)
+			if version := got.URL.Query().Get("expectedVersion"); version != test.want {
+				t.Fatalf("expectedVersion %q, want %q", version, test.want)
+			}
+		})
+	}
+}
+
+func TestForwardResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		headers    map[string]string
+		response   proto.Message
+		wantStatus int
+		wantETag   string
+	}{
+		{
+			name:     "a read is tagged",
+			method:   http.MethodGet,
+			path:     keyPrefix + "k",
+			response: &kvpb.GetKVResponse{StatusCode: 200, Version: 5},
+			wantETag: `"5"`,
+		},
+		{
+			name:       "a read the client holds is not modified",
+			method:     http.MethodGet,
+			path:       keyPrefix + "k",
+			headers:    map[string]string{"If-None-Match": `"4", W/"5"`},
+			response:   &kvpb.GetKVResponse{StatusCode: 200, Version: 5},
+			wantStatus: http.StatusNotModified,
+			wantETag:   `"5"`,
+		},
+		{
+			name:     "an older tag is modified",
+			method:   http.MethodGet,
+			path:     keyPrefix + "k",
+			headers:  map[string]string{"If-None-Match": `"4"`},
+			response: &kvpb.GetKVResponse{StatusCode: 200, Version: 5},
+			wantETag: `"5"`,
+		},
+		{
+			name:     "a write is tagged with its version",
+			method:   http.MethodPost,
+			path:     setPath,
+			headers:  map[string]string{"If-Match": `"4"`},
+			response: &kvpb.SetKeyValueResponse{StatusCode: 200, Version: 5},
+			wantETag: `"5"`,
+		},
+		{
+			name:       "a write at a stale version fails its precondition",
+			method:     http.MethodPost,
+			path:       setPath,
+			headers:    map[string]string{"If-Match": `"4"`},
+			response:   &kvpb.SetKeyValueResponse{StatusCode: 409},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:       "If-Match on a missing key fails its precondition",
+			method:     http.MethodPost,
+			path:       setPath,
+			headers:    map[string]string{"If-Match": "*"},
+			response:   &kvpb.SetKeyValueResponse{StatusCode: 404},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:       "an unconditional conflict is left to the body",
+			method:     http.MethodPost,
+			path:       setPath,
+			response:   &kvpb.SetKeyValueResponse{StatusCode: 409},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "If-None-Match on an existing key fails its delete",
+			method:     http.MethodDelete,
+			path:       keyPrefix + "k",
+			headers:    map[string]string{"If-None-Match": "*"},
+			response:   &kvpb.DeleteKeyValueResponse{StatusCode: 409},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:       "If-None-Match on a missing key is an ordinary 404",
+			method:     http.MethodDelete,
+			path:       keyPrefix + "k",
+			headers:    map[string]string{"If-None-Match": "*"},
+			response:   &kvpb.DeleteKeyValueResponse{StatusCode: 404},
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body := io.Reader(nil)
+			if test.method == http.MethodPost {
+				body = strings.NewReader(`{"key":"k","value":"v"}`)
+			}
+			r := httptest.NewRequest(test.method, test.path, body)
+			for name, value := range test.headers {
+				r.Header.Set(name, value)
+			}
+			got, _, _ := served(t, r)
+
+			recorder := httptest.NewRecorder()
+			if err := ForwardResponse(got.Context(), recorder, test.response); err != nil {
+				t.Fatal(err)
+			}
+			wantStatus := test.wantStatus
+			if wantStatus == 0 {
+				wantStatus = http.StatusOK
+			}
+			if recorder.Code != wantStatus {
+				t.Errorf("status %d, want %d", recorder.Code, wantStatus)
+			}
+			if got := recorder.Header().Get("ETag"); got != test.wantETag {
+				t.Errorf("ETag %q, want %q", got, test.wantETag)
+			}
+		})
+	}
+}
@@ -10,12 +10,17 @@ import (
 	"gorm.io/gorm/logger"
 	"github.com/kv-storage/model"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 	"log"
+	"net"
 )
 
+// DatabaseDsn talks utf8mb4, as MySQL's utf8 cannot hold four-byte UTF-8 such as emoji, which the validator accepts.
 func DatabaseDsn() string {
-	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8&parseTime=True&loc=Local",
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		os.Getenv("MYSQL_USER"),
 		os.Getenv("MYSQL_PASSWORD"),
 		os.Getenv("MYSQL_HOST"),
@@ -24,6 +29,293 @@ func DatabaseDsn() string {
 	)
 }
 
+// ListenAddress switches on single-port mode when set: gRPC, REST and pprof
+// all share this one listener.
+func ListenAddress() string {
+	return os.Getenv("LISTEN_ADDRESS")
+}
+
+// GrpcAddress is the gRPC listen address, overridable so several nodes can share a host.
+// In single-port mode it is the shared listener.
+func GrpcAddress() string {
+	if address := ListenAddress(); address != "" {
+		return address
+	}
+	return envOrDefault("GRPC_ADDRESS", "localhost:50051")
+}
+
+// GatewayAddress is the HTTP gateway listen address, unused in single-port mode.
+func GatewayAddress() string {
+	return envOrDefault("GATEWAY_ADDRESS", ":8090")
+}
+
+// Listen opens a listener on host:port, or on a Unix domain socket for
+// addresses written unix:/path, replacing a socket file left behind by an
+// earlier run. gRPC clients dial the same unix: form.
+func Listen(address string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(address, "unix:")
+	if !ok {
+		return net.Listen("tcp", address)
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// TLSCertFile and TLSKeyFile switch on TLS for gRPC and the gateway when both are set.
+// The same certificate is presented to other nodes when they ask for a client certificate.
+func TLSCertFile() string {
+	return os.Getenv("TLS_CERT_FILE")
+}
+
+func TLSKeyFile() string {
+	return os.Getenv("TLS_KEY_FILE")
+}
+
+// TLSCAFile is the CA bundle that client certificates and other nodes are verified against;
+// empty uses the system roots for nodes.
+func TLSCAFile() string {
+	return os.Getenv("TLS_CA_FILE")
+}
+
+// TLSClientAuth is none, optional (verify client certificates when given) or require.
+func TLSClientAuth() string {
+	return envOrDefault("TLS_CLIENT_AUTH", "none")
+}
+
+// TLSReloadInterval is how often the certificate files are checked for changes.
+func TLSReloadInterval() time.Duration {
+	return time.Duration(envInt("TLS_RELOAD_INTERVAL_SECONDS", 30)) * time.Second
+}
+
+// AuthAPIKeysFile and AuthJWKSFile switch on authentication when either is set.
+// The API key file is a JSON array of {"key", "subject", "roles"} entries.
+func AuthAPIKeysFile() string {
+	return os.Getenv("AUTH_API_KEYS_FILE")
+}
+
+// AuthJWKSFile is a local JWKS holding the RS256 and HS256 keys that sign accepted JWTs.
+func AuthJWKSFile() string {
+	return os.Getenv("AUTH_JWKS_FILE")
+}
+
+// AuthJWTIssuer and AuthJWTAudience, when set, must match a JWT's iss and aud claims.
+func AuthJWTIssuer() string {
+	return os.Getenv("AUTH_JWT_ISSUER")
+}
+
+func AuthJWTAudience() string {
+	return os.Getenv("AUTH_JWT_AUDIENCE")
+}
+
+// RBACPolicyFile switches on role-based access control per key prefix; it needs authentication.
+func RBACPolicyFile() string {
+	return os.Getenv("RBAC_POLICY_FILE")
+}
+
+// RBACReloadInterval is how often the policy file is checked for changes.
+func RBACReloadInterval() time.Duration {
+	return time.Duration(envInt("RBAC_RELOAD_INTERVAL_SECONDS", 10)) * time.Second
+}
+
+// MaxKeyBytes caps key length, at most 255 bytes, the size of the key column.
+func MaxKeyBytes() int {
+	return envInt("MAX_KEY_BYTES", 255)
+}
+
+// MaxValueBytes caps value length; 0 leaves values unlimited.
+func MaxValueBytes() int {
+	return envInt("MAX_VALUE_BYTES", 1<<20)
+}
+
+// KeyCharset is a regexp character class, such as [A-Za-z0-9/_.:-], that new keys must be written in; empty allows any.
+func KeyCharset() string {
+	return os.Getenv("KEY_CHARSET")
+}
+
+// RateLimitReadsPerSecond and RateLimitWritesPerSecond switch on per-client rate limiting;
+// 0 leaves that kind of call unlimited. Deletes and locks count as writes.
+func RateLimitReadsPerSecond() float64 {
+	return float64(envInt("RATE_LIMIT_READS_PER_SECOND", 0))
+}
+
+func RateLimitWritesPerSecond() float64 {
+	return float64(envInt("RATE_LIMIT_WRITES_PER_SECOND", 0))
+}
+
+// RateLimitReadBurst and RateLimitWriteBurst cap how many calls a client can make at once; 0 is one second's worth.
+func RateLimitReadBurst() int {
+	return envInt("RATE_LIMIT_READ_BURST", 0)
+}
+
+func RateLimitWriteBurst() int {
+	return envInt("RATE_LIMIT_WRITE_BURST", 0)
+}
+
+// RateLimitExempt lists the subjects, roles or client addresses that are never rate limited.
+func RateLimitExempt() []string {
+	return splitList(os.Getenv("RATE_LIMIT_EXEMPT"))
+}
+
+// QuotaFile switches on storage quotas per key namespace.
+func QuotaFile() string {
+	return os.Getenv("QUOTA_FILE")
+}
+
+// QuotaRefreshInterval is how often a namespace's usage is measured from the database.
+func QuotaRefreshInterval() time.Duration {
+	return time.Duration(envInt("QUOTA_REFRESH_SECONDS", 30)) * time.Second
+}
+
+// AuditLogFile switches on the hash-chained audit log of every write and delete.
+func AuditLogFile() string {
+	return os.Getenv("AUDIT_LOG_FILE")
+}
+
+// AuditKeyFile holds the secret the audit log's hash chain is keyed with, so
+// that someone able to edit the file cannot rewrite the chain to match.
+func AuditKeyFile() string {
+	return os.Getenv("AUDIT_KEY_FILE")
+}
+
+// AuditMaxBytes is the size at which the audit log is rotated; 0 never rotates it.
+func AuditMaxBytes() int64 {
+	return int64(envInt("AUDIT_MAX_BYTES", 100<<20))
+}
+
+// MemcachedAPIKey is the API key memcached clients act as, since the text protocol cannot log in.
+func MemcachedAPIKey() string {
+	return os.Getenv("MEMCACHED_API_KEY")
+}
+
+// RedisAddress is where the RESP listener serves Redis clients; empty disables it.
+func RedisAddress() string {
+	return os.Getenv("REDIS_ADDRESS")
+}
+
+// MemcachedAddress is where the memcached listener serves its clients; empty disables it.
+func MemcachedAddress() string {
+	return os.Getenv("MEMCACHED_ADDRESS")
+}
+
+// CORSAllowedOrigins lists the browser origins allowed to call the gateway; empty disables CORS.
+func CORSAllowedOrigins() []string {
+	return splitList(os.Getenv("CORS_ALLOWED_ORIGINS"))
+}
+
+// CORSAllowedMethods lists the methods a cross-origin request may use.
+func CORSAllowedMethods() []string {
+	return splitList(envOrDefault("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE,OPTIONS"))
+}
+
+// CORSAllowedHeaders lists the request headers a cross-origin request may send.
+func CORSAllowedHeaders() []string {
+	return splitList(envOrDefault("CORS_ALLOWED_HEADERS", "Content-Type,If-Match,If-None-Match,Last-Event-ID,X-Grpc-Web,X-User-Agent,Grpc-Timeout,Authorization"))
+}
+
+// CORSMaxAge is how long browsers may cache a preflight response.
+func CORSMaxAge() time.Duration {
+	return time.Duration(envInt("CORS_MAX_AGE_SECONDS", 600)) * time.Second
+}
+
+// ShutdownTimeout bounds how long in-flight requests get to finish on shutdown.
+func ShutdownTimeout() time.Duration {
+	return time.Duration(envInt("SHUTDOWN_TIMEOUT_SECONDS", 15)) * time.Second
+}
+
+// PprofAddress is where the profiling server listens. In single-port mode it
+// defaults to empty, as /debug/pprof/ is then served on the shared listener.
+func PprofAddress() string {
+	if ListenAddress() != "" {
+		return os.Getenv("PPROF_ADDRESS")
+	}
+	return envOrDefault("PPROF_ADDRESS", ":6060")
+}
+
+// NodeID identifies this process to its peers, defaulting to the gRPC address.
+func NodeID() string {
+	return envOrDefault("NODE_ID", GrpcAddress())
+}
+
+// PeerAddresses lists the gRPC addresses of the other nodes sharing our database.
+func PeerAddresses() []string {
+	return splitList(os.Getenv("PEER_ADDRESSES"))
+}
+
+// RaftNodeID switches the server into Raft cluster mode when set.
+func RaftNodeID() string {
+	return os.Getenv("RAFT_NODE_ID")
+}
+
+// RaftAddress is where this node's Raft transport listens.
+func RaftAddress() string {
+	return envOrDefault("RAFT_ADDRESS", "127.0.0.1:7000")
+}
+
+// RaftDataDir holds the Raft log and snapshots, one directory per node.
+func RaftDataDir() string {
+	return envOrDefault("RAFT_DATA_DIR", filepath.Join("raft-data", RaftNodeID()))
+}
+
+// RaftBootstrap makes this node form a new single-member cluster on first start.
+func RaftBootstrap() bool {
+	return os.Getenv("RAFT_BOOTSTRAP") == "true"
+}
+
+// RaftJoinAddress is the gRPC address of an existing member to ask for admission.
+func RaftJoinAddress() string {
+	return os.Getenv("RAFT_JOIN")
+}
+
+// ShardNodeID switches the server into sharded mode when set.
+func ShardNodeID() string {
+	return os.Getenv("SHARD_NODE_ID")
+}
+
+// ShardNodes is the initial ring, written as id=grpcAddress pairs separated by commas.
+func ShardNodes() map[string]string {
+	nodes := make(map[string]string)
+	for _, item := range splitList(os.Getenv("SHARD_NODES")) {
+		if id, address, ok := strings.Cut(item, "="); ok {
+			nodes[strings.TrimSpace(id)] = strings.TrimSpace(address)
+		}
+	}
+	return nodes
+}
+
+// ShardVirtualNodes is how many ring positions each node gets.
+func ShardVirtualNodes() int {
+	return envInt("SHARD_VIRTUAL_NODES", 128)
+}
+
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func GoDotEnvVariable(key string) string {
 	err := godotenv.Load(".env")
 	if err != nil {
@@ -34,7 +326,77 @@ func GoDotEnvVariable(key string) string {
 
 func ConnectDB() (*gorm.DB, error) {
 	// Responsible for connecting to the database
-	kvdb, err := gorm.Open(mysql.Open(DatabaseDsn()), &gorm.Config{
+	return openDB(DatabaseDsn())
+}
+
+// Migrate brings the schema up to date. New tables are created in utf8mb4; tables created
+// before keep their charset until converted with ALTER TABLE ... CONVERT TO CHARACTER SET utf8mb4.
+func Migrate(kvdb *gorm.DB) error {
+	err := kvdb.Set("gorm:table_options", "CHARSET=utf8mb4").AutoMigrate(&model.KV{}, &model.RaftState{}, &model.ClusterMember{}, &model.ShardRing{}, &model.Change{}, &model.ChangeWatermark{}, &model.ConsumerCheckpoint{}, &model.Lease{}, &model.LockWaiter{}, &model.HashField{}, &model.ListItem{}, &model.SetMember{}, &model.SortedSetMember{})
+	if err != nil {
+		return err
+	}
+	return autoIncrementChanges(kvdb)
+}
+
+// autoIncrementChanges upgrades a change log numbered by the old sequence row,
+// as AutoMigrate does not add AUTO_INCREMENT to an existing column.
+func autoIncrementChanges(kvdb *gorm.DB) error {
+	if kvdb.Dialector.Name() != "mysql" {
+		return nil
+	}
+	columns, err := kvdb.Migrator().ColumnTypes(&model.Change{})
+	if err != nil {
+		return err
+	}
+	for _, column := range columns {
+		if increments, ok := column.AutoIncrement(); column.Name() == "sequence" && ok && !increments {
+			return kvdb.Exec("ALTER TABLE changes MODIFY sequence BIGINT UNSIGNED NOT NULL AUTO_INCREMENT").Error
+		}
+	}
+	return nil
+}
+
+// ChangeLogRetention is how long change log entries are kept; 0 keeps them forever.
+func ChangeLogRetention() time.Duration {
+	return time.Duration(envInt("CHANGELOG_RETENTION_HOURS", 168)) * time.Hour
+}
+
+// ChangeLogMaxRows caps the change log size; 0 means no cap.
+func ChangeLogMaxRows() int {
+	return envInt("CHANGELOG_MAX_ROWS", 0)
+}
+
+// ReadReplicaDsns lists the full DSNs of read replicas, separated by commas.
+func ReadReplicaDsns() []string {
+	return splitList(os.Getenv("READ_REPLICA_DSNS"))
+}
+
+// ReplicaMaxLag is how far behind a replica may fall before it leaves rotation.
+func ReplicaMaxLag() time.Duration {
+	return time.Duration(envInt("REPLICA_MAX_LAG_SECONDS", 5)) * time.Second
+}
+
+// ReplicaCheckInterval is how often replica lag is measured.
+func ReplicaCheckInterval() time.Duration {
+	return time.Duration(envInt("REPLICA_CHECK_INTERVAL_SECONDS", 2)) * time.Second
+}
+
+// ConnectReplicas opens the configured read replicas; their schema is the primary's.
+func ConnectReplicas() ([]*gorm.DB, error) {
+	var replicas []*gorm.DB
+	for _, dsn := range ReadReplicaDsns() {
+		replica, err := openDB(dsn)
+		if err != nil {
+			return nil, err
+		}
+		replicas = append(replicas, replica)
+	}
+	return replicas, nil
+}
+
+func openDB(dsn string) (*gorm.DB, error) {
+	kvdb, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
 })
 	if err != nil {
@@ -48,8 +410,6 @@ func ConnectDB() (*gorm.DB, error) {
 	sqlDB.SetMaxOpenConns(2000)              // Max active connections
 	sqlDB.SetMaxIdleConns(100)              // Idle connections to keep
 	sqlDB.SetConnMaxLifetime(5 * time.Minute) // Recycle connections
-	// Migrate the schema
-	kvdb.AutoMigrate(&model.KV{})
 	return kvdb, nil
 }
 
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	cacheModule "github.com/kv-storage/cache"
+	"github.com/kv-storage/cluster"
+	"github.com/kv-storage/model"
+	kvpb "github.com/kv-storage/proto/kv"
+	"github.com/kv-storage/replicas"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const writeRetries = 3
+
+var (
+	errNotInteger      = errors.New("value is not an integer")
+	errCounterOverflow = errors.New("counter would overflow")
+	errOutOfBounds     = errors.New("counter would leave its bounds")
+	errWrongType       = errors.New("key holds a collection")
+)
+
+// counter is one Increment/Decrement, with the delta already signed.
+type counter struct {
+	key             string
+	delta           int64
+	initial         int64
+	min             *int64
+	max             *int64
+	saturate        bool
+	requireExisting bool
+	expiresAt       *time.Time
+	// version, when set, is the key's new version instead of the change's sequence
+	version uint64
+}
+
+func (KvServerManager *KvService) Increment(ctx context.Context, request *kvpb.CounterRequest) (*kvpb.CounterResponse, error) {
+	return applyCounter(ctx, request, request.Delta)
+}
+
+func (KvServerManager *KvService) Decrement(ctx context.Context, request *kvpb.CounterRequest) (*kvpb.CounterResponse, error) {
+	return applyCounter(ctx, request, -request.Delta)
+}
+
+func applyCounter(ctx context.Context, request *kvpb.CounterRequest, delta int64) (*kvpb.CounterResponse, error) {
+	c := counter{
+		key:             request.Key,
+		delta:           delta,
+		initial:         request.InitialValue,
+		min:             request.Min,
+		max:             request.Max,
+		saturate:        request.Saturate,
+		requireExisting: request.RequireExisting,
+	}
+
+	// In cluster mode the change is committed through Raft instead
+	if clusterNode != nil {
+		// Expiry is judged by each node's clock, which would let replicas diverge
+		if request.TtlSeconds != 0 {
+			return &kvpb.CounterResponse{
+				Message:    "Counter TTLs are not available in cluster mode",
+				StatusCode: int64(StatusBadRequest),
+			}, nil
+		}
+		if !clusterNode.IsLeader() {
+			connection, forwardCtx, err := clusterNode.ForwardToLeader(ctx)
+			if err != nil {
+				return &kvpb.CounterResponse{
+					Message:    err.Error(),
+					StatusCode: int64(StatusServiceUnavailable),
+				}, nil
+			}
+			// Forward as an Increment, the delta is already signed
+			return kvpb.NewKeyValueStoreClient(connection).Increment(forwardCtx, &kvpb.CounterRequest{
+				Key:             request.Key,
+				Delta:           delta,
+				InitialValue:    request.InitialValue,
+				Min:             request.Min,
+				Max:             request.Max,
+				Saturate:        request.Saturate,
+				RequireExisting: request.RequireExisting,
+			})
+		}
+		result, err := clusterNode.Propose(&cluster.Command{
+			Op:              cluster.OpIncrement,
+			Key:             c.key,
+			Delta:           c.delta,
+			Initial:         c.initial,
+			Min:             c.min,
+			Max:             c.max,
+			Saturate:        c.saturate,
+			RequireExisting: c.requireExisting,
+		})
+		if err != nil {
+			return &kvpb.CounterResponse{
+				Message:    err.Error(),
+				StatusCode: int64(StatusServiceUnavailable),
+			}, nil
+		}
+		value, _ := strconv.ParseInt(result.Value, 10, 64)
+		return &kvpb.CounterResponse{
+			Message:    result.Message,
+			StatusCode: result.StatusCode,
+			Value:      value,
+		}, nil
+	}
+
+	if request.TtlSeconds > 0 {
+		expiresAt := time.Now().Add(time.Duration(request.TtlSeconds) * time.Second)
+		c.expiresAt = &expiresAt
+	}
+	statusCode, message, stored, expiring := updateCounter(kvDbConnector, c, time.Now())
+	value := stored.value
+	sessionToken := ""
+	if statusCode == StatusOK {
+		// The cache has no notion of expiry, so counters with a TTL are never cached
+		if expiring {
+			cache.DeleteKey(c.key)
+		} else {
+			cache.Put(c.key, cacheModule.Entry{Value: strconv.FormatInt(value, 10), Version: stored.version, Flags: stored.flags})
+		}
+		invalidationBus.Publish(c.key)
+		sessionToken = replicas.NewSessionToken()
+	}
+	return &kvpb.CounterResponse{
+		Message:      message,
+		StatusCode:   statusCode,
+		Value:        value,
+		SessionToken: sessionToken,
+	}, nil
+}
+
+// counterValue is a counter as stored after an update.
+type counterValue struct {
+	value   int64
+	version uint64
+	flags   uint32
+}
+
+// updateCounter applies c under a row lock and logs the new value in the same
+// transaction. It also reports whether the key now carries an expiry. Two
+// calls racing to create the same counter conflict in InnoDB, so the loser
+// retries and finds the winner's row.
+func updateCounter(db *gorm.DB, c counter, now time.Time) (int64, string, counterValue, bool) {
+	var stored counterValue
+	var expiring bool
+	var err error
+	for attempt := 0; attempt < writeRetries; attempt++ {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var stepErr error
+			stored, expiring, stepErr = counterStep(tx, c, now)
+			return stepErr
+		})
+		if err == nil || !retryableWriteError(err) {
+			break
+		}
+	}
+	switch {
+	case err == nil:
+		return StatusOK, "Counter updated", stored, expiring
+	case err == gorm.ErrRecordNotFound:
+		return StatusNotFound, "Key not found", counterValue{}, false
+	case err == errWrongType:
+		return StatusConflict, "Key holds a collection, not a counter", counterValue{}, false
+	case err == errNotInteger:
+		return StatusBadRequest, "Value is not an integer", counterValue{}, false
+	case err == errCounterOverflow:
+		return StatusBadRequest, "Counter would overflow", stored, false
+	case err == errOutOfBounds:
+		return StatusConflict, "Counter would leave its bounds", stored, false
+	default:
+		return StatusInternalServerError, "Database error", counterValue{}, false
+	}
+}
+
+// counterStep returns the current value alongside errOutOfBounds and
+// errCounterOverflow, so callers can report what the counter is left at.
+func counterStep(tx *gorm.DB, c counter, now time.Time) (counterValue, bool, error) {
+	var kv model.KV
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key_name = ?", c.key).Limit(1).Find(&kv).Error; err != nil {
+		return counterValue{}, false, err
+	}
+	live := kv.ID != 0 && (kv.ExpiresAt == nil || kv.ExpiresAt.After(now))
+	if !live && c.requireExisting {
+		return counterValue{}, false, gorm.ErrRecordNotFound
+	}
+	if live && kv.Type != model.TypeString {
+		return counterValue{}, false, errWrongType
+	}
+	current := c.initial
+	if live {
+		parsed, err := strconv.ParseInt(kv.Value, 10, 64)
+		if err != nil {
+			return counterValue{}, false, errNotInteger
+		}
+		current = parsed
+	}
+	kept := counterValue{value: current, version: kv.Version, flags: kv.Flags}
+
+	next := current + c.delta
+	if (c.delta > 0 && next < current) || (c.delta < 0 && next > current) {
+		if !c.saturate {
+			return kept, false, errCounterOverflow
+		}
+		next = math.MaxInt64
+		if c.delta < 0 {
+			next = math.MinInt64
+		}
+	}
+	switch {
+	case c.min != nil && next < *c.min && c.saturate:
+		next = *c.min
+	case c.max != nil && next > *c.max && c.saturate:
+		next = *c.max
+	case (c.min != nil && next < *c.min) || (c.max != nil && next > *c.max):
+		return kept, false, errOutOfBounds
+	}
+
+	value := strconv.FormatInt(next, 10)
+	version, err := recordWrite(tx, c.version, model.ChangeSet, c.key, value)
+	if err != nil {
+		return counterValue{}, false, err
+	}
+	switch {
+	case kv.ID == 0:
+		kv = model.KV{Key: c.key, Value: value, Type: model.TypeString, Version: version, ExpiresAt: c.expiresAt}
+		if err := tx.Create(&kv).Error; err != nil {
+			return counterValue{}, false, err
+		}
+	case live:
+		// An existing counter keeps its expiry, so a TTL bounds a fixed window
+		kv.Version = version
+		if err := tx.Model(&kv).Updates(map[string]any{"value": value, "version": kv.Version}).Error; err != nil {
+			return counterValue{}, false, err
+		}
+	default:
+		// An expired but not yet reaped counter starts over
+		kv.Version = version
+		kv.Flags = 0
+		err := tx.Model(&kv).Updates(map[string]any{"value": value, "version": kv.Version, "flags": 0, "expires_at": c.expiresAt}).Error
+		if err != nil {
+			return counterValue{}, false, err
+		}
+		kv.ExpiresAt = c.expiresAt
+	}
+	return counterValue{value: next, version: kv.Version, flags: kv.Flags}, kv.ExpiresAt != nil, nil
+}
+
+func retryableWriteError(err error) bool {
+	return strings.Contains(err.Error(), "Duplicate entry") || strings.Contains(err.Error(), "Deadlock found")
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	kvpb "github.com/kv-storage/proto/kv"
+)
+
+func TestCounter(t *testing.T) {
+	ten, zero := int64(10), int64(0)
+	tests := []struct {
+		name      string
+		existing  string
+		decrement bool
+		request   *kvpb.CounterRequest
+		wantCode  int64
+		wantValue int64
+	}{
+		{"a new counter starts at its initial value", "", false, &kvpb.CounterRequest{Delta: 2, InitialValue: 5}, 200, 7},
+		{"an existing counter", "40", false, &kvpb.CounterRequest{Delta: 2}, 200, 42},
+		{"a decrement", "40", true, &kvpb.CounterRequest{Delta: 2}, 200, 38},
+		{"a missing counter that must exist", "", false, &kvpb.CounterRequest{Delta: 1, RequireExisting: true}, 404, 0},
+		{"a value that is not an integer", "forty", false, &kvpb.CounterRequest{Delta: 1}, 400, 0},
+		{"past the maximum", "9", false, &kvpb.CounterRequest{Delta: 2, Max: &ten}, 409, 9},
+		{"saturating at the maximum", "9", false, &kvpb.CounterRequest{Delta: 2, Max: &ten, Saturate: true}, 200, 10},
+		{"below the minimum", "1", true, &kvpb.CounterRequest{Delta: 2, Min: &zero}, 409, 1},
+		{"saturating at the minimum", "1", true, &kvpb.CounterRequest{Delta: 2, Min: &zero, Saturate: true}, 200, 0},
+		{"an overflow", "9223372036854775807", false, &kvpb.CounterRequest{Delta: 1}, 400, math.MaxInt64},
+		{"saturating on overflow", "9223372036854775806", false, &kvpb.CounterRequest{Delta: 5, Saturate: true}, 200, math.MaxInt64},
+		{"saturating on underflow", "-9223372036854775807", true, &kvpb.CounterRequest{Delta: 5, Saturate: true}, 200, math.MinInt64},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useStore(t)
+			server := &KvService{}
+			if test.existing != "" {
+				set(t, "hits", test.existing)
+			}
+			test.request.Key = "hits"
+			call := server.Increment
+			if test.decrement {
+				call = server.Decrement
+			}
+			response, err := call(context.Background(), test.request)
+			if err != nil || response.StatusCode != test.wantCode || response.Value != test.wantValue {
+				t.Fatalf("got %v, %v, want %d with value %d", response, err, test.wantCode, test.wantValue)
+			}
+		})
+	}
+}
+
+func TestCounterTTL(t *testing.T) {
+	useStore(t)
+	server := &KvService{}
+	for want := int64(1); want <= 2; want++ {
+		response, err := server.Increment(context.Background(), &kvpb.CounterRequest{Key: "window", Delta: 1, TtlSeconds: 60})
+		if err != nil || response.Value != want {
+			t.Fatalf("got %v, %v, want %d", response, err, want)
+		}
+	}
+	// A counter with a TTL is never cached, as the cache would outlive it
+	if _, ok := cache.Get("window"); ok {
+		t.Fatal("an expiring counter was cached")
+	}
+}
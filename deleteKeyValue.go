@@ -3,48 +3,104 @@ package main
 import (
 	"context"
 	kvpb "github.com/kv-storage/proto/kv"
+	"github.com/kv-storage/audit"
+	"github.com/kv-storage/cluster"
 	"github.com/kv-storage/model"
+	"github.com/kv-storage/replicas"
 	"gorm.io/gorm"
+	"time"
 )
 
 func (KvServerManager *KvService) DeleteKeyValue(ctx context.Context, request *kvpb.DeleteKeyValueRequest) (*kvpb.DeleteKeyValueResponse, error) {
 	key := request.Key
 
-	// Check if key is missing
-	if key == "" {
+	// In cluster mode the delete is committed through Raft instead
+	if clusterNode != nil {
+		if !clusterNode.IsLeader() {
+			connection, forwardCtx, err := clusterNode.ForwardToLeader(ctx)
+			if err != nil {
+				return &kvpb.DeleteKeyValueResponse{
+					Message:    err.Error(),
+					StatusCode: int64(StatusServiceUnavailable),
+				}, nil
+			}
+			return kvpb.NewKeyValueStoreClient(connection).DeleteKeyValue(forwardCtx, request)
+		}
+		result, err := clusterNode.Propose(&cluster.Command{Op: cluster.OpDelete, Key: key, ExpectedVersion: request.ExpectedVersion})
+		if err != nil {
+			return &kvpb.DeleteKeyValueResponse{
+				Message:    err.Error(),
+				StatusCode: int64(StatusServiceUnavailable),
+			}, nil
+		}
+		audit.Replaced(ctx, result.Previous)
 		return &kvpb.DeleteKeyValueResponse{
-			Message:    "Key missing in delete request",
-			StatusCode: int64(StatusBadRequest),
+			Message:    result.Message,
+			StatusCode: result.StatusCode,
 		}, nil
 	}
 
+	statusCode, message, version, previous := removeKeyValue(kvDbConnector, key, request.ExpectedVersion, 0)
+	audit.Replaced(ctx, previous)
+	sessionToken := ""
+	if statusCode == StatusOK {
+		cache.Delete(key, version)
+		invalidationBus.Publish(key)
+		sessionToken = replicas.NewSessionToken()
+	}
+	return &kvpb.DeleteKeyValueResponse{
+		Message:      message,
+		StatusCode:   statusCode,
+		SessionToken: sessionToken,
+	}, nil
+}
+
+// removeKeyValue deletes the row and logs the change; the caller updates the cache once it is committed.
+// A non-zero expectedVersion only deletes the key at that version. The version returned is the
+// delete's place in the change log, later than any version the key was written at, unless
+// version fixes it as cluster mode does. The string the key held is returned too, nil if none.
+func removeKeyValue(db *gorm.DB, key string, expectedVersion, version uint64) (int64, string, uint64, *string) {
 	// Check if key exists in DB
 	var existingKeyValuePair model.KV
-	err := kvDbConnector.Where("key_name = ?", key).First(&existingKeyValuePair).Error
+	err := db.Where("key_name = ? AND (expires_at IS NULL OR expires_at > ?)", key, time.Now()).First(&existingKeyValuePair).Error
 
 	if err == gorm.ErrRecordNotFound {
-		return &kvpb.DeleteKeyValueResponse{
-			Message:    "Key not found",
-			StatusCode: int64(StatusNotFound),
-		}, nil
+		return StatusNotFound, "Key not found", 0, nil
 	} else if err != nil {
-		return &kvpb.DeleteKeyValueResponse{
-			Message:    "Database error",
-			StatusCode: int64(StatusInternalServerError),
-		}, nil
+		return StatusInternalServerError, "Database error", 0, nil
+	}
+	if expectedVersion != 0 && existingKeyValuePair.Version != expectedVersion {
+		return StatusConflict, "Key has changed since expectedVersion", 0, nil
 	}
 
-	// Delete key-value pair
-	deleteResult := kvDbConnector.Delete(&existingKeyValuePair)
-	if deleteResult.Error != nil {
-		return &kvpb.DeleteKeyValueResponse{
-			Message:    "Failed to delete key-value pair",
-			StatusCode: int64(StatusInternalServerError),
-		}, nil
+	// Delete key-value pair, logging the change in the same transaction
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Matching the version read above also catches a write that landed since,
+		// so the value read is the one deleted
+		deleteResult := tx.Where("version = ?", existingKeyValuePair.Version).Delete(&existingKeyValuePair)
+		if deleteResult.Error != nil {
+			return deleteResult.Error
+		}
+		// A concurrent delete or update got there first
+		if deleteResult.RowsAffected == 0 && expectedVersion != 0 {
+			return errVersionMismatch
+		}
+		if deleteResult.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := deleteElements(tx, existingKeyValuePair); err != nil {
+			return err
+		}
+		var err error
+		version, err = recordWrite(tx, version, model.ChangeDelete, key, "")
+		return err
+	})
+	if err == gorm.ErrRecordNotFound {
+		return StatusNotFound, "Key not found", 0, nil
+	} else if err == errVersionMismatch {
+		return StatusConflict, "Key has changed since expectedVersion", 0, nil
+	} else if err != nil {
+		return StatusInternalServerError, "Failed to delete key-value pair", 0, nil
 	}
-	cache.DeleteKey(key)
-	return &kvpb.DeleteKeyValueResponse{
-		Message:    "Key-value pair successfully deleted",
-		StatusCode: int64(StatusOK),
-	}, nil
-}
\ No newline at end of file
+	return StatusOK, "Key-value pair successfully deleted", version, stringValue(existingKeyValuePair)
+}
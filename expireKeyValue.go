@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/kv-storage/changefeed"
+	"github.com/kv-storage/model"
+	kvpb "github.com/kv-storage/proto/kv"
+	"github.com/kv-storage/replicas"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	expiryInterval = time.Second
+	expiryBatch    = 500
+)
+
+// dropExpired deletes key if its TTL has passed, logging the delete in tx,
+// and reports whether it did.
+func dropExpired(tx *gorm.DB, key string, now time.Time) (bool, error) {
+	var kv model.KV
+	if err := tx.Where("key_name = ? AND expires_at <= ?", key, now).Limit(1).Find(&kv).Error; err != nil || kv.ID == 0 {
+		return false, err
+	}
+	result := tx.Where("expires_at <= ?", now).Delete(&kv)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	if err := deleteElements(tx, kv); err != nil {
+		return false, err
+	}
+	_, err := changefeed.Record(tx, model.ChangeDelete, key, "")
+	return true, err
+}
+
+// reapExpiredKeys deletes keys whose TTL has passed. Reads already treat them
+// as missing; this frees the rows and tells change feed consumers and peers.
+func reapExpiredKeys() {
+	for range time.Tick(expiryInterval) {
+		var keys []string
+		if err := kvDbConnector.Model(&model.KV{}).Where("expires_at <= ?", time.Now()).Limit(expiryBatch).Pluck("key_name", &keys).Error; err != nil {
+			logger.Warn("Expiry reaper failed", zap.Error(err))
+			continue
+		}
+		for _, key := range keys {
+			var dropped bool
+			err := kvDbConnector.Transaction(func(tx *gorm.DB) error {
+				var err error
+				dropped, err = dropExpired(tx, key, time.Now())
+				return err
+			})
+			if err != nil {
+				logger.Warn("Failed to drop expired key", zap.String("key", key), zap.Error(err))
+				continue
+			}
+			if dropped {
+				cache.DeleteKey(key)
+				invalidationBus.Publish(key)
+			}
+		}
+	}
+}
+
+func (KvServerManager *KvService) Expire(ctx context.Context, request *kvpb.ExpireRequest) (*kvpb.ExpireResponse, error) {
+	// Expiry is judged by each node's clock, which would let replicas diverge
+	if clusterNode != nil {
+		return &kvpb.ExpireResponse{
+			Message:    "Key TTLs are not available in cluster mode",
+			StatusCode: int64(StatusBadRequest),
+		}, nil
+	}
+
+	var statusCode int64
+	var message string
+	if request.Persist {
+		statusCode, message = persistKey(kvDbConnector, request.Key, time.Now())
+	} else if request.TtlMilliseconds <= 0 {
+		statusCode, message, _, _ = removeKeyValue(kvDbConnector, request.Key, 0, 0)
+	} else {
+		now := time.Now()
+		result := kvDbConnector.Model(&model.KV{}).
+			Where("key_name = ? AND (expires_at IS NULL OR expires_at > ?)", request.Key, now).
+			Update("expires_at", now.Add(time.Duration(request.TtlMilliseconds)*time.Millisecond))
+		switch {
+		case result.Error != nil:
+			statusCode, message = StatusInternalServerError, "Database error"
+		case result.RowsAffected == 0:
+			statusCode, message = StatusNotFound, "Key not found"
+		default:
+			statusCode, message = StatusOK, "Expiry set"
+		}
+	}
+	sessionToken := ""
+	if statusCode == StatusOK {
+		// Either way the key must leave the cache, which cannot expire entries
+		cache.DeleteKey(request.Key)
+		invalidationBus.Publish(request.Key)
+		sessionToken = replicas.NewSessionToken()
+	}
+	return &kvpb.ExpireResponse{
+		Message:      message,
+		StatusCode:   statusCode,
+		SessionToken: sessionToken,
+	}, nil
+}
+
+// persistKey clears a live key's expiry. MySQL counts only changed rows, so a
+// key that never had one is looked up separately.
+func persistKey(db *gorm.DB, key string, now time.Time) (int64, string) {
+	result := db.Model(&model.KV{}).
+		Where("key_name = ? AND expires_at > ?", key, now).
+		Update("expires_at", nil)
+	if result.Error != nil {
+		return StatusInternalServerError, "Database error"
+	}
+	if result.RowsAffected == 0 {
+		var live int64
+		if err := db.Model(&model.KV{}).Where("key_name = ? AND expires_at IS NULL", key).Count(&live).Error; err != nil {
+			return StatusInternalServerError, "Database error"
+		}
+		if live == 0 {
+			return StatusNotFound, "Key not found"
+		}
+	}
+	return StatusOK, "Expiry removed"
+}
+
+func (KvServerManager *KvService) GetTTL(ctx context.Context, request *kvpb.TTLRequest) (*kvpb.TTLResponse, error) {
+	now := time.Now()
+	var kv model.KV
+	err := kvDbConnector.Where("key_name = ? AND (expires_at IS NULL OR expires_at > ?)", request.Key, now).Limit(1).Find(&kv).Error
+	if err != nil {
+		return &kvpb.TTLResponse{
+			Message:    "Database error",
+			StatusCode: int64(StatusInternalServerError),
+		}, nil
+	}
+	if kv.ID == 0 {
+		return &kvpb.TTLResponse{
+			Message:    "Key not found",
+			StatusCode: int64(StatusNotFound),
+		}, nil
+	}
+	ttl := int64(-1)
+	if kv.ExpiresAt != nil {
+		ttl = kv.ExpiresAt.Sub(now).Milliseconds()
+	}
+	return &kvpb.TTLResponse{
+		Message:         "Key found",
+		StatusCode:      int64(StatusOK),
+		TtlMilliseconds: ttl,
+	}, nil
+}
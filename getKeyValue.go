@@ -3,35 +3,65 @@ package main
 import (
 	"context"
 	kvpb "github.com/kv-storage/proto/kv"
+	cacheModule "github.com/kv-storage/cache"
 	"github.com/kv-storage/model"
+	"github.com/kv-storage/replicas"
+	"time"
 )
 
 func (KvServerManager *KvService) GetKeyValue(ctx context.Context, request *kvpb.GetKVRequest) (*kvpb.GetKVResponse, error) {
 	// getting the key from request...
 	key := request.Key;
-	// checking in the cache
-	value,isValueExist := cache.Get(key);
+	// In cluster mode confirm with the leader that we are not serving a stale replica
+	if clusterNode != nil {
+		if err := clusterNode.WaitReadIndex(ctx); err != nil {
+			return &kvpb.GetKVResponse{
+				Message:    err.Error(),
+				StatusCode: int64(StatusServiceUnavailable),
+			}, nil
+		}
+	}
+	// checking in the cache, unless the caller asked for a strong read
+	entry,isValueExist := cacheModule.Entry{}, false
+	if request.Consistency != replicas.Strong {
+		entry,isValueExist = cache.Get(key);
+	}
 	if isValueExist == true  {
 		return &kvpb.GetKVResponse{
 			Message:"Key found",
 			StatusCode : int64(StatusOK),
-			Value:value,
+			Value:entry.Value,
+			Version:entry.Version,
+			Flags:entry.Flags,
 		},nil
 	}
-	// Checking into the database
+	// Remember the generation before reading, so a write racing with us wins over our fill
+	generation := cache.Generation(key)
+	// Checking into the database, on a replica when one is fresh enough
+	reader, isPrimary := readRouter.Reader(request.Consistency, request.SessionToken)
 	var keyValue model.KV
-	if err := kvDbConnector.Where("key_name = ?", key).First(&keyValue).Error; err != nil {
+	if err := reader.Where("key_name = ? AND (expires_at IS NULL OR expires_at > ?)", key, time.Now()).First(&keyValue).Error; err != nil {
 		return &kvpb.GetKVResponse{
 			Message:    "Key not found",
 			StatusCode: int64(StatusNotFound),
 		}, nil
 	}
-	if(!isValueExist) {
-		cache.Put(key,keyValue.Value);
+	if keyValue.Type != "" && keyValue.Type != model.TypeString {
+		return &kvpb.GetKVResponse{
+			Message:    wrongType(keyValue.Type, model.TypeString).Message,
+			StatusCode: int64(StatusConflict),
+		}, nil
+	}
+	// Replica reads may be behind, so only primary reads populate the cache,
+	// and the cache cannot expire entries, so keys with a TTL stay out of it
+	if(!isValueExist && isPrimary && keyValue.ExpiresAt == nil) {
+		cache.Fill(key,cacheModule.Entry{Value: keyValue.Value, Version: keyValue.Version, Flags: keyValue.Flags},generation);
 	}
 	return &kvpb.GetKVResponse{
 		Message:"Key found",
 		StatusCode : int64(StatusOK),
 		Value:keyValue.Value,
+		Version:keyValue.Version,
+		Flags:keyValue.Flags,
 	},nil
 }
@@ -0,0 +1,163 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http2"
+)
+
+const (
+	contentTypePrefix = "application/grpc-web"
+	textContentType   = "application/grpc-web-text"
+	trailerFlag       = 0x80
+)
+
+// ExposedHeaders are the response headers a browser must be allowed to
+// read for gRPC-Web clients to see a call's status.
+var ExposedHeaders = []string{"Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin"}
+
+// IsRequest reports whether r is a gRPC-Web call.
+func IsRequest(r *http.Request) bool {
+	return r.Method == http.MethodPost && strings.HasPrefix(r.Header.Get("Content-Type"), contentTypePrefix)
+}
+
+// Handler serves gRPC-Web calls over HTTP/1.1 by handing them to a gRPC
+// server as if they had arrived over HTTP/2, then folding the trailers into
+// the response body where browsers can read them. Both the binary and the
+// base64 text encodings are supported, for unary and server-streaming calls.
+func Handler(grpcServer http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType := r.Header.Get("Content-Type")
+		text := strings.HasPrefix(contentType, textContentType)
+		body := r.Body
+		if text {
+			decoded, err := decodeText(r.Body)
+			if err != nil {
+				http.Error(w, "Malformed grpc-web-text body", http.StatusBadRequest)
+				return
+			}
+			body = io.NopCloser(bytes.NewReader(decoded))
+		}
+
+		request := r.Clone(r.Context())
+		request.ProtoMajor, request.ProtoMinor, request.Proto = 2, 0, "HTTP/2"
+		request.Body = body
+		request.ContentLength = -1
+		request.Header.Del("Content-Length")
+		// application/grpc-web+proto and -text+proto both carry protobuf
+		subtype := contentType[strings.LastIndexAny(contentType, "+")+1:]
+		if !strings.Contains(contentType, "+") {
+			subtype = "proto"
+		}
+		request.Header.Set("Content-Type", "application/grpc+"+subtype)
+
+		response := &webResponse{w: w, headers: make(http.Header), text: text, contentType: contentType}
+		grpcServer.ServeHTTP(response, request)
+		response.finish()
+	})
+}
+
+// decodeText decodes a base64 body, which clients may send as several
+// padded chunks; every 4-byte quantum decodes on its own.
+func decodeText(body io.Reader) ([]byte, error) {
+	encoded, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	encoded = bytes.Join(bytes.Fields(encoded), nil)
+	if len(encoded)%4 != 0 {
+		return nil, base64.CorruptInputError(len(encoded))
+	}
+	decoded := make([]byte, 0, len(encoded)/4*3)
+	quantum := make([]byte, 3)
+	for i := 0; i < len(encoded); i += 4 {
+		n, err := base64.StdEncoding.Decode(quantum, encoded[i:i+4])
+		if err != nil {
+			return nil, err
+		}
+		decoded = append(decoded, quantum[:n]...)
+	}
+	return decoded, nil
+}
+
+// webResponse collects what the gRPC server writes. Headers set before the
+// first write go out as headers; everything set after is a trailer.
+type webResponse struct {
+	w           http.ResponseWriter
+	headers     http.Header
+	sent        map[string]bool
+	text        bool
+	contentType string
+}
+
+func (r *webResponse) Header() http.Header {
+	return r.headers
+}
+
+func (r *webResponse) WriteHeader(statusCode int) {
+	if r.sent != nil {
+		return
+	}
+	r.sent = make(map[string]bool)
+	declared := make(map[string]bool)
+	for _, name := range r.headers.Values("Trailer") {
+		declared[http.CanonicalHeaderKey(name)] = true
+	}
+	out := r.w.Header()
+	for name, values := range r.headers {
+		if name == "Trailer" || declared[name] || strings.HasPrefix(name, http2.TrailerPrefix) {
+			continue
+		}
+		out[name] = values
+		r.sent[name] = true
+	}
+	out.Set("Content-Type", r.contentType)
+	out.Del("Content-Length")
+	r.w.WriteHeader(statusCode)
+}
+
+func (r *webResponse) Write(data []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return len(data), r.writeBody(data)
+}
+
+func (r *webResponse) writeBody(data []byte) error {
+	if r.text {
+		// Each write is padded, which gRPC-Web text clients accept between chunks
+		data = []byte(base64.StdEncoding.EncodeToString(data))
+	}
+	_, err := r.w.Write(data)
+	return err
+}
+
+func (r *webResponse) Flush() {
+	r.WriteHeader(http.StatusOK)
+	if flusher, ok := r.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// finish sends the trailers as the final frame of the body.
+func (r *webResponse) finish() {
+	r.WriteHeader(http.StatusOK)
+	var trailers bytes.Buffer
+	for name, values := range r.headers {
+		if name == "Trailer" || r.sent[name] {
+			continue
+		}
+		name = strings.ToLower(strings.TrimPrefix(name, http2.TrailerPrefix))
+		for _, value := range values {
+			trailers.WriteString(name + ": " + value + "\r\n")
+		}
+	}
+	frame := make([]byte, 5, 5+trailers.Len())
+	frame[0] = trailerFlag
+	binary.BigEndian.PutUint32(frame[1:], uint32(trailers.Len()))
+	r.writeBody(append(frame, trailers.Bytes()...))
+	r.Flush()
+}
@@ -0,0 +1,139 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// frame wraps message the way gRPC does on the wire.
+func frame(flag byte, message []byte) []byte {
+	header := make([]byte, 5)
+	header[0] = flag
+	binary.BigEndian.PutUint32(header[1:], uint32(len(message)))
+	return append(header, message...)
+}
+
+// frames splits a gRPC-Web body into its messages and its trailers.
+func frames(t *testing.T, body []byte) ([][]byte, string) {
+	t.Helper()
+	var messages [][]byte
+	for len(body) > 0 {
+		if len(body) < 5 {
+			t.Fatalf("a frame cut short: %q", body)
+		}
+		size := binary.BigEndian.Uint32(body[1:5])
+		payload := body[5 : 5+size]
+		if body[0]&trailerFlag != 0 {
+			return messages, string(payload)
+		}
+		messages = append(messages, payload)
+		body = body[5+size:]
+	}
+	t.Fatal("no trailer frame")
+	return nil, ""
+}
+
+func TestHandler(t *testing.T) {
+	grpcServer := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("kv", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	server := httptest.NewServer(Handler(grpcServer))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		contentType string
+		service     string
+		wantStatus  string
+		wantServing bool
+	}{
+		{"binary", "application/grpc-web+proto", "kv", "grpc-status: 0", true},
+		{"binary without a subtype", "application/grpc-web", "kv", "grpc-status: 0", true},
+		{"text", "application/grpc-web-text", "kv", "grpc-status: 0", true},
+		{"a failed call", "application/grpc-web+proto", "unknown", "grpc-status: 5", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			message, err := proto.Marshal(&healthpb.HealthCheckRequest{Service: test.service})
+			if err != nil {
+				t.Fatal(err)
+			}
+			body := frame(0, message)
+			text := strings.HasPrefix(test.contentType, textContentType)
+			if text {
+				body = []byte(base64.StdEncoding.EncodeToString(body))
+			}
+			request, err := http.NewRequest(http.MethodPost, server.URL+healthpb.Health_Check_FullMethodName, bytes.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			request.Header.Set("Content-Type", test.contentType)
+			if !IsRequest(request) {
+				t.Fatal("not taken for a gRPC-Web call")
+			}
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer response.Body.Close()
+			if response.Header.Get("Content-Type") != test.contentType || response.Header.Get("Grpc-Status") != "" {
+				t.Fatalf("headers %v, want the request's content type and no trailers", response.Header)
+			}
+			body, err = io.ReadAll(response.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if text {
+				if body, err = decodeText(bytes.NewReader(body)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			messages, trailers := frames(t, body)
+			if !strings.Contains(trailers, test.wantStatus+"\r\n") {
+				t.Fatalf("trailers %q, want %q", trailers, test.wantStatus)
+			}
+			if (len(messages) == 1) != test.wantServing {
+				t.Fatalf("got %d messages", len(messages))
+			}
+			if test.wantServing {
+				var check healthpb.HealthCheckResponse
+				if err := proto.Unmarshal(messages[0], &check); err != nil || check.Status != healthpb.HealthCheckResponse_SERVING {
+					t.Fatalf("got %v, %v", &check, err)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{"one chunk", base64.StdEncoding.EncodeToString([]byte("hello")), "hello", false},
+		{"padded chunks", base64.StdEncoding.EncodeToString([]byte("he")) + base64.StdEncoding.EncodeToString([]byte("llo")), "hello", false},
+		{"line breaks", "aGVs\r\nbG8=", "hello", false},
+		{"cut short", "aGVsbG", "", true},
+		{"not base64", "a!b?", "", true},
+	}
+	for _, test := range tests {
+		got, err := decodeText(strings.NewReader(test.body))
+		if (err != nil) != test.wantErr || string(got) != test.want {
+			t.Errorf("%s: got %q, %v, want %q", test.name, got, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"sort"
+
+	"github.com/kv-storage/model"
+	kvpb "github.com/kv-storage/proto/kv"
+	"gorm.io/gorm"
+)
+
+func (KvServerManager *KvService) HashSet(ctx context.Context, request *kvpb.HashSetRequest) (*kvpb.CollectionResponse, error) {
+	return mutateCollection(ctx, request, collectionOp{Op: opHashSet, Key: request.Key, Fields: request.Fields})
+}
+
+func (KvServerManager *KvService) HashDelete(ctx context.Context, request *kvpb.HashDeleteRequest) (*kvpb.CollectionResponse, error) {
+	return mutateCollection(ctx, request, collectionOp{Op: opHashDelete, Key: request.Key, Values: request.Fields})
+}
+
+func (KvServerManager *KvService) HashGet(ctx context.Context, request *kvpb.HashGetRequest) (*kvpb.CollectionResponse, error) {
+	kv, failure := readCollection(ctx, request.Key, model.TypeHash)
+	if failure != nil {
+		return failure, nil
+	}
+	var field model.HashField
+	if err := kvDbConnector.Where("kv_id = ? AND field = ?", kv.ID, request.Field).Limit(1).Find(&field).Error; err != nil {
+		return collectionError(StatusInternalServerError, "Database error"), nil
+	}
+	if field.KVID == 0 {
+		return collectionError(StatusNotFound, "Field not found"), nil
+	}
+	return &kvpb.CollectionResponse{
+		Message:    "Field found",
+		StatusCode: int64(StatusOK),
+		Count:      1,
+		Values:     []string{field.Value},
+	}, nil
+}
+
+func (KvServerManager *KvService) HashGetAll(ctx context.Context, request *kvpb.CollectionKeyRequest) (*kvpb.CollectionResponse, error) {
+	kv, failure := readCollection(ctx, request.Key, model.TypeHash)
+	if failure != nil {
+		return failure, nil
+	}
+	var fields []model.HashField
+	if err := kvDbConnector.Where("kv_id = ?", kv.ID).Find(&fields).Error; err != nil {
+		return collectionError(StatusInternalServerError, "Database error"), nil
+	}
+	response := &kvpb.CollectionResponse{
+		Message:    "Key found",
+		StatusCode: int64(StatusOK),
+		Count:      int64(len(fields)),
+		Fields:     make(map[string]string, len(fields)),
+	}
+	for _, field := range fields {
+		response.Fields[field.Field] = field.Value
+	}
+	return response, nil
+}
+
+// hashSet writes fields and reports how many of them are new.
+func hashSet(tx *gorm.DB, kvID uint, fields map[string]string) (int64, error) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	// A fixed order keeps replicated applies identical across nodes
+	sort.Strings(names)
+	existing, err := existingNames(tx, &model.HashField{}, "field", kvID, names)
+	if err != nil {
+		return 0, err
+	}
+	var added []model.HashField
+	for _, name := range names {
+		if existing[name] {
+			err := tx.Model(&model.HashField{}).Where("kv_id = ? AND field = ?", kvID, name).Update("value", fields[name]).Error
+			if err != nil {
+				return 0, err
+			}
+			continue
+		}
+		added = append(added, model.HashField{KVID: kvID, Field: name, Value: fields[name]})
+	}
+	if len(added) > 0 {
+		if err := tx.Create(&added).Error; err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(added)), nil
+}
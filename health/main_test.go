@@ -0,0 +1,73 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"gorm.io/gorm"
+)
+
+func TestProbe(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "health.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	probe := NewProbe(db, zap.NewNop(), "kv.KeyValueStore")
+	defer probe.Shutdown()
+
+	steps := []struct {
+		name      string
+		step      func()
+		wantReady int
+		want      healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{"on start", func() {}, http.StatusServiceUnavailable, healthpb.HealthCheckResponse_NOT_SERVING},
+		{"migrated", probe.MarkMigrated, http.StatusServiceUnavailable, healthpb.HealthCheckResponse_NOT_SERVING},
+		{"warmed", probe.MarkWarmed, http.StatusOK, healthpb.HealthCheckResponse_SERVING},
+		{"shutting down", probe.Shutdown, http.StatusServiceUnavailable, healthpb.HealthCheckResponse_NOT_SERVING},
+	}
+	for _, step := range steps {
+		step.step()
+		recorder := httptest.NewRecorder()
+		probe.Readiness(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if recorder.Code != step.wantReady {
+			t.Errorf("%s: /readyz answered %d, want %d", step.name, recorder.Code, step.wantReady)
+		}
+		for _, service := range []string{"", "kv.KeyValueStore"} {
+			response, err := probe.Server().Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+			if err != nil || response.Status != step.want {
+				t.Errorf("%s: %q is %v, %v, want %v", step.name, service, response, err, step.want)
+			}
+		}
+		live := httptest.NewRecorder()
+		probe.Live(live, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if live.Code != http.StatusOK {
+			t.Errorf("%s: /healthz answered %d", step.name, live.Code)
+		}
+	}
+}
+
+func TestReadyNeedsTheDatabase(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "health.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	probe := NewProbe(db, zap.NewNop())
+	defer probe.Shutdown()
+	probe.MarkMigrated()
+	probe.MarkWarmed()
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.Close()
+	if err := probe.Ready(context.Background()); err == nil {
+		t.Fatal("ready with the database closed")
+	}
+}
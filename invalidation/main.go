@@ -0,0 +1,152 @@
+package invalidation
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	cacheModule "github.com/kv-storage/cache"
+	kvpb "github.com/kv-storage/proto/kv"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	queueSize   = 1024
+	sendTimeout = 2 * time.Second
+	maxBackoff  = 5 * time.Second
+)
+
+// stream tracks the last sequence number applied from one origin.
+type stream struct {
+	epoch    int64
+	sequence uint64
+}
+
+type peer struct {
+	address string
+	client  kvpb.CacheInvalidationClient
+	queue   chan *kvpb.InvalidateRequest
+}
+
+// Bus broadcasts local mutations to peer nodes and applies theirs to the local cache.
+//
+// Every message carries the sender's origin, a per-boot epoch and a sequence
+// number. Receivers drop duplicates and flush their whole cache whenever they
+// detect a gap, so a lost message can never leave a stale entry behind.
+type Bus struct {
+	kvpb.UnimplementedCacheInvalidationServer
+
+	origin string
+	epoch  int64
+	cache  *cacheModule.LRUCache
+	peers  []*peer
+	logger *zap.Logger
+
+	// publishing guards sequence so numbers reach every queue in the order they were taken
+	publishing sync.Mutex
+	sequence   uint64
+
+	mu   sync.Mutex
+	seen map[string]stream
+}
+
+func NewBus(origin string, peerAddresses []string, peerCredentials credentials.TransportCredentials, cache *cacheModule.LRUCache, logger *zap.Logger) (*Bus, error) {
+	bus := &Bus{
+		origin: origin,
+		epoch:  time.Now().UnixNano(),
+		cache:  cache,
+		logger: logger,
+		seen:   make(map[string]stream),
+	}
+	for _, address := range peerAddresses {
+		connection, err := grpc.NewClient(address, grpc.WithTransportCredentials(peerCredentials))
+		if err != nil {
+			return nil, err
+		}
+		p := &peer{
+			address: address,
+			client:  kvpb.NewCacheInvalidationClient(connection),
+			queue:   make(chan *kvpb.InvalidateRequest, queueSize),
+		}
+		bus.peers = append(bus.peers, p)
+		go bus.deliver(p)
+	}
+	return bus, nil
+}
+
+// Publish queues an invalidation of key for every peer. It never blocks: if a
+// peer's queue is full the message is dropped and the peer flushes once it
+// sees the resulting sequence gap.
+func (b *Bus) Publish(key string) {
+	if b == nil || len(b.peers) == 0 {
+		return
+	}
+	b.publishing.Lock()
+	defer b.publishing.Unlock()
+	b.sequence++
+	message := &kvpb.InvalidateRequest{
+		Origin:   b.origin,
+		Epoch:    b.epoch,
+		Sequence: b.sequence,
+		Key:      key,
+	}
+	for _, p := range b.peers {
+		select {
+		case p.queue <- message:
+		default:
+			b.logger.Warn("Invalidation queue full, dropping message", zap.String("peer", p.address), zap.Uint64("sequence", message.Sequence))
+		}
+	}
+}
+
+// deliver sends queued messages to one peer in order, retrying the head of the queue until it lands.
+func (b *Bus) deliver(p *peer) {
+	for message := range p.queue {
+		backoff := 100 * time.Millisecond
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+			_, err := p.client.Invalidate(ctx, message)
+			cancel()
+			if err == nil {
+				break
+			}
+			b.logger.Debug("Invalidation delivery failed", zap.String("peer", p.address), zap.Error(err))
+			time.Sleep(backoff)
+			if backoff *= 2; backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+	}
+}
+
+func (b *Bus) Invalidate(ctx context.Context, request *kvpb.InvalidateRequest) (*kvpb.InvalidateResponse, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	last, known := b.seen[request.Origin]
+	switch {
+	case known && last.epoch == request.Epoch && request.Sequence <= last.sequence:
+		return &kvpb.InvalidateResponse{
+			Message:    "Duplicate invalidation ignored",
+			StatusCode: 200,
+		}, nil
+	case known && last.epoch == request.Epoch && request.Sequence == last.sequence+1,
+		!known && request.Sequence == 1:
+		b.cache.DeleteKey(request.Key)
+	default:
+		// Either messages were lost or the origin restarted mid-stream.
+		b.logger.Warn("Invalidation gap detected, flushing cache",
+			zap.String("origin", request.Origin),
+			zap.Uint64("expected", last.sequence+1),
+			zap.Uint64("received", request.Sequence),
+		)
+		b.cache.Flush()
+	}
+	b.seen[request.Origin] = stream{epoch: request.Epoch, sequence: request.Sequence}
+	return &kvpb.InvalidateResponse{
+		Message:    "Key invalidated",
+		StatusCode: 200,
+	}, nil
+}
@@ -0,0 +1,125 @@
+package invalidation
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	cacheModule "github.com/kv-storage/cache"
+	kvpb "github.com/kv-storage/proto/kv"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestInvalidate(t *testing.T) {
+	type message struct {
+		origin   string
+		epoch    int64
+		sequence uint64
+		key      string
+	}
+	tests := []struct {
+		name     string
+		messages []message
+		// wantCached are the keys of a, b and c still cached afterwards
+		wantCached []string
+	}{
+		{"the first message drops its key", []message{{"n1", 1, 1, "a"}}, []string{"b", "c"}},
+		{"messages in order drop their keys", []message{{"n1", 1, 1, "a"}, {"n1", 1, 2, "b"}}, []string{"c"}},
+		{"a duplicate is ignored", []message{{"n1", 1, 1, "a"}, {"n1", 1, 1, "b"}}, []string{"b", "c"}},
+		{"origins are tracked apart", []message{{"n1", 1, 1, "a"}, {"n2", 7, 1, "b"}}, []string{"c"}},
+		{"a gap flushes everything", []message{{"n1", 1, 1, "a"}, {"n1", 1, 3, "b"}}, nil},
+		{"joining part-way flushes everything", []message{{"n1", 1, 5, "a"}}, nil},
+		{"a restarted origin flushes everything", []message{{"n1", 1, 1, "a"}, {"n1", 2, 2, "b"}}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cache := cacheModule.NewLRUCache(10)
+			for _, key := range []string{"a", "b", "c"} {
+				cache.Put(key, cacheModule.Entry{Value: key})
+			}
+			bus, err := NewBus("self", nil, insecure.NewCredentials(), cache, zap.NewNop())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, m := range test.messages {
+				request := &kvpb.InvalidateRequest{Origin: m.origin, Epoch: m.epoch, Sequence: m.sequence, Key: m.key}
+				if response, err := bus.Invalidate(context.Background(), request); err != nil || response.StatusCode != 200 {
+					t.Fatalf("Invalidate = %v, %v", response, err)
+				}
+			}
+			var cached []string
+			for _, key := range []string{"a", "b", "c"} {
+				if _, ok := cache.Get(key); ok {
+					cached = append(cached, key)
+				}
+			}
+			if len(cached) != len(test.wantCached) {
+				t.Fatalf("cached %v, want %v", cached, test.wantCached)
+			}
+			for i := range cached {
+				if cached[i] != test.wantCached[i] {
+					t.Fatalf("cached %v, want %v", cached, test.wantCached)
+				}
+			}
+		})
+	}
+}
+
+// peerServer records the invalidations a peer receives.
+type peerServer struct {
+	kvpb.UnimplementedCacheInvalidationServer
+
+	mu       sync.Mutex
+	received []*kvpb.InvalidateRequest
+}
+
+func (p *peerServer) Invalidate(ctx context.Context, request *kvpb.InvalidateRequest) (*kvpb.InvalidateResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.received = append(p.received, request)
+	return &kvpb.InvalidateResponse{StatusCode: 200}, nil
+}
+
+func TestPublishDeliversInOrder(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer := &peerServer{}
+	server := grpc.NewServer()
+	kvpb.RegisterCacheInvalidationServer(server, peer)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	bus, err := NewBus("self", []string{listener.Addr().String()}, insecure.NewCredentials(), cacheModule.NewLRUCache(10), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{"a", "b", "c", "d"}
+	for _, key := range keys {
+		bus.Publish(key)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		peer.mu.Lock()
+		received := append([]*kvpb.InvalidateRequest(nil), peer.received...)
+		peer.mu.Unlock()
+		if len(received) == len(keys) {
+			for i, request := range received {
+				if request.Origin != "self" || request.Epoch != bus.epoch || request.Sequence != uint64(i+1) || request.Key != keys[i] {
+					t.Fatalf("message %d is %v, want %s at sequence %d", i, request, keys[i], i+1)
+				}
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("received %d of %d invalidations", len(received), len(keys))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
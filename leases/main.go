@@ -0,0 +1,315 @@
+package leases
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/kv-storage/auth"
+	"github.com/kv-storage/model"
+	kvpb "github.com/kv-storage/proto/kv"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	reapInterval = time.Second
+	lockPoll     = 50 * time.Millisecond
+	maxTTL       = 24 * 60 * 60
+)
+
+// ErrLeaseNotFound is returned when attaching to a lease that expired or never existed.
+var ErrLeaseNotFound = errors.New("lease not found or expired")
+
+// Store is the local key-value data that leases can own.
+type Store interface {
+	// Drop deletes a key whose lease has ended.
+	Drop(key string) error
+}
+
+// Owner is who a call acts for as a lease holder: the authenticated subject,
+// or no one when authentication is off.
+func Owner(ctx context.Context) string {
+	if principal, ok := auth.FromContext(ctx); ok {
+		return principal.Subject
+	}
+	return ""
+}
+
+// Attach checks that a lease is alive and owned by owner, and locks it against
+// revocation for the rest of tx, so a key created in tx cannot outlive its
+// lease. Someone else's lease is reported as not found.
+func Attach(tx *gorm.DB, leaseID uint64, owner string) error {
+	var lease model.Lease
+	err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+		Where("id = ? AND owner = ? AND expires_at > ?", leaseID, owner, time.Now()).
+		Limit(1).Find(&lease).Error
+	if err != nil {
+		return err
+	}
+	if lease.ID == 0 {
+		return ErrLeaseNotFound
+	}
+	return nil
+}
+
+// Manager grants and expires leases and runs the locks built on them.
+type Manager struct {
+	kvpb.UnimplementedLeasesServer
+	kvpb.UnimplementedLocksServer
+
+	db     *gorm.DB
+	store  Store
+	logger *zap.Logger
+	// unavailable, when set, is the reason every call is refused
+	unavailable string
+}
+
+func NewManager(db *gorm.DB, store Store, logger *zap.Logger) *Manager {
+	manager := &Manager{db: db, store: store, logger: logger}
+	go func() {
+		for range time.Tick(reapInterval) {
+			manager.reap()
+		}
+	}()
+	return manager
+}
+
+// Unavailable answers every lease and lock call with 400 and reason, for
+// modes where the nodes do not share the database leases live in.
+func Unavailable(reason string) *Manager {
+	return &Manager{unavailable: reason}
+}
+
+// reap revokes every expired lease, which is what frees a crashed holder's keys and locks.
+func (m *Manager) reap() {
+	var expired []model.Lease
+	if err := m.db.Where("expires_at <= ?", time.Now()).Limit(100).Find(&expired).Error; err != nil {
+		m.logger.Warn("Lease reaper failed", zap.Error(err))
+		return
+	}
+	for _, lease := range expired {
+		if _, err := m.release(lease.ID); err != nil {
+			m.logger.Warn("Failed to release expired lease", zap.Uint64("lease", lease.ID), zap.Error(err))
+			continue
+		}
+		m.logger.Debug("Lease expired", zap.Uint64("lease", lease.ID))
+	}
+}
+
+// release drops everything attached to an already-ended lease, deleting the
+// lease row last so an interrupted release is picked up again by the reaper.
+func (m *Manager) release(leaseID uint64) (int64, error) {
+	var keys []string
+	if err := m.db.Model(&model.KV{}).Where("lease_id = ?", leaseID).Pluck("key_name", &keys).Error; err != nil {
+		return 0, err
+	}
+	for _, key := range keys {
+		if err := m.store.Drop(key); err != nil {
+			return 0, err
+		}
+	}
+	if err := m.db.Where("lease_id = ?", leaseID).Delete(&model.LockWaiter{}).Error; err != nil {
+		return 0, err
+	}
+	return int64(len(keys)), m.db.Delete(&model.Lease{}, leaseID).Error
+}
+
+func (m *Manager) GrantLease(ctx context.Context, request *kvpb.GrantLeaseRequest) (*kvpb.GrantLeaseResponse, error) {
+	if m.unavailable != "" {
+		return &kvpb.GrantLeaseResponse{Message: m.unavailable, StatusCode: 400}, nil
+	}
+	if request.TtlSeconds <= 0 || request.TtlSeconds > maxTTL {
+		return &kvpb.GrantLeaseResponse{
+			Message:    "ttlSeconds must be between 1 and 86400",
+			StatusCode: 400,
+		}, nil
+	}
+	lease := model.Lease{
+		Owner:      Owner(ctx),
+		TTLSeconds: request.TtlSeconds,
+		ExpiresAt:  time.Now().Add(time.Duration(request.TtlSeconds) * time.Second),
+	}
+	if err := m.db.WithContext(ctx).Create(&lease).Error; err != nil {
+		return &kvpb.GrantLeaseResponse{
+			Message:    "Database error",
+			StatusCode: 500,
+		}, nil
+	}
+	return &kvpb.GrantLeaseResponse{
+		Message:    "Lease granted",
+		StatusCode: 201,
+		LeaseId:    lease.ID,
+		TtlSeconds: lease.TTLSeconds,
+	}, nil
+}
+
+// KeepAlive renews a lease for its full TTL on every message received.
+func (m *Manager) KeepAlive(stream grpc.BidiStreamingServer[kvpb.KeepAliveRequest, kvpb.KeepAliveResponse]) error {
+	for {
+		request, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(m.renew(stream.Context(), request.LeaseId)); err != nil {
+			return err
+		}
+	}
+}
+
+func (m *Manager) renew(ctx context.Context, leaseID uint64) *kvpb.KeepAliveResponse {
+	if m.unavailable != "" {
+		return &kvpb.KeepAliveResponse{Message: m.unavailable, StatusCode: 400, LeaseId: leaseID}
+	}
+	var lease model.Lease
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND owner = ? AND expires_at > ?", leaseID, Owner(ctx), time.Now()).
+			Limit(1).Find(&lease).Error
+		if err != nil || lease.ID == 0 {
+			return err
+		}
+		lease.ExpiresAt = time.Now().Add(time.Duration(lease.TTLSeconds) * time.Second)
+		return tx.Model(&lease).Update("expires_at", lease.ExpiresAt).Error
+	})
+	if err != nil {
+		return &kvpb.KeepAliveResponse{Message: "Database error", StatusCode: 500, LeaseId: leaseID}
+	}
+	if lease.ID == 0 {
+		return &kvpb.KeepAliveResponse{Message: ErrLeaseNotFound.Error(), StatusCode: 404, LeaseId: leaseID}
+	}
+	return &kvpb.KeepAliveResponse{
+		Message:    "Lease renewed",
+		StatusCode: 200,
+		LeaseId:    leaseID,
+		TtlSeconds: lease.TTLSeconds,
+	}
+}
+
+func (m *Manager) RevokeLease(ctx context.Context, request *kvpb.RevokeLeaseRequest) (*kvpb.RevokeLeaseResponse, error) {
+	if m.unavailable != "" {
+		return &kvpb.RevokeLeaseResponse{Message: m.unavailable, StatusCode: 400}, nil
+	}
+	// Expire it first so keep-alives and new attachments stop immediately.
+	result := m.db.WithContext(ctx).Model(&model.Lease{}).
+		Where("id = ? AND owner = ? AND expires_at > ?", request.LeaseId, Owner(ctx), time.Now()).
+		Update("expires_at", time.Now())
+	if result.Error != nil {
+		return &kvpb.RevokeLeaseResponse{Message: "Database error", StatusCode: 500}, nil
+	}
+	if result.RowsAffected == 0 {
+		return &kvpb.RevokeLeaseResponse{Message: ErrLeaseNotFound.Error(), StatusCode: 404}, nil
+	}
+	deleted, err := m.release(request.LeaseId)
+	if err != nil {
+		// The reaper finishes the job, the lease is already unusable.
+		m.logger.Warn("Lease revocation incomplete", zap.Uint64("lease", request.LeaseId), zap.Error(err))
+	}
+	return &kvpb.RevokeLeaseResponse{
+		Message:     "Lease revoked",
+		StatusCode:  200,
+		DeletedKeys: deleted,
+	}, nil
+}
+
+// Lock joins the FIFO queue for a name and blocks until it reaches the head.
+// Calling it again with the same lease waits on the same place in the queue.
+// The returned fencing token grows with every acquisition, so storage that
+// remembers the highest token seen can reject writes from a stale holder.
+func (m *Manager) Lock(ctx context.Context, request *kvpb.LockRequest) (*kvpb.LockResponse, error) {
+	if m.unavailable != "" {
+		return &kvpb.LockResponse{Message: m.unavailable, StatusCode: 400}, nil
+	}
+	if request.Name == "" || request.LeaseId == 0 {
+		return &kvpb.LockResponse{
+			Message:    "Lock name and leaseId are required",
+			StatusCode: 400,
+		}, nil
+	}
+	waiter := model.LockWaiter{Name: request.Name, LeaseID: request.LeaseId}
+	var err error
+	// A retried call takes up the lease's existing place in the queue; the
+	// unique index settles two copies of one call racing to create it
+	for attempt := 0; attempt < 2; attempt++ {
+		err = m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := Attach(tx, request.LeaseId, Owner(ctx)); err != nil {
+				return err
+			}
+			err := tx.Where("name = ? AND lease_id = ?", request.Name, request.LeaseId).Limit(1).Find(&waiter).Error
+			if err != nil || waiter.Token != 0 {
+				return err
+			}
+			return tx.Create(&waiter).Error
+		})
+		if err == nil || !strings.Contains(err.Error(), "Duplicate entry") {
+			break
+		}
+	}
+	if err == ErrLeaseNotFound {
+		return &kvpb.LockResponse{Message: err.Error(), StatusCode: 404}, nil
+	}
+	if err != nil {
+		return &kvpb.LockResponse{Message: "Database error", StatusCode: 500}, nil
+	}
+
+	ticker := time.NewTicker(lockPoll)
+	defer ticker.Stop()
+	for {
+		var head model.LockWaiter
+		err := m.db.WithContext(ctx).Where("name = ?", request.Name).Order("token").Limit(1).Find(&head).Error
+		if ctx.Err() != nil {
+			m.abandon(waiter)
+			return &kvpb.LockResponse{Message: "Gave up waiting for lock", StatusCode: 408}, nil
+		}
+		if err != nil {
+			m.abandon(waiter)
+			return &kvpb.LockResponse{Message: "Database error", StatusCode: 500}, nil
+		}
+		if head.Token == waiter.Token {
+			return &kvpb.LockResponse{
+				Message:      "Lock acquired",
+				StatusCode:   200,
+				FencingToken: waiter.Token,
+			}, nil
+		}
+		if head.Token == 0 || head.Token > waiter.Token {
+			// Our own entry is gone: the lease ended while we were queued.
+			return &kvpb.LockResponse{Message: ErrLeaseNotFound.Error(), StatusCode: 404}, nil
+		}
+		select {
+		case <-ctx.Done():
+			m.abandon(waiter)
+			return &kvpb.LockResponse{Message: "Gave up waiting for lock", StatusCode: 408}, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Manager) abandon(waiter model.LockWaiter) {
+	if err := m.db.Delete(&waiter).Error; err != nil {
+		m.logger.Warn("Failed to leave lock queue", zap.String("lock", waiter.Name), zap.Error(err))
+	}
+}
+
+func (m *Manager) Unlock(ctx context.Context, request *kvpb.UnlockRequest) (*kvpb.UnlockResponse, error) {
+	if m.unavailable != "" {
+		return &kvpb.UnlockResponse{Message: m.unavailable, StatusCode: 400}, nil
+	}
+	// Only the lease holding the lock may release it
+	owned := m.db.Model(&model.Lease{}).Select("id").Where("owner = ?", Owner(ctx))
+	result := m.db.WithContext(ctx).Where("name = ? AND token = ? AND lease_id IN (?)", request.Name, request.FencingToken, owned).Delete(&model.LockWaiter{})
+	if result.Error != nil {
+		return &kvpb.UnlockResponse{Message: "Database error", StatusCode: 500}, nil
+	}
+	if result.RowsAffected == 0 {
+		return &kvpb.UnlockResponse{Message: "Lock not held with this token", StatusCode: 404}, nil
+	}
+	return &kvpb.UnlockResponse{Message: "Lock released", StatusCode: 200}, nil
+}
@@ -0,0 +1,296 @@
+package leases
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/kv-storage/auth"
+	"github.com/kv-storage/model"
+	kvpb "github.com/kv-storage/proto/kv"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// store drops keys straight from the database, recording which.
+type store struct {
+	db *gorm.DB
+
+	mu      sync.Mutex
+	dropped []string
+}
+
+func (s *store) Drop(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dropped = append(s.dropped, key)
+	return s.db.Where("key_name = ?", key).Delete(&model.KV{}).Error
+}
+
+func newManager(t *testing.T) (*Manager, *store) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "leases.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.KV{}, &model.Lease{}, &model.LockWaiter{}); err != nil {
+		t.Fatal(err)
+	}
+	s := &store{db: db}
+	return &Manager{db: db, store: s, logger: zap.NewNop()}, s
+}
+
+func grant(t *testing.T, m *Manager, ttlSeconds int64) uint64 {
+	t.Helper()
+	response, err := m.GrantLease(context.Background(), &kvpb.GrantLeaseRequest{TtlSeconds: ttlSeconds})
+	if err != nil || response.StatusCode != 201 {
+		t.Fatalf("GrantLease = %v, %v", response, err)
+	}
+	return response.LeaseId
+}
+
+func lock(t *testing.T, m *Manager, name string, leaseID uint64) *kvpb.LockResponse {
+	t.Helper()
+	response, err := m.Lock(context.Background(), &kvpb.LockRequest{Name: name, LeaseId: leaseID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+// queued waits until n callers are in the queue for name.
+func queued(t *testing.T, m *Manager, name string, n int64) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		var count int64
+		if err := m.db.Model(&model.LockWaiter{}).Where("name = ?", name).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count == n {
+			return
+		}
+	}
+	t.Fatalf("never saw %d callers queued for %s", n, name)
+}
+
+func TestLocksAreFairAndFenced(t *testing.T) {
+	m, _ := newManager(t)
+	first, second, third := grant(t, m, 60), grant(t, m, 60), grant(t, m, 60)
+
+	held := lock(t, m, "jobs/nightly", first)
+	if held.StatusCode != 200 {
+		t.Fatalf("first Lock = %v", held)
+	}
+	if again := lock(t, m, "jobs/nightly", first); again.StatusCode != 200 || again.FencingToken != held.FencingToken {
+		t.Fatalf("retried Lock = %v, want the same token %d", again, held.FencingToken)
+	}
+
+	// Queue the second and third callers, in that order
+	acquired := make(chan *kvpb.LockResponse, 2)
+	for i, leaseID := range []uint64{second, third} {
+		go func() {
+			response, _ := m.Lock(context.Background(), &kvpb.LockRequest{Name: "jobs/nightly", LeaseId: leaseID})
+			acquired <- response
+		}()
+		queued(t, m, "jobs/nightly", int64(i+2))
+	}
+
+	previous := held.FencingToken
+	for range 2 {
+		if response, err := m.Unlock(context.Background(), &kvpb.UnlockRequest{Name: "jobs/nightly", FencingToken: previous}); err != nil || response.StatusCode != 200 {
+			t.Fatalf("Unlock = %v, %v", response, err)
+		}
+		next := <-acquired
+		if next.StatusCode != 200 || next.FencingToken <= previous {
+			t.Fatalf("next holder got %v, want a token above %d", next, previous)
+		}
+		previous = next.FencingToken
+	}
+
+	if response, _ := m.Unlock(context.Background(), &kvpb.UnlockRequest{Name: "jobs/nightly", FencingToken: held.FencingToken}); response.StatusCode != 404 {
+		t.Fatalf("a stale holder's Unlock = %v, want 404", response)
+	}
+}
+
+func TestLockWithoutALiveLease(t *testing.T) {
+	m, _ := newManager(t)
+	tests := []struct {
+		name    string
+		request *kvpb.LockRequest
+		want    int64
+	}{
+		{"no name", &kvpb.LockRequest{LeaseId: 1}, 400},
+		{"no lease", &kvpb.LockRequest{Name: "jobs/nightly"}, 400},
+		{"an unknown lease", &kvpb.LockRequest{Name: "jobs/nightly", LeaseId: 99}, 404},
+	}
+	for _, test := range tests {
+		if response, err := m.Lock(context.Background(), test.request); err != nil || response.StatusCode != test.want {
+			t.Errorf("%s: Lock = %v, %v, want %d", test.name, response, err, test.want)
+		}
+	}
+}
+
+func TestGivingUpLeavesTheQueue(t *testing.T) {
+	m, _ := newManager(t)
+	holder, waiter := grant(t, m, 60), grant(t, m, 60)
+	lock(t, m, "jobs/nightly", holder)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	response, err := m.Lock(ctx, &kvpb.LockRequest{Name: "jobs/nightly", LeaseId: waiter})
+	if err != nil || response.StatusCode != 408 {
+		t.Fatalf("Lock = %v, %v, want 408", response, err)
+	}
+	queued(t, m, "jobs/nightly", 1)
+}
+
+func TestRevokingALeaseFreesItsKeysAndLocks(t *testing.T) {
+	m, s := newManager(t)
+	revoked, waiting := grant(t, m, 60), grant(t, m, 60)
+	for _, kv := range []model.KV{{Key: "session/a", LeaseID: &revoked}, {Key: "session/b", LeaseID: &waiting}, {Key: "kept"}} {
+		if err := m.db.Create(&kv).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	lock(t, m, "jobs/nightly", revoked)
+	acquired := make(chan *kvpb.LockResponse, 1)
+	go func() {
+		response, _ := m.Lock(context.Background(), &kvpb.LockRequest{Name: "jobs/nightly", LeaseId: waiting})
+		acquired <- response
+	}()
+	queued(t, m, "jobs/nightly", 2)
+
+	response, err := m.RevokeLease(context.Background(), &kvpb.RevokeLeaseRequest{LeaseId: revoked})
+	if err != nil || response.StatusCode != 200 || response.DeletedKeys != 1 {
+		t.Fatalf("RevokeLease = %v, %v, want one key deleted", response, err)
+	}
+	if len(s.dropped) != 1 || s.dropped[0] != "session/a" {
+		t.Fatalf("dropped %v, want session/a", s.dropped)
+	}
+	if next := <-acquired; next.StatusCode != 200 {
+		t.Fatalf("the waiter got %v once the holder's lease was revoked", next)
+	}
+	if err := Attach(m.db, revoked, ""); err != ErrLeaseNotFound {
+		t.Fatalf("attaching to a revoked lease: %v", err)
+	}
+	if response, _ := m.RevokeLease(context.Background(), &kvpb.RevokeLeaseRequest{LeaseId: revoked}); response.StatusCode != 404 {
+		t.Fatalf("revoking twice = %v, want 404", response)
+	}
+}
+
+func TestLeasesBelongToTheirGranter(t *testing.T) {
+	m, _ := newManager(t)
+	alice := auth.NewContext(context.Background(), &auth.Principal{Subject: "alice"})
+	bob := auth.NewContext(context.Background(), &auth.Principal{Subject: "bob"})
+	granted, err := m.GrantLease(alice, &kvpb.GrantLeaseRequest{TtlSeconds: 60})
+	if err != nil || granted.StatusCode != 201 {
+		t.Fatalf("GrantLease = %v, %v", granted, err)
+	}
+	leaseID := granted.LeaseId
+
+	if response := m.renew(bob, leaseID); response.StatusCode != 404 {
+		t.Errorf("renewing someone else's lease = %v, want 404", response)
+	}
+	if response, _ := m.Lock(bob, &kvpb.LockRequest{Name: "jobs/nightly", LeaseId: leaseID}); response.StatusCode != 404 {
+		t.Errorf("locking with someone else's lease = %v, want 404", response)
+	}
+	if err := Attach(m.db, leaseID, "bob"); err != ErrLeaseNotFound {
+		t.Errorf("attaching to someone else's lease: %v, want ErrLeaseNotFound", err)
+	}
+	held, _ := m.Lock(alice, &kvpb.LockRequest{Name: "jobs/nightly", LeaseId: leaseID})
+	if held.StatusCode != 200 {
+		t.Fatalf("the owner's Lock = %v", held)
+	}
+	if response, _ := m.Unlock(bob, &kvpb.UnlockRequest{Name: "jobs/nightly", FencingToken: held.FencingToken}); response.StatusCode != 404 {
+		t.Errorf("unlocking someone else's lock = %v, want 404", response)
+	}
+	if response, _ := m.RevokeLease(bob, &kvpb.RevokeLeaseRequest{LeaseId: leaseID}); response.StatusCode != 404 {
+		t.Errorf("revoking someone else's lease = %v, want 404", response)
+	}
+	if response := m.renew(alice, leaseID); response.StatusCode != 200 {
+		t.Errorf("the owner's renew = %v, want 200", response)
+	}
+	if response, _ := m.RevokeLease(alice, &kvpb.RevokeLeaseRequest{LeaseId: leaseID}); response.StatusCode != 200 {
+		t.Errorf("the owner's RevokeLease = %v, want 200", response)
+	}
+}
+
+func TestReaperReleasesExpiredLeases(t *testing.T) {
+	m, s := newManager(t)
+	expired := grant(t, m, 60)
+	if err := m.db.Create(&model.KV{Key: "session/a", LeaseID: &expired}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := m.db.Model(&model.Lease{}).Where("id = ?", expired).Update("expires_at", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if response := m.renew(context.Background(), expired); response.StatusCode != 404 {
+		t.Fatalf("renewing an expired lease = %v, want 404", response)
+	}
+	m.reap()
+	if len(s.dropped) != 1 {
+		t.Fatalf("dropped %v, want the expired lease's key", s.dropped)
+	}
+	var leases int64
+	if err := m.db.Model(&model.Lease{}).Count(&leases).Error; err != nil || leases != 0 {
+		t.Fatalf("%d leases left, %v, want none", leases, err)
+	}
+}
+
+func TestRenewExtendsALease(t *testing.T) {
+	m, _ := newManager(t)
+	leaseID := grant(t, m, 60)
+	if err := m.db.Model(&model.Lease{}).Where("id = ?", leaseID).Update("expires_at", time.Now().Add(time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if response := m.renew(context.Background(), leaseID); response.StatusCode != 200 || response.TtlSeconds != 60 {
+		t.Fatalf("renew = %v", response)
+	}
+	var lease model.Lease
+	if err := m.db.First(&lease, leaseID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if time.Until(lease.ExpiresAt) < 50*time.Second {
+		t.Fatalf("lease expires in %v, want its full TTL", time.Until(lease.ExpiresAt))
+	}
+}
+
+func TestGrantLease(t *testing.T) {
+	m, _ := newManager(t)
+	tests := []struct {
+		ttlSeconds int64
+		want       int64
+	}{
+		{1, 201},
+		{maxTTL, 201},
+		{0, 400},
+		{-1, 400},
+		{maxTTL + 1, 400},
+	}
+	for _, test := range tests {
+		if response, err := m.GrantLease(context.Background(), &kvpb.GrantLeaseRequest{TtlSeconds: test.ttlSeconds}); err != nil || response.StatusCode != test.want {
+			t.Errorf("GrantLease(%d) = %v, %v, want %d", test.ttlSeconds, response, err, test.want)
+		}
+	}
+}
+
+func TestUnavailable(t *testing.T) {
+	m := Unavailable("not here")
+	ctx := context.Background()
+	grantResponse, _ := m.GrantLease(ctx, &kvpb.GrantLeaseRequest{TtlSeconds: 10})
+	lockResponse, _ := m.Lock(ctx, &kvpb.LockRequest{Name: "n", LeaseId: 1})
+	revokeResponse, _ := m.RevokeLease(ctx, &kvpb.RevokeLeaseRequest{LeaseId: 1})
+	unlockResponse, _ := m.Unlock(ctx, &kvpb.UnlockRequest{Name: "n", FencingToken: 1})
+	renewResponse := m.renew(ctx, 1)
+	for _, response := range []interface {
+		GetStatusCode() int64
+		GetMessage() string
+	}{grantResponse, lockResponse, revokeResponse, unlockResponse, renewResponse} {
+		if response.GetStatusCode() != 400 || response.GetMessage() != "not here" {
+			t.Errorf("got %d %q, want 400 with the reason", response.GetStatusCode(), response.GetMessage())
+		}
+	}
+}
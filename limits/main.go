@@ -0,0 +1,560 @@
+package limits
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kv-storage/auth"
+	"github.com/kv-storage/model"
+	kvpb "github.com/kv-storage/proto/kv"
+	"github.com/kv-storage/rbac"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"gorm.io/gorm"
+)
+
+const (
+	// RetryAfterHeader tells a throttled client how many seconds to wait; the
+	// gateway sends it as HTTP Retry-After
+	RetryAfterHeader = "retry-after"
+
+	// evictInterval is how often clients whose buckets have refilled are forgotten
+	evictInterval = time.Minute
+	// remeasureAfter is how stale a measurement may be before a write it would reject is re-checked
+	remeasureAfter = time.Second
+)
+
+// Client identifies who a call counts against: the authenticated subject,
+// or else the address it came from.
+func Client(ctx context.Context) (identity string, roles []string) {
+	if principal, ok := auth.FromContext(ctx); ok {
+		return principal.Subject, principal.Roles
+	}
+	return auth.Address(ctx), nil
+}
+
+// Proxied reports whether this node hands a call, or one message of a
+// stream, on to another node to serve. That node charges it instead, so
+// nothing counts twice.
+type Proxied func(ctx context.Context, method string, message any) bool
+
+// RateLimiter gives every client a token bucket for reads and another for
+// writes, deletes and locks. Admin calls are never limited, since nodes make
+// them to keep the cluster running.
+type RateLimiter struct {
+	readRate, writeRate   rate.Limit
+	readBurst, writeBurst int
+	exempt                []string
+	proxied               Proxied
+
+	mu      sync.Mutex
+	clients map[string]*buckets
+}
+
+type buckets struct {
+	read, write *rate.Limiter
+}
+
+// NewRateLimiter allows each client perSecond calls of each kind, in bursts
+// of up to burst; a rate of 0 leaves that kind unlimited, and a burst of 0
+// defaults to one second's worth. A client is exempt when its subject,
+// address or any of its roles is listed in exempt. Calls proxied to another
+// node are left to that node.
+func NewRateLimiter(readsPerSecond float64, readBurst int, writesPerSecond float64, writeBurst int, exempt []string, proxied Proxied) *RateLimiter {
+	l := &RateLimiter{
+		readRate:   limit(readsPerSecond),
+		readBurst:  burst(readsPerSecond, readBurst),
+		writeRate:  limit(writesPerSecond),
+		writeBurst: burst(writesPerSecond, writeBurst),
+		exempt:     exempt,
+		proxied:    proxied,
+		clients:    make(map[string]*buckets),
+	}
+	go func() {
+		for range time.Tick(evictInterval) {
+			l.evict()
+		}
+	}()
+	return l
+}
+
+func limit(perSecond float64) rate.Limit {
+	if perSecond <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(perSecond)
+}
+
+func burst(perSecond float64, burst int) int {
+	if burst > 0 {
+		return burst
+	}
+	return max(1, int(math.Ceil(perSecond)))
+}
+
+func (l *RateLimiter) bucketsFor(client string) *buckets {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.clients[client]
+	if !ok {
+		b = &buckets{read: rate.NewLimiter(l.readRate, l.readBurst), write: rate.NewLimiter(l.writeRate, l.writeBurst)}
+		l.clients[client] = b
+	}
+	return b
+}
+
+// evict forgets clients whose buckets are full again, which loses nothing:
+// a new pair of buckets starts out full too.
+func (l *RateLimiter) evict() {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for client, b := range l.clients {
+		if b.read.TokensAt(now) >= float64(l.readBurst) && b.write.TokensAt(now) >= float64(l.writeBurst) {
+			delete(l.clients, client)
+		}
+	}
+}
+
+// bucket is the bucket a call of method by the caller takes a token from, and
+// its kind, or nil when such calls are not limited.
+func (l *RateLimiter) bucket(ctx context.Context, method string) (*rate.Limiter, string, string) {
+	operation, _, ok := rbac.Classify(method, nil)
+	if !ok || operation == rbac.OpAdmin {
+		return nil, "", ""
+	}
+	client, roles := Client(ctx)
+	if slices.Contains(l.exempt, client) || slices.ContainsFunc(roles, func(role string) bool { return slices.Contains(l.exempt, role) }) {
+		return nil, "", ""
+	}
+	b := l.bucketsFor(client)
+	if operation == rbac.OpRead {
+		return b.read, "read", client
+	}
+	return b.write, "write", client
+}
+
+// allow takes a token for the call, or returns ResourceExhausted with how
+// long until one is available.
+func (l *RateLimiter) allow(ctx context.Context, method string, setHeader func(metadata.MD) error) error {
+	bucket, kind, client := l.bucket(ctx, method)
+	if bucket == nil {
+		return nil
+	}
+	now := time.Now()
+	reservation := bucket.ReserveN(now, 1)
+	wait := reservation.DelayFrom(now)
+	if wait == 0 {
+		return nil
+	}
+	reservation.CancelAt(now)
+	seconds := int(math.Ceil(wait.Seconds()))
+	setHeader(metadata.Pairs(RetryAfterHeader, strconv.Itoa(seconds)))
+	st, err := status.New(codes.ResourceExhausted, fmt.Sprintf("%s rate limit exceeded for %s, retry in %s", kind, client, wait.Round(time.Millisecond))).
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)})
+	if err != nil {
+		return status.Errorf(codes.ResourceExhausted, "%s rate limit exceeded for %s", kind, client)
+	}
+	return st.Err()
+}
+
+func (l *RateLimiter) UnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if l.proxied(ctx, info.FullMethod, req) {
+		return handler(ctx, req)
+	}
+	setHeader := func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }
+	if err := l.allow(ctx, info.FullMethod, setHeader); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// wait takes a token for one message of a stream, holding the message back
+// until the token is due, so a client sending too fast is slowed to its rate
+// rather than cut off part-way.
+func (l *RateLimiter) wait(ctx context.Context, method string) error {
+	bucket, _, _ := l.bucket(ctx, method)
+	if bucket == nil {
+		return nil
+	}
+	reservation := bucket.Reserve()
+	delay := reservation.Delay()
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		reservation.Cancel()
+		return status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// StreamInterceptor takes a token for every message the client sends, so a
+// BulkSet costs one write per record.
+func (l *RateLimiter) StreamInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &limitedStream{ServerStream: stream, check: func(message any) error {
+		if l.proxied(stream.Context(), info.FullMethod, message) {
+			return nil
+		}
+		return l.wait(stream.Context(), info.FullMethod)
+	}})
+}
+
+type limitedStream struct {
+	grpc.ServerStream
+	check func(message any) error
+}
+
+func (s *limitedStream) RecvMsg(message any) error {
+	if err := s.ServerStream.RecvMsg(message); err != nil {
+		return err
+	}
+	return s.check(message)
+}
+
+// growing are the calls that can add keys or bytes, and so count against a quota.
+var growing = map[string]bool{
+	kvpb.KeyValueStore_SetKeyValue_FullMethodName:  true,
+	kvpb.KeyValueStore_BulkSet_FullMethodName:      true,
+	kvpb.KeyValueStore_Increment_FullMethodName:    true,
+	kvpb.KeyValueStore_Decrement_FullMethodName:    true,
+	kvpb.KeyValueStore_HashSet_FullMethodName:      true,
+	kvpb.KeyValueStore_ListPush_FullMethodName:     true,
+	kvpb.KeyValueStore_SetAdd_FullMethodName:       true,
+	kvpb.KeyValueStore_SortedSetAdd_FullMethodName: true,
+}
+
+// Limit caps a namespace's keys and bytes, counting each key's name and
+// value and the members of its collections; 0 leaves either unlimited.
+type Limit struct {
+	MaxKeys  int64 `json:"maxKeys"`
+	MaxBytes int64 `json:"maxBytes"`
+}
+
+// QuotaConfig is the quota file, as in
+//
+//	{"separator": "/",
+//	 "default": {"maxKeys": 100000, "maxBytes": 104857600},
+//	 "namespaces": {"billing": {"maxKeys": 1000000, "maxBytes": 1073741824}}}
+//
+// A key's namespace is its name up to the first separator; keys without one
+// share the "" namespace. Namespaces not listed get the default.
+type QuotaConfig struct {
+	Separator  string           `json:"separator"`
+	Default    Limit            `json:"default"`
+	Namespaces map[string]Limit `json:"namespaces"`
+}
+
+func LoadQuotaConfig(file string) (*QuotaConfig, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	config := QuotaConfig{Separator: "/"}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if config.Separator == "" {
+		return nil, fmt.Errorf("%s: separator must not be empty", file)
+	}
+	return &config, nil
+}
+
+// Quota rejects writes that would take a namespace over its limit. Usage is
+// measured from the database at most once per refresh interval and kept up
+// to date in between by what each write and delete changed; a write the
+// running total would reject is re-checked against a fresh measurement
+// first. In sharded mode each node enforces the quota on the keys it holds.
+type Quota struct {
+	db      *gorm.DB
+	config  *QuotaConfig
+	refresh time.Duration
+	proxied Proxied
+	logger  *zap.Logger
+
+	mu    sync.Mutex
+	usage map[string]*usage
+}
+
+type usage struct {
+	mu       sync.Mutex
+	keys     int64
+	bytes    int64
+	measured time.Time
+}
+
+// NewQuota leaves calls proxied to another node to that node.
+func NewQuota(db *gorm.DB, config *QuotaConfig, refresh time.Duration, proxied Proxied, logger *zap.Logger) *Quota {
+	return &Quota{db: db, config: config, refresh: refresh, proxied: proxied, logger: logger.Named("quota"), usage: make(map[string]*usage)}
+}
+
+func (q *Quota) namespace(key string) string {
+	namespace, _, found := strings.Cut(key, q.config.Separator)
+	if !found {
+		return ""
+	}
+	return namespace
+}
+
+func (q *Quota) limitFor(namespace string) Limit {
+	if limit, ok := q.config.Namespaces[namespace]; ok {
+		return limit
+	}
+	return q.config.Default
+}
+
+func (q *Quota) usageOf(namespace string) *usage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	u, ok := q.usage[namespace]
+	if !ok {
+		u = &usage{}
+		q.usage[namespace] = u
+	}
+	return u
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// inNamespace is the condition on kvs.key_name selecting a namespace's keys.
+func (q *Quota) inNamespace(namespace string) (string, string) {
+	separator := likeEscaper.Replace(q.config.Separator)
+	if namespace == "" {
+		return "kvs.key_name NOT LIKE ?", "%" + separator + "%"
+	}
+	return "kvs.key_name LIKE ?", likeEscaper.Replace(namespace) + separator + "%"
+}
+
+// memberTable is where a collection type keeps its members, and what each counts for.
+type memberTable struct {
+	model any
+	table string
+	size  string
+}
+
+var memberTables = map[string]memberTable{
+	model.TypeHash:      {&model.HashField{}, "hash_fields", "LENGTH(hash_fields.field) + LENGTH(hash_fields.value)"},
+	model.TypeList:      {&model.ListItem{}, "list_items", "LENGTH(list_items.value)"},
+	model.TypeSet:       {&model.SetMember{}, "set_members", "LENGTH(set_members.member)"},
+	model.TypeSortedSet: {&model.SortedSetMember{}, "sorted_set_members", "LENGTH(sorted_set_members.member) + 8"},
+}
+
+// measure counts a namespace's keys and bytes, collection members included.
+func (q *Quota) measure(namespace string, u *usage) error {
+	condition, pattern := q.inNamespace(namespace)
+	var keys, bytes int64
+	row := q.db.Model(&model.KV{}).Where(condition, pattern).
+		Select("COUNT(*), COALESCE(SUM(LENGTH(kvs.key_name) + LENGTH(kvs.value)), 0)").Row()
+	if err := row.Scan(&keys, &bytes); err != nil {
+		return err
+	}
+	for _, m := range memberTables {
+		var memberBytes int64
+		row := q.db.Model(m.model).Joins("JOIN kvs ON kvs.id = "+m.table+".kv_id").Where(condition, pattern).
+			Select("COALESCE(SUM(" + m.size + "), 0)").Row()
+		if err := row.Scan(&memberBytes); err != nil {
+			return err
+		}
+		bytes += memberBytes
+	}
+	u.keys, u.bytes, u.measured = keys, bytes, time.Now()
+	return nil
+}
+
+// footprint is what one key counts for, as measure counts it.
+type footprint struct {
+	keys, bytes int64
+}
+
+func (q *Quota) footprintOf(key string) (footprint, error) {
+	var kv struct {
+		ID    uint
+		Type  string
+		Bytes int64
+	}
+	err := q.db.Model(&model.KV{}).Where("key_name = ?", key).
+		Select("id, type, LENGTH(key_name) + LENGTH(value) AS bytes").Limit(1).Scan(&kv).Error
+	if err != nil || kv.ID == 0 {
+		return footprint{}, err
+	}
+	f := footprint{keys: 1, bytes: kv.Bytes}
+	if m, ok := memberTables[kv.Type]; ok {
+		var memberBytes int64
+		row := q.db.Model(m.model).Where(m.table+".kv_id = ?", kv.ID).Select("COALESCE(SUM(" + m.size + "), 0)").Row()
+		if err := row.Scan(&memberBytes); err != nil {
+			return footprint{}, err
+		}
+		f.bytes += memberBytes
+	}
+	return f, nil
+}
+
+// charge is a write or delete counted against its namespace before it runs:
+// the key's footprint beforehand, and the change the write was estimated to
+// make, until settle replaces it with the change it made.
+type charge struct {
+	usage  *usage
+	key    string
+	before footprint
+	change footprint
+}
+
+// admit counts a write or delete against its namespace's quota, rejecting a
+// write that would take the namespace over it. A database error lets the
+// call through uncounted rather than failing it for want of a measurement.
+func (q *Quota) admit(method string, message any) (*charge, error) {
+	request, ok := message.(proto.Message)
+	if !ok {
+		return nil, nil
+	}
+	operation, key, _ := rbac.Classify(method, request)
+	if key == "" || !strings.HasPrefix(method, "/"+kvpb.KeyValueStore_ServiceDesc.ServiceName+"/") ||
+		(operation != rbac.OpWrite && operation != rbac.OpDelete) {
+		return nil, nil
+	}
+	namespace := q.namespace(key)
+	limit := q.limitFor(namespace)
+	if limit.MaxKeys <= 0 && limit.MaxBytes <= 0 {
+		return nil, nil
+	}
+	before, err := q.footprintOf(key)
+	if err != nil {
+		q.logger.Warn("Cannot measure key", zap.String("key", key), zap.Error(err))
+		return nil, nil
+	}
+	c := &charge{usage: q.usageOf(namespace), key: key, before: before}
+	if growing[method] {
+		c.change = estimate(key, request, before)
+	}
+
+	u := c.usage
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if time.Since(u.measured) > q.refresh {
+		if err := q.measure(namespace, u); err != nil {
+			q.logger.Warn("Cannot measure namespace usage", zap.String("namespace", namespace), zap.Error(err))
+			return nil, nil
+		}
+	}
+	violation := q.violation(limit, u, c.change)
+	if violation != "" && time.Since(u.measured) > remeasureAfter {
+		if err := q.measure(namespace, u); err != nil {
+			q.logger.Warn("Cannot measure namespace usage", zap.String("namespace", namespace), zap.Error(err))
+			return nil, nil
+		}
+		violation = q.violation(limit, u, c.change)
+	}
+	if violation != "" {
+		description := fmt.Sprintf("namespace %q is at its quota of %d keys", namespace, limit.MaxKeys)
+		if violation == "bytes" {
+			description = fmt.Sprintf("namespace %q is at its quota of %d bytes", namespace, limit.MaxBytes)
+		}
+		st, err := status.New(codes.ResourceExhausted, description).WithDetails(&errdetails.QuotaFailure{
+			Violations: []*errdetails.QuotaFailure_Violation{{Subject: "namespace:" + namespace, Description: description}},
+		})
+		if err != nil {
+			return nil, status.Error(codes.ResourceExhausted, description)
+		}
+		return nil, st.Err()
+	}
+	u.keys += c.change.keys
+	u.bytes += c.change.bytes
+	return c, nil
+}
+
+// estimate is how much a write to a key with footprint before may change it.
+// A string write replaces the key outright; a collection write is taken to
+// add everything it carries, which is the most it can add.
+func estimate(key string, request proto.Message, before footprint) footprint {
+	after := footprint{keys: 1, bytes: before.bytes}
+	switch r := request.(type) {
+	case *kvpb.SetKeyValueRequest:
+		after.bytes = int64(len(key) + len(r.Value))
+	case *kvpb.BulkSetRequest:
+		after.bytes = int64(len(key) + len(r.Value))
+	default:
+		if before.keys == 0 {
+			after.bytes = int64(len(key))
+		}
+		after.bytes += int64(proto.Size(request) - len(key))
+	}
+	return footprint{keys: after.keys - before.keys, bytes: after.bytes - before.bytes}
+}
+
+// settle swaps a call's estimated change for the change it actually made,
+// found by measuring the key again once the call is done.
+func (q *Quota) settle(c *charge) {
+	if c == nil {
+		return
+	}
+	after, err := q.footprintOf(c.key)
+	if err != nil {
+		// The estimate stands until the next measurement
+		q.logger.Warn("Cannot measure key", zap.String("key", c.key), zap.Error(err))
+		return
+	}
+	c.usage.mu.Lock()
+	defer c.usage.mu.Unlock()
+	c.usage.keys += after.keys - c.before.keys - c.change.keys
+	c.usage.bytes += after.bytes - c.before.bytes - c.change.bytes
+}
+
+// violation names the limit a change would break, if any. Changes that do
+// not grow a namespace never break one.
+func (q *Quota) violation(limit Limit, u *usage, change footprint) string {
+	if limit.MaxKeys > 0 && change.keys > 0 && u.keys+change.keys > limit.MaxKeys {
+		return "keys"
+	}
+	if limit.MaxBytes > 0 && change.bytes > 0 && u.bytes+change.bytes > limit.MaxBytes {
+		return "bytes"
+	}
+	return ""
+}
+
+func (q *Quota) UnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if q.proxied(ctx, info.FullMethod, req) {
+		return handler(ctx, req)
+	}
+	c, err := q.admit(info.FullMethod, req)
+	if err != nil {
+		return nil, err
+	}
+	response, err := handler(ctx, req)
+	q.settle(c)
+	return response, err
+}
+
+// StreamInterceptor holds each record of a BulkSet to its own namespace's
+// quota. A record over quota comes back from Recv as a RecordError, failing
+// that record alone. Records are written in batches the interceptor cannot
+// see, so their estimates stand until the next measurement.
+func (q *Quota) StreamInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &limitedStream{ServerStream: stream, check: func(message any) error {
+		if q.proxied(stream.Context(), info.FullMethod, message) {
+			return nil
+		}
+		if _, err := q.admit(info.FullMethod, message); err != nil {
+			return &rbac.RecordError{Message: message, Err: err}
+		}
+		return nil
+	}})
+}
@@ -0,0 +1,303 @@
+package limits
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/kv-storage/auth"
+	"github.com/kv-storage/model"
+	kvpb "github.com/kv-storage/proto/kv"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+)
+
+func never(ctx context.Context, method string, message any) bool { return false }
+
+func as(subject string, roles ...string) context.Context {
+	return auth.NewContext(context.Background(), &auth.Principal{Subject: subject, Roles: roles})
+}
+
+func TestRateLimiter(t *testing.T) {
+	get, set := kvpb.KeyValueStore_GetKeyValue_FullMethodName, kvpb.KeyValueStore_SetKeyValue_FullMethodName
+	type call struct {
+		ctx    context.Context
+		method string
+		want   codes.Code
+	}
+	tests := []struct {
+		name  string
+		calls []call
+	}{
+		{"a burst is allowed, then throttled", []call{
+			{as("a"), set, codes.OK}, {as("a"), set, codes.OK}, {as("a"), set, codes.ResourceExhausted},
+		}},
+		{"reads and writes have buckets of their own", []call{
+			{as("a"), set, codes.OK}, {as("a"), set, codes.OK}, {as("a"), get, codes.OK}, {as("a"), get, codes.OK}, {as("a"), get, codes.ResourceExhausted},
+		}},
+		{"every client has its own buckets", []call{
+			{as("a"), set, codes.OK}, {as("a"), set, codes.OK}, {as("b"), set, codes.OK}, {as("a"), set, codes.ResourceExhausted},
+		}},
+		{"an exempt role is never limited", []call{
+			{as("a", "batch"), set, codes.OK}, {as("a", "batch"), set, codes.OK}, {as("a", "batch"), set, codes.OK},
+		}},
+		{"admin calls are never limited", []call{
+			{as("a"), kvpb.ShardRing_GetRing_FullMethodName, codes.OK}, {as("a"), kvpb.ShardRing_GetRing_FullMethodName, codes.OK},
+			{as("a"), kvpb.ShardRing_GetRing_FullMethodName, codes.OK},
+		}},
+		{"unclassified calls are not limited here", []call{
+			{as("a"), "/kv.KeyValueStore/Unknown", codes.OK}, {as("a"), "/kv.KeyValueStore/Unknown", codes.OK},
+			{as("a"), "/kv.KeyValueStore/Unknown", codes.OK},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := NewRateLimiter(1, 2, 1, 2, []string{"batch"}, never)
+			for i, c := range test.calls {
+				var header metadata.MD
+				err := l.allow(c.ctx, c.method, func(md metadata.MD) error { header = md; return nil })
+				if status.Code(err) != c.want {
+					t.Fatalf("call %d: got %v, want %v", i, err, c.want)
+				}
+				if c.want == codes.ResourceExhausted && header.Get(RetryAfterHeader)[0] != "1" {
+					t.Fatalf("call %d: %s %v, want 1 second", i, RetryAfterHeader, header.Get(RetryAfterHeader))
+				}
+			}
+		})
+	}
+}
+
+func TestBurst(t *testing.T) {
+	tests := []struct {
+		perSecond float64
+		burst     int
+		want      int
+	}{
+		{10, 0, 10},
+		{0.5, 0, 1},
+		{2.5, 0, 3},
+		{10, 4, 4},
+	}
+	for _, test := range tests {
+		if got := burst(test.perSecond, test.burst); got != test.want {
+			t.Errorf("burst(%v, %d) = %d, want %d", test.perSecond, test.burst, got, test.want)
+		}
+	}
+}
+
+func TestStreamsAreSlowedRatherThanRejected(t *testing.T) {
+	l := NewRateLimiter(0, 0, 50, 1, nil, never)
+	ctx := as("a")
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.wait(ctx, kvpb.KeyValueStore_BulkSet_FullMethodName); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// One record at once, then one every 20ms
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("three records took %v, want them paced to 50 a second", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := l.wait(cancelled, kvpb.KeyValueStore_BulkSet_FullMethodName); status.Code(err) != codes.Canceled {
+		t.Fatalf("got %v, want Canceled once the stream ends", err)
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	hashSet := &kvpb.HashSetRequest{Key: "k", Fields: map[string]string{"field": "value"}}
+	tests := []struct {
+		name    string
+		request proto.Message
+		before  footprint
+		want    footprint
+	}{
+		{"a new string", &kvpb.SetKeyValueRequest{Key: "k", Value: "value"}, footprint{}, footprint{1, 6}},
+		{"a longer string", &kvpb.SetKeyValueRequest{Key: "k", Value: "value"}, footprint{1, 3}, footprint{0, 3}},
+		{"a shorter string", &kvpb.SetKeyValueRequest{Key: "k", Value: "v"}, footprint{1, 6}, footprint{0, -4}},
+		{"a bulk record", &kvpb.BulkSetRequest{Key: "k", Value: "value"}, footprint{}, footprint{1, 6}},
+		{"a new collection", hashSet, footprint{}, footprint{1, int64(proto.Size(hashSet))}},
+		{"an existing collection", hashSet, footprint{1, 40}, footprint{0, int64(proto.Size(hashSet) - 1)}},
+	}
+	for _, test := range tests {
+		if got := estimate("k", test.request, test.before); got != test.want {
+			t.Errorf("%s: estimate = %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestViolation(t *testing.T) {
+	q := &Quota{}
+	limit := Limit{MaxKeys: 10, MaxBytes: 100}
+	tests := []struct {
+		name        string
+		keys, bytes int64
+		change      footprint
+		want        string
+	}{
+		{"room for both", 5, 50, footprint{1, 10}, ""},
+		{"exactly full", 9, 90, footprint{1, 10}, ""},
+		{"one key too many", 10, 50, footprint{1, 10}, "keys"},
+		{"one byte too many", 5, 91, footprint{1, 10}, "bytes"},
+		{"an overwrite adds no key", 10, 50, footprint{0, 10}, ""},
+		{"shrinking a namespace over its quota", 12, 200, footprint{0, -10}, ""},
+	}
+	for _, test := range tests {
+		if got := q.violation(limit, &usage{keys: test.keys, bytes: test.bytes}, test.change); got != test.want {
+			t.Errorf("%s: violation = %q, want %q", test.name, got, test.want)
+		}
+	}
+	if got := q.violation(Limit{}, &usage{keys: 1 << 40}, footprint{1, 1 << 40}); got != "" {
+		t.Errorf("an unlimited namespace broke its %s limit", got)
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	tests := []struct {
+		separator string
+		key       string
+		want      string
+	}{
+		{"/", "billing/invoices/1", "billing"},
+		{"/", "billing", ""},
+		{"/", "/leading", ""},
+		{"::", "billing::1", "billing"},
+		{"::", "billing:1", ""},
+	}
+	for _, test := range tests {
+		q := &Quota{config: &QuotaConfig{Separator: test.separator}}
+		if got := q.namespace(test.key); got != test.want {
+			t.Errorf("namespace(%q) with %q = %q, want %q", test.key, test.separator, got, test.want)
+		}
+	}
+}
+
+func TestLoadQuotaConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        string
+		wantSeparator string
+		wantErr       bool
+	}{
+		{"the separator defaults to a slash", `{"default": {"maxKeys": 10}}`, "/", false},
+		{"a separator of its own", `{"separator": ":"}`, ":", false},
+		{"an empty separator", `{"separator": ""}`, "", true},
+		{"not JSON", `separator: /`, "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "quotas.json")
+			if err := os.WriteFile(file, []byte(test.config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			config, err := LoadQuotaConfig(file)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got %v, want an error %v", err, test.wantErr)
+			}
+			if err == nil && config.Separator != test.wantSeparator {
+				t.Fatalf("separator %q, want %q", config.Separator, test.wantSeparator)
+			}
+		})
+	}
+}
+
+func newQuota(t *testing.T, limit Limit) *Quota {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "quota.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.KV{}, &model.HashField{}, &model.ListItem{}, &model.SetMember{}, &model.SortedSetMember{}); err != nil {
+		t.Fatal(err)
+	}
+	config := &QuotaConfig{Separator: "/", Namespaces: map[string]Limit{"billing": limit}}
+	return NewQuota(db, config, time.Hour, never, zap.NewNop())
+}
+
+// call admits a write or delete, applies it as the store would, and settles.
+func call(t *testing.T, q *Quota, method string, request proto.Message, apply func(db *gorm.DB)) error {
+	t.Helper()
+	c, err := q.admit(method, request)
+	if err != nil {
+		return err
+	}
+	apply(q.db)
+	q.settle(c)
+	return nil
+}
+
+func set(key, value string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		db.Where("key_name = ?", key).Assign(model.KV{Value: value}).FirstOrCreate(&model.KV{Key: key})
+	}
+}
+
+func TestQuota(t *testing.T) {
+	setMethod, deleteMethod := kvpb.KeyValueStore_SetKeyValue_FullMethodName, kvpb.KeyValueStore_DeleteKeyValue_FullMethodName
+	q := newQuota(t, Limit{MaxKeys: 2, MaxBytes: 40})
+	steps := []struct {
+		name    string
+		method  string
+		request proto.Message
+		apply   func(db *gorm.DB)
+		want    codes.Code
+		// wantKeys and wantBytes are the namespace's usage afterwards
+		wantKeys, wantBytes int64
+	}{
+		{"a first key", setMethod, &kvpb.SetKeyValueRequest{Key: "billing/a", Value: "12345"}, set("billing/a", "12345"), codes.OK, 1, 14},
+		{"a second key", setMethod, &kvpb.SetKeyValueRequest{Key: "billing/b", Value: "12345"}, set("billing/b", "12345"), codes.OK, 2, 28},
+		{"a third key is over quota", setMethod, &kvpb.SetKeyValueRequest{Key: "billing/c", Value: "1"}, set("billing/c", "1"), codes.ResourceExhausted, 2, 28},
+		{"overwriting adds no key", setMethod, &kvpb.SetKeyValueRequest{Key: "billing/a", Value: "123456789"}, set("billing/a", "123456789"), codes.OK, 2, 32},
+		{"growing past the bytes is over quota", setMethod, &kvpb.SetKeyValueRequest{Key: "billing/a", Value: "12345678901234567890"}, set("billing/a", "12345678901234567890"), codes.ResourceExhausted, 2, 32},
+		{"another namespace is not counted", setMethod, &kvpb.SetKeyValueRequest{Key: "payroll/a", Value: "1"}, set("payroll/a", "1"), codes.OK, 2, 32},
+		{"a delete frees its key", deleteMethod, &kvpb.DeleteKeyValueRequest{Key: "billing/b"}, func(db *gorm.DB) {
+			db.Where("key_name = ?", "billing/b").Delete(&model.KV{})
+		}, codes.OK, 1, 18},
+		{"a write that fails counts for nothing", setMethod, &kvpb.SetKeyValueRequest{Key: "billing/d", Value: "1"}, func(db *gorm.DB) {}, codes.OK, 1, 18},
+		{"so there is room for another key", setMethod, &kvpb.SetKeyValueRequest{Key: "billing/c", Value: "1"}, set("billing/c", "1"), codes.OK, 2, 28},
+	}
+	for _, step := range steps {
+		err := call(t, q, step.method, step.request, step.apply)
+		if status.Code(err) != step.want {
+			t.Fatalf("%s: got %v, want %v", step.name, err, step.want)
+		}
+		u := q.usageOf("billing")
+		if u.keys != step.wantKeys || u.bytes != step.wantBytes {
+			t.Fatalf("%s: usage %d keys and %d bytes, want %d and %d", step.name, u.keys, u.bytes, step.wantKeys, step.wantBytes)
+		}
+	}
+
+	// What the running total reached matches a fresh measurement
+	var measured usage
+	if err := q.measure("billing", &measured); err != nil {
+		t.Fatal(err)
+	}
+	if u := q.usageOf("billing"); measured.keys != u.keys || measured.bytes != u.bytes {
+		t.Fatalf("running total %d keys and %d bytes, measured %d and %d", u.keys, u.bytes, measured.keys, measured.bytes)
+	}
+}
+
+func TestQuotaSkipsProxiedCalls(t *testing.T) {
+	q := newQuota(t, Limit{MaxKeys: 1})
+	q.proxied = func(ctx context.Context, method string, message any) bool { return true }
+	request := &kvpb.SetKeyValueRequest{Key: "billing/a", Value: "1"}
+	info := &grpc.UnaryServerInfo{FullMethod: kvpb.KeyValueStore_SetKeyValue_FullMethodName}
+	for i := 0; i < 3; i++ {
+		_, err := q.UnaryInterceptor(context.Background(), request, info, func(ctx context.Context, req any) (any, error) {
+			return nil, nil
+		})
+		if err != nil {
+			t.Fatalf("a call proxied to its owner was charged here: %v", err)
+		}
+	}
+}
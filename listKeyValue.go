@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kv-storage/model"
+	kvpb "github.com/kv-storage/proto/kv"
+	"gorm.io/gorm"
+)
+
+func (KvServerManager *KvService) ListPush(ctx context.Context, request *kvpb.ListPushRequest) (*kvpb.CollectionResponse, error) {
+	op := opListPushHead
+	if request.Tail {
+		op = opListPushTail
+	}
+	return mutateCollection(ctx, request, collectionOp{Op: op, Key: request.Key, Values: request.Values})
+}
+
+func (KvServerManager *KvService) ListPop(ctx context.Context, request *kvpb.ListPopRequest) (*kvpb.CollectionResponse, error) {
+	count := request.Count
+	if count == 0 {
+		count = 1
+	}
+	op := opListPopHead
+	if request.Tail {
+		op = opListPopTail
+	}
+	return mutateCollection(ctx, request, collectionOp{Op: op, Key: request.Key, Count: count})
+}
+
+func (KvServerManager *KvService) ListRange(ctx context.Context, request *kvpb.ListRangeRequest) (*kvpb.CollectionResponse, error) {
+	kv, failure := readCollection(ctx, request.Key, model.TypeList)
+	if failure != nil {
+		return failure, nil
+	}
+	var length int64
+	if err := kvDbConnector.Model(&model.ListItem{}).Where("kv_id = ?", kv.ID).Count(&length).Error; err != nil {
+		return collectionError(StatusInternalServerError, "Database error"), nil
+	}
+	start, stop := request.Start, request.Stop
+	if start < 0 {
+		start = max(length+start, 0)
+	}
+	if stop < 0 {
+		stop = length + stop
+	}
+	stop = min(stop, length-1)
+	response := &kvpb.CollectionResponse{Message: "Key found", StatusCode: int64(StatusOK), Values: []string{}}
+	if start > stop {
+		return response, nil
+	}
+	err := kvDbConnector.Model(&model.ListItem{}).Where("kv_id = ?", kv.ID).
+		Order("position").Offset(int(start)).Limit(int(stop-start+1)).
+		Pluck("value", &response.Values).Error
+	if err != nil {
+		return collectionError(StatusInternalServerError, "Database error"), nil
+	}
+	response.Count = int64(len(response.Values))
+	return response, nil
+}
+
+// listPush adds values one at a time at the chosen end, so pushing a, b, c
+// to the head leaves c first, and returns the list's new length.
+func listPush(tx *gorm.DB, kvID uint, values []string, tail bool) (int64, error) {
+	var lowest, highest sql.NullInt64
+	row := tx.Model(&model.ListItem{}).Where("kv_id = ?", kvID).Select("MIN(position), MAX(position)").Row()
+	if err := row.Scan(&lowest, &highest); err != nil {
+		return 0, err
+	}
+	items := make([]model.ListItem, len(values))
+	for i, value := range values {
+		position := lowest.Int64 - int64(i) - 1
+		if tail {
+			position = highest.Int64 + int64(i) + 1
+		}
+		items[i] = model.ListItem{KVID: kvID, Position: position, Value: value}
+	}
+	if err := tx.Create(&items).Error; err != nil {
+		return 0, err
+	}
+	var length int64
+	err := tx.Model(&model.ListItem{}).Where("kv_id = ?", kvID).Count(&length).Error
+	return length, err
+}
+
+// listPop removes and returns up to count items from the chosen end.
+func listPop(tx *gorm.DB, kvID uint, count int64, tail bool) ([]string, error) {
+	order := "position"
+	if tail {
+		order = "position DESC"
+	}
+	var items []model.ListItem
+	if err := tx.Where("kv_id = ?", kvID).Order(order).Limit(int(count)).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	values := make([]string, len(items))
+	positions := make([]int64, len(items))
+	for i, item := range items {
+		values[i] = item.Value
+		positions[i] = item.Position
+	}
+	if len(items) > 0 {
+		if err := tx.Where("kv_id = ? AND position IN ?", kvID, positions).Delete(&model.ListItem{}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kv-storage/model"
+	kvpb "github.com/kv-storage/proto/kv"
+)
+
+const copyBatch = 500
+
+// localStore lets background subsystems (shard handoff, lease expiry) drop
+// keys through the same path as DeleteKeyValue, keeping caches and peers in
+// sync, and lets handoff read collections back out.
+type localStore struct{}
+
+func (localStore) Drop(key string) error {
+	statusCode, message, version, _ := removeKeyValue(kvDbConnector, key, 0, 0)
+	if statusCode != StatusOK && statusCode != StatusNotFound {
+		return errors.New(message)
+	}
+	if statusCode == StatusOK {
+		cache.Delete(key, version)
+	} else {
+		cache.DeleteKey(key)
+	}
+	invalidationBus.Publish(key)
+	return nil
+}
+
+// CopyCollection replays a collection's elements as writes, in batches.
+func (localStore) CopyCollection(ctx context.Context, client kvpb.KeyValueStoreClient, kv model.KV) error {
+	var err error
+	switch kv.Type {
+	case model.TypeHash:
+		var fields []model.HashField
+		if err := kvDbConnector.Where("kv_id = ?", kv.ID).Find(&fields).Error; err != nil {
+			return err
+		}
+		for start := 0; start < len(fields) && err == nil; start += copyBatch {
+			request := &kvpb.HashSetRequest{Key: kv.Key, Fields: map[string]string{}}
+			for _, field := range fields[start:min(start+copyBatch, len(fields))] {
+				request.Fields[field.Field] = field.Value
+			}
+			err = collectionCallError(client.HashSet(ctx, request))
+		}
+	case model.TypeList:
+		var values []string
+		if err := kvDbConnector.Model(&model.ListItem{}).Where("kv_id = ?", kv.ID).Order("position").Pluck("value", &values).Error; err != nil {
+			return err
+		}
+		for start := 0; start < len(values) && err == nil; start += copyBatch {
+			request := &kvpb.ListPushRequest{Key: kv.Key, Values: values[start:min(start+copyBatch, len(values))], Tail: true}
+			err = collectionCallError(client.ListPush(ctx, request))
+		}
+	case model.TypeSet:
+		var members []string
+		if err := kvDbConnector.Model(&model.SetMember{}).Where("kv_id = ?", kv.ID).Pluck("member", &members).Error; err != nil {
+			return err
+		}
+		for start := 0; start < len(members) && err == nil; start += copyBatch {
+			request := &kvpb.SetMembersRequest{Key: kv.Key, Members: members[start:min(start+copyBatch, len(members))]}
+			err = collectionCallError(client.SetAdd(ctx, request))
+		}
+	case model.TypeSortedSet:
+		var members []model.SortedSetMember
+		if err := kvDbConnector.Where("kv_id = ?", kv.ID).Find(&members).Error; err != nil {
+			return err
+		}
+		for start := 0; start < len(members) && err == nil; start += copyBatch {
+			request := &kvpb.SortedSetAddRequest{Key: kv.Key}
+			for _, member := range members[start:min(start+copyBatch, len(members))] {
+				request.Members = append(request.Members, &kvpb.ScoredMember{Member: member.Member, Score: member.Score})
+			}
+			err = collectionCallError(client.SortedSetAdd(ctx, request))
+		}
+	}
+	return err
+}
+
+func collectionCallError(response *kvpb.CollectionResponse, err error) error {
+	if err == nil && response.StatusCode != StatusOK {
+		return errors.New(response.Message)
+	}
+	return err
+}
@@ -6,6 +6,9 @@ import (
 	"net"
 	"context"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
@@ -15,6 +18,33 @@ import (
 	kvpb "github.com/kv-storage/proto/kv"
 	"gorm.io/gorm"
 	cacheModule "github.com/kv-storage/cache"
+	"github.com/kv-storage/invalidation"
+	"github.com/kv-storage/cluster"
+	"github.com/kv-storage/sharding"
+	"github.com/kv-storage/replicas"
+	"github.com/kv-storage/resp"
+	"github.com/kv-storage/memcache"
+	"github.com/kv-storage/conditional"
+	"github.com/kv-storage/health"
+	"github.com/kv-storage/watch"
+	"github.com/kv-storage/grpcweb"
+	"github.com/rs/cors"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/grpc/credentials"
+	"github.com/kv-storage/certs"
+	"github.com/kv-storage/auth"
+	"github.com/kv-storage/limits"
+	"github.com/kv-storage/audit"
+	"github.com/kv-storage/validation"
+	"github.com/kv-storage/rbac"
+	"strings"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"github.com/kv-storage/changefeed"
+	"github.com/kv-storage/model"
+	"github.com/kv-storage/leases"
 	_ "net/http/pprof"
 	
 )
@@ -28,9 +58,16 @@ const (
 	StatusNotFound         = 404
 	StatusUnauthorized     = 401
 	StatusForbidden        = 403
+	StatusTooManyRequests  = 429
+	StatusServiceUnavailable = 503
 )
+const cacheCapacity = 200
+const inProcessBufferSize = 1 << 20
 var logger *zap.Logger
 var cache *cacheModule.LRUCache
+var invalidationBus *invalidation.Bus
+var clusterNode *cluster.Node
+var shardRouter *sharding.Router
 func init() {
 	var err error
 	logger, err = zap.NewDevelopment()
@@ -40,6 +77,11 @@ func init() {
 }
 
 var kvDbConnector *gorm.DB;
+var readRouter *replicas.Router
+var changeFeed *changefeed.Feed
+var leaseManager *leases.Manager
+var probe *health.Probe
+var validator *validation.Validator
 
 type KvService struct {
 	kvpb.UnimplementedKeyValueStoreServer
@@ -53,7 +95,7 @@ func startServer() {
 	logger.Info("Starting server...")
 	
 	// Initiaizing the cacahe
-	cache = cacheModule.NewLRUCache(200);
+	cache = cacheModule.NewLRUCache(cacheCapacity);
 
 	// Initialize the gotenv file..
 	err := godotenv.Load()
@@ -61,74 +103,451 @@ func startServer() {
 		logger.Fatal("Error loading .env file", zap.Error(err))
 	}
 
+	//  Start pprof profiling server on :6060 unless PPROF_ADDRESS says otherwise
+	if pprofAddress := config.PprofAddress(); pprofAddress != "" {
+		go func() {
+			log.Printf("pprof running at %s/debug/pprof/", pprofAddress)
+			if err := http.ListenAndServe(pprofAddress, nil); err != nil {
+				log.Fatalf("pprof server failed: %v", err)
+			}
+		}()
+	}
+
 	// Connect to the database
 	kvDbConnector, err = config.ConnectDB()
 	if err != nil {
 		logger.Fatal("Error connecting to database", zap.Error(err))
 	}
 
-	// Creating TCP Socket listener on port 50051
-	listener, err := net.Listen("tcp", "localhost:50051")
+	// Readiness stays off until the schema is migrated and the cache is warm
+	probe = health.NewProbe(kvDbConnector, logger, kvpb.KeyValueStore_ServiceDesc.ServiceName)
+	if err := config.Migrate(kvDbConnector); err != nil {
+		logger.Error("Schema migration failed", zap.Error(err))
+	} else {
+		probe.MarkMigrated()
+	}
+	go func() {
+		if err := warmCache(kvDbConnector, cacheCapacity); err != nil {
+			logger.Warn("Cache warm-up failed", zap.Error(err))
+		}
+		probe.MarkWarmed()
+	}()
+
+	// Cache-miss reads can be served by read replicas, writes always hit the primary
+	replicaConnectors, err := config.ConnectReplicas()
+	if err != nil {
+		logger.Fatal("Error connecting to read replica", zap.Error(err))
+	}
+	readRouter = replicas.NewRouter(kvDbConnector, replicaConnectors, config.ReplicaMaxLag(), config.ReplicaCheckInterval(), logger)
+
+	// Every mutation is also written to the change log for downstream consumers
+	changeFeed, err = changefeed.NewFeed(kvDbConnector, config.ChangeLogRetention(), config.ChangeLogMaxRows(), logger)
+	if err != nil {
+		logger.Fatal("Error starting change feed", zap.Error(err))
+	}
+	// Outside cluster mode versions are change sequences, so new ones must start above every stored version
+	if config.RaftNodeID() == "" {
+		var newest uint64
+		err := kvDbConnector.Model(&model.KV{}).Select("COALESCE(MAX(version), 0)").Scan(&newest).Error
+		if err == nil {
+			err = changefeed.Seed(kvDbConnector, newest)
+		}
+		if err != nil {
+			logger.Fatal("Error seeding change sequences", zap.Error(err))
+		}
+	}
+
+	// TLS_CERT_FILE and TLS_KEY_FILE secure gRPC, the gateway and calls to other nodes
+	var certificates *certs.Reloader
+	var peerCredentials credentials.TransportCredentials = insecure.NewCredentials()
+	serverOptions := []grpc.ServerOption{}
+	if config.TLSCertFile() != "" || config.TLSKeyFile() != "" {
+		certificates, err = certs.NewReloader(config.TLSCertFile(), config.TLSKeyFile(), config.TLSCAFile(), config.TLSClientAuth(), config.TLSReloadInterval(), logger)
+		if err != nil {
+			logger.Fatal("Error loading TLS certificates", zap.Error(err))
+		}
+		peerCredentials = credentials.NewTLS(certificates.ClientConfig())
+		serverOptions = append(serverOptions, grpc.Creds(certs.ServerCredentials(certificates.ServerConfig(), func(conn net.Conn) bool {
+			return conn.LocalAddr().Network() == "bufconn"
+		})))
+		logger.Info("TLS enabled", zap.String("clientAuth", config.TLSClientAuth()))
+	}
+
+	// Peers sharing this database get told about our writes so their caches stay fresh
+	invalidationBus, err = invalidation.NewBus(config.NodeID(), config.PeerAddresses(), peerCredentials, cache, logger)
+	if err != nil {
+		logger.Fatal("Error creating invalidation bus", zap.Error(err))
+	}
+
+	// Creating the gRPC listener, localhost:50051 unless GRPC_ADDRESS or LISTEN_ADDRESS says otherwise
+	grpcAddress := config.GrpcAddress()
+	listener, err := config.Listen(grpcAddress)
 	if err != nil {
 		logger.Fatal("Failed to start server", zap.Error(err))
 	}
+	interceptors := []grpc.UnaryServerInterceptor{config.UnaryInterceptor}
+	var streamInterceptors []grpc.StreamServerInterceptor
+
+	// AUTH_API_KEYS_FILE or AUTH_JWKS_FILE switches on authentication, ahead of any forwarding
+	var authenticator *auth.Authenticator
+	if config.AuthAPIKeysFile() != "" || config.AuthJWKSFile() != "" {
+		authenticator, err = auth.NewAuthenticator(config.AuthAPIKeysFile(), config.AuthJWKSFile(), config.AuthJWTIssuer(), config.AuthJWTAudience())
+		if err != nil {
+			logger.Fatal("Error loading credentials", zap.Error(err))
+		}
+		// Nodes call each other with no credential but their client certificate
+		multiNode := len(config.PeerAddresses()) > 0 || config.RaftNodeID() != "" || config.ShardNodeID() != ""
+		if multiNode && (certificates == nil || config.TLSClientAuth() != certs.ClientAuthRequire) {
+			logger.Fatal("Authentication with PEER_ADDRESSES, cluster or sharded mode needs TLS with TLS_CLIENT_AUTH=require, so nodes can authenticate to each other")
+		}
+		interceptors = append(interceptors, authenticator.UnaryInterceptor)
+		streamInterceptors = append(streamInterceptors, authenticator.StreamInterceptor)
+		logger.Info("Authentication enabled")
+	}
+	// RBAC_POLICY_FILE limits each role to operations on its key prefixes
+	if policyFile := config.RBACPolicyFile(); policyFile != "" {
+		if len(streamInterceptors) == 0 {
+			logger.Fatal("RBAC_POLICY_FILE needs AUTH_API_KEYS_FILE or AUTH_JWKS_FILE")
+		}
+		enforcer, err := rbac.NewEnforcer(policyFile, config.RBACReloadInterval(), logger)
+		if err != nil {
+			logger.Fatal("Error loading access policy", zap.Error(err))
+		}
+		interceptors = append(interceptors, enforcer.UnaryInterceptor)
+		streamInterceptors = append(streamInterceptors, enforcer.StreamInterceptor)
+		logger.Info("Access control enabled", zap.String("policy", policyFile))
+	}
+
+	// RATE_LIMIT_*_PER_SECOND give each client its own budget, so one cannot starve the database pool
+	if config.RateLimitReadsPerSecond() > 0 || config.RateLimitWritesPerSecond() > 0 {
+		rateLimiter := limits.NewRateLimiter(config.RateLimitReadsPerSecond(), config.RateLimitReadBurst(),
+			config.RateLimitWritesPerSecond(), config.RateLimitWriteBurst(), config.RateLimitExempt(), proxied)
+		interceptors = append(interceptors, rateLimiter.UnaryInterceptor)
+		streamInterceptors = append(streamInterceptors, rateLimiter.StreamInterceptor)
+		logger.Info("Rate limiting enabled",
+			zap.Float64("readsPerSecond", config.RateLimitReadsPerSecond()),
+			zap.Float64("writesPerSecond", config.RateLimitWritesPerSecond()))
+	}
+	// MAX_KEY_BYTES, MAX_VALUE_BYTES and KEY_CHARSET reject bad input before it reaches the store
+	validator, err = validation.NewValidator(config.MaxKeyBytes(), config.MaxValueBytes(), config.KeyCharset())
+	if err != nil {
+		logger.Fatal("Invalid validation settings", zap.Error(err))
+	}
+	interceptors = append(interceptors, validator.UnaryInterceptor)
+	// QUOTA_FILE caps the keys and bytes each namespace may store
+	if quotaFile := config.QuotaFile(); quotaFile != "" {
+		quotaConfig, err := limits.LoadQuotaConfig(quotaFile)
+		if err != nil {
+			logger.Fatal("Error loading quotas", zap.Error(err))
+		}
+		quota := limits.NewQuota(kvDbConnector, quotaConfig, config.QuotaRefreshInterval(), proxied, logger)
+		interceptors = append(interceptors, quota.UnaryInterceptor)
+		streamInterceptors = append(streamInterceptors, quota.StreamInterceptor)
+		logger.Info("Storage quotas enabled", zap.String("quotas", quotaFile))
+	}
+
+	// SHARD_NODE_ID switches on sharded mode: each key lives on its owner in the ring
+	if nodeID := config.ShardNodeID(); nodeID != "" {
+		if config.RaftNodeID() != "" {
+			logger.Fatal("Sharded mode and cluster mode cannot be combined")
+		}
+		shardRouter, err = sharding.NewRouter(nodeID, grpcAddress, config.ShardNodes(), config.ShardVirtualNodes(), kvDbConnector, localStore{}, peerCredentials, logger)
+		if err != nil {
+			logger.Fatal("Error starting shard router", zap.Error(err))
+		}
+		interceptors = append(interceptors, shardRouter.UnaryInterceptor)
+		logger.Info("Sharded mode enabled", zap.String("node", nodeID))
+	}
+	// AUDIT_LOG_FILE records each write once, where it is applied, after any forwarding to its leader or owner
+	if auditFile := config.AuditLogFile(); auditFile != "" {
+		var auditKey []byte
+		if keyFile := config.AuditKeyFile(); keyFile != "" {
+			if auditKey, err = audit.ReadKey(keyFile); err != nil {
+				logger.Fatal("Error reading the audit key", zap.Error(err))
+			}
+		} else {
+			logger.Warn("AUDIT_KEY_FILE is not set, so anyone who can edit the audit log can rewrite its hash chain to match")
+		}
+		auditLog, err := audit.NewLog(auditFile, config.AuditMaxBytes(), auditKey, proxied, logger)
+		if err != nil {
+			logger.Fatal("Error opening audit log", zap.Error(err))
+		}
+		defer auditLog.Close()
+		interceptors = append(interceptors, auditLog.UnaryInterceptor)
+		streamInterceptors = append(streamInterceptors, auditLog.StreamInterceptor)
+		logger.Info("Audit log enabled", zap.String("file", auditFile))
+	}
+
 	// Create a new gRPC server
-	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(config.UnaryInterceptor),
-	)
+	grpcServer := grpc.NewServer(append(serverOptions,
+		grpc.ChainUnaryInterceptor(interceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+	)...)
 
 	// Register the KvService to the gRPC server
 	kvpb.RegisterKeyValueStoreServer(grpcServer, &KvService{})
-	logger.Info("Serving gRPC", zap.String("address", "localhost:50051"))
+	kvpb.RegisterCacheInvalidationServer(grpcServer, invalidationBus)
+	kvpb.RegisterChangeFeedServer(grpcServer, changeFeed)
+	healthpb.RegisterHealthServer(grpcServer, probe.Server())
+	reflection.Register(grpcServer)
+	if shardRouter != nil {
+		kvpb.RegisterShardRingServer(grpcServer, shardRouter)
+	}
 
-	// Start the server in a new goroutine
+	// RAFT_NODE_ID switches on cluster mode: writes go through the Raft log
+	if nodeID := config.RaftNodeID(); nodeID != "" {
+		clusterNode, err = cluster.NewNode(cluster.Config{
+			NodeID:      nodeID,
+			RaftAddress: config.RaftAddress(),
+			GrpcAddress: grpcAddress,
+			DataDir:     config.RaftDataDir(),
+			Bootstrap:   config.RaftBootstrap(),
+			JoinAddress: config.RaftJoinAddress(),
+			PeerCredentials: peerCredentials,
+		}, kvDbConnector, kvStateMachine{}, logger)
+		if err != nil {
+			logger.Fatal("Error starting cluster node", zap.Error(err))
+		}
+		kvpb.RegisterClusterAdminServer(grpcServer, clusterNode)
+		logger.Info("Cluster mode enabled", zap.String("node", nodeID), zap.String("raft", config.RaftAddress()))
+	}
+
+	// Leases and locks need every node to see the same database, so single-store
+	// mode only; elsewhere calls are refused with the reason instead of Unimplemented
+	if clusterNode == nil && shardRouter == nil {
+		leaseManager = leases.NewManager(kvDbConnector, localStore{}, logger)
+		kvpb.RegisterLeasesServer(grpcServer, leaseManager)
+		kvpb.RegisterLocksServer(grpcServer, leaseManager)
+	} else {
+		unavailable := leases.Unavailable("Leases and locks are not available in cluster or sharded mode")
+		kvpb.RegisterLeasesServer(grpcServer, unavailable)
+		kvpb.RegisterLocksServer(grpcServer, unavailable)
+		logger.Warn("Leases and locks are disabled: they are only available in single-store mode")
+	}
+	// Only single-store and sharded nodes create keys with a TTL
+	if clusterNode == nil {
+		go reapExpiredKeys()
+	}
+	logger.Info("Serving gRPC", zap.String("address", grpcAddress), zap.Strings("peers", config.PeerAddresses()))
+
+	// In single-port mode the HTTP server below takes the listener and hands gRPC calls over
+	singlePort := config.ListenAddress() != ""
+	if !singlePort {
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				logger.Fatal("Failed to serve", zap.Error(err))
+			}
+		}()
+	}
+
+	// The gateway and the protocol listeners call the service in-process, over an
+	// in-memory connection that still runs every interceptor
+	inProcess := bufconn.Listen(inProcessBufferSize)
 	go func() {
-		if err := grpcServer.Serve(listener); err != nil {
-			logger.Fatal("Failed to serve", zap.Error(err))
+		if err := grpcServer.Serve(inProcess); err != nil {
+			logger.Fatal("Failed to serve in-process", zap.Error(err))
 		}
 	}()
-
-	// Create a new gRPC-Gateway server
-	// it connect to the gRPC server we just started and act as a grpc client!
-	connection, err := grpc.DialContext(
-		context.Background(),
-		"localhost:50051",
-		grpc.WithBlock(),
+	connection, err := grpc.NewClient("passthrough:///in-process",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return inProcess.DialContext(ctx)
+		}),
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		// Checked before marshalling too, since RESP and memcached values need not be UTF-8
+		grpc.WithChainUnaryInterceptor(validator.UnaryClientInterceptor),
 	)
 	if err != nil {
 		logger.Fatal("Failed to dial server", zap.Error(err))
 	}
 	// Create a new gRPC-Gateway mux
-	gwmux := runtime.NewServeMux()
+	gwmux := runtime.NewServeMux(
+		runtime.WithForwardResponseOption(conditional.ForwardResponse),
+		runtime.WithIncomingHeaderMatcher(incomingHeader),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeader),
+	)
 	
 	// Register the service to the gRPC Gateway
 	kvpb.RegisterKeyValueStoreHandler(context.Background(),gwmux,connection)
+	if shardRouter != nil {
+		kvpb.RegisterShardRingHandler(context.Background(), gwmux, connection)
+	}
+	// Registered last so it wins over GET /api/kv/{key}
+	watchHandler := watch.NewHandler(kvpb.NewChangeFeedClient(connection), logger)
+	gwmux.HandlePath("GET", "/api/kv/watch", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		watchHandler.ServeHTTP(w, r)
+	})
+	gwmux.HandlePath("GET", "/healthz", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		probe.Live(w, r)
+	})
+	gwmux.HandlePath("GET", "/readyz", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		probe.Readiness(w, r)
+	})
 
-	// Create a new HTTP server
-	gwServer := &http.Server{
-		Addr:    ":8090",
-		Handler: gwmux,
+	// Serve Redis clients through the same connection when a RESP address is set
+	if redisAddress := config.RedisAddress(); redisAddress != "" {
+		redisListener, err := net.Listen("tcp", redisAddress)
+		if err != nil {
+			logger.Fatal("Failed to listen for RESP", zap.Error(err))
+		}
+		redisServer := resp.NewServer(kvpb.NewKeyValueStoreClient(connection), authenticator, logger)
+		go func() {
+			if err := redisServer.Serve(redisListener); err != nil {
+				logger.Fatal("Failed to serve RESP", zap.Error(err))
+			}
+		}()
+		logger.Info("Serving RESP", zap.String("address", redisAddress))
 	}
-	logger.Info("Serving gRPC-Gateway", zap.String("address", "http://0.0.0.0:8090"))
-	if err := gwServer.ListenAndServe(); err != http.ErrServerClosed {
+
+	// Likewise for memcached clients when a memcached address is set
+	if memcachedAddress := config.MemcachedAddress(); memcachedAddress != "" {
+		memcachedListener, err := net.Listen("tcp", memcachedAddress)
+		if err != nil {
+			logger.Fatal("Failed to listen for memcached", zap.Error(err))
+		}
+		memcachedServer := memcache.NewServer(kvpb.NewKeyValueStoreClient(connection), config.MemcachedAPIKey(), config.MaxKeyBytes(), config.MaxValueBytes(), logger)
+		go func() {
+			if err := memcachedServer.Serve(memcachedListener); err != nil {
+				logger.Fatal("Failed to serve memcached", zap.Error(err))
+			}
+		}()
+		logger.Info("Serving memcached", zap.String("address", memcachedAddress))
+	}
+
+	// Create a new HTTP server, on its own listener unless it shares the gRPC one
+	handler := gatewayHandler(conditional.Middleware(gwmux), grpcServer)
+	gatewayListener := listener
+	if singlePort {
+		handler = h2c.NewHandler(multiplexHandler(grpcServer, handler), &http2.Server{})
+	} else if gatewayListener, err = config.Listen(config.GatewayAddress()); err != nil {
+		logger.Fatal("Failed to listen for the gateway", zap.Error(err))
+	}
+	gwServer := &http.Server{Handler: handler}
+	gwServer.RegisterOnShutdown(watchHandler.Close)
+	shutdownDone := make(chan struct{})
+	go shutdownOnSignal(gwServer, grpcServer, shutdownDone)
+	logger.Info("Serving gRPC-Gateway", zap.String("address", gatewayListener.Addr().String()), zap.Bool("singlePort", singlePort))
+	if certificates != nil {
+		gwServer.TLSConfig = certificates.ServerConfig()
+		err = gwServer.ServeTLS(gatewayListener, "", "")
+	} else {
+		err = gwServer.Serve(gatewayListener)
+	}
+	if err != http.ErrServerClosed {
 		log.Fatalf("Failed to listen and serve: %v", err)
 	}
+	<-shutdownDone
 	
 }
 
+// incomingHeader forwards X-Api-Key to gRPC along with the gateway's defaults,
+// which already include Authorization.
+func incomingHeader(key string) (string, bool) {
+	if strings.EqualFold(key, auth.APIKeyHeader) {
+		return auth.APIKeyHeader, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// outgoingHeader sends a throttled client's retry hint as Retry-After, and
+// other response metadata with the gateway's usual Grpc-Metadata- prefix.
+func outgoingHeader(key string) (string, bool) {
+	if key == limits.RetryAfterHeader {
+		return "Retry-After", true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
+
+// proxied reports whether this node hands a call, or one record of a
+// stream, on to another node: a follower forwards writes to the leader, and
+// a shard forwards keys it does not own to their owner. Rate limits, quotas
+// and the audit log apply where the call is served, so nothing counts twice.
+func proxied(ctx context.Context, method string, message any) bool {
+	if !strings.HasPrefix(method, "/"+kvpb.KeyValueStore_ServiceDesc.ServiceName+"/") {
+		return false
+	}
+	operation, key, _ := rbac.Classify(method, message)
+	switch {
+	case clusterNode != nil:
+		return (operation == rbac.OpWrite || operation == rbac.OpDelete) && !clusterNode.IsLeader() && !cluster.Forwarded(ctx)
+	case shardRouter != nil:
+		return key != "" && shardRouter.Owner(ctx, key) != ""
+	}
+	return false
+}
+
+// gatewayHandler hands gRPC-Web calls straight to the gRPC server and the
+// rest to the gateway, behind CORS when browser origins are configured.
+func gatewayHandler(gateway http.Handler, grpcServer *grpc.Server) http.Handler {
+	webHandler := grpcweb.Handler(grpcServer)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if grpcweb.IsRequest(r) {
+			webHandler.ServeHTTP(w, r)
+			return
+		}
+		gateway.ServeHTTP(w, r)
+	})
+	origins := config.CORSAllowedOrigins()
+	if len(origins) == 0 {
+		return handler
+	}
+	return cors.New(cors.Options{
+		AllowedOrigins: origins,
+		AllowedMethods: config.CORSAllowedMethods(),
+		AllowedHeaders: config.CORSAllowedHeaders(),
+		ExposedHeaders: append([]string{"ETag"}, grpcweb.ExposedHeaders...),
+		MaxAge:         int(config.CORSMaxAge().Seconds()),
+	}).Handler(handler)
+}
+
+// multiplexHandler serves native gRPC, told apart by HTTP/2 and its content
+// type, next to the gateway on one port, along with pprof under /debug/pprof/.
+func multiplexHandler(grpcServer *grpc.Server, gateway http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType := r.Header.Get("Content-Type")
+		switch {
+		case r.ProtoMajor == 2 && strings.HasPrefix(contentType, "application/grpc") && !grpcweb.IsRequest(r):
+			grpcServer.ServeHTTP(w, r)
+		case strings.HasPrefix(r.URL.Path, "/debug/pprof/"):
+			http.DefaultServeMux.ServeHTTP(w, r)
+		default:
+			gateway.ServeHTTP(w, r)
+		}
+	})
+}
+
+// shutdownOnSignal reports NOT_SERVING on SIGINT or SIGTERM, then lets
+// in-flight requests finish within the shutdown timeout before stopping.
+func shutdownOnSignal(gwServer *http.Server, grpcServer *grpc.Server, done chan<- struct{}) {
+	defer close(done)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	<-signals
+	logger.Info("Shutting down")
+	probe.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout())
+	defer cancel()
+	if err := gwServer.Shutdown(ctx); err != nil {
+		logger.Warn("Gateway did not drain in time", zap.Error(err))
+	}
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		// Change feed streams never end on their own
+		grpcServer.Stop()
+	}
+}
+
 // This will print how many CPU cores Go is using
 func init() {
     fmt.Println("GOMAXPROCS:", hello.GOMAXPROCS(0))
 }
 func main() {
-	//  Start pprof profiling server on :6060
-	go func() {
-		log.Println("pprof running at http://localhost:6060/debug/pprof/")
-		if err := http.ListenAndServe(":6060", nil); err != nil {
-			log.Fatalf("pprof server failed: %v", err)
-		}
-	}()
 	// Start the server
 	startServer()
 }
\ No newline at end of file
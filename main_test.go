@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/kv-storage/audit"
+	cacheModule "github.com/kv-storage/cache"
+	"github.com/kv-storage/changefeed"
+	"github.com/kv-storage/model"
+	kvpb "github.com/kv-storage/proto/kv"
+	"github.com/kv-storage/replicas"
+	"go.uber.org/zap"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"gorm.io/gorm"
+)
+
+// useStore points the handlers at a fresh single-node store.
+func useStore(t *testing.T) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "kv.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.AutoMigrate(&model.KV{}, &model.Change{}, &model.ChangeWatermark{}, &model.ConsumerCheckpoint{}, &model.Lease{}, &model.LockWaiter{}, &model.HashField{}, &model.ListItem{}, &model.SetMember{}, &model.SortedSetMember{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger = zap.NewNop()
+	kvDbConnector = db
+	cache = cacheModule.NewLRUCache(100)
+	readRouter = replicas.NewRouter(db, nil, time.Second, time.Second, logger)
+	if changeFeed, err = changefeed.NewFeed(db, 0, 0, logger); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func set(t *testing.T, key, value string) {
+	t.Helper()
+	response, err := (&KvService{}).SetKeyValue(context.Background(), &kvpb.SetKeyValueRequest{Key: key, Value: value})
+	if err != nil || response.StatusCode != StatusCreated && response.StatusCode != StatusOK {
+		t.Fatalf("SetKeyValue(%s) = %v, %v", key, response, err)
+	}
+}
+
+func TestGatewayHandlerCORS(t *testing.T) {
+	gateway := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("gateway"))
+	})
+	tests := []struct {
+		name      string
+		origins   string
+		method    string
+		origin    string
+		wantAllow string
+		wantBody  string
+	}{
+		{"CORS off", "", http.MethodGet, "https://app.example", "", "gateway"},
+		{"an allowed origin", "https://app.example", http.MethodGet, "https://app.example", "https://app.example", "gateway"},
+		{"another origin", "https://app.example", http.MethodGet, "https://evil.example", "", "gateway"},
+		{"a preflight", "https://app.example", http.MethodOptions, "https://app.example", "https://app.example", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("CORS_ALLOWED_ORIGINS", test.origins)
+			request := httptest.NewRequest(test.method, "/api/kv/a", nil)
+			request.Header.Set("Origin", test.origin)
+			if test.method == http.MethodOptions {
+				request.Header.Set("Access-Control-Request-Method", http.MethodPut)
+				request.Header.Set("Access-Control-Request-Headers", "if-match")
+			}
+			recorder := httptest.NewRecorder()
+			gatewayHandler(gateway, grpc.NewServer()).ServeHTTP(recorder, request)
+			if got := recorder.Header().Get("Access-Control-Allow-Origin"); got != test.wantAllow {
+				t.Fatalf("allowed origin %q, want %q", got, test.wantAllow)
+			}
+			if recorder.Body.String() != test.wantBody {
+				t.Fatalf("got %q, want %q", recorder.Body.String(), test.wantBody)
+			}
+		})
+	}
+}
+
+func TestMultiplexHandler(t *testing.T) {
+	grpcServer := grpc.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+	gateway := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("gateway"))
+	})
+	server := httptest.NewServer(h2c.NewHandler(multiplexHandler(grpcServer, gateway), &http2.Server{}))
+	defer server.Close()
+
+	connection, err := grpc.NewClient(strings.TrimPrefix(server.URL, "http://"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer connection.Close()
+	if response, err := healthpb.NewHealthClient(connection).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil || response.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("gRPC on the shared port: %v, %v", response, err)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		want        string
+	}{
+		{"REST", "/api/kv/a", "", "gateway"},
+		{"gRPC-Web over HTTP/1", "/kv.KeyValueStore/GetKeyValue", "application/grpc-web+proto", "gateway"},
+		{"pprof", "/debug/pprof/cmdline", "", os.Args[0]},
+	}
+	for _, test := range tests {
+		request, err := http.NewRequest(http.MethodGet, server.URL+test.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Content-Type", test.contentType)
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil || !strings.HasPrefix(string(body), test.want) {
+			t.Errorf("%s: got %q, %v, want %q", test.name, body, err, test.want)
+		}
+	}
+}
+
+func TestAuditRecordsWhatAWriteReplaced(t *testing.T) {
+	useStore(t)
+	path := filepath.Join(t.TempDir(), "audit.log")
+	auditLog, err := audit.NewLog(path, 0, nil, func(context.Context, string, any) bool { return false }, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auditLog.Close()
+	server := &KvService{}
+	calls := []struct {
+		method  string
+		request any
+		handler grpc.UnaryHandler
+		wantOld string
+	}{
+		{kvpb.KeyValueStore_SetKeyValue_FullMethodName, &kvpb.SetKeyValueRequest{Key: "a", Value: "1", Mode: SetModeUpsert}, func(ctx context.Context, req any) (any, error) {
+			return server.SetKeyValue(ctx, req.(*kvpb.SetKeyValueRequest))
+		}, ""},
+		{kvpb.KeyValueStore_SetKeyValue_FullMethodName, &kvpb.SetKeyValueRequest{Key: "a", Value: "2", Mode: SetModeUpsert}, func(ctx context.Context, req any) (any, error) {
+			return server.SetKeyValue(ctx, req.(*kvpb.SetKeyValueRequest))
+		}, "1"},
+		{kvpb.KeyValueStore_DeleteKeyValue_FullMethodName, &kvpb.DeleteKeyValueRequest{Key: "a"}, func(ctx context.Context, req any) (any, error) {
+			return server.DeleteKeyValue(ctx, req.(*kvpb.DeleteKeyValueRequest))
+		}, "2"},
+	}
+	for _, call := range calls {
+		if _, err := auditLog.UnaryInterceptor(context.Background(), call.request, &grpc.UnaryServerInfo{FullMethod: call.method}, call.handler); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != len(calls) {
+		t.Fatalf("%d entries, want %d", len(lines), len(calls))
+	}
+	for i, line := range lines {
+		var entry audit.Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		want := ""
+		if calls[i].wantOld != "" {
+			sum := sha256.Sum256([]byte(calls[i].wantOld))
+			want = hex.EncodeToString(sum[:])
+		}
+		if entry.OldValueSHA256 != want {
+			t.Errorf("entry %d records old value hash %q, want the hash of %q", i+1, entry.OldValueSHA256, calls[i].wantOld)
+		}
+	}
+}
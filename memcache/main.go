@@ -0,0 +1,444 @@
+package memcache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	kvpb "github.com/kv-storage/proto/kv"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	requestTimeout = 10 * time.Second
+	serverVersion  = "1.6.21"
+
+	maxLineLength = 2048
+	// maxMessageBytes bounds values when MAX_VALUE_BYTES leaves them
+	// unlimited, as gRPC servers take no larger messages by default
+	maxMessageBytes = 4 << 20
+	// Larger exptimes are absolute unix times, as in memcached.
+	maxRelativeExpiry = 60 * 60 * 24 * 30
+
+	errFormat     = "CLIENT_ERROR bad command line format"
+	errNonNumeric = "CLIENT_ERROR cannot increment or decrement non-numeric value"
+	errBinary     = "CLIENT_ERROR value must be valid UTF-8, binary values are not stored"
+)
+
+var errLineTooLong = errors.New("line too long")
+
+// Server speaks the memcached text protocol and serves every command through
+// the KeyValueStore service, so memcached clients share its cache, store and
+// rules. A key's version is its cas value. The service stores text, so
+// unlike memcached it refuses keys and values that are not valid UTF-8.
+type Server struct {
+	client        kvpb.KeyValueStoreClient
+	apiKey        string
+	maxKeyBytes   int
+	maxValueBytes int
+	logger        *zap.Logger
+}
+
+// NewServer makes every call with apiKey, when set, as the text protocol
+// has no way for clients to log in themselves. Keys and values over
+// maxKeyBytes and maxValueBytes, the service's own limits, are refused
+// before their data is read; with 0, values go up to what a gRPC message
+// carries by default.
+func NewServer(client kvpb.KeyValueStoreClient, apiKey string, maxKeyBytes, maxValueBytes int, logger *zap.Logger) *Server {
+	if maxValueBytes <= 0 {
+		maxValueBytes = maxMessageBytes
+	}
+	return &Server{client: client, apiKey: apiKey, maxKeyBytes: maxKeyBytes, maxValueBytes: maxValueBytes, logger: logger}
+}
+
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReaderSize(conn, maxLineLength)
+	writer := bufio.NewWriter(conn)
+	for {
+		line, err := readLine(reader)
+		if err == errLineTooLong {
+			writer.WriteString("CLIENT_ERROR line too long\r\n")
+			writer.Flush()
+			return
+		}
+		if err != nil {
+			if err != io.EOF {
+				s.logger.Debug("Memcached connection closed", zap.String("remote", conn.RemoteAddr().String()), zap.Error(err))
+			}
+			return
+		}
+		if !s.handle(reader, writer, conn.RemoteAddr().String(), strings.Fields(line)) {
+			writer.Flush()
+			return
+		}
+		// Pipelined commands are answered in one write
+		if reader.Buffered() == 0 {
+			if err := writer.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readLine returns one command line without its line ending.
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		return "", errLineTooLong
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.TrimRight(line, "\r\n")), nil
+}
+
+// handle runs one command from the client at remote and reports whether to
+// keep the connection open.
+func (s *Server) handle(reader *bufio.Reader, writer *bufio.Writer, remote string, args []string) bool {
+	if len(args) == 0 {
+		writer.WriteString("ERROR\r\n")
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	// Rate limits count the client, not this listener
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-forwarded-for", remote)
+	if s.apiKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+s.apiKey)
+	}
+
+	name := args[0]
+	args = args[1:]
+	switch name {
+	case "get", "gets":
+		if len(args) == 0 {
+			writer.WriteString("ERROR\r\n")
+			return true
+		}
+		s.get(ctx, writer, args, name == "gets")
+	case "set", "add", "replace", "cas":
+		return s.store(ctx, reader, writer, name, args)
+	case "delete":
+		s.delete(ctx, writer, args)
+	case "incr", "decr":
+		s.counter(ctx, writer, args, name == "decr")
+	case "touch":
+		s.touch(ctx, writer, args)
+	case "version":
+		writer.WriteString("VERSION " + serverVersion + "\r\n")
+	case "quit":
+		return false
+	default:
+		writer.WriteString("ERROR\r\n")
+	}
+	return true
+}
+
+// reply writes message unless the client asked for noreply.
+func reply(writer *bufio.Writer, noreply bool, message string) {
+	if !noreply {
+		writer.WriteString(message + "\r\n")
+	}
+}
+
+// noreply strips a trailing noreply argument.
+func noreply(args []string) ([]string, bool) {
+	if len(args) > 0 && args[len(args)-1] == "noreply" {
+		return args[:len(args)-1], true
+	}
+	return args, false
+}
+
+func (s *Server) validKey(key string) bool {
+	if len(key) == 0 || len(key) > s.maxKeyBytes || !utf8.ValidString(key) {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// ttlMilliseconds converts a memcached exptime into a TTL: 0 for none and -1
+// for a time that has already passed.
+func ttlMilliseconds(exptime int64, now time.Time) int64 {
+	switch {
+	case exptime == 0:
+		return 0
+	case exptime < 0:
+		return -1
+	case exptime <= maxRelativeExpiry:
+		return exptime * 1000
+	}
+	ttl := time.Unix(exptime, 0).Sub(now).Milliseconds()
+	if ttl <= 0 {
+		return -1
+	}
+	return ttl
+}
+
+// get writes a VALUE block per live string key; missing keys and collections
+// are skipped. Every key is looked up before anything is written, so a
+// failure is reported on its own rather than part-way through the blocks.
+func (s *Server) get(ctx context.Context, writer *bufio.Writer, keys []string, withCas bool) {
+	for _, key := range keys {
+		if !s.validKey(key) {
+			writer.WriteString(errFormat + "\r\n")
+			return
+		}
+	}
+	responses := make([]*kvpb.GetKVResponse, len(keys))
+	for i, key := range keys {
+		response, err := s.client.GetKeyValue(ctx, &kvpb.GetKVRequest{Key: key})
+		if err != nil {
+			writer.WriteString(errorReply(err) + "\r\n")
+			return
+		}
+		switch response.StatusCode {
+		case 200:
+			responses[i] = response
+		case 404, 409:
+		default:
+			writer.WriteString("SERVER_ERROR " + response.Message + "\r\n")
+			return
+		}
+	}
+	for i, response := range responses {
+		if response == nil {
+			continue
+		}
+		if withCas {
+			fmt.Fprintf(writer, "VALUE %s %d %d %d\r\n", keys[i], response.Flags, len(response.Value), response.Version)
+		} else {
+			fmt.Fprintf(writer, "VALUE %s %d %d\r\n", keys[i], response.Flags, len(response.Value))
+		}
+		writer.WriteString(response.Value)
+		writer.WriteString("\r\n")
+	}
+	writer.WriteString("END\r\n")
+}
+
+// store handles set, add, replace and cas, whose data block follows the
+// command line. It reports whether the connection can carry on, which it
+// cannot once the data block is out of step with the header.
+func (s *Server) store(ctx context.Context, reader *bufio.Reader, writer *bufio.Writer, name string, args []string) bool {
+	args, quiet := noreply(args)
+	wanted := 4
+	if name == "cas" {
+		wanted = 5
+	}
+	if len(args) != wanted {
+		writer.WriteString("ERROR\r\n")
+		return true
+	}
+	flags, flagsErr := strconv.ParseUint(args[1], 10, 32)
+	exptime, exptimeErr := strconv.ParseInt(args[2], 10, 64)
+	size, sizeErr := strconv.Atoi(args[3])
+	if flagsErr != nil || exptimeErr != nil || sizeErr != nil || size < 0 || !s.validKey(args[0]) {
+		writer.WriteString(errFormat + "\r\n")
+		// Without a size there is no telling where the data block ends
+		return sizeErr == nil && size >= 0 && discard(reader, size)
+	}
+	if size > s.maxValueBytes {
+		writer.WriteString("SERVER_ERROR object too large for cache\r\n")
+		return discard(reader, size)
+	}
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return false
+	}
+	if !bytes.HasSuffix(data, []byte("\r\n")) {
+		writer.WriteString("CLIENT_ERROR bad data chunk\r\n")
+		return false
+	}
+	if !utf8.Valid(data[:size]) {
+		reply(writer, quiet, errBinary)
+		return true
+	}
+
+	request := &kvpb.SetKeyValueRequest{
+		Key:   args[0],
+		Value: string(data[:size]),
+		Flags: uint32(flags),
+	}
+	switch name {
+	case "set":
+		request.Mode = "upsert"
+	case "add":
+		request.Mode = "create"
+	case "replace":
+		request.Mode = "update"
+	case "cas":
+		version, err := strconv.ParseUint(args[4], 10, 64)
+		if err != nil {
+			writer.WriteString(errFormat + "\r\n")
+			return true
+		}
+		// Versions are never 0, so a zero cas can only name a key that has changed
+		if version == 0 {
+			version = math.MaxUint64
+		}
+		request.Mode = "update"
+		request.ExpectedVersion = version
+	}
+	switch ttl := ttlMilliseconds(exptime, time.Now()); ttl {
+	case 0:
+	case -1:
+		// Stored already expired, as memcached does with a past exptime
+		request.TtlMilliseconds = 1
+	default:
+		request.TtlMilliseconds = ttl
+	}
+
+	response, err := s.client.SetKeyValue(ctx, request)
+	switch {
+	case err != nil:
+		reply(writer, quiet, errorReply(err))
+	case response.StatusCode == 200 || response.StatusCode == 201:
+		reply(writer, quiet, "STORED")
+	case name == "cas" && response.StatusCode == 409:
+		reply(writer, quiet, "EXISTS")
+	case name == "cas" && response.StatusCode == 404:
+		reply(writer, quiet, "NOT_FOUND")
+	case (name == "add" && response.StatusCode == 409) || (name == "replace" && response.StatusCode == 404):
+		reply(writer, quiet, "NOT_STORED")
+	case response.StatusCode == 400:
+		reply(writer, quiet, "CLIENT_ERROR "+response.Message)
+	default:
+		reply(writer, quiet, "SERVER_ERROR "+response.Message)
+	}
+	return true
+}
+
+// discard skips a data block and its line ending.
+func discard(reader *bufio.Reader, size int) bool {
+	_, err := reader.Discard(size + 2)
+	return err == nil
+}
+
+func (s *Server) delete(ctx context.Context, writer *bufio.Writer, args []string) {
+	args, quiet := noreply(args)
+	// Old clients send a hold time, which memcached only accepts as 0
+	if len(args) == 2 && args[1] == "0" {
+		args = args[:1]
+	}
+	if len(args) != 1 || !s.validKey(args[0]) {
+		writer.WriteString(errFormat + "\r\n")
+		return
+	}
+	response, err := s.client.DeleteKeyValue(ctx, &kvpb.DeleteKeyValueRequest{Key: args[0]})
+	switch {
+	case err != nil:
+		reply(writer, quiet, errorReply(err))
+	case response.StatusCode == 200:
+		reply(writer, quiet, "DELETED")
+	case response.StatusCode == 404:
+		reply(writer, quiet, "NOT_FOUND")
+	default:
+		reply(writer, quiet, "SERVER_ERROR "+response.Message)
+	}
+}
+
+// counter handles incr and decr. Counters are signed 64-bit here, so incr
+// stops at the largest int64 rather than wrapping, and decr stops at 0.
+func (s *Server) counter(ctx context.Context, writer *bufio.Writer, args []string, decrement bool) {
+	args, quiet := noreply(args)
+	if len(args) != 2 || !s.validKey(args[0]) {
+		writer.WriteString(errFormat + "\r\n")
+		return
+	}
+	delta, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || delta < 0 {
+		writer.WriteString("CLIENT_ERROR invalid numeric delta argument\r\n")
+		return
+	}
+	request := &kvpb.CounterRequest{Key: args[0], Delta: delta, Saturate: true, RequireExisting: true}
+	var response *kvpb.CounterResponse
+	if decrement {
+		floor := int64(0)
+		request.Min = &floor
+		response, err = s.client.Decrement(ctx, request)
+	} else {
+		response, err = s.client.Increment(ctx, request)
+	}
+	switch {
+	case err != nil:
+		reply(writer, quiet, errorReply(err))
+	case response.StatusCode == 200:
+		reply(writer, quiet, strconv.FormatInt(response.Value, 10))
+	case response.StatusCode == 404:
+		reply(writer, quiet, "NOT_FOUND")
+	// Both a non-integer value and a collection key have nothing to count
+	case response.StatusCode == 400 || response.StatusCode == 409:
+		reply(writer, quiet, errNonNumeric)
+	default:
+		reply(writer, quiet, "SERVER_ERROR "+response.Message)
+	}
+}
+
+// touch sets a key's exptime; 0 makes it permanent again.
+func (s *Server) touch(ctx context.Context, writer *bufio.Writer, args []string) {
+	args, quiet := noreply(args)
+	if len(args) != 2 || !s.validKey(args[0]) {
+		writer.WriteString(errFormat + "\r\n")
+		return
+	}
+	exptime, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		writer.WriteString("CLIENT_ERROR invalid exptime argument\r\n")
+		return
+	}
+	request := &kvpb.ExpireRequest{Key: args[0]}
+	// A past exptime asks Expire to delete the key straight away
+	switch ttl := ttlMilliseconds(exptime, time.Now()); ttl {
+	case 0:
+		request.Persist = true
+	default:
+		request.TtlMilliseconds = ttl
+	}
+	response, err := s.client.Expire(ctx, request)
+	switch {
+	case err != nil:
+		reply(writer, quiet, errorReply(err))
+	case response.StatusCode == 200:
+		reply(writer, quiet, "TOUCHED")
+	case response.StatusCode == 404:
+		reply(writer, quiet, "NOT_FOUND")
+	default:
+		reply(writer, quiet, "SERVER_ERROR "+response.Message)
+	}
+}
+
+// errorReply blames the client for bad input, as CLIENT_ERROR, and the
+// server for anything else.
+func errorReply(err error) string {
+	if st, ok := status.FromError(err); ok && st.Code() == codes.InvalidArgument {
+		return "CLIENT_ERROR " + st.Message()
+	}
+	return "SERVER_ERROR " + err.Error()
+}
@@ -0,0 +1,129 @@
+package memcache
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	kvpb "github.com/kv-storage/proto/kv"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// store is a KeyValueStore client over a map, versioning every write.
+type store struct {
+	kvpb.KeyValueStoreClient
+	values  map[string]*kvpb.GetKVResponse
+	version uint64
+}
+
+func (s *store) GetKeyValue(ctx context.Context, request *kvpb.GetKVRequest, opts ...grpc.CallOption) (*kvpb.GetKVResponse, error) {
+	if response, ok := s.values[request.Key]; ok {
+		return response, nil
+	}
+	return &kvpb.GetKVResponse{StatusCode: 404, Message: "Key not found"}, nil
+}
+
+func (s *store) SetKeyValue(ctx context.Context, request *kvpb.SetKeyValueRequest, opts ...grpc.CallOption) (*kvpb.SetKeyValueResponse, error) {
+	existing, exists := s.values[request.Key]
+	switch {
+	case request.Mode == "create" && exists:
+		return &kvpb.SetKeyValueResponse{StatusCode: 409, Message: "Key already exists"}, nil
+	case request.Mode == "update" && !exists:
+		return &kvpb.SetKeyValueResponse{StatusCode: 404, Message: "Key not found"}, nil
+	case request.ExpectedVersion != 0 && existing.Version != request.ExpectedVersion:
+		return &kvpb.SetKeyValueResponse{StatusCode: 409, Message: "Version mismatch"}, nil
+	}
+	s.version++
+	s.values[request.Key] = &kvpb.GetKVResponse{StatusCode: 200, Value: request.Value, Flags: request.Flags, Version: s.version}
+	return &kvpb.SetKeyValueResponse{StatusCode: 201, Version: s.version}, nil
+}
+
+func (s *store) DeleteKeyValue(ctx context.Context, request *kvpb.DeleteKeyValueRequest, opts ...grpc.CallOption) (*kvpb.DeleteKeyValueResponse, error) {
+	if _, ok := s.values[request.Key]; !ok {
+		return &kvpb.DeleteKeyValueResponse{StatusCode: 404}, nil
+	}
+	delete(s.values, request.Key)
+	return &kvpb.DeleteKeyValueResponse{StatusCode: 200}, nil
+}
+
+func TestCommands(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer(&store{values: map[string]*kvpb.GetKVResponse{}}, "", 8, 10, zap.NewNop())
+	go server.Serve(listener)
+	defer listener.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	replies := bufio.NewReader(conn)
+
+	steps := []struct {
+		send string
+		want string
+	}{
+		{"set a 5 0 2\r\nhi\r\n", "STORED\r\n"},
+		{"get a missing\r\n", "VALUE a 5 2\r\nhi\r\nEND\r\n"},
+		{"gets a\r\n", "VALUE a 5 2 1\r\nhi\r\nEND\r\n"},
+		{"cas a 0 0 2 7\r\nho\r\n", "EXISTS\r\n"},
+		{"cas a 0 0 2 1\r\nho\r\n", "STORED\r\n"},
+		{"cas a 0 0 2 0\r\nhu\r\n", "EXISTS\r\n"},
+		{"add a 0 0 1\r\nx\r\n", "NOT_STORED\r\n"},
+		{"replace b 0 0 1\r\nx\r\n", "NOT_STORED\r\n"},
+		{"set b 0 0 1 noreply\r\nx\r\n", ""},
+		{"get a b\r\n", "VALUE a 0 2\r\nho\r\nVALUE b 0 1\r\nx\r\nEND\r\n"},
+		{"set big 0 0 11\r\n01234567890\r\n", "SERVER_ERROR object too large for cache\r\n"},
+		{"set toolongkey 0 0 1\r\nx\r\n", "CLIENT_ERROR bad command line format\r\n"},
+		{"get toolongkey\r\n", "CLIENT_ERROR bad command line format\r\n"},
+		{"set c 0 0 2\r\n\xff\xfe\r\n", errBinary + "\r\n"},
+		{"get \xff\r\n", "CLIENT_ERROR bad command line format\r\n"},
+		{"delete a\r\n", "DELETED\r\n"},
+		{"delete a\r\n", "NOT_FOUND\r\n"},
+		{"flush_all\r\n", "ERROR\r\n"},
+		{"version\r\n", "VERSION " + serverVersion + "\r\n"},
+	}
+	for _, step := range steps {
+		if _, err := conn.Write([]byte(step.send)); err != nil {
+			t.Fatal(err)
+		}
+		var got strings.Builder
+		for lines := strings.Count(step.want, "\r\n"); lines > 0; lines-- {
+			line, err := replies.ReadString('\n')
+			if err != nil {
+				t.Fatalf("%q: %v after %q", step.send, err, got.String())
+			}
+			got.WriteString(line)
+		}
+		if got.String() != step.want {
+			t.Fatalf("%q replied %q, want %q", step.send, got.String(), step.want)
+		}
+	}
+}
+
+func TestTTLMilliseconds(t *testing.T) {
+	now := time.Unix(2_000_000_000, 0)
+	tests := []struct {
+		exptime int64
+		want    int64
+	}{
+		{0, 0},
+		{-1, -1},
+		{10, 10_000},
+		{maxRelativeExpiry, maxRelativeExpiry * 1000},
+		{now.Unix() + 5, 5_000},
+		{now.Unix() - 5, -1},
+	}
+	for _, test := range tests {
+		if got := ttlMilliseconds(test.exptime, now); got != test.want {
+			t.Errorf("ttlMilliseconds(%d) = %d, want %d", test.exptime, got, test.want)
+		}
+	}
+}
@@ -1,8 +1,129 @@
 package model
 
+import "time"
 
+const (
+    TypeString    = "string"
+    TypeHash      = "hash"
+    TypeList      = "list"
+    TypeSet       = "set"
+    TypeSortedSet = "zset"
+)
+
+// KV is one key. Collection types keep an empty Value and store their
+// contents in the backing table for the type, referencing the row ID.
 type KV struct {
-    ID    uint   `gorm:"primaryKey"`
-    Key   string `gorm:"column:key_name;size:255;uniqueIndex;not null"`
+    ID        uint       `gorm:"primaryKey"`
+    Key       string     `gorm:"column:key_name;size:255;uniqueIndex;not null"`
+    Value     string     `gorm:"not null"`
+    Type      string     `gorm:"size:8;not null;default:string"`
+    // Version is the change log sequence of the key's last write, or its Raft log
+    // index in cluster mode, so conditional writes can detect a lost update, and
+    // it never repeats for a key, even one deleted and created again.
+    Version   uint64     `gorm:"not null;default:1"`
+    // Flags are opaque to the store, kept for memcached clients.
+    Flags     uint32     `gorm:"not null;default:0"`
+    LeaseID   *uint64    `gorm:"index"`
+    // ExpiresAt is set for keys created with a TTL; expired rows read as missing until reaped.
+    ExpiresAt *time.Time `gorm:"index"`
+}
+
+// HashField is one field of a hash key.
+type HashField struct {
+    KVID  uint   `gorm:"primaryKey;autoIncrement:false"`
+    Field string `gorm:"primaryKey;size:255"`
     Value string `gorm:"not null"`
-}
\ No newline at end of file
+}
+
+// ListItem is one element of a list key. Positions only order the items:
+// pushing to the head takes one below the lowest, to the tail one above the highest.
+type ListItem struct {
+    KVID     uint   `gorm:"primaryKey;autoIncrement:false"`
+    Position int64  `gorm:"primaryKey;autoIncrement:false"`
+    Value    string `gorm:"not null"`
+}
+
+// SetMember is one member of a set key.
+type SetMember struct {
+    KVID   uint   `gorm:"primaryKey;autoIncrement:false"`
+    Member string `gorm:"primaryKey;size:255"`
+}
+
+// SortedSetMember is one member of a sorted set key, indexed for range-by-score reads.
+type SortedSetMember struct {
+    KVID   uint    `gorm:"primaryKey;autoIncrement:false;index:idx_sorted_set_score,priority:1"`
+    Member string  `gorm:"primaryKey;size:255"`
+    Score  float64 `gorm:"not null;index:idx_sorted_set_score,priority:2"`
+}
+
+// RaftState records the last log index applied to this node's database in cluster mode.
+type RaftState struct {
+    ID           uint   `gorm:"primaryKey"`
+    AppliedIndex uint64 `gorm:"not null"`
+}
+
+// ClusterMember maps a Raft node to the gRPC address writes are forwarded to.
+type ClusterMember struct {
+    NodeID      string `gorm:"primaryKey;size:255"`
+    RaftAddress string `gorm:"not null"`
+    GrpcAddress string `gorm:"not null"`
+}
+
+// ShardRing persists the last ring this node accepted in sharded mode.
+type ShardRing struct {
+    ID      uint   `gorm:"primaryKey"`
+    Version uint64 `gorm:"not null"`
+    Nodes   string `gorm:"type:text;not null"`
+}
+
+const (
+    ChangeSet    = "set"
+    ChangeDelete = "delete"
+    // ChangeCollection logs a field-level change to a collection key. Its
+    // value is the operation as JSON, e.g. {"op":"hset","fields":{"a":"1"}}.
+    ChangeCollection = "collection"
+)
+
+// Change is one committed mutation, numbered in the order writers reached the
+// log. A write that rolls back leaves a gap in the numbering.
+type Change struct {
+    Sequence  uint64    `gorm:"primaryKey;autoIncrement"`
+    Op        string    `gorm:"size:16;not null"`
+    Key       string    `gorm:"column:key_name;size:255;not null"`
+    Value     string    `gorm:"not null"`
+    CreatedAt time.Time `gorm:"index;not null"`
+}
+
+// ChangeWatermark is the highest change sequence pruned from the log. A
+// consumer resuming below it has missed changes.
+type ChangeWatermark struct {
+    ID     uint   `gorm:"primaryKey"`
+    Pruned uint64 `gorm:"not null"`
+}
+
+// ConsumerCheckpoint is the last change sequence a feed consumer has processed.
+type ConsumerCheckpoint struct {
+    ConsumerID string    `gorm:"primaryKey;size:255"`
+    Sequence   uint64    `gorm:"not null"`
+    UpdatedAt  time.Time
+}
+
+// Lease keeps attached keys and locks alive until it expires or is revoked.
+type Lease struct {
+    ID         uint64    `gorm:"primaryKey"`
+    // Owner is the subject that granted the lease, the only one that may use
+    // or revoke it. It is empty when authentication is off.
+    Owner      string    `gorm:"size:255;not null;default:''"`
+    TTLSeconds int64     `gorm:"not null"`
+    ExpiresAt  time.Time `gorm:"index;not null"`
+}
+
+// LockWaiter is a holder or queued waiter of a named lock. The lowest token
+// for a name holds the lock, which makes the queue FIFO and the token a
+// monotonically increasing fencing token.
+type LockWaiter struct {
+    Token     uint64 `gorm:"primaryKey"`
+    Name      string `gorm:"size:255;index;uniqueIndex:idx_lock_waiter_lease;not null"`
+    LeaseID   uint64 `gorm:"index;uniqueIndex:idx_lock_waiter_lease;not null"`
+    CreatedAt time.Time
+}
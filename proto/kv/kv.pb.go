@@ -23,8 +23,12 @@ const (
 )
 
 type GetKVRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// "strong" reads the primary, bypassing cache and replicas; default is eventual
+	Consistency string `protobuf:"bytes,2,opt,name=consistency,proto3" json:"consistency,omitempty"`
+	// token from an earlier write, so replicas that have not caught up are skipped
+	SessionToken  string `protobuf:"bytes,3,opt,name=sessionToken,proto3" json:"sessionToken,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -66,11 +70,28 @@ func (x *GetKVRequest) GetKey() string {
 	return ""
 }
 
+func (x *GetKVRequest) GetConsistency() string {
+	if x != nil {
+		return x.Consistency
+	}
+	return ""
+}
+
+func (x *GetKVRequest) GetSessionToken() string {
+	if x != nil {
+		return x.SessionToken
+	}
+	return ""
+}
+
 type GetKVResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
-	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
-	Value         string                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Message    string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	Value      string                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	// bumped by every write to the value, for expectedVersion
+	Version       uint64 `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	Flags         uint32 `protobuf:"varint,5,opt,name=flags,proto3" json:"flags,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -126,12 +147,39 @@ func (x *GetKVResponse) GetValue() string {
 	return ""
 }
 
+func (x *GetKVResponse) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *GetKVResponse) GetFlags() uint32 {
+	if x != nil {
+		return x.Flags
+	}
+	return 0
+}
+
 type SetKeyValueRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	// when set, the key is deleted once the lease expires or is revoked
+	LeaseId uint64 `protobuf:"varint,3,opt,name=leaseId,proto3" json:"leaseId,omitempty"`
+	// "create" (the default) fails with 409 if the key exists, "update" fails
+	// with 404 if it does not, "upsert" does either. Replacing a key drops its
+	// old type, lease and TTL.
+	Mode string `protobuf:"bytes,4,opt,name=mode,proto3" json:"mode,omitempty"`
+	// when set, the key reads as missing once this many milliseconds have passed
+	TtlMilliseconds int64 `protobuf:"varint,5,opt,name=ttlMilliseconds,proto3" json:"ttlMilliseconds,omitempty"`
+	// opaque client flags returned by reads, as memcached keeps them
+	Flags uint32 `protobuf:"varint,6,opt,name=flags,proto3" json:"flags,omitempty"`
+	// when set, an update only applies if the key is still at this version,
+	// otherwise it fails with 409
+	ExpectedVersion uint64 `protobuf:"varint,7,opt,name=expectedVersion,proto3" json:"expectedVersion,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *SetKeyValueRequest) Reset() {
@@ -178,10 +226,47 @@ func (x *SetKeyValueRequest) GetValue() string {
 	return ""
 }
 
+func (x *SetKeyValueRequest) GetLeaseId() uint64 {
+	if x != nil {
+		return x.LeaseId
+	}
+	return 0
+}
+
+func (x *SetKeyValueRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *SetKeyValueRequest) GetTtlMilliseconds() int64 {
+	if x != nil {
+		return x.TtlMilliseconds
+	}
+	return 0
+}
+
+func (x *SetKeyValueRequest) GetFlags() uint32 {
+	if x != nil {
+		return x.Flags
+	}
+	return 0
+}
+
+func (x *SetKeyValueRequest) GetExpectedVersion() uint64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
 type SetKeyValueResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
 	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	SessionToken  string                 `protobuf:"bytes,3,opt,name=sessionToken,proto3" json:"sessionToken,omitempty"`
+	Version       uint64                 `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -230,16 +315,272 @@ func (x *SetKeyValueResponse) GetStatusCode() int64 {
 	return 0
 }
 
-type DeleteKeyValueRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+func (x *SetKeyValueResponse) GetSessionToken() string {
+	if x != nil {
+		return x.SessionToken
+	}
+	return ""
+}
+
+func (x *SetKeyValueResponse) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// BulkSetRequest is one record of a BulkSet stream.
+type BulkSetRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Flags uint32                 `protobuf:"varint,3,opt,name=flags,proto3" json:"flags,omitempty"`
+	// read from the first record: "skip" (default) leaves existing keys alone, "overwrite" replaces them
+	Policy        string `protobuf:"bytes,4,opt,name=policy,proto3" json:"policy,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BulkSetRequest) Reset() {
+	*x = BulkSetRequest{}
+	mi := &file_kv_kv_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BulkSetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BulkSetRequest) ProtoMessage() {}
+
+func (x *BulkSetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BulkSetRequest.ProtoReflect.Descriptor instead.
+func (*BulkSetRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BulkSetRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *BulkSetRequest) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *BulkSetRequest) GetFlags() uint32 {
+	if x != nil {
+		return x.Flags
+	}
+	return 0
+}
+
+func (x *BulkSetRequest) GetPolicy() string {
+	if x != nil {
+		return x.Policy
+	}
+	return ""
+}
+
+type BulkSetFailure struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// position of the record in the stream, from 0
+	Index         int64  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Key           string `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	StatusCode    int64  `protobuf:"varint,3,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	Message       string `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BulkSetFailure) Reset() {
+	*x = BulkSetFailure{}
+	mi := &file_kv_kv_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BulkSetFailure) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BulkSetFailure) ProtoMessage() {}
+
+func (x *BulkSetFailure) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BulkSetFailure.ProtoReflect.Descriptor instead.
+func (*BulkSetFailure) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BulkSetFailure) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *BulkSetFailure) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *BulkSetFailure) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *BulkSetFailure) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type BulkSetResponse struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Message      string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode   int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	SessionToken string                 `protobuf:"bytes,3,opt,name=sessionToken,proto3" json:"sessionToken,omitempty"`
+	Created      int64                  `protobuf:"varint,4,opt,name=created,proto3" json:"created,omitempty"`
+	Updated      int64                  `protobuf:"varint,5,opt,name=updated,proto3" json:"updated,omitempty"`
+	Skipped      int64                  `protobuf:"varint,6,opt,name=skipped,proto3" json:"skipped,omitempty"`
+	Failed       int64                  `protobuf:"varint,7,opt,name=failed,proto3" json:"failed,omitempty"`
+	// the first failures only, failed counts them all
+	Failures      []*BulkSetFailure `protobuf:"bytes,8,rep,name=failures,proto3" json:"failures,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
+func (x *BulkSetResponse) Reset() {
+	*x = BulkSetResponse{}
+	mi := &file_kv_kv_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BulkSetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BulkSetResponse) ProtoMessage() {}
+
+func (x *BulkSetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BulkSetResponse.ProtoReflect.Descriptor instead.
+func (*BulkSetResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BulkSetResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *BulkSetResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *BulkSetResponse) GetSessionToken() string {
+	if x != nil {
+		return x.SessionToken
+	}
+	return ""
+}
+
+func (x *BulkSetResponse) GetCreated() int64 {
+	if x != nil {
+		return x.Created
+	}
+	return 0
+}
+
+func (x *BulkSetResponse) GetUpdated() int64 {
+	if x != nil {
+		return x.Updated
+	}
+	return 0
+}
+
+func (x *BulkSetResponse) GetSkipped() int64 {
+	if x != nil {
+		return x.Skipped
+	}
+	return 0
+}
+
+func (x *BulkSetResponse) GetFailed() int64 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *BulkSetResponse) GetFailures() []*BulkSetFailure {
+	if x != nil {
+		return x.Failures
+	}
+	return nil
+}
+
+type DeleteKeyValueRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// when set, the key is only deleted at this version
+	ExpectedVersion uint64 `protobuf:"varint,2,opt,name=expectedVersion,proto3" json:"expectedVersion,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
 func (x *DeleteKeyValueRequest) Reset() {
 	*x = DeleteKeyValueRequest{}
-	mi := &file_kv_kv_proto_msgTypes[4]
+	mi := &file_kv_kv_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -251,7 +592,7 @@ func (x *DeleteKeyValueRequest) String() string {
 func (*DeleteKeyValueRequest) ProtoMessage() {}
 
 func (x *DeleteKeyValueRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_kv_kv_proto_msgTypes[4]
+	mi := &file_kv_kv_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -264,7 +605,7 @@ func (x *DeleteKeyValueRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteKeyValueRequest.ProtoReflect.Descriptor instead.
 func (*DeleteKeyValueRequest) Descriptor() ([]byte, []int) {
-	return file_kv_kv_proto_rawDescGZIP(), []int{4}
+	return file_kv_kv_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *DeleteKeyValueRequest) GetKey() string {
@@ -274,17 +615,25 @@ func (x *DeleteKeyValueRequest) GetKey() string {
 	return ""
 }
 
+func (x *DeleteKeyValueRequest) GetExpectedVersion() uint64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
 type DeleteKeyValueResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
 	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	SessionToken  string                 `protobuf:"bytes,3,opt,name=sessionToken,proto3" json:"sessionToken,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *DeleteKeyValueResponse) Reset() {
 	*x = DeleteKeyValueResponse{}
-	mi := &file_kv_kv_proto_msgTypes[5]
+	mi := &file_kv_kv_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -296,7 +645,7 @@ func (x *DeleteKeyValueResponse) String() string {
 func (*DeleteKeyValueResponse) ProtoMessage() {}
 
 func (x *DeleteKeyValueResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_kv_kv_proto_msgTypes[5]
+	mi := &file_kv_kv_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -309,7 +658,7 @@ func (x *DeleteKeyValueResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteKeyValueResponse.ProtoReflect.Descriptor instead.
 func (*DeleteKeyValueResponse) Descriptor() ([]byte, []int) {
-	return file_kv_kv_proto_rawDescGZIP(), []int{5}
+	return file_kv_kv_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *DeleteKeyValueResponse) GetMessage() string {
@@ -326,38 +675,3547 @@ func (x *DeleteKeyValueResponse) GetStatusCode() int64 {
 	return 0
 }
 
-var File_kv_kv_proto protoreflect.FileDescriptor
+func (x *DeleteKeyValueResponse) GetSessionToken() string {
+	if x != nil {
+		return x.SessionToken
+	}
+	return ""
+}
 
-const file_kv_kv_proto_rawDesc = "" +
-	"\n" +
-	"\vkv/kv.proto\x12\x02kv\x1a\x1cgoogle/api/annotations.proto\" \n" +
-	"\fGetKVRequest\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\"_\n" +
-	"\rGetKVResponse\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
-	"\n" +
-	"statusCode\x18\x02 \x01(\x03R\n" +
-	"statusCode\x12\x14\n" +
-	"\x05value\x18\x03 \x01(\tR\x05value\"<\n" +
-	"\x12SetKeyValueRequest\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value\"O\n" +
+// A ttlMilliseconds of zero or less deletes the key straight away.
+type ExpireRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Key             string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	TtlMilliseconds int64                  `protobuf:"varint,2,opt,name=ttlMilliseconds,proto3" json:"ttlMilliseconds,omitempty"`
+	// clear the key's TTL instead, so it never expires
+	Persist       bool `protobuf:"varint,3,opt,name=persist,proto3" json:"persist,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExpireRequest) Reset() {
+	*x = ExpireRequest{}
+	mi := &file_kv_kv_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExpireRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpireRequest) ProtoMessage() {}
+
+func (x *ExpireRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpireRequest.ProtoReflect.Descriptor instead.
+func (*ExpireRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ExpireRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ExpireRequest) GetTtlMilliseconds() int64 {
+	if x != nil {
+		return x.TtlMilliseconds
+	}
+	return 0
+}
+
+func (x *ExpireRequest) GetPersist() bool {
+	if x != nil {
+		return x.Persist
+	}
+	return false
+}
+
+type ExpireResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	SessionToken  string                 `protobuf:"bytes,3,opt,name=sessionToken,proto3" json:"sessionToken,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExpireResponse) Reset() {
+	*x = ExpireResponse{}
+	mi := &file_kv_kv_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExpireResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpireResponse) ProtoMessage() {}
+
+func (x *ExpireResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpireResponse.ProtoReflect.Descriptor instead.
+func (*ExpireResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ExpireResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ExpireResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *ExpireResponse) GetSessionToken() string {
+	if x != nil {
+		return x.SessionToken
+	}
+	return ""
+}
+
+type TTLRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TTLRequest) Reset() {
+	*x = TTLRequest{}
+	mi := &file_kv_kv_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TTLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TTLRequest) ProtoMessage() {}
+
+func (x *TTLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TTLRequest.ProtoReflect.Descriptor instead.
+func (*TTLRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TTLRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type TTLResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Message    string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	// -1 when the key never expires
+	TtlMilliseconds int64 `protobuf:"varint,3,opt,name=ttlMilliseconds,proto3" json:"ttlMilliseconds,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *TTLResponse) Reset() {
+	*x = TTLResponse{}
+	mi := &file_kv_kv_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TTLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TTLResponse) ProtoMessage() {}
+
+func (x *TTLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TTLResponse.ProtoReflect.Descriptor instead.
+func (*TTLResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *TTLResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *TTLResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *TTLResponse) GetTtlMilliseconds() int64 {
+	if x != nil {
+		return x.TtlMilliseconds
+	}
+	return 0
+}
+
+// Scan pages through keys in a stable order. Start with cursor 0 and pass
+// back the returned cursor until it is 0 again. count bounds the keys
+// examined per call, so a page may hold fewer matches, or none.
+type ScanRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Cursor uint64                 `protobuf:"varint,1,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// glob pattern with *, ? and [...], as in Redis
+	Match         string `protobuf:"bytes,2,opt,name=match,proto3" json:"match,omitempty"`
+	Count         int64  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanRequest) Reset() {
+	*x = ScanRequest{}
+	mi := &file_kv_kv_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRequest) ProtoMessage() {}
+
+func (x *ScanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRequest.ProtoReflect.Descriptor instead.
+func (*ScanRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ScanRequest) GetCursor() uint64 {
+	if x != nil {
+		return x.Cursor
+	}
+	return 0
+}
+
+func (x *ScanRequest) GetMatch() string {
+	if x != nil {
+		return x.Match
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type ScanResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	Keys          []string               `protobuf:"bytes,3,rep,name=keys,proto3" json:"keys,omitempty"`
+	Cursor        uint64                 `protobuf:"varint,4,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanResponse) Reset() {
+	*x = ScanResponse{}
+	mi := &file_kv_kv_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanResponse) ProtoMessage() {}
+
+func (x *ScanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanResponse.ProtoReflect.Descriptor instead.
+func (*ScanResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ScanResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ScanResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *ScanResponse) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+func (x *ScanResponse) GetCursor() uint64 {
+	if x != nil {
+		return x.Cursor
+	}
+	return 0
+}
+
+// Increment and Decrement add delta to an integer value. A missing key starts
+// from initialValue; ttlSeconds only applies when the call creates the counter.
+type CounterRequest struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Key          string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Delta        int64                  `protobuf:"varint,2,opt,name=delta,proto3" json:"delta,omitempty"`
+	InitialValue int64                  `protobuf:"varint,3,opt,name=initialValue,proto3" json:"initialValue,omitempty"`
+	// a change that would leave [min, max] is rejected and the value kept, unless saturate is set
+	Min        *int64 `protobuf:"varint,4,opt,name=min,proto3,oneof" json:"min,omitempty"`
+	Max        *int64 `protobuf:"varint,5,opt,name=max,proto3,oneof" json:"max,omitempty"`
+	TtlSeconds int64  `protobuf:"varint,6,opt,name=ttlSeconds,proto3" json:"ttlSeconds,omitempty"`
+	// clamp at min or max instead of rejecting the change
+	Saturate bool `protobuf:"varint,7,opt,name=saturate,proto3" json:"saturate,omitempty"`
+	// fail with 404 instead of creating a missing counter
+	RequireExisting bool `protobuf:"varint,8,opt,name=requireExisting,proto3" json:"requireExisting,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CounterRequest) Reset() {
+	*x = CounterRequest{}
+	mi := &file_kv_kv_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CounterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CounterRequest) ProtoMessage() {}
+
+func (x *CounterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CounterRequest.ProtoReflect.Descriptor instead.
+func (*CounterRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CounterRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *CounterRequest) GetDelta() int64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+func (x *CounterRequest) GetInitialValue() int64 {
+	if x != nil {
+		return x.InitialValue
+	}
+	return 0
+}
+
+func (x *CounterRequest) GetMin() int64 {
+	if x != nil && x.Min != nil {
+		return *x.Min
+	}
+	return 0
+}
+
+func (x *CounterRequest) GetMax() int64 {
+	if x != nil && x.Max != nil {
+		return *x.Max
+	}
+	return 0
+}
+
+func (x *CounterRequest) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *CounterRequest) GetSaturate() bool {
+	if x != nil {
+		return x.Saturate
+	}
+	return false
+}
+
+func (x *CounterRequest) GetRequireExisting() bool {
+	if x != nil {
+		return x.RequireExisting
+	}
+	return false
+}
+
+type CounterResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	Value         int64                  `protobuf:"varint,3,opt,name=value,proto3" json:"value,omitempty"`
+	SessionToken  string                 `protobuf:"bytes,4,opt,name=sessionToken,proto3" json:"sessionToken,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CounterResponse) Reset() {
+	*x = CounterResponse{}
+	mi := &file_kv_kv_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CounterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CounterResponse) ProtoMessage() {}
+
+func (x *CounterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CounterResponse.ProtoReflect.Descriptor instead.
+func (*CounterResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CounterResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *CounterResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *CounterResponse) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *CounterResponse) GetSessionToken() string {
+	if x != nil {
+		return x.SessionToken
+	}
+	return ""
+}
+
+// Collection operations act on one field or member of a typed key. A key is
+// created by its first write and deleted once its last element is removed;
+// operations against a key of another type fail with statusCode 409.
+type CollectionResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Message    string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	// elements added, removed or, for list pushes, the new length
+	Count         int64             `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	Values        []string          `protobuf:"bytes,4,rep,name=values,proto3" json:"values,omitempty"`
+	Fields        map[string]string `protobuf:"bytes,5,rep,name=fields,proto3" json:"fields,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Members       []*ScoredMember   `protobuf:"bytes,6,rep,name=members,proto3" json:"members,omitempty"`
+	SessionToken  string            `protobuf:"bytes,7,opt,name=sessionToken,proto3" json:"sessionToken,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CollectionResponse) Reset() {
+	*x = CollectionResponse{}
+	mi := &file_kv_kv_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CollectionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectionResponse) ProtoMessage() {}
+
+func (x *CollectionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectionResponse.ProtoReflect.Descriptor instead.
+func (*CollectionResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *CollectionResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *CollectionResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *CollectionResponse) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *CollectionResponse) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+func (x *CollectionResponse) GetFields() map[string]string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+func (x *CollectionResponse) GetMembers() []*ScoredMember {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+func (x *CollectionResponse) GetSessionToken() string {
+	if x != nil {
+		return x.SessionToken
+	}
+	return ""
+}
+
+type CollectionKeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CollectionKeyRequest) Reset() {
+	*x = CollectionKeyRequest{}
+	mi := &file_kv_kv_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CollectionKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectionKeyRequest) ProtoMessage() {}
+
+func (x *CollectionKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectionKeyRequest.ProtoReflect.Descriptor instead.
+func (*CollectionKeyRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *CollectionKeyRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type HashSetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Fields        map[string]string      `protobuf:"bytes,2,rep,name=fields,proto3" json:"fields,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HashSetRequest) Reset() {
+	*x = HashSetRequest{}
+	mi := &file_kv_kv_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HashSetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashSetRequest) ProtoMessage() {}
+
+func (x *HashSetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashSetRequest.ProtoReflect.Descriptor instead.
+func (*HashSetRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *HashSetRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *HashSetRequest) GetFields() map[string]string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+type HashGetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Field         string                 `protobuf:"bytes,2,opt,name=field,proto3" json:"field,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HashGetRequest) Reset() {
+	*x = HashGetRequest{}
+	mi := &file_kv_kv_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HashGetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashGetRequest) ProtoMessage() {}
+
+func (x *HashGetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashGetRequest.ProtoReflect.Descriptor instead.
+func (*HashGetRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *HashGetRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *HashGetRequest) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+type HashDeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Fields        []string               `protobuf:"bytes,2,rep,name=fields,proto3" json:"fields,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HashDeleteRequest) Reset() {
+	*x = HashDeleteRequest{}
+	mi := &file_kv_kv_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HashDeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashDeleteRequest) ProtoMessage() {}
+
+func (x *HashDeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashDeleteRequest.ProtoReflect.Descriptor instead.
+func (*HashDeleteRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *HashDeleteRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *HashDeleteRequest) GetFields() []string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+type ListPushRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Key    string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Values []string               `protobuf:"bytes,2,rep,name=values,proto3" json:"values,omitempty"`
+	// push to the tail instead of the head
+	Tail          bool `protobuf:"varint,3,opt,name=tail,proto3" json:"tail,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPushRequest) Reset() {
+	*x = ListPushRequest{}
+	mi := &file_kv_kv_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPushRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPushRequest) ProtoMessage() {}
+
+func (x *ListPushRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPushRequest.ProtoReflect.Descriptor instead.
+func (*ListPushRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ListPushRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ListPushRequest) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+func (x *ListPushRequest) GetTail() bool {
+	if x != nil {
+		return x.Tail
+	}
+	return false
+}
+
+type ListPopRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// defaults to 1
+	Count         int64 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Tail          bool  `protobuf:"varint,3,opt,name=tail,proto3" json:"tail,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPopRequest) Reset() {
+	*x = ListPopRequest{}
+	mi := &file_kv_kv_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPopRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPopRequest) ProtoMessage() {}
+
+func (x *ListPopRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPopRequest.ProtoReflect.Descriptor instead.
+func (*ListPopRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ListPopRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ListPopRequest) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *ListPopRequest) GetTail() bool {
+	if x != nil {
+		return x.Tail
+	}
+	return false
+}
+
+// start and stop are inclusive; negative indexes count back from the tail
+type ListRangeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Start         int64                  `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
+	Stop          int64                  `protobuf:"varint,3,opt,name=stop,proto3" json:"stop,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRangeRequest) Reset() {
+	*x = ListRangeRequest{}
+	mi := &file_kv_kv_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRangeRequest) ProtoMessage() {}
+
+func (x *ListRangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRangeRequest.ProtoReflect.Descriptor instead.
+func (*ListRangeRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ListRangeRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ListRangeRequest) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *ListRangeRequest) GetStop() int64 {
+	if x != nil {
+		return x.Stop
+	}
+	return 0
+}
+
+type SetMembersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Members       []string               `protobuf:"bytes,2,rep,name=members,proto3" json:"members,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetMembersRequest) Reset() {
+	*x = SetMembersRequest{}
+	mi := &file_kv_kv_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetMembersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetMembersRequest) ProtoMessage() {}
+
+func (x *SetMembersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetMembersRequest.ProtoReflect.Descriptor instead.
+func (*SetMembersRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *SetMembersRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SetMembersRequest) GetMembers() []string {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type ScoredMember struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Member        string                 `protobuf:"bytes,1,opt,name=member,proto3" json:"member,omitempty"`
+	Score         float64                `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScoredMember) Reset() {
+	*x = ScoredMember{}
+	mi := &file_kv_kv_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScoredMember) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScoredMember) ProtoMessage() {}
+
+func (x *ScoredMember) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScoredMember.ProtoReflect.Descriptor instead.
+func (*ScoredMember) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ScoredMember) GetMember() string {
+	if x != nil {
+		return x.Member
+	}
+	return ""
+}
+
+func (x *ScoredMember) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+type SortedSetAddRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Members       []*ScoredMember        `protobuf:"bytes,2,rep,name=members,proto3" json:"members,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SortedSetAddRequest) Reset() {
+	*x = SortedSetAddRequest{}
+	mi := &file_kv_kv_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SortedSetAddRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SortedSetAddRequest) ProtoMessage() {}
+
+func (x *SortedSetAddRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SortedSetAddRequest.ProtoReflect.Descriptor instead.
+func (*SortedSetAddRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *SortedSetAddRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SortedSetAddRequest) GetMembers() []*ScoredMember {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+// an unset min or max leaves that end of the range open; limit 0 means no limit
+type SortedSetRangeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Min           *float64               `protobuf:"fixed64,2,opt,name=min,proto3,oneof" json:"min,omitempty"`
+	Max           *float64               `protobuf:"fixed64,3,opt,name=max,proto3,oneof" json:"max,omitempty"`
+	Offset        int64                  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	Limit         int64                  `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SortedSetRangeRequest) Reset() {
+	*x = SortedSetRangeRequest{}
+	mi := &file_kv_kv_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SortedSetRangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SortedSetRangeRequest) ProtoMessage() {}
+
+func (x *SortedSetRangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SortedSetRangeRequest.ProtoReflect.Descriptor instead.
+func (*SortedSetRangeRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *SortedSetRangeRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SortedSetRangeRequest) GetMin() float64 {
+	if x != nil && x.Min != nil {
+		return *x.Min
+	}
+	return 0
+}
+
+func (x *SortedSetRangeRequest) GetMax() float64 {
+	if x != nil && x.Max != nil {
+		return *x.Max
+	}
+	return 0
+}
+
+func (x *SortedSetRangeRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *SortedSetRangeRequest) GetLimit() int64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type InvalidateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Origin        string                 `protobuf:"bytes,1,opt,name=origin,proto3" json:"origin,omitempty"`
+	Epoch         int64                  `protobuf:"varint,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Sequence      uint64                 `protobuf:"varint,3,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Key           string                 `protobuf:"bytes,4,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InvalidateRequest) Reset() {
+	*x = InvalidateRequest{}
+	mi := &file_kv_kv_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InvalidateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InvalidateRequest) ProtoMessage() {}
+
+func (x *InvalidateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InvalidateRequest.ProtoReflect.Descriptor instead.
+func (*InvalidateRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *InvalidateRequest) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+func (x *InvalidateRequest) GetEpoch() int64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *InvalidateRequest) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *InvalidateRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type InvalidateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InvalidateResponse) Reset() {
+	*x = InvalidateResponse{}
+	mi := &file_kv_kv_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InvalidateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InvalidateResponse) ProtoMessage() {}
+
+func (x *InvalidateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InvalidateResponse.ProtoReflect.Descriptor instead.
+func (*InvalidateResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *InvalidateResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *InvalidateResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+type Member struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	RaftAddress   string                 `protobuf:"bytes,2,opt,name=raftAddress,proto3" json:"raftAddress,omitempty"`
+	GrpcAddress   string                 `protobuf:"bytes,3,opt,name=grpcAddress,proto3" json:"grpcAddress,omitempty"`
+	Voter         bool                   `protobuf:"varint,4,opt,name=voter,proto3" json:"voter,omitempty"`
+	Leader        bool                   `protobuf:"varint,5,opt,name=leader,proto3" json:"leader,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Member) Reset() {
+	*x = Member{}
+	mi := &file_kv_kv_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Member) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Member) ProtoMessage() {}
+
+func (x *Member) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Member.ProtoReflect.Descriptor instead.
+func (*Member) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *Member) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *Member) GetRaftAddress() string {
+	if x != nil {
+		return x.RaftAddress
+	}
+	return ""
+}
+
+func (x *Member) GetGrpcAddress() string {
+	if x != nil {
+		return x.GrpcAddress
+	}
+	return ""
+}
+
+func (x *Member) GetVoter() bool {
+	if x != nil {
+		return x.Voter
+	}
+	return false
+}
+
+func (x *Member) GetLeader() bool {
+	if x != nil {
+		return x.Leader
+	}
+	return false
+}
+
+type AddMemberRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	RaftAddress   string                 `protobuf:"bytes,2,opt,name=raftAddress,proto3" json:"raftAddress,omitempty"`
+	GrpcAddress   string                 `protobuf:"bytes,3,opt,name=grpcAddress,proto3" json:"grpcAddress,omitempty"`
+	NonVoter      bool                   `protobuf:"varint,4,opt,name=nonVoter,proto3" json:"nonVoter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddMemberRequest) Reset() {
+	*x = AddMemberRequest{}
+	mi := &file_kv_kv_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddMemberRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddMemberRequest) ProtoMessage() {}
+
+func (x *AddMemberRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddMemberRequest.ProtoReflect.Descriptor instead.
+func (*AddMemberRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *AddMemberRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *AddMemberRequest) GetRaftAddress() string {
+	if x != nil {
+		return x.RaftAddress
+	}
+	return ""
+}
+
+func (x *AddMemberRequest) GetGrpcAddress() string {
+	if x != nil {
+		return x.GrpcAddress
+	}
+	return ""
+}
+
+func (x *AddMemberRequest) GetNonVoter() bool {
+	if x != nil {
+		return x.NonVoter
+	}
+	return false
+}
+
+type AddMemberResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddMemberResponse) Reset() {
+	*x = AddMemberResponse{}
+	mi := &file_kv_kv_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddMemberResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddMemberResponse) ProtoMessage() {}
+
+func (x *AddMemberResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddMemberResponse.ProtoReflect.Descriptor instead.
+func (*AddMemberResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *AddMemberResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *AddMemberResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+type RemoveMemberRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveMemberRequest) Reset() {
+	*x = RemoveMemberRequest{}
+	mi := &file_kv_kv_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveMemberRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveMemberRequest) ProtoMessage() {}
+
+func (x *RemoveMemberRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveMemberRequest.ProtoReflect.Descriptor instead.
+func (*RemoveMemberRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *RemoveMemberRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+type RemoveMemberResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveMemberResponse) Reset() {
+	*x = RemoveMemberResponse{}
+	mi := &file_kv_kv_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveMemberResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveMemberResponse) ProtoMessage() {}
+
+func (x *RemoveMemberResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveMemberResponse.ProtoReflect.Descriptor instead.
+func (*RemoveMemberResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *RemoveMemberResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *RemoveMemberResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+type ListMembersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMembersRequest) Reset() {
+	*x = ListMembersRequest{}
+	mi := &file_kv_kv_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMembersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMembersRequest) ProtoMessage() {}
+
+func (x *ListMembersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMembersRequest.ProtoReflect.Descriptor instead.
+func (*ListMembersRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{36}
+}
+
+type ListMembersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	Members       []*Member              `protobuf:"bytes,3,rep,name=members,proto3" json:"members,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMembersResponse) Reset() {
+	*x = ListMembersResponse{}
+	mi := &file_kv_kv_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMembersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMembersResponse) ProtoMessage() {}
+
+func (x *ListMembersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMembersResponse.ProtoReflect.Descriptor instead.
+func (*ListMembersResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ListMembersResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ListMembersResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *ListMembersResponse) GetMembers() []*Member {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type ReadIndexRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadIndexRequest) Reset() {
+	*x = ReadIndexRequest{}
+	mi := &file_kv_kv_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadIndexRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadIndexRequest) ProtoMessage() {}
+
+func (x *ReadIndexRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadIndexRequest.ProtoReflect.Descriptor instead.
+func (*ReadIndexRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{38}
+}
+
+type ReadIndexResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	Index         uint64                 `protobuf:"varint,3,opt,name=index,proto3" json:"index,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadIndexResponse) Reset() {
+	*x = ReadIndexResponse{}
+	mi := &file_kv_kv_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadIndexResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadIndexResponse) ProtoMessage() {}
+
+func (x *ReadIndexResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadIndexResponse.ProtoReflect.Descriptor instead.
+func (*ReadIndexResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *ReadIndexResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ReadIndexResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *ReadIndexResponse) GetIndex() uint64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+type ShardNode struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	Address       string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	Tokens        []uint64               `protobuf:"varint,3,rep,packed,name=tokens,proto3" json:"tokens,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShardNode) Reset() {
+	*x = ShardNode{}
+	mi := &file_kv_kv_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShardNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShardNode) ProtoMessage() {}
+
+func (x *ShardNode) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShardNode.ProtoReflect.Descriptor instead.
+func (*ShardNode) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ShardNode) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *ShardNode) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *ShardNode) GetTokens() []uint64 {
+	if x != nil {
+		return x.Tokens
+	}
+	return nil
+}
+
+type GetRingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRingRequest) Reset() {
+	*x = GetRingRequest{}
+	mi := &file_kv_kv_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRingRequest) ProtoMessage() {}
+
+func (x *GetRingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRingRequest.ProtoReflect.Descriptor instead.
+func (*GetRingRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{41}
+}
+
+type GetRingResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	Version       uint64                 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	VirtualNodes  int32                  `protobuf:"varint,4,opt,name=virtualNodes,proto3" json:"virtualNodes,omitempty"`
+	Hash          string                 `protobuf:"bytes,5,opt,name=hash,proto3" json:"hash,omitempty"`
+	Nodes         []*ShardNode           `protobuf:"bytes,6,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRingResponse) Reset() {
+	*x = GetRingResponse{}
+	mi := &file_kv_kv_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRingResponse) ProtoMessage() {}
+
+func (x *GetRingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRingResponse.ProtoReflect.Descriptor instead.
+func (*GetRingResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *GetRingResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *GetRingResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *GetRingResponse) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *GetRingResponse) GetVirtualNodes() int32 {
+	if x != nil {
+		return x.VirtualNodes
+	}
+	return 0
+}
+
+func (x *GetRingResponse) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *GetRingResponse) GetNodes() []*ShardNode {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+type AddShardNodeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	Address       string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddShardNodeRequest) Reset() {
+	*x = AddShardNodeRequest{}
+	mi := &file_kv_kv_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddShardNodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddShardNodeRequest) ProtoMessage() {}
+
+func (x *AddShardNodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddShardNodeRequest.ProtoReflect.Descriptor instead.
+func (*AddShardNodeRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *AddShardNodeRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *AddShardNodeRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+type AddShardNodeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	Version       uint64                 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddShardNodeResponse) Reset() {
+	*x = AddShardNodeResponse{}
+	mi := &file_kv_kv_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddShardNodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddShardNodeResponse) ProtoMessage() {}
+
+func (x *AddShardNodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddShardNodeResponse.ProtoReflect.Descriptor instead.
+func (*AddShardNodeResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *AddShardNodeResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *AddShardNodeResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *AddShardNodeResponse) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type RemoveShardNodeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveShardNodeRequest) Reset() {
+	*x = RemoveShardNodeRequest{}
+	mi := &file_kv_kv_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveShardNodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveShardNodeRequest) ProtoMessage() {}
+
+func (x *RemoveShardNodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveShardNodeRequest.ProtoReflect.Descriptor instead.
+func (*RemoveShardNodeRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *RemoveShardNodeRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+type RemoveShardNodeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	Version       uint64                 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveShardNodeResponse) Reset() {
+	*x = RemoveShardNodeResponse{}
+	mi := &file_kv_kv_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveShardNodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveShardNodeResponse) ProtoMessage() {}
+
+func (x *RemoveShardNodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveShardNodeResponse.ProtoReflect.Descriptor instead.
+func (*RemoveShardNodeResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *RemoveShardNodeResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *RemoveShardNodeResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *RemoveShardNodeResponse) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type UpdateRingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       uint64                 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Nodes         []*ShardNode           `protobuf:"bytes,2,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRingRequest) Reset() {
+	*x = UpdateRingRequest{}
+	mi := &file_kv_kv_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRingRequest) ProtoMessage() {}
+
+func (x *UpdateRingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRingRequest.ProtoReflect.Descriptor instead.
+func (*UpdateRingRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *UpdateRingRequest) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *UpdateRingRequest) GetNodes() []*ShardNode {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+type UpdateRingResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRingResponse) Reset() {
+	*x = UpdateRingResponse{}
+	mi := &file_kv_kv_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRingResponse) ProtoMessage() {}
+
+func (x *UpdateRingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRingResponse.ProtoReflect.Descriptor instead.
+func (*UpdateRingResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *UpdateRingResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *UpdateRingResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+type ReadChangesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// changes are delivered from afterSequence + 1; 0 resumes from the consumer's checkpoint
+	AfterSequence uint64 `protobuf:"varint,1,opt,name=afterSequence,proto3" json:"afterSequence,omitempty"`
+	ConsumerId    string `protobuf:"bytes,2,opt,name=consumerId,proto3" json:"consumerId,omitempty"`
+	// keep the stream open and deliver new changes as they commit
+	Follow bool `protobuf:"varint,3,opt,name=follow,proto3" json:"follow,omitempty"`
+	// only deliver changes to keys starting with prefix
+	Prefix string `protobuf:"bytes,4,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// without an afterSequence, start after the newest change instead of the consumer's checkpoint
+	FromNow       bool `protobuf:"varint,5,opt,name=fromNow,proto3" json:"fromNow,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadChangesRequest) Reset() {
+	*x = ReadChangesRequest{}
+	mi := &file_kv_kv_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadChangesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadChangesRequest) ProtoMessage() {}
+
+func (x *ReadChangesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadChangesRequest.ProtoReflect.Descriptor instead.
+func (*ReadChangesRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *ReadChangesRequest) GetAfterSequence() uint64 {
+	if x != nil {
+		return x.AfterSequence
+	}
+	return 0
+}
+
+func (x *ReadChangesRequest) GetConsumerId() string {
+	if x != nil {
+		return x.ConsumerId
+	}
+	return ""
+}
+
+func (x *ReadChangesRequest) GetFollow() bool {
+	if x != nil {
+		return x.Follow
+	}
+	return false
+}
+
+func (x *ReadChangesRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *ReadChangesRequest) GetFromNow() bool {
+	if x != nil {
+		return x.FromNow
+	}
+	return false
+}
+
+type ChangeEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sequence      uint64                 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Op            string                 `protobuf:"bytes,2,opt,name=op,proto3" json:"op,omitempty"`
+	Key           string                 `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangeEvent) Reset() {
+	*x = ChangeEvent{}
+	mi := &file_kv_kv_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangeEvent) ProtoMessage() {}
+
+func (x *ChangeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangeEvent.ProtoReflect.Descriptor instead.
+func (*ChangeEvent) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *ChangeEvent) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *ChangeEvent) GetOp() string {
+	if x != nil {
+		return x.Op
+	}
+	return ""
+}
+
+func (x *ChangeEvent) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ChangeEvent) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *ChangeEvent) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type CommitCheckpointRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ConsumerId    string                 `protobuf:"bytes,1,opt,name=consumerId,proto3" json:"consumerId,omitempty"`
+	Sequence      uint64                 `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitCheckpointRequest) Reset() {
+	*x = CommitCheckpointRequest{}
+	mi := &file_kv_kv_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitCheckpointRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitCheckpointRequest) ProtoMessage() {}
+
+func (x *CommitCheckpointRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitCheckpointRequest.ProtoReflect.Descriptor instead.
+func (*CommitCheckpointRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *CommitCheckpointRequest) GetConsumerId() string {
+	if x != nil {
+		return x.ConsumerId
+	}
+	return ""
+}
+
+func (x *CommitCheckpointRequest) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+type CommitCheckpointResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitCheckpointResponse) Reset() {
+	*x = CommitCheckpointResponse{}
+	mi := &file_kv_kv_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitCheckpointResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitCheckpointResponse) ProtoMessage() {}
+
+func (x *CommitCheckpointResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitCheckpointResponse.ProtoReflect.Descriptor instead.
+func (*CommitCheckpointResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *CommitCheckpointResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *CommitCheckpointResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+type GrantLeaseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TtlSeconds    int64                  `protobuf:"varint,1,opt,name=ttlSeconds,proto3" json:"ttlSeconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GrantLeaseRequest) Reset() {
+	*x = GrantLeaseRequest{}
+	mi := &file_kv_kv_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GrantLeaseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GrantLeaseRequest) ProtoMessage() {}
+
+func (x *GrantLeaseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GrantLeaseRequest.ProtoReflect.Descriptor instead.
+func (*GrantLeaseRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *GrantLeaseRequest) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+type GrantLeaseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	LeaseId       uint64                 `protobuf:"varint,3,opt,name=leaseId,proto3" json:"leaseId,omitempty"`
+	TtlSeconds    int64                  `protobuf:"varint,4,opt,name=ttlSeconds,proto3" json:"ttlSeconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GrantLeaseResponse) Reset() {
+	*x = GrantLeaseResponse{}
+	mi := &file_kv_kv_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GrantLeaseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GrantLeaseResponse) ProtoMessage() {}
+
+func (x *GrantLeaseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GrantLeaseResponse.ProtoReflect.Descriptor instead.
+func (*GrantLeaseResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *GrantLeaseResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *GrantLeaseResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *GrantLeaseResponse) GetLeaseId() uint64 {
+	if x != nil {
+		return x.LeaseId
+	}
+	return 0
+}
+
+func (x *GrantLeaseResponse) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+type KeepAliveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LeaseId       uint64                 `protobuf:"varint,1,opt,name=leaseId,proto3" json:"leaseId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeepAliveRequest) Reset() {
+	*x = KeepAliveRequest{}
+	mi := &file_kv_kv_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeepAliveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeepAliveRequest) ProtoMessage() {}
+
+func (x *KeepAliveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeepAliveRequest.ProtoReflect.Descriptor instead.
+func (*KeepAliveRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *KeepAliveRequest) GetLeaseId() uint64 {
+	if x != nil {
+		return x.LeaseId
+	}
+	return 0
+}
+
+type KeepAliveResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	LeaseId       uint64                 `protobuf:"varint,3,opt,name=leaseId,proto3" json:"leaseId,omitempty"`
+	TtlSeconds    int64                  `protobuf:"varint,4,opt,name=ttlSeconds,proto3" json:"ttlSeconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeepAliveResponse) Reset() {
+	*x = KeepAliveResponse{}
+	mi := &file_kv_kv_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeepAliveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeepAliveResponse) ProtoMessage() {}
+
+func (x *KeepAliveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeepAliveResponse.ProtoReflect.Descriptor instead.
+func (*KeepAliveResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *KeepAliveResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *KeepAliveResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *KeepAliveResponse) GetLeaseId() uint64 {
+	if x != nil {
+		return x.LeaseId
+	}
+	return 0
+}
+
+func (x *KeepAliveResponse) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+type RevokeLeaseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LeaseId       uint64                 `protobuf:"varint,1,opt,name=leaseId,proto3" json:"leaseId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeLeaseRequest) Reset() {
+	*x = RevokeLeaseRequest{}
+	mi := &file_kv_kv_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeLeaseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeLeaseRequest) ProtoMessage() {}
+
+func (x *RevokeLeaseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeLeaseRequest.ProtoReflect.Descriptor instead.
+func (*RevokeLeaseRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *RevokeLeaseRequest) GetLeaseId() uint64 {
+	if x != nil {
+		return x.LeaseId
+	}
+	return 0
+}
+
+type RevokeLeaseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	DeletedKeys   int64                  `protobuf:"varint,3,opt,name=deletedKeys,proto3" json:"deletedKeys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeLeaseResponse) Reset() {
+	*x = RevokeLeaseResponse{}
+	mi := &file_kv_kv_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeLeaseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeLeaseResponse) ProtoMessage() {}
+
+func (x *RevokeLeaseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[58]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeLeaseResponse.ProtoReflect.Descriptor instead.
+func (*RevokeLeaseResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *RevokeLeaseResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *RevokeLeaseResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *RevokeLeaseResponse) GetDeletedKeys() int64 {
+	if x != nil {
+		return x.DeletedKeys
+	}
+	return 0
+}
+
+type LockRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	LeaseId       uint64                 `protobuf:"varint,2,opt,name=leaseId,proto3" json:"leaseId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LockRequest) Reset() {
+	*x = LockRequest{}
+	mi := &file_kv_kv_proto_msgTypes[59]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockRequest) ProtoMessage() {}
+
+func (x *LockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[59]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockRequest.ProtoReflect.Descriptor instead.
+func (*LockRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{59}
+}
+
+func (x *LockRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *LockRequest) GetLeaseId() uint64 {
+	if x != nil {
+		return x.LeaseId
+	}
+	return 0
+}
+
+type LockResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	FencingToken  uint64                 `protobuf:"varint,3,opt,name=fencingToken,proto3" json:"fencingToken,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LockResponse) Reset() {
+	*x = LockResponse{}
+	mi := &file_kv_kv_proto_msgTypes[60]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LockResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockResponse) ProtoMessage() {}
+
+func (x *LockResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[60]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockResponse.ProtoReflect.Descriptor instead.
+func (*LockResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{60}
+}
+
+func (x *LockResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *LockResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *LockResponse) GetFencingToken() uint64 {
+	if x != nil {
+		return x.FencingToken
+	}
+	return 0
+}
+
+type UnlockRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	FencingToken  uint64                 `protobuf:"varint,2,opt,name=fencingToken,proto3" json:"fencingToken,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnlockRequest) Reset() {
+	*x = UnlockRequest{}
+	mi := &file_kv_kv_proto_msgTypes[61]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnlockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnlockRequest) ProtoMessage() {}
+
+func (x *UnlockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[61]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnlockRequest.ProtoReflect.Descriptor instead.
+func (*UnlockRequest) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{61}
+}
+
+func (x *UnlockRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UnlockRequest) GetFencingToken() uint64 {
+	if x != nil {
+		return x.FencingToken
+	}
+	return 0
+}
+
+type UnlockResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	StatusCode    int64                  `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnlockResponse) Reset() {
+	*x = UnlockResponse{}
+	mi := &file_kv_kv_proto_msgTypes[62]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnlockResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnlockResponse) ProtoMessage() {}
+
+func (x *UnlockResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kv_kv_proto_msgTypes[62]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnlockResponse.ProtoReflect.Descriptor instead.
+func (*UnlockResponse) Descriptor() ([]byte, []int) {
+	return file_kv_kv_proto_rawDescGZIP(), []int{62}
+}
+
+func (x *UnlockResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *UnlockResponse) GetStatusCode() int64 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+var File_kv_kv_proto protoreflect.FileDescriptor
+
+const file_kv_kv_proto_rawDesc = "" +
+	"\n" +
+	"\vkv/kv.proto\x12\x02kv\x1a\x1cgoogle/api/annotations.proto\"f\n" +
+	"\fGetKVRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12 \n" +
+	"\vconsistency\x18\x02 \x01(\tR\vconsistency\x12\"\n" +
+	"\fsessionToken\x18\x03 \x01(\tR\fsessionToken\"\x8f\x01\n" +
+	"\rGetKVResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x04R\aversion\x12\x14\n" +
+	"\x05flags\x18\x05 \x01(\rR\x05flags\"\xd4\x01\n" +
+	"\x12SetKeyValueRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x18\n" +
+	"\aleaseId\x18\x03 \x01(\x04R\aleaseId\x12\x12\n" +
+	"\x04mode\x18\x04 \x01(\tR\x04mode\x12(\n" +
+	"\x0fttlMilliseconds\x18\x05 \x01(\x03R\x0fttlMilliseconds\x12\x14\n" +
+	"\x05flags\x18\x06 \x01(\rR\x05flags\x12(\n" +
+	"\x0fexpectedVersion\x18\a \x01(\x04R\x0fexpectedVersion\"\x8d\x01\n" +
 	"\x13SetKeyValueResponse\x12\x18\n" +
 	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
 	"\n" +
 	"statusCode\x18\x02 \x01(\x03R\n" +
-	"statusCode\")\n" +
+	"statusCode\x12\"\n" +
+	"\fsessionToken\x18\x03 \x01(\tR\fsessionToken\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x04R\aversion\"f\n" +
+	"\x0eBulkSetRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x14\n" +
+	"\x05flags\x18\x03 \x01(\rR\x05flags\x12\x16\n" +
+	"\x06policy\x18\x04 \x01(\tR\x06policy\"r\n" +
+	"\x0eBulkSetFailure\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x03R\x05index\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x03 \x01(\x03R\n" +
+	"statusCode\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\"\x85\x02\n" +
+	"\x0fBulkSetResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\x12\"\n" +
+	"\fsessionToken\x18\x03 \x01(\tR\fsessionToken\x12\x18\n" +
+	"\acreated\x18\x04 \x01(\x03R\acreated\x12\x18\n" +
+	"\aupdated\x18\x05 \x01(\x03R\aupdated\x12\x18\n" +
+	"\askipped\x18\x06 \x01(\x03R\askipped\x12\x16\n" +
+	"\x06failed\x18\a \x01(\x03R\x06failed\x12.\n" +
+	"\bfailures\x18\b \x03(\v2\x12.kv.BulkSetFailureR\bfailures\"S\n" +
 	"\x15DeleteKeyValueRequest\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\"R\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12(\n" +
+	"\x0fexpectedVersion\x18\x02 \x01(\x04R\x0fexpectedVersion\"v\n" +
 	"\x16DeleteKeyValueResponse\x12\x18\n" +
 	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
 	"\n" +
 	"statusCode\x18\x02 \x01(\x03R\n" +
-	"statusCode2\x8e\x02\n" +
+	"statusCode\x12\"\n" +
+	"\fsessionToken\x18\x03 \x01(\tR\fsessionToken\"e\n" +
+	"\rExpireRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12(\n" +
+	"\x0fttlMilliseconds\x18\x02 \x01(\x03R\x0fttlMilliseconds\x12\x18\n" +
+	"\apersist\x18\x03 \x01(\bR\apersist\"n\n" +
+	"\x0eExpireResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\x12\"\n" +
+	"\fsessionToken\x18\x03 \x01(\tR\fsessionToken\"\x1e\n" +
+	"\n" +
+	"TTLRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"q\n" +
+	"\vTTLResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\x12(\n" +
+	"\x0fttlMilliseconds\x18\x03 \x01(\x03R\x0fttlMilliseconds\"Q\n" +
+	"\vScanRequest\x12\x16\n" +
+	"\x06cursor\x18\x01 \x01(\x04R\x06cursor\x12\x14\n" +
+	"\x05match\x18\x02 \x01(\tR\x05match\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x03R\x05count\"t\n" +
+	"\fScanResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\x12\x12\n" +
+	"\x04keys\x18\x03 \x03(\tR\x04keys\x12\x16\n" +
+	"\x06cursor\x18\x04 \x01(\x04R\x06cursor\"\x80\x02\n" +
+	"\x0eCounterRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05delta\x18\x02 \x01(\x03R\x05delta\x12\"\n" +
+	"\finitialValue\x18\x03 \x01(\x03R\finitialValue\x12\x15\n" +
+	"\x03min\x18\x04 \x01(\x03H\x00R\x03min\x88\x01\x01\x12\x15\n" +
+	"\x03max\x18\x05 \x01(\x03H\x01R\x03max\x88\x01\x01\x12\x1e\n" +
+	"\n" +
+	"ttlSeconds\x18\x06 \x01(\x03R\n" +
+	"ttlSeconds\x12\x1a\n" +
+	"\bsaturate\x18\a \x01(\bR\bsaturate\x12(\n" +
+	"\x0frequireExisting\x18\b \x01(\bR\x0frequireExistingB\x06\n" +
+	"\x04_minB\x06\n" +
+	"\x04_max\"\x85\x01\n" +
+	"\x0fCounterResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\x03R\x05value\x12\"\n" +
+	"\fsessionToken\x18\x04 \x01(\tR\fsessionToken\"\xc3\x02\n" +
+	"\x12CollectionResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x03R\x05count\x12\x16\n" +
+	"\x06values\x18\x04 \x03(\tR\x06values\x12:\n" +
+	"\x06fields\x18\x05 \x03(\v2\".kv.CollectionResponse.FieldsEntryR\x06fields\x12*\n" +
+	"\amembers\x18\x06 \x03(\v2\x10.kv.ScoredMemberR\amembers\x12\"\n" +
+	"\fsessionToken\x18\a \x01(\tR\fsessionToken\x1a9\n" +
+	"\vFieldsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"(\n" +
+	"\x14CollectionKeyRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"\x95\x01\n" +
+	"\x0eHashSetRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x126\n" +
+	"\x06fields\x18\x02 \x03(\v2\x1e.kv.HashSetRequest.FieldsEntryR\x06fields\x1a9\n" +
+	"\vFieldsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"8\n" +
+	"\x0eHashGetRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05field\x18\x02 \x01(\tR\x05field\"=\n" +
+	"\x11HashDeleteRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
+	"\x06fields\x18\x02 \x03(\tR\x06fields\"O\n" +
+	"\x0fListPushRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
+	"\x06values\x18\x02 \x03(\tR\x06values\x12\x12\n" +
+	"\x04tail\x18\x03 \x01(\bR\x04tail\"L\n" +
+	"\x0eListPopRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\x12\x12\n" +
+	"\x04tail\x18\x03 \x01(\bR\x04tail\"N\n" +
+	"\x10ListRangeRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05start\x18\x02 \x01(\x03R\x05start\x12\x12\n" +
+	"\x04stop\x18\x03 \x01(\x03R\x04stop\"?\n" +
+	"\x11SetMembersRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x18\n" +
+	"\amembers\x18\x02 \x03(\tR\amembers\"<\n" +
+	"\fScoredMember\x12\x16\n" +
+	"\x06member\x18\x01 \x01(\tR\x06member\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x01R\x05score\"S\n" +
+	"\x13SortedSetAddRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12*\n" +
+	"\amembers\x18\x02 \x03(\v2\x10.kv.ScoredMemberR\amembers\"\x95\x01\n" +
+	"\x15SortedSetRangeRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x15\n" +
+	"\x03min\x18\x02 \x01(\x01H\x00R\x03min\x88\x01\x01\x12\x15\n" +
+	"\x03max\x18\x03 \x01(\x01H\x01R\x03max\x88\x01\x01\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x03R\x06offset\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\x03R\x05limitB\x06\n" +
+	"\x04_minB\x06\n" +
+	"\x04_max\"o\n" +
+	"\x11InvalidateRequest\x12\x16\n" +
+	"\x06origin\x18\x01 \x01(\tR\x06origin\x12\x14\n" +
+	"\x05epoch\x18\x02 \x01(\x03R\x05epoch\x12\x1a\n" +
+	"\bsequence\x18\x03 \x01(\x04R\bsequence\x12\x10\n" +
+	"\x03key\x18\x04 \x01(\tR\x03key\"N\n" +
+	"\x12InvalidateResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\"\x92\x01\n" +
+	"\x06Member\x12\x16\n" +
+	"\x06nodeId\x18\x01 \x01(\tR\x06nodeId\x12 \n" +
+	"\vraftAddress\x18\x02 \x01(\tR\vraftAddress\x12 \n" +
+	"\vgrpcAddress\x18\x03 \x01(\tR\vgrpcAddress\x12\x14\n" +
+	"\x05voter\x18\x04 \x01(\bR\x05voter\x12\x16\n" +
+	"\x06leader\x18\x05 \x01(\bR\x06leader\"\x8a\x01\n" +
+	"\x10AddMemberRequest\x12\x16\n" +
+	"\x06nodeId\x18\x01 \x01(\tR\x06nodeId\x12 \n" +
+	"\vraftAddress\x18\x02 \x01(\tR\vraftAddress\x12 \n" +
+	"\vgrpcAddress\x18\x03 \x01(\tR\vgrpcAddress\x12\x1a\n" +
+	"\bnonVoter\x18\x04 \x01(\bR\bnonVoter\"M\n" +
+	"\x11AddMemberResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\"-\n" +
+	"\x13RemoveMemberRequest\x12\x16\n" +
+	"\x06nodeId\x18\x01 \x01(\tR\x06nodeId\"P\n" +
+	"\x14RemoveMemberResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\"\x14\n" +
+	"\x12ListMembersRequest\"u\n" +
+	"\x13ListMembersResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\x12$\n" +
+	"\amembers\x18\x03 \x03(\v2\n" +
+	".kv.MemberR\amembers\"\x12\n" +
+	"\x10ReadIndexRequest\"c\n" +
+	"\x11ReadIndexResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\x12\x14\n" +
+	"\x05index\x18\x03 \x01(\x04R\x05index\"U\n" +
+	"\tShardNode\x12\x16\n" +
+	"\x06nodeId\x18\x01 \x01(\tR\x06nodeId\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x16\n" +
+	"\x06tokens\x18\x03 \x03(\x04R\x06tokens\"\x10\n" +
+	"\x0eGetRingRequest\"\xc2\x01\n" +
+	"\x0fGetRingResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x04R\aversion\x12\"\n" +
+	"\fvirtualNodes\x18\x04 \x01(\x05R\fvirtualNodes\x12\x12\n" +
+	"\x04hash\x18\x05 \x01(\tR\x04hash\x12#\n" +
+	"\x05nodes\x18\x06 \x03(\v2\r.kv.ShardNodeR\x05nodes\"G\n" +
+	"\x13AddShardNodeRequest\x12\x16\n" +
+	"\x06nodeId\x18\x01 \x01(\tR\x06nodeId\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\"j\n" +
+	"\x14AddShardNodeResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x04R\aversion\"0\n" +
+	"\x16RemoveShardNodeRequest\x12\x16\n" +
+	"\x06nodeId\x18\x01 \x01(\tR\x06nodeId\"m\n" +
+	"\x17RemoveShardNodeResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x04R\aversion\"R\n" +
+	"\x11UpdateRingRequest\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x04R\aversion\x12#\n" +
+	"\x05nodes\x18\x02 \x03(\v2\r.kv.ShardNodeR\x05nodes\"N\n" +
+	"\x12UpdateRingResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\"\xa4\x01\n" +
+	"\x12ReadChangesRequest\x12$\n" +
+	"\rafterSequence\x18\x01 \x01(\x04R\rafterSequence\x12\x1e\n" +
+	"\n" +
+	"consumerId\x18\x02 \x01(\tR\n" +
+	"consumerId\x12\x16\n" +
+	"\x06follow\x18\x03 \x01(\bR\x06follow\x12\x16\n" +
+	"\x06prefix\x18\x04 \x01(\tR\x06prefix\x12\x18\n" +
+	"\afromNow\x18\x05 \x01(\bR\afromNow\"\x7f\n" +
+	"\vChangeEvent\x12\x1a\n" +
+	"\bsequence\x18\x01 \x01(\x04R\bsequence\x12\x0e\n" +
+	"\x02op\x18\x02 \x01(\tR\x02op\x12\x10\n" +
+	"\x03key\x18\x03 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x04 \x01(\tR\x05value\x12\x1c\n" +
+	"\ttimestamp\x18\x05 \x01(\x03R\ttimestamp\"U\n" +
+	"\x17CommitCheckpointRequest\x12\x1e\n" +
+	"\n" +
+	"consumerId\x18\x01 \x01(\tR\n" +
+	"consumerId\x12\x1a\n" +
+	"\bsequence\x18\x02 \x01(\x04R\bsequence\"T\n" +
+	"\x18CommitCheckpointResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\"3\n" +
+	"\x11GrantLeaseRequest\x12\x1e\n" +
+	"\n" +
+	"ttlSeconds\x18\x01 \x01(\x03R\n" +
+	"ttlSeconds\"\x88\x01\n" +
+	"\x12GrantLeaseResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\x12\x18\n" +
+	"\aleaseId\x18\x03 \x01(\x04R\aleaseId\x12\x1e\n" +
+	"\n" +
+	"ttlSeconds\x18\x04 \x01(\x03R\n" +
+	"ttlSeconds\",\n" +
+	"\x10KeepAliveRequest\x12\x18\n" +
+	"\aleaseId\x18\x01 \x01(\x04R\aleaseId\"\x87\x01\n" +
+	"\x11KeepAliveResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\x12\x18\n" +
+	"\aleaseId\x18\x03 \x01(\x04R\aleaseId\x12\x1e\n" +
+	"\n" +
+	"ttlSeconds\x18\x04 \x01(\x03R\n" +
+	"ttlSeconds\".\n" +
+	"\x12RevokeLeaseRequest\x12\x18\n" +
+	"\aleaseId\x18\x01 \x01(\x04R\aleaseId\"q\n" +
+	"\x13RevokeLeaseResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\x12 \n" +
+	"\vdeletedKeys\x18\x03 \x01(\x03R\vdeletedKeys\";\n" +
+	"\vLockRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\aleaseId\x18\x02 \x01(\x04R\aleaseId\"l\n" +
+	"\fLockResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode\x12\"\n" +
+	"\ffencingToken\x18\x03 \x01(\x04R\ffencingToken\"G\n" +
+	"\rUnlockRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\"\n" +
+	"\ffencingToken\x18\x02 \x01(\x04R\ffencingToken\"J\n" +
+	"\x0eUnlockResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"statusCode\x18\x02 \x01(\x03R\n" +
+	"statusCode2\xde\x0e\n" +
 	"\rKeyValueStore\x12I\n" +
 	"\vGetKeyValue\x12\x10.kv.GetKVRequest\x1a\x11.kv.GetKVResponse\"\x15\x82\xd3\xe4\x93\x02\x0f\x12\r/api/kv/{key}\x12R\n" +
-	"\vSetKeyValue\x12\x16.kv.SetKeyValueRequest\x1a\x17.kv.SetKeyValueResponse\"\x12\x82\xd3\xe4\x93\x02\f:\x01*\"\a/api/kv\x12^\n" +
-	"\x0eDeleteKeyValue\x12\x19.kv.DeleteKeyValueRequest\x1a\x1a.kv.DeleteKeyValueResponse\"\x15\x82\xd3\xe4\x93\x02\x0f*\r/api/kv/{key}B\fZ\n" +
+	"\vSetKeyValue\x12\x16.kv.SetKeyValueRequest\x1a\x17.kv.SetKeyValueResponse\"\x12\x82\xd3\xe4\x93\x02\f:\x01*\"\a/api/kv\x12M\n" +
+	"\aBulkSet\x12\x12.kv.BulkSetRequest\x1a\x13.kv.BulkSetResponse\"\x17\x82\xd3\xe4\x93\x02\x11:\x01*\"\f/api/kv/bulk(\x01\x12^\n" +
+	"\x0eDeleteKeyValue\x12\x19.kv.DeleteKeyValueRequest\x1a\x1a.kv.DeleteKeyValueResponse\"\x15\x82\xd3\xe4\x93\x02\x0f*\r/api/kv/{key}\x12P\n" +
+	"\x06Expire\x12\x11.kv.ExpireRequest\x1a\x12.kv.ExpireResponse\"\x1f\x82\xd3\xe4\x93\x02\x19:\x01*\"\x14/api/kv/{key}/expire\x12D\n" +
+	"\x06GetTTL\x12\x0e.kv.TTLRequest\x1a\x0f.kv.TTLResponse\"\x19\x82\xd3\xe4\x93\x02\x13\x12\x11/api/kv/{key}/ttl\x12<\n" +
+	"\x04Scan\x12\x0f.kv.ScanRequest\x1a\x10.kv.ScanResponse\"\x11\x82\xd3\xe4\x93\x02\v\x12\t/api/keys\x12X\n" +
+	"\tIncrement\x12\x12.kv.CounterRequest\x1a\x13.kv.CounterResponse\"\"\x82\xd3\xe4\x93\x02\x1c:\x01*\"\x17/api/kv/{key}/increment\x12X\n" +
+	"\tDecrement\x12\x12.kv.CounterRequest\x1a\x13.kv.CounterResponse\"\"\x82\xd3\xe4\x93\x02\x1c:\x01*\"\x17/api/kv/{key}/decrement\x12Q\n" +
+	"\aHashSet\x12\x12.kv.HashSetRequest\x1a\x16.kv.CollectionResponse\"\x1a\x82\xd3\xe4\x93\x02\x14:\x01*\"\x0f/api/hash/{key}\x12V\n" +
+	"\aHashGet\x12\x12.kv.HashGetRequest\x1a\x16.kv.CollectionResponse\"\x1f\x82\xd3\xe4\x93\x02\x19\x12\x17/api/hash/{key}/{field}\x12W\n" +
+	"\n" +
+	"HashGetAll\x12\x18.kv.CollectionKeyRequest\x1a\x16.kv.CollectionResponse\"\x17\x82\xd3\xe4\x93\x02\x11\x12\x0f/api/hash/{key}\x12T\n" +
+	"\n" +
+	"HashDelete\x12\x15.kv.HashDeleteRequest\x1a\x16.kv.CollectionResponse\"\x17\x82\xd3\xe4\x93\x02\x11*\x0f/api/hash/{key}\x12S\n" +
+	"\bListPush\x12\x13.kv.ListPushRequest\x1a\x16.kv.CollectionResponse\"\x1a\x82\xd3\xe4\x93\x02\x14:\x01*\"\x0f/api/list/{key}\x12U\n" +
+	"\aListPop\x12\x12.kv.ListPopRequest\x1a\x16.kv.CollectionResponse\"\x1e\x82\xd3\xe4\x93\x02\x18:\x01*\"\x13/api/list/{key}/pop\x12R\n" +
+	"\tListRange\x12\x14.kv.ListRangeRequest\x1a\x16.kv.CollectionResponse\"\x17\x82\xd3\xe4\x93\x02\x11\x12\x0f/api/list/{key}\x12R\n" +
+	"\x06SetAdd\x12\x15.kv.SetMembersRequest\x1a\x16.kv.CollectionResponse\"\x19\x82\xd3\xe4\x93\x02\x13:\x01*\"\x0e/api/set/{key}\x12R\n" +
+	"\tSetRemove\x12\x15.kv.SetMembersRequest\x1a\x16.kv.CollectionResponse\"\x16\x82\xd3\xe4\x93\x02\x10*\x0e/api/set/{key}\x12V\n" +
+	"\n" +
+	"SetMembers\x12\x18.kv.CollectionKeyRequest\x1a\x16.kv.CollectionResponse\"\x16\x82\xd3\xe4\x93\x02\x10\x12\x0e/api/set/{key}\x12[\n" +
+	"\fSortedSetAdd\x12\x17.kv.SortedSetAddRequest\x1a\x16.kv.CollectionResponse\"\x1a\x82\xd3\xe4\x93\x02\x14:\x01*\"\x0f/api/zset/{key}\x12Y\n" +
+	"\x0fSortedSetRemove\x12\x15.kv.SetMembersRequest\x1a\x16.kv.CollectionResponse\"\x17\x82\xd3\xe4\x93\x02\x11*\x0f/api/zset/{key}\x12c\n" +
+	"\x15SortedSetRangeByScore\x12\x19.kv.SortedSetRangeRequest\x1a\x16.kv.CollectionResponse\"\x17\x82\xd3\xe4\x93\x02\x11\x12\x0f/api/zset/{key}2P\n" +
+	"\x11CacheInvalidation\x12;\n" +
+	"\n" +
+	"Invalidate\x12\x15.kv.InvalidateRequest\x1a\x16.kv.InvalidateResponse2\x85\x02\n" +
+	"\fClusterAdmin\x128\n" +
+	"\tAddMember\x12\x14.kv.AddMemberRequest\x1a\x15.kv.AddMemberResponse\x12A\n" +
+	"\fRemoveMember\x12\x17.kv.RemoveMemberRequest\x1a\x18.kv.RemoveMemberResponse\x12>\n" +
+	"\vListMembers\x12\x16.kv.ListMembersRequest\x1a\x17.kv.ListMembersResponse\x128\n" +
+	"\tReadIndex\x12\x14.kv.ReadIndexRequest\x1a\x15.kv.ReadIndexResponse2\x9e\x02\n" +
+	"\tShardRing\x12E\n" +
+	"\aGetRing\x12\x12.kv.GetRingRequest\x1a\x13.kv.GetRingResponse\"\x11\x82\xd3\xe4\x93\x02\v\x12\t/api/ring\x12A\n" +
+	"\fAddShardNode\x12\x17.kv.AddShardNodeRequest\x1a\x18.kv.AddShardNodeResponse\x12J\n" +
+	"\x0fRemoveShardNode\x12\x1a.kv.RemoveShardNodeRequest\x1a\x1b.kv.RemoveShardNodeResponse\x12;\n" +
+	"\n" +
+	"UpdateRing\x12\x15.kv.UpdateRingRequest\x1a\x16.kv.UpdateRingResponse2\x95\x01\n" +
+	"\n" +
+	"ChangeFeed\x128\n" +
+	"\vReadChanges\x12\x16.kv.ReadChangesRequest\x1a\x0f.kv.ChangeEvent0\x01\x12M\n" +
+	"\x10CommitCheckpoint\x12\x1b.kv.CommitCheckpointRequest\x1a\x1c.kv.CommitCheckpointResponse2\xc3\x01\n" +
+	"\x06Leases\x12;\n" +
+	"\n" +
+	"GrantLease\x12\x15.kv.GrantLeaseRequest\x1a\x16.kv.GrantLeaseResponse\x12<\n" +
+	"\tKeepAlive\x12\x14.kv.KeepAliveRequest\x1a\x15.kv.KeepAliveResponse(\x010\x01\x12>\n" +
+	"\vRevokeLease\x12\x16.kv.RevokeLeaseRequest\x1a\x17.kv.RevokeLeaseResponse2c\n" +
+	"\x05Locks\x12)\n" +
+	"\x04Lock\x12\x0f.kv.LockRequest\x1a\x10.kv.LockResponse\x12/\n" +
+	"\x06Unlock\x12\x11.kv.UnlockRequest\x1a\x12.kv.UnlockResponseB\fZ\n" +
 	"./proto/kvb\x06proto3"
 
 var (
@@ -372,27 +4230,164 @@ func file_kv_kv_proto_rawDescGZIP() []byte {
 	return file_kv_kv_proto_rawDescData
 }
 
-var file_kv_kv_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_kv_kv_proto_msgTypes = make([]protoimpl.MessageInfo, 65)
 var file_kv_kv_proto_goTypes = []any{
-	(*GetKVRequest)(nil),           // 0: kv.GetKVRequest
-	(*GetKVResponse)(nil),          // 1: kv.GetKVResponse
-	(*SetKeyValueRequest)(nil),     // 2: kv.SetKeyValueRequest
-	(*SetKeyValueResponse)(nil),    // 3: kv.SetKeyValueResponse
-	(*DeleteKeyValueRequest)(nil),  // 4: kv.DeleteKeyValueRequest
-	(*DeleteKeyValueResponse)(nil), // 5: kv.DeleteKeyValueResponse
+	(*GetKVRequest)(nil),             // 0: kv.GetKVRequest
+	(*GetKVResponse)(nil),            // 1: kv.GetKVResponse
+	(*SetKeyValueRequest)(nil),       // 2: kv.SetKeyValueRequest
+	(*SetKeyValueResponse)(nil),      // 3: kv.SetKeyValueResponse
+	(*BulkSetRequest)(nil),           // 4: kv.BulkSetRequest
+	(*BulkSetFailure)(nil),           // 5: kv.BulkSetFailure
+	(*BulkSetResponse)(nil),          // 6: kv.BulkSetResponse
+	(*DeleteKeyValueRequest)(nil),    // 7: kv.DeleteKeyValueRequest
+	(*DeleteKeyValueResponse)(nil),   // 8: kv.DeleteKeyValueResponse
+	(*ExpireRequest)(nil),            // 9: kv.ExpireRequest
+	(*ExpireResponse)(nil),           // 10: kv.ExpireResponse
+	(*TTLRequest)(nil),               // 11: kv.TTLRequest
+	(*TTLResponse)(nil),              // 12: kv.TTLResponse
+	(*ScanRequest)(nil),              // 13: kv.ScanRequest
+	(*ScanResponse)(nil),             // 14: kv.ScanResponse
+	(*CounterRequest)(nil),           // 15: kv.CounterRequest
+	(*CounterResponse)(nil),          // 16: kv.CounterResponse
+	(*CollectionResponse)(nil),       // 17: kv.CollectionResponse
+	(*CollectionKeyRequest)(nil),     // 18: kv.CollectionKeyRequest
+	(*HashSetRequest)(nil),           // 19: kv.HashSetRequest
+	(*HashGetRequest)(nil),           // 20: kv.HashGetRequest
+	(*HashDeleteRequest)(nil),        // 21: kv.HashDeleteRequest
+	(*ListPushRequest)(nil),          // 22: kv.ListPushRequest
+	(*ListPopRequest)(nil),           // 23: kv.ListPopRequest
+	(*ListRangeRequest)(nil),         // 24: kv.ListRangeRequest
+	(*SetMembersRequest)(nil),        // 25: kv.SetMembersRequest
+	(*ScoredMember)(nil),             // 26: kv.ScoredMember
+	(*SortedSetAddRequest)(nil),      // 27: kv.SortedSetAddRequest
+	(*SortedSetRangeRequest)(nil),    // 28: kv.SortedSetRangeRequest
+	(*InvalidateRequest)(nil),        // 29: kv.InvalidateRequest
+	(*InvalidateResponse)(nil),       // 30: kv.InvalidateResponse
+	(*Member)(nil),                   // 31: kv.Member
+	(*AddMemberRequest)(nil),         // 32: kv.AddMemberRequest
+	(*AddMemberResponse)(nil),        // 33: kv.AddMemberResponse
+	(*RemoveMemberRequest)(nil),      // 34: kv.RemoveMemberRequest
+	(*RemoveMemberResponse)(nil),     // 35: kv.RemoveMemberResponse
+	(*ListMembersRequest)(nil),       // 36: kv.ListMembersRequest
+	(*ListMembersResponse)(nil),      // 37: kv.ListMembersResponse
+	(*ReadIndexRequest)(nil),         // 38: kv.ReadIndexRequest
+	(*ReadIndexResponse)(nil),        // 39: kv.ReadIndexResponse
+	(*ShardNode)(nil),                // 40: kv.ShardNode
+	(*GetRingRequest)(nil),           // 41: kv.GetRingRequest
+	(*GetRingResponse)(nil),          // 42: kv.GetRingResponse
+	(*AddShardNodeRequest)(nil),      // 43: kv.AddShardNodeRequest
+	(*AddShardNodeResponse)(nil),     // 44: kv.AddShardNodeResponse
+	(*RemoveShardNodeRequest)(nil),   // 45: kv.RemoveShardNodeRequest
+	(*RemoveShardNodeResponse)(nil),  // 46: kv.RemoveShardNodeResponse
+	(*UpdateRingRequest)(nil),        // 47: kv.UpdateRingRequest
+	(*UpdateRingResponse)(nil),       // 48: kv.UpdateRingResponse
+	(*ReadChangesRequest)(nil),       // 49: kv.ReadChangesRequest
+	(*ChangeEvent)(nil),              // 50: kv.ChangeEvent
+	(*CommitCheckpointRequest)(nil),  // 51: kv.CommitCheckpointRequest
+	(*CommitCheckpointResponse)(nil), // 52: kv.CommitCheckpointResponse
+	(*GrantLeaseRequest)(nil),        // 53: kv.GrantLeaseRequest
+	(*GrantLeaseResponse)(nil),       // 54: kv.GrantLeaseResponse
+	(*KeepAliveRequest)(nil),         // 55: kv.KeepAliveRequest
+	(*KeepAliveResponse)(nil),        // 56: kv.KeepAliveResponse
+	(*RevokeLeaseRequest)(nil),       // 57: kv.RevokeLeaseRequest
+	(*RevokeLeaseResponse)(nil),      // 58: kv.RevokeLeaseResponse
+	(*LockRequest)(nil),              // 59: kv.LockRequest
+	(*LockResponse)(nil),             // 60: kv.LockResponse
+	(*UnlockRequest)(nil),            // 61: kv.UnlockRequest
+	(*UnlockResponse)(nil),           // 62: kv.UnlockResponse
+	nil,                              // 63: kv.CollectionResponse.FieldsEntry
+	nil,                              // 64: kv.HashSetRequest.FieldsEntry
 }
 var file_kv_kv_proto_depIdxs = []int32{
-	0, // 0: kv.KeyValueStore.GetKeyValue:input_type -> kv.GetKVRequest
-	2, // 1: kv.KeyValueStore.SetKeyValue:input_type -> kv.SetKeyValueRequest
-	4, // 2: kv.KeyValueStore.DeleteKeyValue:input_type -> kv.DeleteKeyValueRequest
-	1, // 3: kv.KeyValueStore.GetKeyValue:output_type -> kv.GetKVResponse
-	3, // 4: kv.KeyValueStore.SetKeyValue:output_type -> kv.SetKeyValueResponse
-	5, // 5: kv.KeyValueStore.DeleteKeyValue:output_type -> kv.DeleteKeyValueResponse
-	3, // [3:6] is the sub-list for method output_type
-	0, // [0:3] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	5,  // 0: kv.BulkSetResponse.failures:type_name -> kv.BulkSetFailure
+	63, // 1: kv.CollectionResponse.fields:type_name -> kv.CollectionResponse.FieldsEntry
+	26, // 2: kv.CollectionResponse.members:type_name -> kv.ScoredMember
+	64, // 3: kv.HashSetRequest.fields:type_name -> kv.HashSetRequest.FieldsEntry
+	26, // 4: kv.SortedSetAddRequest.members:type_name -> kv.ScoredMember
+	31, // 5: kv.ListMembersResponse.members:type_name -> kv.Member
+	40, // 6: kv.GetRingResponse.nodes:type_name -> kv.ShardNode
+	40, // 7: kv.UpdateRingRequest.nodes:type_name -> kv.ShardNode
+	0,  // 8: kv.KeyValueStore.GetKeyValue:input_type -> kv.GetKVRequest
+	2,  // 9: kv.KeyValueStore.SetKeyValue:input_type -> kv.SetKeyValueRequest
+	4,  // 10: kv.KeyValueStore.BulkSet:input_type -> kv.BulkSetRequest
+	7,  // 11: kv.KeyValueStore.DeleteKeyValue:input_type -> kv.DeleteKeyValueRequest
+	9,  // 12: kv.KeyValueStore.Expire:input_type -> kv.ExpireRequest
+	11, // 13: kv.KeyValueStore.GetTTL:input_type -> kv.TTLRequest
+	13, // 14: kv.KeyValueStore.Scan:input_type -> kv.ScanRequest
+	15, // 15: kv.KeyValueStore.Increment:input_type -> kv.CounterRequest
+	15, // 16: kv.KeyValueStore.Decrement:input_type -> kv.CounterRequest
+	19, // 17: kv.KeyValueStore.HashSet:input_type -> kv.HashSetRequest
+	20, // 18: kv.KeyValueStore.HashGet:input_type -> kv.HashGetRequest
+	18, // 19: kv.KeyValueStore.HashGetAll:input_type -> kv.CollectionKeyRequest
+	21, // 20: kv.KeyValueStore.HashDelete:input_type -> kv.HashDeleteRequest
+	22, // 21: kv.KeyValueStore.ListPush:input_type -> kv.ListPushRequest
+	23, // 22: kv.KeyValueStore.ListPop:input_type -> kv.ListPopRequest
+	24, // 23: kv.KeyValueStore.ListRange:input_type -> kv.ListRangeRequest
+	25, // 24: kv.KeyValueStore.SetAdd:input_type -> kv.SetMembersRequest
+	25, // 25: kv.KeyValueStore.SetRemove:input_type -> kv.SetMembersRequest
+	18, // 26: kv.KeyValueStore.SetMembers:input_type -> kv.CollectionKeyRequest
+	27, // 27: kv.KeyValueStore.SortedSetAdd:input_type -> kv.SortedSetAddRequest
+	25, // 28: kv.KeyValueStore.SortedSetRemove:input_type -> kv.SetMembersRequest
+	28, // 29: kv.KeyValueStore.SortedSetRangeByScore:input_type -> kv.SortedSetRangeRequest
+	29, // 30: kv.CacheInvalidation.Invalidate:input_type -> kv.InvalidateRequest
+	32, // 31: kv.ClusterAdmin.AddMember:input_type -> kv.AddMemberRequest
+	34, // 32: kv.ClusterAdmin.RemoveMember:input_type -> kv.RemoveMemberRequest
+	36, // 33: kv.ClusterAdmin.ListMembers:input_type -> kv.ListMembersRequest
+	38, // 34: kv.ClusterAdmin.ReadIndex:input_type -> kv.ReadIndexRequest
+	41, // 35: kv.ShardRing.GetRing:input_type -> kv.GetRingRequest
+	43, // 36: kv.ShardRing.AddShardNode:input_type -> kv.AddShardNodeRequest
+	45, // 37: kv.ShardRing.RemoveShardNode:input_type -> kv.RemoveShardNodeRequest
+	47, // 38: kv.ShardRing.UpdateRing:input_type -> kv.UpdateRingRequest
+	49, // 39: kv.ChangeFeed.ReadChanges:input_type -> kv.ReadChangesRequest
+	51, // 40: kv.ChangeFeed.CommitCheckpoint:input_type -> kv.CommitCheckpointRequest
+	53, // 41: kv.Leases.GrantLease:input_type -> kv.GrantLeaseRequest
+	55, // 42: kv.Leases.KeepAlive:input_type -> kv.KeepAliveRequest
+	57, // 43: kv.Leases.RevokeLease:input_type -> kv.RevokeLeaseRequest
+	59, // 44: kv.Locks.Lock:input_type -> kv.LockRequest
+	61, // 45: kv.Locks.Unlock:input_type -> kv.UnlockRequest
+	1,  // 46: kv.KeyValueStore.GetKeyValue:output_type -> kv.GetKVResponse
+	3,  // 47: kv.KeyValueStore.SetKeyValue:output_type -> kv.SetKeyValueResponse
+	6,  // 48: kv.KeyValueStore.BulkSet:output_type -> kv.BulkSetResponse
+	8,  // 49: kv.KeyValueStore.DeleteKeyValue:output_type -> kv.DeleteKeyValueResponse
+	10, // 50: kv.KeyValueStore.Expire:output_type -> kv.ExpireResponse
+	12, // 51: kv.KeyValueStore.GetTTL:output_type -> kv.TTLResponse
+	14, // 52: kv.KeyValueStore.Scan:output_type -> kv.ScanResponse
+	16, // 53: kv.KeyValueStore.Increment:output_type -> kv.CounterResponse
+	16, // 54: kv.KeyValueStore.Decrement:output_type -> kv.CounterResponse
+	17, // 55: kv.KeyValueStore.HashSet:output_type -> kv.CollectionResponse
+	17, // 56: kv.KeyValueStore.HashGet:output_type -> kv.CollectionResponse
+	17, // 57: kv.KeyValueStore.HashGetAll:output_type -> kv.CollectionResponse
+	17, // 58: kv.KeyValueStore.HashDelete:output_type -> kv.CollectionResponse
+	17, // 59: kv.KeyValueStore.ListPush:output_type -> kv.CollectionResponse
+	17, // 60: kv.KeyValueStore.ListPop:output_type -> kv.CollectionResponse
+	17, // 61: kv.KeyValueStore.ListRange:output_type -> kv.CollectionResponse
+	17, // 62: kv.KeyValueStore.SetAdd:output_type -> kv.CollectionResponse
+	17, // 63: kv.KeyValueStore.SetRemove:output_type -> kv.CollectionResponse
+	17, // 64: kv.KeyValueStore.SetMembers:output_type -> kv.CollectionResponse
+	17, // 65: kv.KeyValueStore.SortedSetAdd:output_type -> kv.CollectionResponse
+	17, // 66: kv.KeyValueStore.SortedSetRemove:output_type -> kv.CollectionResponse
+	17, // 67: kv.KeyValueStore.SortedSetRangeByScore:output_type -> kv.CollectionResponse
+	30, // 68: kv.CacheInvalidation.Invalidate:output_type -> kv.InvalidateResponse
+	33, // 69: kv.ClusterAdmin.AddMember:output_type -> kv.AddMemberResponse
+	35, // 70: kv.ClusterAdmin.RemoveMember:output_type -> kv.RemoveMemberResponse
+	37, // 71: kv.ClusterAdmin.ListMembers:output_type -> kv.ListMembersResponse
+	39, // 72: kv.ClusterAdmin.ReadIndex:output_type -> kv.ReadIndexResponse
+	42, // 73: kv.ShardRing.GetRing:output_type -> kv.GetRingResponse
+	44, // 74: kv.ShardRing.AddShardNode:output_type -> kv.AddShardNodeResponse
+	46, // 75: kv.ShardRing.RemoveShardNode:output_type -> kv.RemoveShardNodeResponse
+	48, // 76: kv.ShardRing.UpdateRing:output_type -> kv.UpdateRingResponse
+	50, // 77: kv.ChangeFeed.ReadChanges:output_type -> kv.ChangeEvent
+	52, // 78: kv.ChangeFeed.CommitCheckpoint:output_type -> kv.CommitCheckpointResponse
+	54, // 79: kv.Leases.GrantLease:output_type -> kv.GrantLeaseResponse
+	56, // 80: kv.Leases.KeepAlive:output_type -> kv.KeepAliveResponse
+	58, // 81: kv.Leases.RevokeLease:output_type -> kv.RevokeLeaseResponse
+	60, // 82: kv.Locks.Lock:output_type -> kv.LockResponse
+	62, // 83: kv.Locks.Unlock:output_type -> kv.UnlockResponse
+	46, // [46:84] is the sub-list for method output_type
+	8,  // [8:46] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
 }
 
 func init() { file_kv_kv_proto_init() }
@@ -400,15 +4395,17 @@ func file_kv_kv_proto_init() {
 	if File_kv_kv_proto != nil {
 		return
 	}
+	file_kv_kv_proto_msgTypes[15].OneofWrappers = []any{}
+	file_kv_kv_proto_msgTypes[28].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_kv_kv_proto_rawDesc), len(file_kv_kv_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   65,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   7,
 		},
 		GoTypes:           file_kv_kv_proto_goTypes,
 		DependencyIndexes: file_kv_kv_proto_depIdxs,
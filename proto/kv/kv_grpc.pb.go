@@ -19,9 +19,28 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	KeyValueStore_GetKeyValue_FullMethodName    = "/kv.KeyValueStore/GetKeyValue"
-	KeyValueStore_SetKeyValue_FullMethodName    = "/kv.KeyValueStore/SetKeyValue"
-	KeyValueStore_DeleteKeyValue_FullMethodName = "/kv.KeyValueStore/DeleteKeyValue"
+	KeyValueStore_GetKeyValue_FullMethodName           = "/kv.KeyValueStore/GetKeyValue"
+	KeyValueStore_SetKeyValue_FullMethodName           = "/kv.KeyValueStore/SetKeyValue"
+	KeyValueStore_BulkSet_FullMethodName               = "/kv.KeyValueStore/BulkSet"
+	KeyValueStore_DeleteKeyValue_FullMethodName        = "/kv.KeyValueStore/DeleteKeyValue"
+	KeyValueStore_Expire_FullMethodName                = "/kv.KeyValueStore/Expire"
+	KeyValueStore_GetTTL_FullMethodName                = "/kv.KeyValueStore/GetTTL"
+	KeyValueStore_Scan_FullMethodName                  = "/kv.KeyValueStore/Scan"
+	KeyValueStore_Increment_FullMethodName             = "/kv.KeyValueStore/Increment"
+	KeyValueStore_Decrement_FullMethodName             = "/kv.KeyValueStore/Decrement"
+	KeyValueStore_HashSet_FullMethodName               = "/kv.KeyValueStore/HashSet"
+	KeyValueStore_HashGet_FullMethodName               = "/kv.KeyValueStore/HashGet"
+	KeyValueStore_HashGetAll_FullMethodName            = "/kv.KeyValueStore/HashGetAll"
+	KeyValueStore_HashDelete_FullMethodName            = "/kv.KeyValueStore/HashDelete"
+	KeyValueStore_ListPush_FullMethodName              = "/kv.KeyValueStore/ListPush"
+	KeyValueStore_ListPop_FullMethodName               = "/kv.KeyValueStore/ListPop"
+	KeyValueStore_ListRange_FullMethodName             = "/kv.KeyValueStore/ListRange"
+	KeyValueStore_SetAdd_FullMethodName                = "/kv.KeyValueStore/SetAdd"
+	KeyValueStore_SetRemove_FullMethodName             = "/kv.KeyValueStore/SetRemove"
+	KeyValueStore_SetMembers_FullMethodName            = "/kv.KeyValueStore/SetMembers"
+	KeyValueStore_SortedSetAdd_FullMethodName          = "/kv.KeyValueStore/SortedSetAdd"
+	KeyValueStore_SortedSetRemove_FullMethodName       = "/kv.KeyValueStore/SortedSetRemove"
+	KeyValueStore_SortedSetRangeByScore_FullMethodName = "/kv.KeyValueStore/SortedSetRangeByScore"
 )
 
 // KeyValueStoreClient is the client API for KeyValueStore service.
@@ -30,7 +49,26 @@ const (
 type KeyValueStoreClient interface {
 	GetKeyValue(ctx context.Context, in *GetKVRequest, opts ...grpc.CallOption) (*GetKVResponse, error)
 	SetKeyValue(ctx context.Context, in *SetKeyValueRequest, opts ...grpc.CallOption) (*SetKeyValueResponse, error)
+	BulkSet(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[BulkSetRequest, BulkSetResponse], error)
 	DeleteKeyValue(ctx context.Context, in *DeleteKeyValueRequest, opts ...grpc.CallOption) (*DeleteKeyValueResponse, error)
+	Expire(ctx context.Context, in *ExpireRequest, opts ...grpc.CallOption) (*ExpireResponse, error)
+	GetTTL(ctx context.Context, in *TTLRequest, opts ...grpc.CallOption) (*TTLResponse, error)
+	Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanResponse, error)
+	Increment(ctx context.Context, in *CounterRequest, opts ...grpc.CallOption) (*CounterResponse, error)
+	Decrement(ctx context.Context, in *CounterRequest, opts ...grpc.CallOption) (*CounterResponse, error)
+	HashSet(ctx context.Context, in *HashSetRequest, opts ...grpc.CallOption) (*CollectionResponse, error)
+	HashGet(ctx context.Context, in *HashGetRequest, opts ...grpc.CallOption) (*CollectionResponse, error)
+	HashGetAll(ctx context.Context, in *CollectionKeyRequest, opts ...grpc.CallOption) (*CollectionResponse, error)
+	HashDelete(ctx context.Context, in *HashDeleteRequest, opts ...grpc.CallOption) (*CollectionResponse, error)
+	ListPush(ctx context.Context, in *ListPushRequest, opts ...grpc.CallOption) (*CollectionResponse, error)
+	ListPop(ctx context.Context, in *ListPopRequest, opts ...grpc.CallOption) (*CollectionResponse, error)
+	ListRange(ctx context.Context, in *ListRangeRequest, opts ...grpc.CallOption) (*CollectionResponse, error)
+	SetAdd(ctx context.Context, in *SetMembersRequest, opts ...grpc.CallOption) (*CollectionResponse, error)
+	SetRemove(ctx context.Context, in *SetMembersRequest, opts ...grpc.CallOption) (*CollectionResponse, error)
+	SetMembers(ctx context.Context, in *CollectionKeyRequest, opts ...grpc.CallOption) (*CollectionResponse, error)
+	SortedSetAdd(ctx context.Context, in *SortedSetAddRequest, opts ...grpc.CallOption) (*CollectionResponse, error)
+	SortedSetRemove(ctx context.Context, in *SetMembersRequest, opts ...grpc.CallOption) (*CollectionResponse, error)
+	SortedSetRangeByScore(ctx context.Context, in *SortedSetRangeRequest, opts ...grpc.CallOption) (*CollectionResponse, error)
 }
 
 type keyValueStoreClient struct {
@@ -61,6 +99,19 @@ func (c *keyValueStoreClient) SetKeyValue(ctx context.Context, in *SetKeyValueRe
 	return out, nil
 }
 
+func (c *keyValueStoreClient) BulkSet(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[BulkSetRequest, BulkSetResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KeyValueStore_ServiceDesc.Streams[0], KeyValueStore_BulkSet_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BulkSetRequest, BulkSetResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KeyValueStore_BulkSetClient = grpc.ClientStreamingClient[BulkSetRequest, BulkSetResponse]
+
 func (c *keyValueStoreClient) DeleteKeyValue(ctx context.Context, in *DeleteKeyValueRequest, opts ...grpc.CallOption) (*DeleteKeyValueResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(DeleteKeyValueResponse)
@@ -71,13 +122,212 @@ func (c *keyValueStoreClient) DeleteKeyValue(ctx context.Context, in *DeleteKeyV
 	return out, nil
 }
 
+func (c *keyValueStoreClient) Expire(ctx context.Context, in *ExpireRequest, opts ...grpc.CallOption) (*ExpireResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExpireResponse)
+	err := c.cc.Invoke(ctx, KeyValueStore_Expire_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueStoreClient) GetTTL(ctx context.Context, in *TTLRequest, opts ...grpc.CallOption) (*TTLResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TTLResponse)
+	err := c.cc.Invoke(ctx, KeyValueStore_GetTTL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueStoreClient) Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ScanResponse)
+	err := c.cc.Invoke(ctx, KeyValueStore_Scan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueStoreClient) Increment(ctx context.Context, in *CounterRequest, opts ...grpc.CallOption) (*CounterResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CounterResponse)
+	err := c.cc.Invoke(ctx, KeyValueStore_Increment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueStoreClient) Decrement(ctx context.Context, in *CounterRequest, opts ...grpc.CallOption) (*CounterResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CounterResponse)
+	err := c.cc.Invoke(ctx, KeyValueStore_Decrement_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueStoreClient) HashSet(ctx context.Context, in *HashSetRequest, opts ...grpc.CallOption) (*CollectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CollectionResponse)
+	err := c.cc.Invoke(ctx, KeyValueStore_HashSet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueStoreClient) HashGet(ctx context.Context, in *HashGetRequest, opts ...grpc.CallOption) (*CollectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CollectionResponse)
+	err := c.cc.Invoke(ctx, KeyValueStore_HashGet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueStoreClient) HashGetAll(ctx context.Context, in *CollectionKeyRequest, opts ...grpc.CallOption) (*CollectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CollectionResponse)
+	err := c.cc.Invoke(ctx, KeyValueStore_HashGetAll_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueStoreClient) HashDelete(ctx context.Context, in *HashDeleteRequest, opts ...grpc.CallOption) (*CollectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CollectionResponse)
+	err := c.cc.Invoke(ctx, KeyValueStore_HashDelete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueStoreClient) ListPush(ctx context.Context, in *ListPushRequest, opts ...grpc.CallOption) (*CollectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CollectionResponse)
+	err := c.cc.Invoke(ctx, KeyValueStore_ListPush_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueStoreClient) ListPop(ctx context.Context, in *ListPopRequest, opts ...grpc.CallOption) (*CollectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CollectionResponse)
+	err := c.cc.Invoke(ctx, KeyValueStore_ListPop_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueStoreClient) ListRange(ctx context.Context, in *ListRangeRequest, opts ...grpc.CallOption) (*CollectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CollectionResponse)
+	err := c.cc.Invoke(ctx, KeyValueStore_ListRange_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueStoreClient) SetAdd(ctx context.Context, in *SetMembersRequest, opts ...grpc.CallOption) (*CollectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CollectionResponse)
+	err := c.cc.Invoke(ctx, KeyValueStore_SetAdd_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueStoreClient) SetRemove(ctx context.Context, in *SetMembersRequest, opts ...grpc.CallOption) (*CollectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CollectionResponse)
+	err := c.cc.Invoke(ctx, KeyValueStore_SetRemove_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueStoreClient) SetMembers(ctx context.Context, in *CollectionKeyRequest, opts ...grpc.CallOption) (*CollectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CollectionResponse)
+	err := c.cc.Invoke(ctx, KeyValueStore_SetMembers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueStoreClient) SortedSetAdd(ctx context.Context, in *SortedSetAddRequest, opts ...grpc.CallOption) (*CollectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CollectionResponse)
+	err := c.cc.Invoke(ctx, KeyValueStore_SortedSetAdd_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueStoreClient) SortedSetRemove(ctx context.Context, in *SetMembersRequest, opts ...grpc.CallOption) (*CollectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CollectionResponse)
+	err := c.cc.Invoke(ctx, KeyValueStore_SortedSetRemove_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueStoreClient) SortedSetRangeByScore(ctx context.Context, in *SortedSetRangeRequest, opts ...grpc.CallOption) (*CollectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CollectionResponse)
+	err := c.cc.Invoke(ctx, KeyValueStore_SortedSetRangeByScore_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // KeyValueStoreServer is the server API for KeyValueStore service.
 // All implementations must embed UnimplementedKeyValueStoreServer
 // for forward compatibility.
 type KeyValueStoreServer interface {
 	GetKeyValue(context.Context, *GetKVRequest) (*GetKVResponse, error)
 	SetKeyValue(context.Context, *SetKeyValueRequest) (*SetKeyValueResponse, error)
+	BulkSet(grpc.ClientStreamingServer[BulkSetRequest, BulkSetResponse]) error
 	DeleteKeyValue(context.Context, *DeleteKeyValueRequest) (*DeleteKeyValueResponse, error)
+	Expire(context.Context, *ExpireRequest) (*ExpireResponse, error)
+	GetTTL(context.Context, *TTLRequest) (*TTLResponse, error)
+	Scan(context.Context, *ScanRequest) (*ScanResponse, error)
+	Increment(context.Context, *CounterRequest) (*CounterResponse, error)
+	Decrement(context.Context, *CounterRequest) (*CounterResponse, error)
+	HashSet(context.Context, *HashSetRequest) (*CollectionResponse, error)
+	HashGet(context.Context, *HashGetRequest) (*CollectionResponse, error)
+	HashGetAll(context.Context, *CollectionKeyRequest) (*CollectionResponse, error)
+	HashDelete(context.Context, *HashDeleteRequest) (*CollectionResponse, error)
+	ListPush(context.Context, *ListPushRequest) (*CollectionResponse, error)
+	ListPop(context.Context, *ListPopRequest) (*CollectionResponse, error)
+	ListRange(context.Context, *ListRangeRequest) (*CollectionResponse, error)
+	SetAdd(context.Context, *SetMembersRequest) (*CollectionResponse, error)
+	SetRemove(context.Context, *SetMembersRequest) (*CollectionResponse, error)
+	SetMembers(context.Context, *CollectionKeyRequest) (*CollectionResponse, error)
+	SortedSetAdd(context.Context, *SortedSetAddRequest) (*CollectionResponse, error)
+	SortedSetRemove(context.Context, *SetMembersRequest) (*CollectionResponse, error)
+	SortedSetRangeByScore(context.Context, *SortedSetRangeRequest) (*CollectionResponse, error)
 	mustEmbedUnimplementedKeyValueStoreServer()
 }
 
@@ -94,9 +344,66 @@ func (UnimplementedKeyValueStoreServer) GetKeyValue(context.Context, *GetKVReque
 func (UnimplementedKeyValueStoreServer) SetKeyValue(context.Context, *SetKeyValueRequest) (*SetKeyValueResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetKeyValue not implemented")
 }
+func (UnimplementedKeyValueStoreServer) BulkSet(grpc.ClientStreamingServer[BulkSetRequest, BulkSetResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method BulkSet not implemented")
+}
 func (UnimplementedKeyValueStoreServer) DeleteKeyValue(context.Context, *DeleteKeyValueRequest) (*DeleteKeyValueResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteKeyValue not implemented")
 }
+func (UnimplementedKeyValueStoreServer) Expire(context.Context, *ExpireRequest) (*ExpireResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Expire not implemented")
+}
+func (UnimplementedKeyValueStoreServer) GetTTL(context.Context, *TTLRequest) (*TTLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTTL not implemented")
+}
+func (UnimplementedKeyValueStoreServer) Scan(context.Context, *ScanRequest) (*ScanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Scan not implemented")
+}
+func (UnimplementedKeyValueStoreServer) Increment(context.Context, *CounterRequest) (*CounterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Increment not implemented")
+}
+func (UnimplementedKeyValueStoreServer) Decrement(context.Context, *CounterRequest) (*CounterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Decrement not implemented")
+}
+func (UnimplementedKeyValueStoreServer) HashSet(context.Context, *HashSetRequest) (*CollectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HashSet not implemented")
+}
+func (UnimplementedKeyValueStoreServer) HashGet(context.Context, *HashGetRequest) (*CollectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HashGet not implemented")
+}
+func (UnimplementedKeyValueStoreServer) HashGetAll(context.Context, *CollectionKeyRequest) (*CollectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HashGetAll not implemented")
+}
+func (UnimplementedKeyValueStoreServer) HashDelete(context.Context, *HashDeleteRequest) (*CollectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HashDelete not implemented")
+}
+func (UnimplementedKeyValueStoreServer) ListPush(context.Context, *ListPushRequest) (*CollectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPush not implemented")
+}
+func (UnimplementedKeyValueStoreServer) ListPop(context.Context, *ListPopRequest) (*CollectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPop not implemented")
+}
+func (UnimplementedKeyValueStoreServer) ListRange(context.Context, *ListRangeRequest) (*CollectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRange not implemented")
+}
+func (UnimplementedKeyValueStoreServer) SetAdd(context.Context, *SetMembersRequest) (*CollectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetAdd not implemented")
+}
+func (UnimplementedKeyValueStoreServer) SetRemove(context.Context, *SetMembersRequest) (*CollectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRemove not implemented")
+}
+func (UnimplementedKeyValueStoreServer) SetMembers(context.Context, *CollectionKeyRequest) (*CollectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetMembers not implemented")
+}
+func (UnimplementedKeyValueStoreServer) SortedSetAdd(context.Context, *SortedSetAddRequest) (*CollectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SortedSetAdd not implemented")
+}
+func (UnimplementedKeyValueStoreServer) SortedSetRemove(context.Context, *SetMembersRequest) (*CollectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SortedSetRemove not implemented")
+}
+func (UnimplementedKeyValueStoreServer) SortedSetRangeByScore(context.Context, *SortedSetRangeRequest) (*CollectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SortedSetRangeByScore not implemented")
+}
 func (UnimplementedKeyValueStoreServer) mustEmbedUnimplementedKeyValueStoreServer() {}
 func (UnimplementedKeyValueStoreServer) testEmbeddedByValue()                       {}
 
@@ -154,6 +461,13 @@ func _KeyValueStore_SetKeyValue_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KeyValueStore_BulkSet_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(KeyValueStoreServer).BulkSet(&grpc.GenericServerStream[BulkSetRequest, BulkSetResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KeyValueStore_BulkSetServer = grpc.ClientStreamingServer[BulkSetRequest, BulkSetResponse]
+
 func _KeyValueStore_DeleteKeyValue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeleteKeyValueRequest)
 	if err := dec(in); err != nil {
@@ -172,24 +486,1441 @@ func _KeyValueStore_DeleteKeyValue_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
-// KeyValueStore_ServiceDesc is the grpc.ServiceDesc for KeyValueStore service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var KeyValueStore_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "kv.KeyValueStore",
-	HandlerType: (*KeyValueStoreServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "GetKeyValue",
-			Handler:    _KeyValueStore_GetKeyValue_Handler,
-		},
-		{
-			MethodName: "SetKeyValue",
-			Handler:    _KeyValueStore_SetKeyValue_Handler,
-		},
-		{
-			MethodName: "DeleteKeyValue",
-			Handler:    _KeyValueStore_DeleteKeyValue_Handler,
+func _KeyValueStore_Expire_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExpireRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueStoreServer).Expire(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyValueStore_Expire_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueStoreServer).Expire(ctx, req.(*ExpireRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValueStore_GetTTL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TTLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueStoreServer).GetTTL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyValueStore_GetTTL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueStoreServer).GetTTL(ctx, req.(*TTLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValueStore_Scan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueStoreServer).Scan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyValueStore_Scan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueStoreServer).Scan(ctx, req.(*ScanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValueStore_Increment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CounterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueStoreServer).Increment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyValueStore_Increment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueStoreServer).Increment(ctx, req.(*CounterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValueStore_Decrement_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CounterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueStoreServer).Decrement(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyValueStore_Decrement_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueStoreServer).Decrement(ctx, req.(*CounterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValueStore_HashSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HashSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueStoreServer).HashSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyValueStore_HashSet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueStoreServer).HashSet(ctx, req.(*HashSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValueStore_HashGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HashGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueStoreServer).HashGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyValueStore_HashGet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueStoreServer).HashGet(ctx, req.(*HashGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValueStore_HashGetAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CollectionKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueStoreServer).HashGetAll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyValueStore_HashGetAll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueStoreServer).HashGetAll(ctx, req.(*CollectionKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValueStore_HashDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HashDeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueStoreServer).HashDelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyValueStore_HashDelete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueStoreServer).HashDelete(ctx, req.(*HashDeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValueStore_ListPush_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPushRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueStoreServer).ListPush(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyValueStore_ListPush_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueStoreServer).ListPush(ctx, req.(*ListPushRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValueStore_ListPop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueStoreServer).ListPop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyValueStore_ListPop_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueStoreServer).ListPop(ctx, req.(*ListPopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValueStore_ListRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueStoreServer).ListRange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyValueStore_ListRange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueStoreServer).ListRange(ctx, req.(*ListRangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValueStore_SetAdd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetMembersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueStoreServer).SetAdd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyValueStore_SetAdd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueStoreServer).SetAdd(ctx, req.(*SetMembersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValueStore_SetRemove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetMembersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueStoreServer).SetRemove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyValueStore_SetRemove_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueStoreServer).SetRemove(ctx, req.(*SetMembersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValueStore_SetMembers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CollectionKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueStoreServer).SetMembers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyValueStore_SetMembers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueStoreServer).SetMembers(ctx, req.(*CollectionKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValueStore_SortedSetAdd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SortedSetAddRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueStoreServer).SortedSetAdd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyValueStore_SortedSetAdd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueStoreServer).SortedSetAdd(ctx, req.(*SortedSetAddRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValueStore_SortedSetRemove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetMembersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueStoreServer).SortedSetRemove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyValueStore_SortedSetRemove_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueStoreServer).SortedSetRemove(ctx, req.(*SetMembersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValueStore_SortedSetRangeByScore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SortedSetRangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueStoreServer).SortedSetRangeByScore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyValueStore_SortedSetRangeByScore_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueStoreServer).SortedSetRangeByScore(ctx, req.(*SortedSetRangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// KeyValueStore_ServiceDesc is the grpc.ServiceDesc for KeyValueStore service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var KeyValueStore_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "kv.KeyValueStore",
+	HandlerType: (*KeyValueStoreServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetKeyValue",
+			Handler:    _KeyValueStore_GetKeyValue_Handler,
+		},
+		{
+			MethodName: "SetKeyValue",
+			Handler:    _KeyValueStore_SetKeyValue_Handler,
+		},
+		{
+			MethodName: "DeleteKeyValue",
+			Handler:    _KeyValueStore_DeleteKeyValue_Handler,
+		},
+		{
+			MethodName: "Expire",
+			Handler:    _KeyValueStore_Expire_Handler,
+		},
+		{
+			MethodName: "GetTTL",
+			Handler:    _KeyValueStore_GetTTL_Handler,
+		},
+		{
+			MethodName: "Scan",
+			Handler:    _KeyValueStore_Scan_Handler,
+		},
+		{
+			MethodName: "Increment",
+			Handler:    _KeyValueStore_Increment_Handler,
+		},
+		{
+			MethodName: "Decrement",
+			Handler:    _KeyValueStore_Decrement_Handler,
+		},
+		{
+			MethodName: "HashSet",
+			Handler:    _KeyValueStore_HashSet_Handler,
+		},
+		{
+			MethodName: "HashGet",
+			Handler:    _KeyValueStore_HashGet_Handler,
+		},
+		{
+			MethodName: "HashGetAll",
+			Handler:    _KeyValueStore_HashGetAll_Handler,
+		},
+		{
+			MethodName: "HashDelete",
+			Handler:    _KeyValueStore_HashDelete_Handler,
+		},
+		{
+			MethodName: "ListPush",
+			Handler:    _KeyValueStore_ListPush_Handler,
+		},
+		{
+			MethodName: "ListPop",
+			Handler:    _KeyValueStore_ListPop_Handler,
+		},
+		{
+			MethodName: "ListRange",
+			Handler:    _KeyValueStore_ListRange_Handler,
+		},
+		{
+			MethodName: "SetAdd",
+			Handler:    _KeyValueStore_SetAdd_Handler,
+		},
+		{
+			MethodName: "SetRemove",
+			Handler:    _KeyValueStore_SetRemove_Handler,
+		},
+		{
+			MethodName: "SetMembers",
+			Handler:    _KeyValueStore_SetMembers_Handler,
+		},
+		{
+			MethodName: "SortedSetAdd",
+			Handler:    _KeyValueStore_SortedSetAdd_Handler,
+		},
+		{
+			MethodName: "SortedSetRemove",
+			Handler:    _KeyValueStore_SortedSetRemove_Handler,
+		},
+		{
+			MethodName: "SortedSetRangeByScore",
+			Handler:    _KeyValueStore_SortedSetRangeByScore_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "BulkSet",
+			Handler:       _KeyValueStore_BulkSet_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "kv/kv.proto",
+}
+
+const (
+	CacheInvalidation_Invalidate_FullMethodName = "/kv.CacheInvalidation/Invalidate"
+)
+
+// CacheInvalidationClient is the client API for CacheInvalidation service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Peer-to-peer cache invalidation between kv-storage processes sharing one database.
+type CacheInvalidationClient interface {
+	Invalidate(ctx context.Context, in *InvalidateRequest, opts ...grpc.CallOption) (*InvalidateResponse, error)
+}
+
+type cacheInvalidationClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCacheInvalidationClient(cc grpc.ClientConnInterface) CacheInvalidationClient {
+	return &cacheInvalidationClient{cc}
+}
+
+func (c *cacheInvalidationClient) Invalidate(ctx context.Context, in *InvalidateRequest, opts ...grpc.CallOption) (*InvalidateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InvalidateResponse)
+	err := c.cc.Invoke(ctx, CacheInvalidation_Invalidate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CacheInvalidationServer is the server API for CacheInvalidation service.
+// All implementations must embed UnimplementedCacheInvalidationServer
+// for forward compatibility.
+//
+// Peer-to-peer cache invalidation between kv-storage processes sharing one database.
+type CacheInvalidationServer interface {
+	Invalidate(context.Context, *InvalidateRequest) (*InvalidateResponse, error)
+	mustEmbedUnimplementedCacheInvalidationServer()
+}
+
+// UnimplementedCacheInvalidationServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCacheInvalidationServer struct{}
+
+func (UnimplementedCacheInvalidationServer) Invalidate(context.Context, *InvalidateRequest) (*InvalidateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Invalidate not implemented")
+}
+func (UnimplementedCacheInvalidationServer) mustEmbedUnimplementedCacheInvalidationServer() {}
+func (UnimplementedCacheInvalidationServer) testEmbeddedByValue()                           {}
+
+// UnsafeCacheInvalidationServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CacheInvalidationServer will
+// result in compilation errors.
+type UnsafeCacheInvalidationServer interface {
+	mustEmbedUnimplementedCacheInvalidationServer()
+}
+
+func RegisterCacheInvalidationServer(s grpc.ServiceRegistrar, srv CacheInvalidationServer) {
+	// If the following call pancis, it indicates UnimplementedCacheInvalidationServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CacheInvalidation_ServiceDesc, srv)
+}
+
+func _CacheInvalidation_Invalidate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InvalidateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheInvalidationServer).Invalidate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheInvalidation_Invalidate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheInvalidationServer).Invalidate(ctx, req.(*InvalidateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CacheInvalidation_ServiceDesc is the grpc.ServiceDesc for CacheInvalidation service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CacheInvalidation_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "kv.CacheInvalidation",
+	HandlerType: (*CacheInvalidationServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Invalidate",
+			Handler:    _CacheInvalidation_Invalidate_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "kv/kv.proto",
+}
+
+const (
+	ClusterAdmin_AddMember_FullMethodName    = "/kv.ClusterAdmin/AddMember"
+	ClusterAdmin_RemoveMember_FullMethodName = "/kv.ClusterAdmin/RemoveMember"
+	ClusterAdmin_ListMembers_FullMethodName  = "/kv.ClusterAdmin/ListMembers"
+	ClusterAdmin_ReadIndex_FullMethodName    = "/kv.ClusterAdmin/ReadIndex"
+)
+
+// ClusterAdminClient is the client API for ClusterAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Membership administration and leader read-index for Raft cluster mode.
+type ClusterAdminClient interface {
+	AddMember(ctx context.Context, in *AddMemberRequest, opts ...grpc.CallOption) (*AddMemberResponse, error)
+	RemoveMember(ctx context.Context, in *RemoveMemberRequest, opts ...grpc.CallOption) (*RemoveMemberResponse, error)
+	ListMembers(ctx context.Context, in *ListMembersRequest, opts ...grpc.CallOption) (*ListMembersResponse, error)
+	ReadIndex(ctx context.Context, in *ReadIndexRequest, opts ...grpc.CallOption) (*ReadIndexResponse, error)
+}
+
+type clusterAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewClusterAdminClient(cc grpc.ClientConnInterface) ClusterAdminClient {
+	return &clusterAdminClient{cc}
+}
+
+func (c *clusterAdminClient) AddMember(ctx context.Context, in *AddMemberRequest, opts ...grpc.CallOption) (*AddMemberResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddMemberResponse)
+	err := c.cc.Invoke(ctx, ClusterAdmin_AddMember_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterAdminClient) RemoveMember(ctx context.Context, in *RemoveMemberRequest, opts ...grpc.CallOption) (*RemoveMemberResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveMemberResponse)
+	err := c.cc.Invoke(ctx, ClusterAdmin_RemoveMember_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterAdminClient) ListMembers(ctx context.Context, in *ListMembersRequest, opts ...grpc.CallOption) (*ListMembersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMembersResponse)
+	err := c.cc.Invoke(ctx, ClusterAdmin_ListMembers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterAdminClient) ReadIndex(ctx context.Context, in *ReadIndexRequest, opts ...grpc.CallOption) (*ReadIndexResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReadIndexResponse)
+	err := c.cc.Invoke(ctx, ClusterAdmin_ReadIndex_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ClusterAdminServer is the server API for ClusterAdmin service.
+// All implementations must embed UnimplementedClusterAdminServer
+// for forward compatibility.
+//
+// Membership administration and leader read-index for Raft cluster mode.
+type ClusterAdminServer interface {
+	AddMember(context.Context, *AddMemberRequest) (*AddMemberResponse, error)
+	RemoveMember(context.Context, *RemoveMemberRequest) (*RemoveMemberResponse, error)
+	ListMembers(context.Context, *ListMembersRequest) (*ListMembersResponse, error)
+	ReadIndex(context.Context, *ReadIndexRequest) (*ReadIndexResponse, error)
+	mustEmbedUnimplementedClusterAdminServer()
+}
+
+// UnimplementedClusterAdminServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedClusterAdminServer struct{}
+
+func (UnimplementedClusterAdminServer) AddMember(context.Context, *AddMemberRequest) (*AddMemberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddMember not implemented")
+}
+func (UnimplementedClusterAdminServer) RemoveMember(context.Context, *RemoveMemberRequest) (*RemoveMemberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveMember not implemented")
+}
+func (UnimplementedClusterAdminServer) ListMembers(context.Context, *ListMembersRequest) (*ListMembersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMembers not implemented")
+}
+func (UnimplementedClusterAdminServer) ReadIndex(context.Context, *ReadIndexRequest) (*ReadIndexResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadIndex not implemented")
+}
+func (UnimplementedClusterAdminServer) mustEmbedUnimplementedClusterAdminServer() {}
+func (UnimplementedClusterAdminServer) testEmbeddedByValue()                      {}
+
+// UnsafeClusterAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ClusterAdminServer will
+// result in compilation errors.
+type UnsafeClusterAdminServer interface {
+	mustEmbedUnimplementedClusterAdminServer()
+}
+
+func RegisterClusterAdminServer(s grpc.ServiceRegistrar, srv ClusterAdminServer) {
+	// If the following call pancis, it indicates UnimplementedClusterAdminServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ClusterAdmin_ServiceDesc, srv)
+}
+
+func _ClusterAdmin_AddMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddMemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterAdminServer).AddMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterAdmin_AddMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterAdminServer).AddMember(ctx, req.(*AddMemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterAdmin_RemoveMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveMemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterAdminServer).RemoveMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterAdmin_RemoveMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterAdminServer).RemoveMember(ctx, req.(*RemoveMemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterAdmin_ListMembers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMembersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterAdminServer).ListMembers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterAdmin_ListMembers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterAdminServer).ListMembers(ctx, req.(*ListMembersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterAdmin_ReadIndex_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadIndexRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterAdminServer).ReadIndex(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterAdmin_ReadIndex_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterAdminServer).ReadIndex(ctx, req.(*ReadIndexRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ClusterAdmin_ServiceDesc is the grpc.ServiceDesc for ClusterAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ClusterAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "kv.ClusterAdmin",
+	HandlerType: (*ClusterAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddMember",
+			Handler:    _ClusterAdmin_AddMember_Handler,
+		},
+		{
+			MethodName: "RemoveMember",
+			Handler:    _ClusterAdmin_RemoveMember_Handler,
+		},
+		{
+			MethodName: "ListMembers",
+			Handler:    _ClusterAdmin_ListMembers_Handler,
+		},
+		{
+			MethodName: "ReadIndex",
+			Handler:    _ClusterAdmin_ReadIndex_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "kv/kv.proto",
+}
+
+const (
+	ShardRing_GetRing_FullMethodName         = "/kv.ShardRing/GetRing"
+	ShardRing_AddShardNode_FullMethodName    = "/kv.ShardRing/AddShardNode"
+	ShardRing_RemoveShardNode_FullMethodName = "/kv.ShardRing/RemoveShardNode"
+	ShardRing_UpdateRing_FullMethodName      = "/kv.ShardRing/UpdateRing"
+)
+
+// ShardRingClient is the client API for ShardRing service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Consistent-hash ring for sharded mode. UpdateRing is node-to-node only.
+type ShardRingClient interface {
+	GetRing(ctx context.Context, in *GetRingRequest, opts ...grpc.CallOption) (*GetRingResponse, error)
+	AddShardNode(ctx context.Context, in *AddShardNodeRequest, opts ...grpc.CallOption) (*AddShardNodeResponse, error)
+	RemoveShardNode(ctx context.Context, in *RemoveShardNodeRequest, opts ...grpc.CallOption) (*RemoveShardNodeResponse, error)
+	UpdateRing(ctx context.Context, in *UpdateRingRequest, opts ...grpc.CallOption) (*UpdateRingResponse, error)
+}
+
+type shardRingClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewShardRingClient(cc grpc.ClientConnInterface) ShardRingClient {
+	return &shardRingClient{cc}
+}
+
+func (c *shardRingClient) GetRing(ctx context.Context, in *GetRingRequest, opts ...grpc.CallOption) (*GetRingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRingResponse)
+	err := c.cc.Invoke(ctx, ShardRing_GetRing_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardRingClient) AddShardNode(ctx context.Context, in *AddShardNodeRequest, opts ...grpc.CallOption) (*AddShardNodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddShardNodeResponse)
+	err := c.cc.Invoke(ctx, ShardRing_AddShardNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardRingClient) RemoveShardNode(ctx context.Context, in *RemoveShardNodeRequest, opts ...grpc.CallOption) (*RemoveShardNodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveShardNodeResponse)
+	err := c.cc.Invoke(ctx, ShardRing_RemoveShardNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardRingClient) UpdateRing(ctx context.Context, in *UpdateRingRequest, opts ...grpc.CallOption) (*UpdateRingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateRingResponse)
+	err := c.cc.Invoke(ctx, ShardRing_UpdateRing_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ShardRingServer is the server API for ShardRing service.
+// All implementations must embed UnimplementedShardRingServer
+// for forward compatibility.
+//
+// Consistent-hash ring for sharded mode. UpdateRing is node-to-node only.
+type ShardRingServer interface {
+	GetRing(context.Context, *GetRingRequest) (*GetRingResponse, error)
+	AddShardNode(context.Context, *AddShardNodeRequest) (*AddShardNodeResponse, error)
+	RemoveShardNode(context.Context, *RemoveShardNodeRequest) (*RemoveShardNodeResponse, error)
+	UpdateRing(context.Context, *UpdateRingRequest) (*UpdateRingResponse, error)
+	mustEmbedUnimplementedShardRingServer()
+}
+
+// UnimplementedShardRingServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedShardRingServer struct{}
+
+func (UnimplementedShardRingServer) GetRing(context.Context, *GetRingRequest) (*GetRingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRing not implemented")
+}
+func (UnimplementedShardRingServer) AddShardNode(context.Context, *AddShardNodeRequest) (*AddShardNodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddShardNode not implemented")
+}
+func (UnimplementedShardRingServer) RemoveShardNode(context.Context, *RemoveShardNodeRequest) (*RemoveShardNodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveShardNode not implemented")
+}
+func (UnimplementedShardRingServer) UpdateRing(context.Context, *UpdateRingRequest) (*UpdateRingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateRing not implemented")
+}
+func (UnimplementedShardRingServer) mustEmbedUnimplementedShardRingServer() {}
+func (UnimplementedShardRingServer) testEmbeddedByValue()                   {}
+
+// UnsafeShardRingServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ShardRingServer will
+// result in compilation errors.
+type UnsafeShardRingServer interface {
+	mustEmbedUnimplementedShardRingServer()
+}
+
+func RegisterShardRingServer(s grpc.ServiceRegistrar, srv ShardRingServer) {
+	// If the following call pancis, it indicates UnimplementedShardRingServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ShardRing_ServiceDesc, srv)
+}
+
+func _ShardRing_GetRing_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardRingServer).GetRing(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardRing_GetRing_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardRingServer).GetRing(ctx, req.(*GetRingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardRing_AddShardNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddShardNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardRingServer).AddShardNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardRing_AddShardNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardRingServer).AddShardNode(ctx, req.(*AddShardNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardRing_RemoveShardNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveShardNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardRingServer).RemoveShardNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardRing_RemoveShardNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardRingServer).RemoveShardNode(ctx, req.(*RemoveShardNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardRing_UpdateRing_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardRingServer).UpdateRing(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardRing_UpdateRing_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardRingServer).UpdateRing(ctx, req.(*UpdateRingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ShardRing_ServiceDesc is the grpc.ServiceDesc for ShardRing service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ShardRing_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "kv.ShardRing",
+	HandlerType: (*ShardRingServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetRing",
+			Handler:    _ShardRing_GetRing_Handler,
+		},
+		{
+			MethodName: "AddShardNode",
+			Handler:    _ShardRing_AddShardNode_Handler,
+		},
+		{
+			MethodName: "RemoveShardNode",
+			Handler:    _ShardRing_RemoveShardNode_Handler,
+		},
+		{
+			MethodName: "UpdateRing",
+			Handler:    _ShardRing_UpdateRing_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "kv/kv.proto",
+}
+
+const (
+	ChangeFeed_ReadChanges_FullMethodName      = "/kv.ChangeFeed/ReadChanges"
+	ChangeFeed_CommitCheckpoint_FullMethodName = "/kv.ChangeFeed/CommitCheckpoint"
+)
+
+// ChangeFeedClient is the client API for ChangeFeed service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Durable, ordered feed of every committed mutation.
+type ChangeFeedClient interface {
+	ReadChanges(ctx context.Context, in *ReadChangesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChangeEvent], error)
+	CommitCheckpoint(ctx context.Context, in *CommitCheckpointRequest, opts ...grpc.CallOption) (*CommitCheckpointResponse, error)
+}
+
+type changeFeedClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewChangeFeedClient(cc grpc.ClientConnInterface) ChangeFeedClient {
+	return &changeFeedClient{cc}
+}
+
+func (c *changeFeedClient) ReadChanges(ctx context.Context, in *ReadChangesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChangeEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ChangeFeed_ServiceDesc.Streams[0], ChangeFeed_ReadChanges_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ReadChangesRequest, ChangeEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ChangeFeed_ReadChangesClient = grpc.ServerStreamingClient[ChangeEvent]
+
+func (c *changeFeedClient) CommitCheckpoint(ctx context.Context, in *CommitCheckpointRequest, opts ...grpc.CallOption) (*CommitCheckpointResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommitCheckpointResponse)
+	err := c.cc.Invoke(ctx, ChangeFeed_CommitCheckpoint_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ChangeFeedServer is the server API for ChangeFeed service.
+// All implementations must embed UnimplementedChangeFeedServer
+// for forward compatibility.
+//
+// Durable, ordered feed of every committed mutation.
+type ChangeFeedServer interface {
+	ReadChanges(*ReadChangesRequest, grpc.ServerStreamingServer[ChangeEvent]) error
+	CommitCheckpoint(context.Context, *CommitCheckpointRequest) (*CommitCheckpointResponse, error)
+	mustEmbedUnimplementedChangeFeedServer()
+}
+
+// UnimplementedChangeFeedServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedChangeFeedServer struct{}
+
+func (UnimplementedChangeFeedServer) ReadChanges(*ReadChangesRequest, grpc.ServerStreamingServer[ChangeEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method ReadChanges not implemented")
+}
+func (UnimplementedChangeFeedServer) CommitCheckpoint(context.Context, *CommitCheckpointRequest) (*CommitCheckpointResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CommitCheckpoint not implemented")
+}
+func (UnimplementedChangeFeedServer) mustEmbedUnimplementedChangeFeedServer() {}
+func (UnimplementedChangeFeedServer) testEmbeddedByValue()                    {}
+
+// UnsafeChangeFeedServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ChangeFeedServer will
+// result in compilation errors.
+type UnsafeChangeFeedServer interface {
+	mustEmbedUnimplementedChangeFeedServer()
+}
+
+func RegisterChangeFeedServer(s grpc.ServiceRegistrar, srv ChangeFeedServer) {
+	// If the following call pancis, it indicates UnimplementedChangeFeedServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ChangeFeed_ServiceDesc, srv)
+}
+
+func _ChangeFeed_ReadChanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReadChangesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChangeFeedServer).ReadChanges(m, &grpc.GenericServerStream[ReadChangesRequest, ChangeEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ChangeFeed_ReadChangesServer = grpc.ServerStreamingServer[ChangeEvent]
+
+func _ChangeFeed_CommitCheckpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommitCheckpointRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChangeFeedServer).CommitCheckpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChangeFeed_CommitCheckpoint_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChangeFeedServer).CommitCheckpoint(ctx, req.(*CommitCheckpointRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ChangeFeed_ServiceDesc is the grpc.ServiceDesc for ChangeFeed service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ChangeFeed_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "kv.ChangeFeed",
+	HandlerType: (*ChangeFeedServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CommitCheckpoint",
+			Handler:    _ChangeFeed_CommitCheckpoint_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ReadChanges",
+			Handler:       _ChangeFeed_ReadChanges_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "kv/kv.proto",
+}
+
+const (
+	Leases_GrantLease_FullMethodName  = "/kv.Leases/GrantLease"
+	Leases_KeepAlive_FullMethodName   = "/kv.Leases/KeepAlive"
+	Leases_RevokeLease_FullMethodName = "/kv.Leases/RevokeLease"
+)
+
+// LeasesClient is the client API for Leases service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Time-bound leases that keys and locks can be attached to.
+type LeasesClient interface {
+	GrantLease(ctx context.Context, in *GrantLeaseRequest, opts ...grpc.CallOption) (*GrantLeaseResponse, error)
+	KeepAlive(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[KeepAliveRequest, KeepAliveResponse], error)
+	RevokeLease(ctx context.Context, in *RevokeLeaseRequest, opts ...grpc.CallOption) (*RevokeLeaseResponse, error)
+}
+
+type leasesClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLeasesClient(cc grpc.ClientConnInterface) LeasesClient {
+	return &leasesClient{cc}
+}
+
+func (c *leasesClient) GrantLease(ctx context.Context, in *GrantLeaseRequest, opts ...grpc.CallOption) (*GrantLeaseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GrantLeaseResponse)
+	err := c.cc.Invoke(ctx, Leases_GrantLease_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *leasesClient) KeepAlive(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[KeepAliveRequest, KeepAliveResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Leases_ServiceDesc.Streams[0], Leases_KeepAlive_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[KeepAliveRequest, KeepAliveResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Leases_KeepAliveClient = grpc.BidiStreamingClient[KeepAliveRequest, KeepAliveResponse]
+
+func (c *leasesClient) RevokeLease(ctx context.Context, in *RevokeLeaseRequest, opts ...grpc.CallOption) (*RevokeLeaseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeLeaseResponse)
+	err := c.cc.Invoke(ctx, Leases_RevokeLease_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LeasesServer is the server API for Leases service.
+// All implementations must embed UnimplementedLeasesServer
+// for forward compatibility.
+//
+// Time-bound leases that keys and locks can be attached to.
+type LeasesServer interface {
+	GrantLease(context.Context, *GrantLeaseRequest) (*GrantLeaseResponse, error)
+	KeepAlive(grpc.BidiStreamingServer[KeepAliveRequest, KeepAliveResponse]) error
+	RevokeLease(context.Context, *RevokeLeaseRequest) (*RevokeLeaseResponse, error)
+	mustEmbedUnimplementedLeasesServer()
+}
+
+// UnimplementedLeasesServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLeasesServer struct{}
+
+func (UnimplementedLeasesServer) GrantLease(context.Context, *GrantLeaseRequest) (*GrantLeaseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GrantLease not implemented")
+}
+func (UnimplementedLeasesServer) KeepAlive(grpc.BidiStreamingServer[KeepAliveRequest, KeepAliveResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method KeepAlive not implemented")
+}
+func (UnimplementedLeasesServer) RevokeLease(context.Context, *RevokeLeaseRequest) (*RevokeLeaseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeLease not implemented")
+}
+func (UnimplementedLeasesServer) mustEmbedUnimplementedLeasesServer() {}
+func (UnimplementedLeasesServer) testEmbeddedByValue()                {}
+
+// UnsafeLeasesServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LeasesServer will
+// result in compilation errors.
+type UnsafeLeasesServer interface {
+	mustEmbedUnimplementedLeasesServer()
+}
+
+func RegisterLeasesServer(s grpc.ServiceRegistrar, srv LeasesServer) {
+	// If the following call pancis, it indicates UnimplementedLeasesServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Leases_ServiceDesc, srv)
+}
+
+func _Leases_GrantLease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GrantLeaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LeasesServer).GrantLease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Leases_GrantLease_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LeasesServer).GrantLease(ctx, req.(*GrantLeaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Leases_KeepAlive_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LeasesServer).KeepAlive(&grpc.GenericServerStream[KeepAliveRequest, KeepAliveResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Leases_KeepAliveServer = grpc.BidiStreamingServer[KeepAliveRequest, KeepAliveResponse]
+
+func _Leases_RevokeLease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeLeaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LeasesServer).RevokeLease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Leases_RevokeLease_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LeasesServer).RevokeLease(ctx, req.(*RevokeLeaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Leases_ServiceDesc is the grpc.ServiceDesc for Leases service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Leases_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "kv.Leases",
+	HandlerType: (*LeasesServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GrantLease",
+			Handler:    _Leases_GrantLease_Handler,
+		},
+		{
+			MethodName: "RevokeLease",
+			Handler:    _Leases_RevokeLease_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "KeepAlive",
+			Handler:       _Leases_KeepAlive_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "kv/kv.proto",
+}
+
+const (
+	Locks_Lock_FullMethodName   = "/kv.Locks/Lock"
+	Locks_Unlock_FullMethodName = "/kv.Locks/Unlock"
+)
+
+// LocksClient is the client API for Locks service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Fair distributed locks held under a lease, with fencing tokens.
+type LocksClient interface {
+	Lock(ctx context.Context, in *LockRequest, opts ...grpc.CallOption) (*LockResponse, error)
+	Unlock(ctx context.Context, in *UnlockRequest, opts ...grpc.CallOption) (*UnlockResponse, error)
+}
+
+type locksClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLocksClient(cc grpc.ClientConnInterface) LocksClient {
+	return &locksClient{cc}
+}
+
+func (c *locksClient) Lock(ctx context.Context, in *LockRequest, opts ...grpc.CallOption) (*LockResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LockResponse)
+	err := c.cc.Invoke(ctx, Locks_Lock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *locksClient) Unlock(ctx context.Context, in *UnlockRequest, opts ...grpc.CallOption) (*UnlockResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnlockResponse)
+	err := c.cc.Invoke(ctx, Locks_Unlock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LocksServer is the server API for Locks service.
+// All implementations must embed UnimplementedLocksServer
+// for forward compatibility.
+//
+// Fair distributed locks held under a lease, with fencing tokens.
+type LocksServer interface {
+	Lock(context.Context, *LockRequest) (*LockResponse, error)
+	Unlock(context.Context, *UnlockRequest) (*UnlockResponse, error)
+	mustEmbedUnimplementedLocksServer()
+}
+
+// UnimplementedLocksServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLocksServer struct{}
+
+func (UnimplementedLocksServer) Lock(context.Context, *LockRequest) (*LockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Lock not implemented")
+}
+func (UnimplementedLocksServer) Unlock(context.Context, *UnlockRequest) (*UnlockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unlock not implemented")
+}
+func (UnimplementedLocksServer) mustEmbedUnimplementedLocksServer() {}
+func (UnimplementedLocksServer) testEmbeddedByValue()               {}
+
+// UnsafeLocksServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LocksServer will
+// result in compilation errors.
+type UnsafeLocksServer interface {
+	mustEmbedUnimplementedLocksServer()
+}
+
+func RegisterLocksServer(s grpc.ServiceRegistrar, srv LocksServer) {
+	// If the following call pancis, it indicates UnimplementedLocksServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Locks_ServiceDesc, srv)
+}
+
+func _Locks_Lock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LocksServer).Lock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Locks_Lock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LocksServer).Lock(ctx, req.(*LockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Locks_Unlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LocksServer).Unlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Locks_Unlock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LocksServer).Unlock(ctx, req.(*UnlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Locks_ServiceDesc is the grpc.ServiceDesc for Locks service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Locks_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "kv.Locks",
+	HandlerType: (*LocksServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Lock",
+			Handler:    _Locks_Lock_Handler,
+		},
+		{
+			MethodName: "Unlock",
+			Handler:    _Locks_Unlock_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
@@ -0,0 +1,325 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kv-storage/auth"
+	kvpb "github.com/kv-storage/proto/kv"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	OpRead   = "read"
+	OpWrite  = "write"
+	OpDelete = "delete"
+	// OpLock covers leases and locks, scoped by lock name
+	OpLock = "lock"
+	// OpAdmin covers cluster membership, the shard ring and cache invalidation
+	OpAdmin = "admin"
+	OpAll   = "*"
+)
+
+// rule is what one RPC needs: an operation, on the key its request names.
+type rule struct {
+	operation string
+	scope     func(proto.Message) string
+}
+
+func keyScope(message proto.Message) string {
+	if keyed, ok := message.(interface{ GetKey() string }); ok {
+		return keyed.GetKey()
+	}
+	return ""
+}
+
+func noScope(proto.Message) string {
+	return ""
+}
+
+// scanScope is the literal start of a SCAN pattern, which every key it can
+// return begins with: billing/* is scoped to billing/.
+func scanScope(message proto.Message) string {
+	match := message.(*kvpb.ScanRequest).GetMatch()
+	if i := strings.IndexAny(match, `*?[\`); i >= 0 {
+		return match[:i]
+	}
+	return match
+}
+
+func changesScope(message proto.Message) string {
+	return message.(*kvpb.ReadChangesRequest).GetPrefix()
+}
+
+func lockScope(message proto.Message) string {
+	if named, ok := message.(interface{ GetName() string }); ok {
+		return named.GetName()
+	}
+	return ""
+}
+
+// rules classifies every RPC the server exposes. A method missing from here
+// is denied to everyone, so a new RPC stays closed until it is added.
+var rules = map[string]rule{
+	kvpb.KeyValueStore_GetKeyValue_FullMethodName:           {OpRead, keyScope},
+	kvpb.KeyValueStore_SetKeyValue_FullMethodName:           {OpWrite, keyScope},
+	kvpb.KeyValueStore_BulkSet_FullMethodName:               {OpWrite, keyScope},
+	kvpb.KeyValueStore_DeleteKeyValue_FullMethodName:        {OpDelete, keyScope},
+	kvpb.KeyValueStore_Expire_FullMethodName:                {OpWrite, keyScope},
+	kvpb.KeyValueStore_GetTTL_FullMethodName:                {OpRead, keyScope},
+	kvpb.KeyValueStore_Scan_FullMethodName:                  {OpRead, scanScope},
+	kvpb.KeyValueStore_Increment_FullMethodName:             {OpWrite, keyScope},
+	kvpb.KeyValueStore_Decrement_FullMethodName:             {OpWrite, keyScope},
+	kvpb.KeyValueStore_HashSet_FullMethodName:               {OpWrite, keyScope},
+	kvpb.KeyValueStore_HashGet_FullMethodName:               {OpRead, keyScope},
+	kvpb.KeyValueStore_HashGetAll_FullMethodName:            {OpRead, keyScope},
+	kvpb.KeyValueStore_HashDelete_FullMethodName:            {OpWrite, keyScope},
+	kvpb.KeyValueStore_ListPush_FullMethodName:              {OpWrite, keyScope},
+	kvpb.KeyValueStore_ListPop_FullMethodName:               {OpWrite, keyScope},
+	kvpb.KeyValueStore_ListRange_FullMethodName:             {OpRead, keyScope},
+	kvpb.KeyValueStore_SetAdd_FullMethodName:                {OpWrite, keyScope},
+	kvpb.KeyValueStore_SetRemove_FullMethodName:             {OpWrite, keyScope},
+	kvpb.KeyValueStore_SetMembers_FullMethodName:            {OpRead, keyScope},
+	kvpb.KeyValueStore_SortedSetAdd_FullMethodName:          {OpWrite, keyScope},
+	kvpb.KeyValueStore_SortedSetRemove_FullMethodName:       {OpWrite, keyScope},
+	kvpb.KeyValueStore_SortedSetRangeByScore_FullMethodName: {OpRead, keyScope},
+
+	kvpb.ChangeFeed_ReadChanges_FullMethodName:      {OpRead, changesScope},
+	kvpb.ChangeFeed_CommitCheckpoint_FullMethodName: {OpRead, noScope},
+
+	kvpb.Leases_GrantLease_FullMethodName:  {OpLock, noScope},
+	kvpb.Leases_KeepAlive_FullMethodName:   {OpLock, noScope},
+	kvpb.Leases_RevokeLease_FullMethodName: {OpLock, noScope},
+	kvpb.Locks_Lock_FullMethodName:         {OpLock, lockScope},
+	kvpb.Locks_Unlock_FullMethodName:       {OpLock, lockScope},
+
+	kvpb.CacheInvalidation_Invalidate_FullMethodName: {OpAdmin, noScope},
+	kvpb.ClusterAdmin_AddMember_FullMethodName:       {OpAdmin, noScope},
+	kvpb.ClusterAdmin_RemoveMember_FullMethodName:    {OpAdmin, noScope},
+	kvpb.ClusterAdmin_ListMembers_FullMethodName:     {OpAdmin, noScope},
+	kvpb.ClusterAdmin_ReadIndex_FullMethodName:       {OpAdmin, noScope},
+	kvpb.ShardRing_GetRing_FullMethodName:            {OpAdmin, noScope},
+	kvpb.ShardRing_AddShardNode_FullMethodName:       {OpAdmin, noScope},
+	kvpb.ShardRing_RemoveShardNode_FullMethodName:    {OpAdmin, noScope},
+	kvpb.ShardRing_UpdateRing_FullMethodName:         {OpAdmin, noScope},
+}
+
+// Classify returns the operation a call performs and the key, key prefix or
+// lock name it is scoped to, or ok false for a method missing from the rules.
+// A nil message leaves the scope empty.
+func Classify(method string, message any) (operation, key string, ok bool) {
+	r, ok := rules[method]
+	if !ok {
+		return "unclassified", "", false
+	}
+	if request, isProto := message.(proto.Message); isProto {
+		key = r.scope(request)
+	}
+	return r.operation, key, true
+}
+
+// Grant allows operations on every key starting with Prefix; an empty
+// prefix covers all keys, including calls that name none.
+type Grant struct {
+	Prefix     string   `json:"prefix"`
+	Operations []string `json:"operations"`
+}
+
+// Policy maps each role to its grants, as in
+//
+//	{"roles": {
+//	  "billing":   [{"prefix": "billing/", "operations": ["read", "write", "delete"]}],
+//	  "dashboard": [{"prefix": "", "operations": ["read"]}],
+//	  "node":      [{"prefix": "", "operations": ["*"]}]
+//	}}
+//
+// Nodes calling each other authenticate by certificate, with the
+// organizational units as roles, so a clustered node's OU needs a role
+// granting write and admin.
+type Policy struct {
+	Roles map[string][]Grant `json:"roles"`
+}
+
+func (p *Policy) allows(roles []string, operation, key string) bool {
+	for _, role := range roles {
+		for _, grant := range p.Roles[role] {
+			if strings.HasPrefix(key, grant.Prefix) &&
+				(slices.Contains(grant.Operations, operation) || slices.Contains(grant.Operations, OpAll)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func loadPolicy(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	for role, grants := range policy.Roles {
+		for _, grant := range grants {
+			for _, operation := range grant.Operations {
+				switch operation {
+				case OpRead, OpWrite, OpDelete, OpLock, OpAdmin, OpAll:
+				default:
+					return nil, fmt.Errorf("%s: role %q grants unknown operation %q", file, role, operation)
+				}
+			}
+		}
+	}
+	return &policy, nil
+}
+
+// Enforcer checks each call against the policy file, reloading it whenever
+// the file changes. A policy that fails to load leaves the previous one in force.
+type Enforcer struct {
+	file   string
+	logger *zap.Logger
+
+	mu       sync.RWMutex
+	policy   *Policy
+	modified time.Time
+}
+
+func NewEnforcer(file string, interval time.Duration, logger *zap.Logger) (*Enforcer, error) {
+	e := &Enforcer{file: file, logger: logger.Named("rbac")}
+	if err := e.reload(); err != nil {
+		return nil, err
+	}
+	go func() {
+		for range time.Tick(interval) {
+			info, err := os.Stat(file)
+			if err != nil {
+				e.logger.Warn("Cannot check the access policy", zap.Error(err))
+				continue
+			}
+			e.mu.RLock()
+			unchanged := info.ModTime().Equal(e.modified)
+			e.mu.RUnlock()
+			if unchanged {
+				continue
+			}
+			if err := e.reload(); err != nil {
+				e.logger.Error("Failed to reload the access policy, keeping the previous one", zap.Error(err))
+				// Report a broken file once, not on every tick until it is fixed
+				e.mu.Lock()
+				e.modified = info.ModTime()
+				e.mu.Unlock()
+				continue
+			}
+			e.logger.Info("Reloaded the access policy", zap.String("file", file))
+		}
+	}()
+	return e, nil
+}
+
+func (e *Enforcer) reload() error {
+	info, err := os.Stat(e.file)
+	if err != nil {
+		return err
+	}
+	policy, err := loadPolicy(e.file)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.policy, e.modified = policy, info.ModTime()
+	return nil
+}
+
+// authorize returns PermissionDenied unless the caller may make this call.
+// Denials are logged at warn level under "Access denied", for alerting.
+func (e *Enforcer) authorize(ctx context.Context, method string, message any) error {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "missing credentials")
+	}
+	operation, key, ok := Classify(method, message)
+	if ok {
+		e.mu.RLock()
+		allowed := e.policy.allows(principal.Roles, operation, key)
+		e.mu.RUnlock()
+		if allowed {
+			return nil
+		}
+	}
+	e.logger.Warn("Access denied",
+		zap.String("subject", principal.Subject),
+		zap.String("authMethod", principal.Method),
+		zap.Strings("roles", principal.Roles),
+		zap.String("method", method),
+		zap.String("operation", operation),
+		zap.String("key", key),
+	)
+	return status.Errorf(codes.PermissionDenied, "%s may not %s %q", principal.Subject, operation, key)
+}
+
+func (e *Enforcer) UnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if auth.Exempt(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	if err := e.authorize(ctx, info.FullMethod, req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// RecordError rejects one message of a client stream without ending the
+// stream. A handler that gets one from Recv reports that record, as
+// received, as failed and reads on; anywhere else it stands for its Err, the
+// call's status.
+type RecordError struct {
+	Message any
+	Err     error
+}
+
+func (e *RecordError) Error() string { return e.Err.Error() }
+
+func (e *RecordError) Unwrap() error { return e.Err }
+
+func (e *RecordError) GRPCStatus() *status.Status { return status.Convert(e.Err) }
+
+// StreamInterceptor checks every message the client sends, so each record
+// of a BulkSet is held to its own key, and one the principal may not write
+// comes back from Recv as a RecordError.
+func (e *Enforcer) StreamInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if auth.Exempt(info.FullMethod) {
+		return handler(srv, stream)
+	}
+	if _, ok := rules[info.FullMethod]; !ok {
+		return e.authorize(stream.Context(), info.FullMethod, nil)
+	}
+	return handler(srv, &authorizedStream{ServerStream: stream, enforcer: e, method: info.FullMethod})
+}
+
+type authorizedStream struct {
+	grpc.ServerStream
+	enforcer *Enforcer
+	method   string
+}
+
+func (s *authorizedStream) RecvMsg(message any) error {
+	if err := s.ServerStream.RecvMsg(message); err != nil {
+		return err
+	}
+	if err := s.enforcer.authorize(s.Context(), s.method, message); err != nil {
+		return &RecordError{Message: message, Err: err}
+	}
+	return nil
+}
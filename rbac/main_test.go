@@ -0,0 +1,176 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kv-storage/auth"
+	kvpb "github.com/kv-storage/proto/kv"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var policy = &Policy{Roles: map[string][]Grant{
+	"billing":   {{Prefix: "billing/", Operations: []string{OpRead, OpWrite}}},
+	"dashboard": {{Prefix: "", Operations: []string{OpRead}}},
+	"node":      {{Prefix: "", Operations: []string{OpAll}}},
+	"locker":    {{Prefix: "jobs/", Operations: []string{OpLock}}},
+}}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		method        string
+		message       any
+		wantOperation string
+		wantKey       string
+		wantOK        bool
+	}{
+		{kvpb.KeyValueStore_SetKeyValue_FullMethodName, &kvpb.SetKeyValueRequest{Key: "billing/1"}, OpWrite, "billing/1", true},
+		{kvpb.KeyValueStore_DeleteKeyValue_FullMethodName, &kvpb.DeleteKeyValueRequest{Key: "k"}, OpDelete, "k", true},
+		{kvpb.KeyValueStore_Scan_FullMethodName, &kvpb.ScanRequest{Match: "billing/*"}, OpRead, "billing/", true},
+		{kvpb.KeyValueStore_Scan_FullMethodName, &kvpb.ScanRequest{Match: "bill?ng"}, OpRead, "bill", true},
+		{kvpb.ChangeFeed_ReadChanges_FullMethodName, &kvpb.ReadChangesRequest{Prefix: "billing/"}, OpRead, "billing/", true},
+		{kvpb.Locks_Lock_FullMethodName, &kvpb.LockRequest{Name: "jobs/nightly"}, OpLock, "jobs/nightly", true},
+		{kvpb.KeyValueStore_BulkSet_FullMethodName, nil, OpWrite, "", true},
+		{"/kv.KeyValueStore/Unknown", &kvpb.SetKeyValueRequest{Key: "k"}, "unclassified", "", false},
+	}
+	for _, test := range tests {
+		operation, key, ok := Classify(test.method, test.message)
+		if operation != test.wantOperation || key != test.wantKey || ok != test.wantOK {
+			t.Errorf("Classify(%s) = %s, %q, %v, want %s, %q, %v", test.method, operation, key, ok, test.wantOperation, test.wantKey, test.wantOK)
+		}
+	}
+}
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		name      string
+		roles     []string
+		operation string
+		key       string
+		want      bool
+	}{
+		{"inside the prefix", []string{"billing"}, OpWrite, "billing/1", true},
+		{"the prefix itself", []string{"billing"}, OpRead, "billing/", true},
+		{"outside the prefix", []string{"billing"}, OpRead, "payroll/1", false},
+		{"a shorter key", []string{"billing"}, OpRead, "billing", false},
+		{"a prefix of the prefix", []string{"billing"}, OpRead, "bill", false},
+		{"an operation not granted", []string{"billing"}, OpDelete, "billing/1", false},
+		{"an empty prefix covers every key", []string{"dashboard"}, OpRead, "anything", true},
+		{"an empty prefix covers calls without a key", []string{"dashboard"}, OpRead, "", true},
+		{"the wildcard grants every operation", []string{"node"}, OpAdmin, "", true},
+		{"any role may grant", []string{"dashboard", "billing"}, OpWrite, "billing/1", true},
+		{"an unknown role grants nothing", []string{"intern"}, OpRead, "billing/1", false},
+		{"no roles grant nothing", nil, OpRead, "", false},
+		{"locks are scoped by name", []string{"locker"}, OpLock, "jobs/nightly", true},
+		{"locks outside the prefix", []string{"locker"}, OpLock, "billing/nightly", false},
+	}
+	for _, test := range tests {
+		if got := policy.allows(test.roles, test.operation, test.key); got != test.want {
+			t.Errorf("%s: allows = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	e := &Enforcer{policy: policy, logger: zap.NewNop()}
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		method    string
+		message   any
+		want      codes.Code
+	}{
+		{"allowed", &auth.Principal{Subject: "b", Roles: []string{"billing"}}, kvpb.KeyValueStore_SetKeyValue_FullMethodName, &kvpb.SetKeyValueRequest{Key: "billing/1"}, codes.OK},
+		{"denied", &auth.Principal{Subject: "b", Roles: []string{"billing"}}, kvpb.KeyValueStore_SetKeyValue_FullMethodName, &kvpb.SetKeyValueRequest{Key: "payroll/1"}, codes.PermissionDenied},
+		{"an unclassified method is denied to everyone", &auth.Principal{Subject: "n", Roles: []string{"node"}}, "/kv.KeyValueStore/Unknown", &kvpb.SetKeyValueRequest{}, codes.PermissionDenied},
+		{"unauthenticated", nil, kvpb.KeyValueStore_GetKeyValue_FullMethodName, &kvpb.GetKVRequest{Key: "k"}, codes.Unauthenticated},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.principal != nil {
+				ctx = auth.NewContext(ctx, test.principal)
+			}
+			if got := status.Code(e.authorize(ctx, test.method, test.message)); got != test.want {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		wantErr bool
+	}{
+		{"valid", `{"roles": {"billing": [{"prefix": "billing/", "operations": ["read", "write"]}]}}`, false},
+		{"unknown operation", `{"roles": {"billing": [{"prefix": "billing/", "operations": ["wirte"]}]}}`, true},
+		{"not JSON", `roles: billing`, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "policy.json")
+			if err := os.WriteFile(file, []byte(test.policy), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := loadPolicy(file); (err != nil) != test.wantErr {
+				t.Fatalf("got %v, want an error %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+// stream delivers records to RecvMsg.
+type stream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	records []*kvpb.BulkSetRequest
+}
+
+func (s *stream) Context() context.Context { return s.ctx }
+
+func (s *stream) RecvMsg(message any) error {
+	proto.Merge(message.(*kvpb.BulkSetRequest), s.records[0])
+	s.records = s.records[1:]
+	return nil
+}
+
+func TestStreamRecordsAreCheckedOneByOne(t *testing.T) {
+	e := &Enforcer{policy: policy, logger: zap.NewNop()}
+	ctx := auth.NewContext(context.Background(), &auth.Principal{Subject: "b", Roles: []string{"billing"}})
+	s := &authorizedStream{
+		ServerStream: &stream{ctx: ctx, records: []*kvpb.BulkSetRequest{{Key: "billing/1"}, {Key: "payroll/1"}, {Key: "billing/2"}}},
+		enforcer:     e,
+		method:       kvpb.KeyValueStore_BulkSet_FullMethodName,
+	}
+	tests := []struct {
+		key      string
+		rejected bool
+	}{
+		{"billing/1", false},
+		{"payroll/1", true},
+		{"billing/2", false},
+	}
+	for _, test := range tests {
+		var record kvpb.BulkSetRequest
+		err := s.RecvMsg(&record)
+		var rejected *RecordError
+		if errors.As(err, &rejected) != test.rejected {
+			t.Fatalf("%s: got %v, want rejected %v", test.key, err, test.rejected)
+		}
+		if record.Key != test.key {
+			t.Fatalf("received %q, want %q", record.Key, test.key)
+		}
+		if test.rejected && (status.Code(err) != codes.PermissionDenied || rejected.Message.(*kvpb.BulkSetRequest).Key != test.key) {
+			t.Fatalf("%s: rejection %v does not carry the record and PermissionDenied", test.key, err)
+		}
+	}
+}
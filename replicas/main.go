@@ -0,0 +1,171 @@
+package replicas
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	Strong = "strong"
+
+	// Seconds_Behind_Source only has second resolution.
+	lagResolution = time.Second
+)
+
+// NewSessionToken marks the moment a write committed. Reads presenting it
+// skip replicas that may not have replayed that write yet.
+func NewSessionToken() string {
+	return strconv.FormatInt(time.Now().UnixMilli(), 10)
+}
+
+func parseSessionToken(token string) (time.Time, bool) {
+	millis, err := strconv.ParseInt(token, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(millis), true
+}
+
+type replica struct {
+	index int
+	db    *gorm.DB
+
+	mu      sync.RWMutex
+	healthy bool
+	// caughtUpTo is the primary time this replica had replayed up to when last checked.
+	caughtUpTo time.Time
+}
+
+func (r *replica) freshness() (bool, time.Time) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.healthy, r.caughtUpTo
+}
+
+// Router sends writes to the primary and spreads cache-miss reads across
+// replicas, pulling any that lag too far behind out of rotation.
+type Router struct {
+	primary  *gorm.DB
+	replicas []*replica
+	maxLag   time.Duration
+	next     atomic.Uint64
+	logger   *zap.Logger
+}
+
+func NewRouter(primary *gorm.DB, replicaDBs []*gorm.DB, maxLag, checkInterval time.Duration, logger *zap.Logger) *Router {
+	router := &Router{primary: primary, maxLag: maxLag, logger: logger}
+	for i, db := range replicaDBs {
+		router.replicas = append(router.replicas, &replica{index: i, db: db})
+	}
+	if len(router.replicas) > 0 {
+		router.checkLag()
+		go func() {
+			for range time.Tick(checkInterval) {
+				router.checkLag()
+			}
+		}()
+	}
+	return router
+}
+
+// Reader picks the database for a read. The second result reports whether
+// it is the primary, since only primary reads may populate the cache.
+func (r *Router) Reader(consistency, sessionToken string) (*gorm.DB, bool) {
+	if consistency == Strong || len(r.replicas) == 0 {
+		return r.primary, true
+	}
+	writtenAt, hasToken := parseSessionToken(sessionToken)
+
+	start := r.next.Add(1)
+	for i := range r.replicas {
+		candidate := r.replicas[(start+uint64(i))%uint64(len(r.replicas))]
+		healthy, caughtUpTo := candidate.freshness()
+		if !healthy {
+			continue
+		}
+		if hasToken && caughtUpTo.Before(writtenAt) {
+			continue
+		}
+		return candidate.db, false
+	}
+	return r.primary, true
+}
+
+func (r *Router) checkLag() {
+	for _, candidate := range r.replicas {
+		checkedAt := time.Now()
+		lag, err := replicationLag(candidate.db)
+		healthy := err == nil && lag <= r.maxLag
+
+		candidate.mu.Lock()
+		if healthy != candidate.healthy {
+			if healthy {
+				r.logger.Info("Replica back in rotation", zap.Int("replica", candidate.index), zap.Duration("lag", lag))
+			} else {
+				r.logger.Warn("Replica out of rotation", zap.Int("replica", candidate.index), zap.Duration("lag", lag), zap.Error(err))
+			}
+		}
+		candidate.healthy = healthy
+		candidate.caughtUpTo = checkedAt.Add(-lag - lagResolution)
+		candidate.mu.Unlock()
+	}
+}
+
+var (
+	errReplicationStopped = errors.New("replication is not running")
+	errNotReplica         = errors.New("server is not configured as a replica")
+)
+
+// replicationLag reads Seconds_Behind_Source (Seconds_Behind_Master before
+// MySQL 8.0.22). A server that is not replicating at all, or whose lag is
+// unknown, is reported as an error, since nothing says it has the writes.
+func replicationLag(db *gorm.DB) (time.Duration, error) {
+	rows, err := db.Raw("SHOW REPLICA STATUS").Rows()
+	if err != nil {
+		rows, err = db.Raw("SHOW SLAVE STATUS").Rows()
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+		return 0, errNotReplica
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	values := make([]sql.RawBytes, len(columns))
+	pointers := make([]any, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	if err := rows.Scan(pointers...); err != nil {
+		return 0, err
+	}
+	for i, column := range columns {
+		if column != "Seconds_Behind_Source" && column != "Seconds_Behind_Master" {
+			continue
+		}
+		if values[i] == nil {
+			return 0, errReplicationStopped
+		}
+		seconds, err := strconv.ParseInt(string(values[i]), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return 0, errReplicationStopped
+}
@@ -0,0 +1,101 @@
+package replicas
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func open(t *testing.T, name string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), name+".db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestReader(t *testing.T) {
+	now := time.Now()
+	token := func(at time.Time) string { return strconv.FormatInt(at.UnixMilli(), 10) }
+	tests := []struct {
+		name         string
+		healthy      []bool
+		caughtUpTo   []time.Time
+		consistency  string
+		sessionToken string
+		// want is the replica read from, -1 for the primary
+		want int
+	}{
+		{"a healthy replica", []bool{true}, []time.Time{now}, "", "", 0},
+		{"strong reads go to the primary", []bool{true}, []time.Time{now}, Strong, "", -1},
+		{"no replicas", nil, nil, "", "", -1},
+		{"an unhealthy replica is skipped", []bool{false, true}, []time.Time{now, now}, "", "", 1},
+		{"no healthy replica", []bool{false, false}, []time.Time{now, now}, "", "", -1},
+		{"a replica behind the session is skipped", []bool{true, true}, []time.Time{now.Add(-time.Minute), now}, "", token(now.Add(-time.Second)), 1},
+		{"every replica behind the session", []bool{true}, []time.Time{now.Add(-time.Minute)}, "", token(now), -1},
+		{"an unreadable session token is ignored", []bool{true}, []time.Time{now.Add(-time.Minute)}, "", "yesterday", 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			primary := open(t, "primary")
+			router := &Router{primary: primary, logger: zap.NewNop()}
+			for i := range test.healthy {
+				router.replicas = append(router.replicas, &replica{index: i, db: open(t, "replica"+strconv.Itoa(i)), healthy: test.healthy[i], caughtUpTo: test.caughtUpTo[i]})
+			}
+			// Start from every replica in turn, so the pick never depends on the rotation
+			for start := 0; start < max(len(router.replicas), 1); start++ {
+				router.next.Store(uint64(start))
+				db, isPrimary := router.Reader(test.consistency, test.sessionToken)
+				switch {
+				case test.want == -1 && (db != primary || !isPrimary):
+					t.Fatalf("read from a replica, want the primary")
+				case test.want >= 0 && (db != router.replicas[test.want].db || isPrimary):
+					t.Fatalf("read from the wrong database, want replica %d", test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReaderSpreadsReads(t *testing.T) {
+	router := &Router{primary: open(t, "primary"), logger: zap.NewNop()}
+	for i := 0; i < 3; i++ {
+		router.replicas = append(router.replicas, &replica{index: i, db: open(t, "replica"+strconv.Itoa(i)), healthy: true, caughtUpTo: time.Now()})
+	}
+	seen := map[*gorm.DB]int{}
+	for i := 0; i < 30; i++ {
+		db, _ := router.Reader("", "")
+		seen[db]++
+	}
+	for i, r := range router.replicas {
+		if seen[r.db] != 10 {
+			t.Fatalf("replica %d served %d of 30 reads, want 10", i, seen[r.db])
+		}
+	}
+}
+
+func TestServerWithoutReplicationStatusIsOutOfRotation(t *testing.T) {
+	primary := open(t, "primary")
+	// SQLite has no replica status at all, like a server that was never made a replica
+	router := NewRouter(primary, []*gorm.DB{open(t, "replica")}, time.Minute, time.Hour, zap.NewNop())
+	if db, isPrimary := router.Reader("", ""); db != primary || !isPrimary {
+		t.Fatal("read from a server with no replication status")
+	}
+}
+
+func TestSessionToken(t *testing.T) {
+	before := time.Now().Truncate(time.Millisecond)
+	writtenAt, ok := parseSessionToken(NewSessionToken())
+	if !ok || writtenAt.Before(before) || writtenAt.After(time.Now()) {
+		t.Fatalf("session token read back as %v, %v", writtenAt, ok)
+	}
+	if _, ok := parseSessionToken(""); ok {
+		t.Fatal("an empty session token parsed")
+	}
+}
@@ -0,0 +1,492 @@
+package resp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kv-storage/auth"
+	kvpb "github.com/kv-storage/proto/kv"
+	"github.com/tidwall/redcon"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	requestTimeout = 10 * time.Second
+	serverVersion  = "7.0.0"
+
+	errWrongType = "WRONGTYPE Operation against a key holding the wrong kind of value"
+	errSyntax    = "ERR syntax error"
+	errWrongPass = "WRONGPASS invalid username-password pair or user is disabled."
+	errNoAuth    = "ERR AUTH called without any password configured"
+	errBinary    = "ERR arguments must be valid UTF-8, binary keys and values are not stored"
+)
+
+// session is the per-connection state.
+type session struct {
+	// protocol is 2 until the client switches to RESP3 with HELLO 3.
+	protocol int
+	// credential is the API key or JWT given to AUTH, sent with every call.
+	credential string
+}
+
+// Server speaks the Redis protocol and serves every command through the
+// KeyValueStore service, so Redis clients share its cache, store and rules.
+// The service stores text, so unlike Redis it refuses arguments that are not
+// valid UTF-8 instead of storing them altered.
+type Server struct {
+	client        kvpb.KeyValueStoreClient
+	authenticator *auth.Authenticator
+	logger        *zap.Logger
+}
+
+// NewServer checks the credentials given to AUTH and HELLO with
+// authenticator, which is nil when authentication is off.
+func NewServer(client kvpb.KeyValueStoreClient, authenticator *auth.Authenticator, logger *zap.Logger) *Server {
+	return &Server{client: client, authenticator: authenticator, logger: logger}
+}
+
+// login checks credential and, if it is valid, sends it with the session's
+// calls from now on. It replies with the error otherwise.
+func (s *Server) login(conn redcon.Conn, sess *session, credential string) bool {
+	if s.authenticator == nil {
+		conn.WriteError(errNoAuth)
+		return false
+	}
+	if _, err := s.authenticator.Check(credential); err != nil {
+		conn.WriteError(errWrongPass)
+		return false
+	}
+	sess.credential = credential
+	return true
+}
+
+func (s *Server) Serve(listener net.Listener) error {
+	return redcon.Serve(listener, s.handle, func(conn redcon.Conn) bool {
+		conn.SetContext(&session{protocol: 2})
+		return true
+	}, func(conn redcon.Conn, err error) {
+		if err != nil {
+			s.logger.Debug("RESP connection closed", zap.String("remote", conn.RemoteAddr()), zap.Error(err))
+		}
+	})
+}
+
+func (s *Server) handle(conn redcon.Conn, cmd redcon.Command) {
+	sess := conn.Context().(*session)
+	args := make([]string, len(cmd.Args))
+	for i, arg := range cmd.Args {
+		if !utf8.Valid(arg) {
+			conn.WriteError(errBinary)
+			return
+		}
+		args[i] = string(arg)
+	}
+	name := strings.ToLower(args[0])
+	args = args[1:]
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	// Rate limits count the client, not this listener
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-forwarded-for", conn.RemoteAddr())
+	if sess.credential != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+sess.credential)
+	}
+
+	switch name {
+	case "ping":
+		switch len(args) {
+		case 0:
+			conn.WriteString("PONG")
+		case 1:
+			conn.WriteBulkString(args[0])
+		default:
+			wrongArgs(conn, name)
+		}
+	case "echo":
+		if len(args) != 1 {
+			wrongArgs(conn, name)
+			return
+		}
+		conn.WriteBulkString(args[0])
+	case "auth":
+		// AUTH password or AUTH username password; the password is the API key or JWT
+		if len(args) != 1 && len(args) != 2 {
+			wrongArgs(conn, name)
+			return
+		}
+		if s.login(conn, sess, args[len(args)-1]) {
+			conn.WriteString("OK")
+		}
+	case "hello":
+		s.hello(conn, sess, args)
+	case "quit":
+		conn.WriteString("OK")
+		conn.Close()
+	case "select":
+		// There is a single keyspace, which clients know as database 0
+		if len(args) != 1 || args[0] != "0" {
+			conn.WriteError("ERR DB index is out of range")
+			return
+		}
+		conn.WriteString("OK")
+	case "client":
+		// CLIENT SETNAME/SETINFO are sent by clients on connect; nothing to record
+		conn.WriteString("OK")
+	case "command":
+		// redis-cli asks for command docs to drive hints, an empty list is enough
+		conn.WriteArray(0)
+	case "get":
+		if len(args) != 1 {
+			wrongArgs(conn, name)
+			return
+		}
+		s.get(ctx, conn, sess, args[0], false)
+	case "mget":
+		if len(args) == 0 {
+			wrongArgs(conn, name)
+			return
+		}
+		conn.WriteArray(len(args))
+		for _, key := range args {
+			s.get(ctx, conn, sess, key, true)
+		}
+	case "set":
+		s.set(ctx, conn, sess, args)
+	case "mset":
+		s.mset(ctx, conn, args)
+	case "del":
+		if len(args) == 0 {
+			wrongArgs(conn, name)
+			return
+		}
+		s.del(ctx, conn, args)
+	case "exists":
+		if len(args) == 0 {
+			wrongArgs(conn, name)
+			return
+		}
+		s.exists(ctx, conn, args)
+	case "expire", "pexpire":
+		if len(args) != 2 {
+			wrongArgs(conn, name)
+			return
+		}
+		s.expire(ctx, conn, args[0], args[1], name == "pexpire")
+	case "ttl", "pttl":
+		if len(args) != 1 {
+			wrongArgs(conn, name)
+			return
+		}
+		s.ttl(ctx, conn, args[0], name == "pttl")
+	case "scan":
+		s.scan(ctx, conn, args)
+	default:
+		conn.WriteError(fmt.Sprintf("ERR unknown command '%s'", name))
+	}
+}
+
+func wrongArgs(conn redcon.Conn, name string) {
+	conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", name))
+}
+
+// writeNull writes the null reply of the session's protocol version.
+func writeNull(conn redcon.Conn, sess *session) {
+	if sess.protocol == 3 {
+		conn.WriteRaw([]byte("_\r\n"))
+		return
+	}
+	conn.WriteNull()
+}
+
+// writeFailure reports a status the command has no reply of its own for.
+func writeFailure(conn redcon.Conn, statusCode int64, message string) {
+	if statusCode == 409 {
+		conn.WriteError(errWrongType)
+		return
+	}
+	conn.WriteError("ERR " + message)
+}
+
+func (s *Server) hello(conn redcon.Conn, sess *session, args []string) {
+	protocol := sess.protocol
+	if len(args) > 0 {
+		var err error
+		protocol, err = strconv.Atoi(args[0])
+		if err != nil || (protocol != 2 && protocol != 3) {
+			conn.WriteError("NOPROTO unsupported protocol version")
+			return
+		}
+	}
+	// SETNAME is accepted but has nothing to act on
+	for i := 1; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "AUTH":
+			if i+2 >= len(args) {
+				conn.WriteError(errSyntax)
+				return
+			}
+			// A failed login leaves the connection as it was, protocol included
+			if !s.login(conn, sess, args[i+2]) {
+				return
+			}
+			i += 2
+		case "SETNAME":
+			i++
+		}
+	}
+	sess.protocol = protocol
+	fields := []any{
+		"server", "kv-storage",
+		"version", serverVersion,
+		"proto", sess.protocol,
+		"mode", "standalone",
+		"role", "master",
+	}
+	if sess.protocol == 3 {
+		conn.WriteRaw([]byte(fmt.Sprintf("%%%d\r\n", len(fields)/2+1)))
+	} else {
+		conn.WriteArray(len(fields) + 2)
+	}
+	for i := 0; i < len(fields); i += 2 {
+		conn.WriteBulkString(fields[i].(string))
+		switch value := fields[i+1].(type) {
+		case int:
+			conn.WriteInt(value)
+		case string:
+			conn.WriteBulkString(value)
+		}
+	}
+	conn.WriteBulkString("modules")
+	conn.WriteArray(0)
+}
+
+// get replies with a key's value. Within MGET a key of another type reads as
+// null rather than failing the whole reply.
+func (s *Server) get(ctx context.Context, conn redcon.Conn, sess *session, key string, multi bool) {
+	response, err := s.client.GetKeyValue(ctx, &kvpb.GetKVRequest{Key: key})
+	switch {
+	case err != nil:
+		conn.WriteError(errorReply(err))
+	case response.StatusCode == 200:
+		conn.WriteBulkString(response.Value)
+	case response.StatusCode == 404 || (multi && response.StatusCode == 409):
+		writeNull(conn, sess)
+	default:
+		writeFailure(conn, response.StatusCode, response.Message)
+	}
+}
+
+// set handles SET key value [NX|XX] [EX seconds|PX milliseconds].
+func (s *Server) set(ctx context.Context, conn redcon.Conn, sess *session, args []string) {
+	if len(args) < 2 {
+		wrongArgs(conn, "set")
+		return
+	}
+	request := &kvpb.SetKeyValueRequest{Key: args[0], Value: args[1], Mode: "upsert"}
+	conditional := false
+	expirySet := false
+	for i := 2; i < len(args); i++ {
+		option := strings.ToUpper(args[i])
+		switch {
+		case (option == "NX" || option == "XX") && !conditional:
+			conditional = true
+			request.Mode = map[string]string{"NX": "create", "XX": "update"}[option]
+		case (option == "EX" || option == "PX") && !expirySet && i+1 < len(args):
+			expirySet = true
+			i++
+			amount, err := strconv.ParseInt(args[i], 10, 64)
+			if err != nil || amount <= 0 {
+				conn.WriteError("ERR invalid expire time in 'set' command")
+				return
+			}
+			if option == "EX" {
+				amount *= 1000
+			}
+			request.TtlMilliseconds = amount
+		default:
+			conn.WriteError(errSyntax)
+			return
+		}
+	}
+
+	response, err := s.client.SetKeyValue(ctx, request)
+	switch {
+	case err != nil:
+		conn.WriteError(errorReply(err))
+	case response.StatusCode == 200 || response.StatusCode == 201:
+		conn.WriteString("OK")
+	// NX on an existing key or XX on a missing one is a no-op, not an error
+	case (request.Mode == "create" && response.StatusCode == 409) || (request.Mode == "update" && response.StatusCode == 404):
+		writeNull(conn, sess)
+	default:
+		conn.WriteError("ERR " + response.Message)
+	}
+}
+
+// mset writes each pair in turn; unlike Redis it is not atomic, so a failure
+// part way leaves the earlier pairs written.
+func (s *Server) mset(ctx context.Context, conn redcon.Conn, args []string) {
+	if len(args) == 0 || len(args)%2 != 0 {
+		wrongArgs(conn, "mset")
+		return
+	}
+	for i := 0; i < len(args); i += 2 {
+		response, err := s.client.SetKeyValue(ctx, &kvpb.SetKeyValueRequest{Key: args[i], Value: args[i+1], Mode: "upsert"})
+		if err != nil {
+			conn.WriteError(errorReply(err))
+			return
+		}
+		if response.StatusCode != 200 && response.StatusCode != 201 {
+			conn.WriteError("ERR " + response.Message)
+			return
+		}
+	}
+	conn.WriteString("OK")
+}
+
+func (s *Server) del(ctx context.Context, conn redcon.Conn, keys []string) {
+	deleted := 0
+	for _, key := range keys {
+		response, err := s.client.DeleteKeyValue(ctx, &kvpb.DeleteKeyValueRequest{Key: key})
+		if err != nil {
+			conn.WriteError(errorReply(err))
+			return
+		}
+		switch response.StatusCode {
+		case 200:
+			deleted++
+		case 404:
+		default:
+			conn.WriteError("ERR " + response.Message)
+			return
+		}
+	}
+	conn.WriteInt(deleted)
+}
+
+// exists counts keys of any type, a key named twice counting twice as in Redis.
+func (s *Server) exists(ctx context.Context, conn redcon.Conn, keys []string) {
+	found := 0
+	for _, key := range keys {
+		response, err := s.client.GetTTL(ctx, &kvpb.TTLRequest{Key: key})
+		if err != nil {
+			conn.WriteError(errorReply(err))
+			return
+		}
+		switch response.StatusCode {
+		case 200:
+			found++
+		case 404:
+		default:
+			conn.WriteError("ERR " + response.Message)
+			return
+		}
+	}
+	conn.WriteInt(found)
+}
+
+func (s *Server) expire(ctx context.Context, conn redcon.Conn, key, amount string, milliseconds bool) {
+	ttl, err := strconv.ParseInt(amount, 10, 64)
+	if err != nil {
+		conn.WriteError("ERR value is not an integer or out of range")
+		return
+	}
+	if !milliseconds {
+		ttl *= 1000
+	}
+	response, err := s.client.Expire(ctx, &kvpb.ExpireRequest{Key: key, TtlMilliseconds: ttl})
+	switch {
+	case err != nil:
+		conn.WriteError(errorReply(err))
+	case response.StatusCode == 200:
+		conn.WriteInt(1)
+	case response.StatusCode == 404:
+		conn.WriteInt(0)
+	default:
+		conn.WriteError("ERR " + response.Message)
+	}
+}
+
+// ttl answers -2 for a missing key and -1 for one that never expires.
+func (s *Server) ttl(ctx context.Context, conn redcon.Conn, key string, milliseconds bool) {
+	response, err := s.client.GetTTL(ctx, &kvpb.TTLRequest{Key: key})
+	switch {
+	case err != nil:
+		conn.WriteError(errorReply(err))
+	case response.StatusCode == 404:
+		conn.WriteInt(-2)
+	case response.StatusCode != 200:
+		conn.WriteError("ERR " + response.Message)
+	case response.TtlMilliseconds < 0 || milliseconds:
+		conn.WriteInt64(response.TtlMilliseconds)
+	default:
+		conn.WriteInt64((response.TtlMilliseconds + 500) / 1000)
+	}
+}
+
+// scan handles SCAN cursor [MATCH pattern] [COUNT count].
+func (s *Server) scan(ctx context.Context, conn redcon.Conn, args []string) {
+	if len(args) == 0 {
+		wrongArgs(conn, "scan")
+		return
+	}
+	cursor, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		conn.WriteError("ERR invalid cursor")
+		return
+	}
+	request := &kvpb.ScanRequest{Cursor: cursor}
+	for i := 1; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			conn.WriteError(errSyntax)
+			return
+		}
+		switch strings.ToUpper(args[i]) {
+		case "MATCH":
+			request.Match = args[i+1]
+		case "COUNT":
+			count, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil || count < 1 {
+				conn.WriteError(errSyntax)
+				return
+			}
+			request.Count = count
+		default:
+			conn.WriteError(errSyntax)
+			return
+		}
+	}
+
+	response, err := s.client.Scan(ctx, request)
+	if err != nil {
+		conn.WriteError(errorReply(err))
+		return
+	}
+	if response.StatusCode != 200 {
+		conn.WriteError("ERR " + response.Message)
+		return
+	}
+	conn.WriteArray(2)
+	conn.WriteBulkString(strconv.FormatUint(response.Cursor, 10))
+	conn.WriteArray(len(response.Keys))
+	for _, key := range response.Keys {
+		conn.WriteBulkString(key)
+	}
+}
+
+// errorReply words a failed call as Redis would: bad input as a plain ERR
+// with what was wrong with it, anything else with the gRPC error.
+func errorReply(err error) string {
+	if st, ok := status.FromError(err); ok && st.Code() == codes.InvalidArgument {
+		return "ERR invalid argument: " + st.Message()
+	}
+	return "ERR " + err.Error()
+}
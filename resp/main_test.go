@@ -0,0 +1,120 @@
+package resp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	kvpb "github.com/kv-storage/proto/kv"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// store is a KeyValueStore client over a map, with one list key.
+type store struct {
+	kvpb.KeyValueStoreClient
+	values map[string]string
+}
+
+func (s *store) GetKeyValue(ctx context.Context, request *kvpb.GetKVRequest, opts ...grpc.CallOption) (*kvpb.GetKVResponse, error) {
+	if request.Key == "list" {
+		return &kvpb.GetKVResponse{StatusCode: 409, Message: "Key holds a list value, not a string"}, nil
+	}
+	value, ok := s.values[request.Key]
+	if !ok {
+		return &kvpb.GetKVResponse{StatusCode: 404, Message: "Key not found"}, nil
+	}
+	return &kvpb.GetKVResponse{StatusCode: 200, Value: value}, nil
+}
+
+func (s *store) SetKeyValue(ctx context.Context, request *kvpb.SetKeyValueRequest, opts ...grpc.CallOption) (*kvpb.SetKeyValueResponse, error) {
+	_, exists := s.values[request.Key]
+	switch {
+	case request.Mode == "create" && exists:
+		return &kvpb.SetKeyValueResponse{StatusCode: 409, Message: "Key already exists"}, nil
+	case request.Mode == "update" && !exists:
+		return &kvpb.SetKeyValueResponse{StatusCode: 404, Message: "Key not found"}, nil
+	}
+	s.values[request.Key] = request.Value
+	return &kvpb.SetKeyValueResponse{StatusCode: 201}, nil
+}
+
+func (s *store) DeleteKeyValue(ctx context.Context, request *kvpb.DeleteKeyValueRequest, opts ...grpc.CallOption) (*kvpb.DeleteKeyValueResponse, error) {
+	if _, ok := s.values[request.Key]; !ok {
+		return &kvpb.DeleteKeyValueResponse{StatusCode: 404}, nil
+	}
+	delete(s.values, request.Key)
+	return &kvpb.DeleteKeyValueResponse{StatusCode: 200}, nil
+}
+
+// command sends args as a RESP array.
+func command(conn net.Conn, args ...string) error {
+	message := fmt.Sprintf("*%d\r\n", len(args))
+	for _, arg := range args {
+		message += fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg)
+	}
+	_, err := conn.Write([]byte(message))
+	return err
+}
+
+func TestCommands(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer(&store{values: map[string]string{}}, nil, zap.NewNop())
+	go server.Serve(listener)
+	defer listener.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	replies := bufio.NewReader(conn)
+
+	// Each step's reply is read line by line, as many lines as it has
+	steps := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"PING"}, "+PONG\r\n"},
+		{[]string{"SET", "a", "1"}, "+OK\r\n"},
+		{[]string{"GET", "a"}, "$1\r\n1\r\n"},
+		{[]string{"SET", "a", "2", "NX"}, "$-1\r\n"},
+		{[]string{"SET", "b", "2", "XX"}, "$-1\r\n"},
+		{[]string{"SET", "a", "2", "EX", "0"}, "-ERR invalid expire time in 'set' command\r\n"},
+		{[]string{"SET", "a", "2", "NX", "XX"}, "-ERR syntax error\r\n"},
+		{[]string{"SET", "a", "\xff\xfe"}, "-" + errBinary + "\r\n"},
+		{[]string{"GET", "\xff"}, "-" + errBinary + "\r\n"},
+		{[]string{"GET", "list"}, "-" + errWrongType + "\r\n"},
+		{[]string{"MGET", "a", "list", "missing"}, "*3\r\n$1\r\n1\r\n$-1\r\n$-1\r\n"},
+		{[]string{"DEL", "a", "missing"}, ":1\r\n"},
+		{[]string{"GET", "a"}, "$-1\r\n"},
+		{[]string{"HELLO", "3"}, "%6\r\n$6\r\nserver\r\n$10\r\nkv-storage\r\n$7\r\nversion\r\n$5\r\n" + serverVersion + "\r\n$5\r\nproto\r\n:3\r\n$4\r\nmode\r\n$10\r\nstandalone\r\n$4\r\nrole\r\n$6\r\nmaster\r\n$7\r\nmodules\r\n*0\r\n"},
+		{[]string{"GET", "a"}, "_\r\n"},
+		{[]string{"AUTH", "secret"}, "-" + errNoAuth + "\r\n"},
+		{[]string{"SELECT", "1"}, "-ERR DB index is out of range\r\n"},
+		{[]string{"GET"}, "-ERR wrong number of arguments for 'get' command\r\n"},
+		{[]string{"FLUSHALL"}, "-ERR unknown command 'flushall'\r\n"},
+	}
+	for _, step := range steps {
+		if err := command(conn, step.args...); err != nil {
+			t.Fatal(err)
+		}
+		var got strings.Builder
+		for lines := strings.Count(step.want, "\r\n"); lines > 0; lines-- {
+			line, err := replies.ReadString('\n')
+			if err != nil {
+				t.Fatalf("%q: %v after %q", step.args, err, got.String())
+			}
+			got.WriteString(line)
+		}
+		if got.String() != step.want {
+			t.Fatalf("%q replied %q, want %q", step.args, got.String(), step.want)
+		}
+	}
+}
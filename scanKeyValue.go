@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/kv-storage/model"
+	kvpb "github.com/kv-storage/proto/kv"
+)
+
+const (
+	defaultScanCount = 10
+	maxScanCount     = 1000
+)
+
+// Scan walks key rows in ID order, so the cursor is simply the last ID
+// examined. Keys created during a scan may or may not be returned, but every
+// key that exists throughout is returned exactly once. In sharded mode it
+// only covers the keys this node holds.
+func (KvServerManager *KvService) Scan(ctx context.Context, request *kvpb.ScanRequest) (*kvpb.ScanResponse, error) {
+	count := request.Count
+	if count <= 0 {
+		count = defaultScanCount
+	}
+	count = min(count, maxScanCount)
+
+	var batch []model.KV
+	err := kvDbConnector.Select("id", "key_name").
+		Where("id > ? AND (expires_at IS NULL OR expires_at > ?)", request.Cursor, time.Now()).
+		Order("id").Limit(int(count)).Find(&batch).Error
+	if err != nil {
+		return &kvpb.ScanResponse{
+			Message:    "Database error",
+			StatusCode: int64(StatusInternalServerError),
+		}, nil
+	}
+	response := &kvpb.ScanResponse{Message: "Scan page", StatusCode: int64(StatusOK), Keys: []string{}}
+	for _, kv := range batch {
+		if request.Match == "" || globMatch(request.Match, kv.Key) {
+			response.Keys = append(response.Keys, kv.Key)
+		}
+	}
+	if int64(len(batch)) == count {
+		response.Cursor = uint64(batch[len(batch)-1].ID)
+	}
+	return response, nil
+}
+
+// globMatch reports whether name matches a Redis-style glob: * matches any
+// run of characters, ? any single one, [abc], [a-z] and [^a] a class, and a
+// backslash escapes the next character.
+func globMatch(pattern, name string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if globMatch(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(name) == 0 {
+				return false
+			}
+		case '[':
+			if len(name) == 0 {
+				return false
+			}
+			matched, rest := matchClass(pattern[1:], name[0])
+			if !matched {
+				return false
+			}
+			pattern = rest
+			name = name[1:]
+			continue
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(name) == 0 || pattern[0] != name[0] {
+				return false
+			}
+		}
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+	return len(name) == 0
+}
+
+// matchClass matches c against the class that pattern opens with, just past
+// the '[', and returns what follows the class.
+func matchClass(pattern string, c byte) (bool, string) {
+	negate := len(pattern) > 0 && pattern[0] == '^'
+	if negate {
+		pattern = pattern[1:]
+	}
+	matched := false
+	for len(pattern) > 0 && pattern[0] != ']' {
+		low := pattern[0]
+		if low == '\\' && len(pattern) > 1 {
+			pattern = pattern[1:]
+			low = pattern[0]
+		}
+		pattern = pattern[1:]
+		high := low
+		if len(pattern) > 1 && pattern[0] == '-' && pattern[1] != ']' {
+			high = pattern[1]
+			pattern = pattern[2:]
+			if low > high {
+				low, high = high, low
+			}
+		}
+		if low <= c && c <= high {
+			matched = true
+		}
+	}
+	if len(pattern) > 0 {
+		// Skip the closing ']'
+		pattern = pattern[1:]
+	}
+	return matched != negate, pattern
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+
+	"github.com/kv-storage/model"
+	kvpb "github.com/kv-storage/proto/kv"
+	"gorm.io/gorm"
+)
+
+func (KvServerManager *KvService) SetAdd(ctx context.Context, request *kvpb.SetMembersRequest) (*kvpb.CollectionResponse, error) {
+	return mutateCollection(ctx, request, collectionOp{Op: opSetAdd, Key: request.Key, Values: request.Members})
+}
+
+func (KvServerManager *KvService) SetRemove(ctx context.Context, request *kvpb.SetMembersRequest) (*kvpb.CollectionResponse, error) {
+	return mutateCollection(ctx, request, collectionOp{Op: opSetRemove, Key: request.Key, Values: request.Members})
+}
+
+func (KvServerManager *KvService) SetMembers(ctx context.Context, request *kvpb.CollectionKeyRequest) (*kvpb.CollectionResponse, error) {
+	kv, failure := readCollection(ctx, request.Key, model.TypeSet)
+	if failure != nil {
+		return failure, nil
+	}
+	response := &kvpb.CollectionResponse{Message: "Key found", StatusCode: int64(StatusOK)}
+	err := kvDbConnector.Model(&model.SetMember{}).Where("kv_id = ?", kv.ID).Order("member").Pluck("member", &response.Values).Error
+	if err != nil {
+		return collectionError(StatusInternalServerError, "Database error"), nil
+	}
+	response.Count = int64(len(response.Values))
+	return response, nil
+}
+
+// setAdd inserts the members not already present and reports how many that was.
+func setAdd(tx *gorm.DB, kvID uint, members []string) (int64, error) {
+	existing, err := existingNames(tx, &model.SetMember{}, "member", kvID, members)
+	if err != nil {
+		return 0, err
+	}
+	var added []model.SetMember
+	for _, member := range members {
+		if existing[member] {
+			continue
+		}
+		existing[member] = true
+		added = append(added, model.SetMember{KVID: kvID, Member: member})
+	}
+	if len(added) > 0 {
+		if err := tx.Create(&added).Error; err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(added)), nil
+}
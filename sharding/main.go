@@ -0,0 +1,595 @@
+package sharding
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kv-storage/model"
+	kvpb "github.com/kv-storage/proto/kv"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"gorm.io/gorm"
+)
+
+const (
+	// HashName tells smart clients how keys and tokens are placed on the ring:
+	// the first 8 bytes of SHA-256, big-endian. Node tokens hash "nodeId#i".
+	HashName = "sha256-64"
+
+	forwardedKey   = "x-kv-shard-forwarded"
+	routedService  = "/kv.KeyValueStore/"
+	handoffBatch   = 500
+	handoffRetry   = 10 * time.Second
+	handoffGrace   = time.Minute
+	forwardTimeout = 5 * time.Second
+)
+
+func hashKey(key string) uint64 {
+	sum := sha256.Sum256([]byte(key))
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+type position struct {
+	token  uint64
+	nodeID string
+}
+
+// Ring is an immutable consistent-hash ring with virtual nodes.
+type Ring struct {
+	Version      uint64
+	VirtualNodes int
+	Nodes        map[string]string
+	positions    []position
+}
+
+func NewRing(version uint64, virtualNodes int, nodes map[string]string) *Ring {
+	ring := &Ring{Version: version, VirtualNodes: virtualNodes, Nodes: nodes}
+	for nodeID := range nodes {
+		for i := 0; i < virtualNodes; i++ {
+			ring.positions = append(ring.positions, position{hashKey(nodeID + "#" + strconv.Itoa(i)), nodeID})
+		}
+	}
+	sort.Slice(ring.positions, func(i, j int) bool {
+		if ring.positions[i].token != ring.positions[j].token {
+			return ring.positions[i].token < ring.positions[j].token
+		}
+		return ring.positions[i].nodeID < ring.positions[j].nodeID
+	})
+	return ring
+}
+
+// Owner is the node holding the first token at or after the key's hash.
+func (r *Ring) Owner(key string) string {
+	if len(r.positions) == 0 {
+		return ""
+	}
+	h := hashKey(key)
+	i := sort.Search(len(r.positions), func(i int) bool { return r.positions[i].token >= h })
+	if i == len(r.positions) {
+		i = 0
+	}
+	return r.positions[i].nodeID
+}
+
+func (r *Ring) tokens(nodeID string) []uint64 {
+	var tokens []uint64
+	for _, p := range r.positions {
+		if p.nodeID == nodeID {
+			tokens = append(tokens, p.token)
+		}
+	}
+	return tokens
+}
+
+func (r *Ring) withNodes(nodes map[string]string) *Ring {
+	return NewRing(r.Version+1, r.VirtualNodes, nodes)
+}
+
+// fingerprint identifies the ring's membership.
+func (r *Ring) fingerprint() string {
+	ids := make([]string, 0, len(r.Nodes))
+	for nodeID := range r.Nodes {
+		ids = append(ids, nodeID)
+	}
+	sort.Strings(ids)
+	h := sha256.New()
+	for _, nodeID := range ids {
+		h.Write([]byte(nodeID + "=" + r.Nodes[nodeID] + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// newerThan orders rings by version, then by fingerprint, so nodes that saw
+// two different rings of the same version all settle on the same one.
+func (r *Ring) newerThan(other *Ring) bool {
+	if r.Version != other.Version {
+		return r.Version > other.Version
+	}
+	return r.fingerprint() > other.fingerprint()
+}
+
+// coordinator is the node that makes membership changes, the lowest node ID.
+func (r *Ring) coordinator() string {
+	coordinator := ""
+	for nodeID := range r.Nodes {
+		if coordinator == "" || nodeID < coordinator {
+			coordinator = nodeID
+		}
+	}
+	return coordinator
+}
+
+// Store is this node's local key-value data.
+type Store interface {
+	// Drop removes a key that has been handed off to its new owner.
+	Drop(key string) error
+	// CopyCollection recreates a hash, list or set key through client. The
+	// elements are merged into whatever the owner already holds for the key.
+	CopyCollection(ctx context.Context, client kvpb.KeyValueStoreClient, kv model.KV) error
+}
+
+// Router sends every KeyValueStore call to the node owning its key and moves
+// data between nodes in the background when the ring changes.
+type Router struct {
+	kvpb.UnimplementedShardRingServer
+
+	self   string
+	db     *gorm.DB
+	store  Store
+	logger *zap.Logger
+
+	peerCredentials credentials.TransportCredentials
+
+	// changing serializes the membership changes this node coordinates
+	changing sync.Mutex
+
+	mu          sync.RWMutex
+	ring        *Ring
+	previous    *Ring
+	connections map[string]*grpc.ClientConn
+	handoff     chan struct{}
+}
+
+// NewRouter starts from the ring saved in the database if there is one, and
+// from the configured nodes otherwise.
+func NewRouter(self, selfAddress string, nodes map[string]string, virtualNodes int, db *gorm.DB, store Store, peerCredentials credentials.TransportCredentials, logger *zap.Logger) (*Router, error) {
+	router := &Router{
+		self:            self,
+		peerCredentials: peerCredentials,
+		db:              db,
+		store:           store,
+		logger:          logger,
+		connections:     make(map[string]*grpc.ClientConn),
+		handoff:         make(chan struct{}, 1),
+	}
+
+	var saved model.ShardRing
+	err := db.Limit(1).Find(&saved, 1).Error
+	if err != nil {
+		return nil, err
+	}
+	if saved.Version > 0 {
+		nodes = make(map[string]string)
+		if err := json.Unmarshal([]byte(saved.Nodes), &nodes); err != nil {
+			return nil, err
+		}
+	} else if _, ok := nodes[self]; !ok {
+		nodes[self] = selfAddress
+	}
+	router.ring = NewRing(saved.Version, virtualNodes, nodes)
+
+	go router.runHandoff()
+	router.scheduleHandoff()
+	return router, nil
+}
+
+func (r *Router) rings() (*Ring, *Ring) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.ring, r.previous
+}
+
+// install swaps in a newer ring, keeping the old one so reads can fall back
+// to previous owners until our handoff has finished.
+func (r *Router) install(ring *Ring) bool {
+	data, err := json.Marshal(ring.Nodes)
+	if err != nil {
+		return false
+	}
+
+	r.mu.Lock()
+	if !ring.newerThan(r.ring) {
+		r.mu.Unlock()
+		return false
+	}
+	r.previous, r.ring = r.ring, ring
+	r.mu.Unlock()
+
+	if err := r.db.Save(&model.ShardRing{ID: 1, Version: ring.Version, Nodes: string(data)}).Error; err != nil {
+		r.logger.Error("Failed to persist shard ring", zap.Uint64("version", ring.Version), zap.Error(err))
+	}
+	r.logger.Info("Installed shard ring", zap.Uint64("version", ring.Version), zap.Int("nodes", len(ring.Nodes)))
+	r.scheduleHandoff()
+	return true
+}
+
+func (r *Router) scheduleHandoff() {
+	select {
+	case r.handoff <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Router) runHandoff() {
+	for range r.handoff {
+		for !r.handOffOnce() {
+			time.Sleep(handoffRetry)
+		}
+		ring, _ := r.rings()
+		time.AfterFunc(handoffGrace, func() {
+			r.mu.Lock()
+			if r.ring == ring {
+				r.previous = nil
+			}
+			r.mu.Unlock()
+		})
+	}
+}
+
+// handOffOnce pushes every local key we no longer own to its owner, then
+// drops it locally. It reports whether every such key was moved.
+func (r *Router) handOffOnce() bool {
+	complete := true
+	var lastID uint
+	for {
+		var batch []model.KV
+		if err := r.db.Where("id > ?", lastID).Order("id").Limit(handoffBatch).Find(&batch).Error; err != nil {
+			r.logger.Error("Handoff scan failed", zap.Error(err))
+			return false
+		}
+		if len(batch) == 0 {
+			return complete
+		}
+		ring, _ := r.rings()
+		for _, kv := range batch {
+			lastID = kv.ID
+			owner := ring.Owner(kv.Key)
+			if owner == r.self {
+				continue
+			}
+			if err := r.moveKey(ring.Nodes[owner], kv); err != nil {
+				r.logger.Warn("Handoff of key failed", zap.String("key", kv.Key), zap.String("owner", owner), zap.Error(err))
+				complete = false
+			}
+		}
+	}
+}
+
+// moveKey copies kv to its owner with whatever remains of its TTL, then
+// drops it here. A key that has already expired is only dropped.
+func (r *Router) moveKey(address string, kv model.KV) error {
+	var ttl int64
+	if kv.ExpiresAt != nil {
+		remaining := time.Until(*kv.ExpiresAt)
+		if remaining <= 0 {
+			return r.store.Drop(kv.Key)
+		}
+		// Rounded up, so a key about to expire is not sent without a TTL
+		ttl = int64((remaining + time.Millisecond - 1) / time.Millisecond)
+	}
+	connection, err := r.connection(address)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), forwardTimeout)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, forwardedKey, r.self)
+	client := kvpb.NewKeyValueStoreClient(connection)
+	if kv.Type != "" && kv.Type != model.TypeString {
+		if err := r.store.CopyCollection(ctx, client, kv); err != nil {
+			return err
+		}
+		if ttl > 0 {
+			response, err := client.Expire(ctx, &kvpb.ExpireRequest{Key: kv.Key, TtlMilliseconds: ttl})
+			if err != nil {
+				return err
+			}
+			if response.StatusCode != 200 {
+				return errors.New(response.Message)
+			}
+		}
+		return r.store.Drop(kv.Key)
+	}
+	response, err := client.SetKeyValue(ctx, &kvpb.SetKeyValueRequest{Key: kv.Key, Value: kv.Value, Flags: kv.Flags, TtlMilliseconds: ttl})
+	if err != nil {
+		return err
+	}
+	// A conflict means the owner already took a newer write for this key.
+	if response.StatusCode != 201 && response.StatusCode != 409 {
+		return errors.New(response.Message)
+	}
+	return r.store.Drop(kv.Key)
+}
+
+func (r *Router) connection(address string) (*grpc.ClientConn, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if connection, ok := r.connections[address]; ok {
+		return connection, nil
+	}
+	connection, err := grpc.NewClient(address, grpc.WithTransportCredentials(r.peerCredentials))
+	if err != nil {
+		return nil, err
+	}
+	r.connections[address] = connection
+	return connection, nil
+}
+
+func forwarded(ctx context.Context) bool {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return len(md.Get(forwardedKey)) > 0
+}
+
+// routingKey extracts the key of a KeyValueStore request, if it has one.
+func routingKey(method string, request any) (string, bool) {
+	if !strings.HasPrefix(method, routedService) {
+		return "", false
+	}
+	message, ok := request.(proto.Message)
+	if !ok {
+		return "", false
+	}
+	field := message.ProtoReflect().Descriptor().Fields().ByName("key")
+	if field == nil || field.Kind() != protoreflect.StringKind {
+		return "", false
+	}
+	key := message.ProtoReflect().Get(field).String()
+	return key, key != ""
+}
+
+func notFound(response any) bool {
+	message, ok := response.(proto.Message)
+	if !ok {
+		return false
+	}
+	field := message.ProtoReflect().Descriptor().Fields().ByName("statusCode")
+	return field != nil && message.ProtoReflect().Get(field).Int() == 404
+}
+
+// UnaryInterceptor serves keys we own and proxies the rest to their owner.
+func (r *Router) UnaryInterceptor(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	key, ok := routingKey(info.FullMethod, request)
+	if !ok || forwarded(ctx) {
+		return handler(ctx, request)
+	}
+
+	ring, previous := r.rings()
+	if owner := ring.Owner(key); owner != r.self {
+		return r.invoke(ctx, ring.Nodes[owner], info.FullMethod, request)
+	}
+	response, err := handler(ctx, request)
+	// While a handoff is running the key may still be on its previous owner.
+	if err == nil && previous != nil && notFound(response) {
+		if owner := previous.Owner(key); owner != r.self {
+			if address, ok := previous.Nodes[owner]; ok {
+				return r.invoke(ctx, address, info.FullMethod, request)
+			}
+		}
+	}
+	return response, err
+}
+
+func (r *Router) invoke(ctx context.Context, address, method string, request any) (any, error) {
+	connection, err := r.connection(address)
+	if err != nil {
+		return nil, err
+	}
+	response, err := newResponse(method)
+	if err != nil {
+		return nil, err
+	}
+	if err := connection.Invoke(r.outgoing(ctx), method, request, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// outgoing carries the caller's metadata, credentials included, on to the
+// owner, marked as forwarded so the owner serves the call itself.
+func (r *Router) outgoing(ctx context.Context) context.Context {
+	incoming, _ := metadata.FromIncomingContext(ctx)
+	md := metadata.MD{}
+	for key, values := range incoming {
+		if !strings.HasPrefix(key, ":") {
+			md[key] = values
+		}
+	}
+	md.Set(forwardedKey, r.self)
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// Owner is for calls the interceptor cannot route, such as the records of a
+// stream: it returns the address of key's owner, or "" when this node serves
+// the key, as it does for every call forwarded to it.
+func (r *Router) Owner(ctx context.Context, key string) string {
+	if forwarded(ctx) {
+		return ""
+	}
+	ring, _ := r.rings()
+	owner := ring.Owner(key)
+	if owner == r.self {
+		return ""
+	}
+	return ring.Nodes[owner]
+}
+
+// Forward returns a connection to the node at address and the context to call it with.
+func (r *Router) Forward(ctx context.Context, address string) (*grpc.ClientConn, context.Context, error) {
+	connection, err := r.connection(address)
+	if err != nil {
+		return nil, nil, err
+	}
+	return connection, r.outgoing(ctx), nil
+}
+
+// newResponse allocates the response message of a method from the registered descriptors.
+func newResponse(method string) (proto.Message, error) {
+	name := protoreflect.FullName(strings.ReplaceAll(strings.TrimPrefix(method, "/"), "/", "."))
+	descriptor, err := protoregistry.GlobalFiles.FindDescriptorByName(name)
+	if err != nil {
+		return nil, err
+	}
+	messageType, err := protoregistry.GlobalTypes.FindMessageByName(descriptor.(protoreflect.MethodDescriptor).Output().FullName())
+	if err != nil {
+		return nil, err
+	}
+	return messageType.New().Interface(), nil
+}
+
+func (r *Router) GetRing(ctx context.Context, request *kvpb.GetRingRequest) (*kvpb.GetRingResponse, error) {
+	ring, _ := r.rings()
+	return &kvpb.GetRingResponse{
+		Message:      "Ring fetched",
+		StatusCode:   200,
+		Version:      ring.Version,
+		VirtualNodes: int32(ring.VirtualNodes),
+		Hash:         HashName,
+		Nodes:        ringNodes(ring, true),
+	}, nil
+}
+
+func ringNodes(ring *Ring, withTokens bool) []*kvpb.ShardNode {
+	var nodes []*kvpb.ShardNode
+	for nodeID, address := range ring.Nodes {
+		node := &kvpb.ShardNode{NodeId: nodeID, Address: address}
+		if withTokens {
+			node.Tokens = ring.tokens(nodeID)
+		}
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].NodeId < nodes[j].NodeId })
+	return nodes
+}
+
+// coordinate forwards a membership change to the ring's coordinator, unless
+// this node is the coordinator, in which case it returns a nil response and
+// holds the lock serializing changes until the returned function is called.
+// Changes made on different nodes at once would otherwise both take the
+// next version.
+func (r *Router) coordinate(ctx context.Context, method string, request any) (any, func(), error) {
+	ring, _ := r.rings()
+	if coordinator := ring.coordinator(); coordinator != r.self && !forwarded(ctx) {
+		response, err := r.invoke(ctx, ring.Nodes[coordinator], method, request)
+		return response, nil, err
+	}
+	r.changing.Lock()
+	return nil, r.changing.Unlock, nil
+}
+
+func (r *Router) AddShardNode(ctx context.Context, request *kvpb.AddShardNodeRequest) (*kvpb.AddShardNodeResponse, error) {
+	if request.NodeId == "" || request.Address == "" {
+		return &kvpb.AddShardNodeResponse{Message: "nodeId and address are required", StatusCode: 400}, nil
+	}
+	response, done, err := r.coordinate(ctx, kvpb.ShardRing_AddShardNode_FullMethodName, request)
+	if err != nil {
+		return nil, err
+	}
+	if done == nil {
+		return response.(*kvpb.AddShardNodeResponse), nil
+	}
+	defer done()
+	ring, _ := r.rings()
+	nodes := make(map[string]string, len(ring.Nodes)+1)
+	for nodeID, address := range ring.Nodes {
+		nodes[nodeID] = address
+	}
+	nodes[request.NodeId] = request.Address
+	next := ring.withNodes(nodes)
+	if !r.install(next) {
+		return &kvpb.AddShardNodeResponse{Message: "Ring changed concurrently, retry", StatusCode: 409}, nil
+	}
+	r.broadcast(ctx, next, ring)
+	return &kvpb.AddShardNodeResponse{Message: "Node added", StatusCode: 200, Version: next.Version}, nil
+}
+
+func (r *Router) RemoveShardNode(ctx context.Context, request *kvpb.RemoveShardNodeRequest) (*kvpb.RemoveShardNodeResponse, error) {
+	response, done, err := r.coordinate(ctx, kvpb.ShardRing_RemoveShardNode_FullMethodName, request)
+	if err != nil {
+		return nil, err
+	}
+	if done == nil {
+		return response.(*kvpb.RemoveShardNodeResponse), nil
+	}
+	defer done()
+	ring, _ := r.rings()
+	if _, ok := ring.Nodes[request.NodeId]; !ok {
+		return &kvpb.RemoveShardNodeResponse{Message: "Node not in ring", StatusCode: 404}, nil
+	}
+	if len(ring.Nodes) == 1 {
+		return &kvpb.RemoveShardNodeResponse{Message: "Cannot remove the last node", StatusCode: 400}, nil
+	}
+	nodes := make(map[string]string, len(ring.Nodes))
+	for nodeID, address := range ring.Nodes {
+		if nodeID != request.NodeId {
+			nodes[nodeID] = address
+		}
+	}
+	next := ring.withNodes(nodes)
+	if !r.install(next) {
+		return &kvpb.RemoveShardNodeResponse{Message: "Ring changed concurrently, retry", StatusCode: 409}, nil
+	}
+	// The removed node still gets the new ring so it hands its keys off.
+	r.broadcast(ctx, next, ring)
+	return &kvpb.RemoveShardNodeResponse{Message: "Node removed", StatusCode: 200, Version: next.Version}, nil
+}
+
+// broadcast sends the new ring to every node in either the old or the new ring.
+func (r *Router) broadcast(ctx context.Context, next, old *Ring) {
+	targets := make(map[string]string)
+	for _, ring := range []*Ring{old, next} {
+		for nodeID, address := range ring.Nodes {
+			if nodeID != r.self {
+				targets[nodeID] = address
+			}
+		}
+	}
+	update := &kvpb.UpdateRingRequest{Version: next.Version, Nodes: ringNodes(next, false)}
+	for nodeID, address := range targets {
+		connection, err := r.connection(address)
+		if err == nil {
+			callCtx, cancel := context.WithTimeout(ctx, forwardTimeout)
+			_, err = kvpb.NewShardRingClient(connection).UpdateRing(callCtx, update)
+			cancel()
+		}
+		if err != nil {
+			r.logger.Warn("Failed to send ring update", zap.String("node", nodeID), zap.Error(err))
+		}
+	}
+}
+
+func (r *Router) UpdateRing(ctx context.Context, request *kvpb.UpdateRingRequest) (*kvpb.UpdateRingResponse, error) {
+	nodes := make(map[string]string, len(request.Nodes))
+	for _, node := range request.Nodes {
+		nodes[node.NodeId] = node.Address
+	}
+	ring, _ := r.rings()
+	if !r.install(NewRing(request.Version, ring.VirtualNodes, nodes)) {
+		return &kvpb.UpdateRingResponse{Message: "Stale ring version ignored", StatusCode: 409}, nil
+	}
+	return &kvpb.UpdateRingResponse{Message: "Ring updated", StatusCode: 200}, nil
+}
@@ -0,0 +1,305 @@
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/kv-storage/model"
+	kvpb "github.com/kv-storage/proto/kv"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"gorm.io/gorm"
+)
+
+// owner is Owner done the slow way: the first token at or after the key's
+// hash, wrapping around to the lowest token.
+func owner(r *Ring, key string) string {
+	h := hashKey(key)
+	var best, lowest *position
+	for i := range r.positions {
+		p := &r.positions[i]
+		if p.token >= h && (best == nil || p.token < best.token) {
+			best = p
+		}
+		if lowest == nil || p.token < lowest.token {
+			lowest = p
+		}
+	}
+	if best == nil {
+		best = lowest
+	}
+	if best == nil {
+		return ""
+	}
+	return best.nodeID
+}
+
+func TestOwner(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes map[string]string
+	}{
+		{"no nodes", map[string]string{}},
+		{"one node", map[string]string{"a": "a:1"}},
+		{"three nodes", map[string]string{"a": "a:1", "b": "b:1", "c": "c:1"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ring := NewRing(1, 4, test.nodes)
+			for i := 0; i < 1000; i++ {
+				key := fmt.Sprintf("key-%d", i)
+				if got, want := ring.Owner(key), owner(ring, key); got != want {
+					t.Fatalf("%s is owned by %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAddingANodeOnlyMovesKeysToIt(t *testing.T) {
+	before := NewRing(1, 64, map[string]string{"a": "a:1", "b": "b:1"})
+	after := before.withNodes(map[string]string{"a": "a:1", "b": "b:1", "c": "c:1"})
+	moved := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		old, owner := before.Owner(key), after.Owner(key)
+		if old != owner {
+			moved++
+			if owner != "c" {
+				t.Fatalf("%s moved from %s to %s, not to the new node", key, old, owner)
+			}
+		}
+	}
+	if moved == 0 {
+		t.Fatal("no key moved to the new node")
+	}
+}
+
+func TestNewerThan(t *testing.T) {
+	two := map[string]string{"a": "a:1", "b": "b:1"}
+	other := map[string]string{"a": "a:1", "c": "c:1"}
+	tests := []struct {
+		name        string
+		ring, other *Ring
+		want        bool
+	}{
+		{"higher version", NewRing(2, 4, two), NewRing(1, 4, other), true},
+		{"lower version", NewRing(1, 4, other), NewRing(2, 4, two), false},
+		{"same ring", NewRing(1, 4, two), NewRing(1, 4, two), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.ring.newerThan(test.other); got != test.want {
+				t.Fatalf("newerThan = %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	// Two rings of one version must be ordered the same way by every node
+	x, y := NewRing(3, 4, two), NewRing(3, 4, other)
+	if x.newerThan(y) == y.newerThan(x) {
+		t.Fatal("rings of the same version with different nodes are not ordered")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	ring := NewRing(1, 4, map[string]string{"a": "a:1", "b": "b:1"})
+	tests := []struct {
+		name  string
+		nodes map[string]string
+		same  bool
+	}{
+		{"same nodes", map[string]string{"b": "b:1", "a": "a:1"}, true},
+		{"moved address", map[string]string{"a": "a:2", "b": "b:1"}, false},
+		{"extra node", map[string]string{"a": "a:1", "b": "b:1", "c": "c:1"}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ring.fingerprint() == NewRing(9, 8, test.nodes).fingerprint(); got != test.same {
+				t.Fatalf("fingerprints equal = %v, want %v", got, test.same)
+			}
+		})
+	}
+}
+
+func TestCoordinator(t *testing.T) {
+	tests := []struct {
+		nodes map[string]string
+		want  string
+	}{
+		{map[string]string{}, ""},
+		{map[string]string{"b": "b:1"}, "b"},
+		{map[string]string{"c": "c:1", "a": "a:1", "b": "b:1"}, "a"},
+	}
+	for _, test := range tests {
+		if got := NewRing(1, 4, test.nodes).coordinator(); got != test.want {
+			t.Fatalf("coordinator of %v = %q, want %q", test.nodes, got, test.want)
+		}
+	}
+}
+
+// peer is the node keys are handed off to.
+type peer struct {
+	kvpb.UnimplementedKeyValueStoreServer
+
+	mu       sync.Mutex
+	received []*kvpb.SetKeyValueRequest
+}
+
+func (p *peer) SetKeyValue(ctx context.Context, request *kvpb.SetKeyValueRequest) (*kvpb.SetKeyValueResponse, error) {
+	if !forwarded(ctx) {
+		return &kvpb.SetKeyValueResponse{StatusCode: 400, Message: "not marked as forwarded"}, nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.received = append(p.received, request)
+	return &kvpb.SetKeyValueResponse{StatusCode: 201, Message: "Key created"}, nil
+}
+
+// store is this node's data, reduced to the keys dropped from it.
+type store struct {
+	mu      sync.Mutex
+	dropped []string
+}
+
+func (s *store) Drop(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dropped = append(s.dropped, key)
+	return nil
+}
+
+func (s *store) CopyCollection(ctx context.Context, client kvpb.KeyValueStoreClient, kv model.KV) error {
+	return nil
+}
+
+func TestHandOff(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &peer{}
+	server := grpc.NewServer()
+	kvpb.RegisterKeyValueStoreServer(server, p)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "shard.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.KV{}); err != nil {
+		t.Fatal(err)
+	}
+	ring := NewRing(2, 16, map[string]string{"a": "a:1", "b": listener.Addr().String()})
+	// The first key this node, a, owns, and the first three it hands off
+	var kept, moved, expiring, expired string
+	for i := 0; kept == "" || moved == "" || expiring == "" || expired == ""; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		switch {
+		case ring.Owner(key) == "a":
+			if kept == "" {
+				kept = key
+			}
+		case moved == "":
+			moved = key
+		case expiring == "":
+			expiring = key
+		case expired == "":
+			expired = key
+		}
+	}
+	later, earlier := time.Now().Add(time.Hour), time.Now().Add(-time.Second)
+	rows := []model.KV{
+		{Key: kept, Value: "kept"},
+		{Key: moved, Value: "moved", Flags: 7},
+		{Key: expiring, Value: "expiring", ExpiresAt: &later},
+		{Key: expired, Value: "expired", ExpiresAt: &earlier},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	s := &store{}
+	router := &Router{
+		self:            "a",
+		db:              db,
+		store:           s,
+		logger:          zap.NewNop(),
+		peerCredentials: insecure.NewCredentials(),
+		ring:            ring,
+		connections:     make(map[string]*grpc.ClientConn),
+	}
+	defer func() {
+		for _, connection := range router.connections {
+			connection.Close()
+		}
+	}()
+	if !router.handOffOnce() {
+		t.Fatal("handoff did not complete")
+	}
+
+	received := map[string]*kvpb.SetKeyValueRequest{}
+	for _, request := range p.received {
+		received[request.Key] = request
+	}
+	tests := []struct {
+		key     string
+		sent    bool
+		dropped bool
+		check   func(*kvpb.SetKeyValueRequest) error
+	}{
+		{key: kept},
+		{key: moved, sent: true, dropped: true, check: func(r *kvpb.SetKeyValueRequest) error {
+			if r.Value != "moved" || r.Flags != 7 || r.TtlMilliseconds != 0 {
+				return fmt.Errorf("sent %+v", r)
+			}
+			return nil
+		}},
+		{key: expiring, sent: true, dropped: true, check: func(r *kvpb.SetKeyValueRequest) error {
+			if r.TtlMilliseconds <= 0 || r.TtlMilliseconds > time.Hour.Milliseconds() {
+				return fmt.Errorf("sent with a TTL of %dms, want what remains of an hour", r.TtlMilliseconds)
+			}
+			return nil
+		}},
+		{key: expired, dropped: true},
+	}
+	for _, test := range tests {
+		request, sent := received[test.key]
+		if sent != test.sent {
+			t.Errorf("%s sent = %v, want %v", test.key, sent, test.sent)
+		}
+		if sent && test.check != nil {
+			if err := test.check(request); err != nil {
+				t.Errorf("%s: %v", test.key, err)
+			}
+		}
+		dropped := false
+		for _, key := range s.dropped {
+			dropped = dropped || key == test.key
+		}
+		if dropped != test.dropped {
+			t.Errorf("%s dropped = %v, want %v", test.key, dropped, test.dropped)
+		}
+	}
+}
+
+func TestOutgoingMarksForwardedCalls(t *testing.T) {
+	router := &Router{self: "a"}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer token"))
+	md, _ := metadata.FromOutgoingContext(router.outgoing(ctx))
+	if got := md.Get(forwardedKey); len(got) != 1 || got[0] != "a" {
+		t.Fatalf("forwarded marker %v, want [a]", got)
+	}
+	if got := md.Get("authorization"); len(got) != 1 || got[0] != "Bearer token" {
+		t.Fatalf("credentials %v were not carried on", got)
+	}
+}
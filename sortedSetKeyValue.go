@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+
+	"github.com/kv-storage/model"
+	kvpb "github.com/kv-storage/proto/kv"
+	"gorm.io/gorm"
+)
+
+func (KvServerManager *KvService) SortedSetAdd(ctx context.Context, request *kvpb.SortedSetAddRequest) (*kvpb.CollectionResponse, error) {
+	members := make([]scoredMember, len(request.Members))
+	for i, member := range request.Members {
+		members[i] = scoredMember{Member: member.Member, Score: member.Score}
+	}
+	return mutateCollection(ctx, request, collectionOp{Op: opSortedSetAdd, Key: request.Key, Members: members})
+}
+
+func (KvServerManager *KvService) SortedSetRemove(ctx context.Context, request *kvpb.SetMembersRequest) (*kvpb.CollectionResponse, error) {
+	return mutateCollection(ctx, request, collectionOp{Op: opSortedSetRemove, Key: request.Key, Values: request.Members})
+}
+
+func (KvServerManager *KvService) SortedSetRangeByScore(ctx context.Context, request *kvpb.SortedSetRangeRequest) (*kvpb.CollectionResponse, error) {
+	kv, failure := readCollection(ctx, request.Key, model.TypeSortedSet)
+	if failure != nil {
+		return failure, nil
+	}
+	query := kvDbConnector.Where("kv_id = ?", kv.ID)
+	if request.Min != nil {
+		query = query.Where("score >= ?", *request.Min)
+	}
+	if request.Max != nil {
+		query = query.Where("score <= ?", *request.Max)
+	}
+	query = query.Order("score").Order("member").Offset(int(request.Offset))
+	if request.Limit > 0 {
+		query = query.Limit(int(request.Limit))
+	}
+	var members []model.SortedSetMember
+	if err := query.Find(&members).Error; err != nil {
+		return collectionError(StatusInternalServerError, "Database error"), nil
+	}
+	response := &kvpb.CollectionResponse{
+		Message:    "Key found",
+		StatusCode: int64(StatusOK),
+		Count:      int64(len(members)),
+		Members:    make([]*kvpb.ScoredMember, len(members)),
+	}
+	for i, member := range members {
+		response.Members[i] = &kvpb.ScoredMember{Member: member.Member, Score: member.Score}
+	}
+	return response, nil
+}
+
+// sortedSetAdd sets each member's score, later duplicates in one call
+// winning, and reports how many members are new.
+func sortedSetAdd(tx *gorm.DB, kvID uint, members []scoredMember) (int64, error) {
+	scores := make(map[string]float64, len(members))
+	var names []string
+	for _, member := range members {
+		if _, seen := scores[member.Member]; !seen {
+			names = append(names, member.Member)
+		}
+		scores[member.Member] = member.Score
+	}
+	existing, err := existingNames(tx, &model.SortedSetMember{}, "member", kvID, names)
+	if err != nil {
+		return 0, err
+	}
+	var added []model.SortedSetMember
+	for _, name := range names {
+		if existing[name] {
+			err := tx.Model(&model.SortedSetMember{}).Where("kv_id = ? AND member = ?", kvID, name).Update("score", scores[name]).Error
+			if err != nil {
+				return 0, err
+			}
+			continue
+		}
+		added = append(added, model.SortedSetMember{KVID: kvID, Member: name, Score: scores[name]})
+	}
+	if len(added) > 0 {
+		if err := tx.Create(&added).Error; err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(added)), nil
+}
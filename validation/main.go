@@ -0,0 +1,231 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	kvpb "github.com/kv-storage/proto/kv"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxMemberBytes is the size of the hash field and set member columns.
+const maxMemberBytes = 255
+
+// creating are the calls that can create a key, the only ones held to the
+// key charset, so keys written before it was narrowed can still be read and deleted.
+var creating = map[string]bool{
+	kvpb.KeyValueStore_SetKeyValue_FullMethodName:  true,
+	kvpb.KeyValueStore_BulkSet_FullMethodName:      true,
+	kvpb.KeyValueStore_Increment_FullMethodName:    true,
+	kvpb.KeyValueStore_Decrement_FullMethodName:    true,
+	kvpb.KeyValueStore_HashSet_FullMethodName:      true,
+	kvpb.KeyValueStore_ListPush_FullMethodName:     true,
+	kvpb.KeyValueStore_SetAdd_FullMethodName:       true,
+	kvpb.KeyValueStore_SortedSetAdd_FullMethodName: true,
+}
+
+// Validator checks every field of KeyValueStore requests before they reach
+// the store, so bad input is reported field by field as InvalidArgument and
+// handlers can take their requests as valid. BulkSet checks
+// its records itself, failing bad ones without ending the stream.
+type Validator struct {
+	maxKeyBytes   int
+	maxValueBytes int
+	keyCharset    string
+	keyPattern    *regexp.Regexp
+}
+
+// NewValidator limits keys to maxKeyBytes and values to maxValueBytes; 0
+// leaves values unlimited. keyCharset, when set, is a regexp character
+// class such as [A-Za-z0-9/_.:-] that every character of a new key must
+// match. Keys never contain control characters.
+func NewValidator(maxKeyBytes, maxValueBytes int, keyCharset string) (*Validator, error) {
+	if maxKeyBytes <= 0 || maxKeyBytes > 255 {
+		return nil, fmt.Errorf("maximum key size %d must be between 1 and 255 bytes, the size of the key column", maxKeyBytes)
+	}
+	v := &Validator{maxKeyBytes: maxKeyBytes, maxValueBytes: maxValueBytes, keyCharset: keyCharset}
+	if keyCharset != "" {
+		pattern, err := regexp.Compile(`^(?:` + keyCharset + `)*$`)
+		if err != nil {
+			return nil, fmt.Errorf("key charset: %w", err)
+		}
+		v.keyPattern = pattern
+	}
+	return v, nil
+}
+
+// violations collects what is wrong with a request, field by field.
+type violations []*errdetails.BadRequest_FieldViolation
+
+func (vs *violations) add(field, format string, args ...any) {
+	*vs = append(*vs, &errdetails.BadRequest_FieldViolation{Field: field, Description: fmt.Sprintf(format, args...)})
+}
+
+func (v *Validator) key(vs *violations, field, key string, created bool) {
+	switch {
+	case key == "":
+		vs.add(field, "must not be empty")
+	case len(key) > v.maxKeyBytes:
+		vs.add(field, "must be at most %d bytes, got %d", v.maxKeyBytes, len(key))
+	case !utf8.ValidString(key):
+		vs.add(field, "must be valid UTF-8")
+	case strings.IndexFunc(key, unicode.IsControl) >= 0:
+		vs.add(field, "must not contain control characters")
+	case created && v.keyPattern != nil && !v.keyPattern.MatchString(key):
+		vs.add(field, "may only contain characters matching %s", v.keyCharset)
+	}
+}
+
+func (v *Validator) value(vs *violations, field, value string) {
+	switch {
+	case v.maxValueBytes > 0 && len(value) > v.maxValueBytes:
+		vs.add(field, "must be at most %d bytes, got %d", v.maxValueBytes, len(value))
+	case !utf8.ValidString(value):
+		vs.add(field, "must be valid UTF-8")
+	}
+}
+
+func (v *Validator) member(vs *violations, field, member string) {
+	switch {
+	case len(member) > maxMemberBytes:
+		vs.add(field, "must be at most %d bytes, got %d", maxMemberBytes, len(member))
+	case !utf8.ValidString(member):
+		vs.add(field, "must be valid UTF-8")
+	}
+}
+
+// Validate returns InvalidArgument, with a BadRequest detail naming each
+// field at fault, unless request is a valid call of method. Calls outside
+// the KeyValueStore service are not checked.
+func (v *Validator) Validate(method string, request any) error {
+	if !strings.HasPrefix(method, "/"+kvpb.KeyValueStore_ServiceDesc.ServiceName+"/") {
+		return nil
+	}
+	var vs violations
+	if keyed, ok := request.(interface{ GetKey() string }); ok {
+		v.key(&vs, "key", keyed.GetKey(), creating[method])
+	}
+	switch r := request.(type) {
+	case *kvpb.SetKeyValueRequest:
+		v.value(&vs, "value", r.Value)
+		switch r.Mode {
+		case "", "create":
+			// Only an existing key has a version to compare against
+			if r.ExpectedVersion != 0 {
+				vs.add("expectedVersion", "needs mode update or upsert")
+			}
+		case "update", "upsert":
+		default:
+			vs.add("mode", "must be create, update or upsert, got %q", r.Mode)
+		}
+		if r.TtlMilliseconds < 0 {
+			vs.add("ttlMilliseconds", "must not be negative")
+		}
+	case *kvpb.BulkSetRequest:
+		v.value(&vs, "value", r.Value)
+		if r.Policy != "" && r.Policy != "skip" && r.Policy != "overwrite" {
+			vs.add("policy", "must be skip or overwrite, got %q", r.Policy)
+		}
+	case *kvpb.CounterRequest:
+		// Decrementing by the smallest int64 would negate it past the largest
+		if method == kvpb.KeyValueStore_Decrement_FullMethodName && r.Delta == math.MinInt64 {
+			vs.add("delta", "is out of range")
+		}
+		if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+			vs.add("min", "must not be above max")
+		}
+		if r.TtlSeconds < 0 {
+			vs.add("ttlSeconds", "must not be negative")
+		}
+	case *kvpb.HashSetRequest:
+		if len(r.Fields) == 0 {
+			vs.add("fields", "must not be empty")
+		}
+		// In field order, so the same request always reports the same way
+		for _, field := range slices.Sorted(maps.Keys(r.Fields)) {
+			v.member(&vs, fmt.Sprintf("fields[%q]", field), field)
+			v.value(&vs, fmt.Sprintf("fields[%q].value", field), r.Fields[field])
+		}
+	case *kvpb.HashGetRequest:
+		v.member(&vs, "field", r.Field)
+	case *kvpb.HashDeleteRequest:
+		if len(r.Fields) == 0 {
+			vs.add("fields", "must not be empty")
+		}
+		for i, field := range r.Fields {
+			v.member(&vs, fmt.Sprintf("fields[%d]", i), field)
+		}
+	case *kvpb.ListPushRequest:
+		if len(r.Values) == 0 {
+			vs.add("values", "must not be empty")
+		}
+		for i, value := range r.Values {
+			v.value(&vs, fmt.Sprintf("values[%d]", i), value)
+		}
+	case *kvpb.ListPopRequest:
+		if r.Count < 0 {
+			vs.add("count", "must not be negative")
+		}
+	case *kvpb.SetMembersRequest:
+		if len(r.Members) == 0 {
+			vs.add("members", "must not be empty")
+		}
+		for i, member := range r.Members {
+			v.member(&vs, fmt.Sprintf("members[%d]", i), member)
+		}
+	case *kvpb.SortedSetAddRequest:
+		if len(r.Members) == 0 {
+			vs.add("members", "must not be empty")
+		}
+		for i, scored := range r.Members {
+			v.member(&vs, fmt.Sprintf("members[%d].member", i), scored.GetMember())
+		}
+	case *kvpb.SortedSetRangeRequest:
+		if r.Offset < 0 {
+			vs.add("offset", "must not be negative")
+		}
+		if r.Limit < 0 {
+			vs.add("limit", "must not be negative")
+		}
+	}
+	if len(vs) == 0 {
+		return nil
+	}
+	descriptions := make([]string, len(vs))
+	for i, violation := range vs {
+		descriptions[i] = violation.Field + ": " + violation.Description
+	}
+	st, err := status.New(codes.InvalidArgument, strings.Join(descriptions, "; ")).
+		WithDetails(&errdetails.BadRequest{FieldViolations: vs})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, strings.Join(descriptions, "; "))
+	}
+	return st.Err()
+}
+
+func (v *Validator) UnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := v.Validate(info.FullMethod, req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// UnaryClientInterceptor checks calls before they are sent. The RESP and
+// memcached listeners carry arbitrary bytes that protobuf cannot marshal
+// unless they are UTF-8, and this reports them the same way the server does.
+func (v *Validator) UnaryClientInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if err := v.Validate(method, req); err != nil {
+		return err
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
@@ -0,0 +1,121 @@
+package validation
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	kvpb "github.com/kv-storage/proto/kv"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fields returns the fields err names, in the order it names them.
+func fields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("got %v, want InvalidArgument", err)
+	}
+	var names []string
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.FieldViolations {
+				names = append(names, violation.Field)
+				if !strings.Contains(st.Message(), violation.Field+": "+violation.Description) {
+					t.Errorf("message %q leaves out %s", st.Message(), violation.Field)
+				}
+			}
+		}
+	}
+	if names == nil {
+		t.Fatalf("%v carries no field violations", err)
+	}
+	return names
+}
+
+func TestValidate(t *testing.T) {
+	v, err := NewValidator(16, 8, `[a-z/]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		set       = kvpb.KeyValueStore_SetKeyValue_FullMethodName
+		get       = kvpb.KeyValueStore_GetKeyValue_FullMethodName
+		increment = kvpb.KeyValueStore_Increment_FullMethodName
+		decrement = kvpb.KeyValueStore_Decrement_FullMethodName
+		five, two = int64(5), int64(2)
+	)
+	tests := []struct {
+		name    string
+		method  string
+		request any
+		want    []string
+	}{
+		{"a valid write", set, &kvpb.SetKeyValueRequest{Key: "a/b", Value: "v", Mode: "update", ExpectedVersion: 3}, nil},
+		{"an empty key", set, &kvpb.SetKeyValueRequest{Value: "v"}, []string{"key"}},
+		{"a key too long", set, &kvpb.SetKeyValueRequest{Key: strings.Repeat("a", 17)}, []string{"key"}},
+		{"a key that is not UTF-8", get, &kvpb.GetKVRequest{Key: "a\xff"}, []string{"key"}},
+		{"a key with a control character", get, &kvpb.GetKVRequest{Key: "a\nb"}, []string{"key"}},
+		{"a new key outside the charset", set, &kvpb.SetKeyValueRequest{Key: "A-1"}, []string{"key"}},
+		{"an old key outside the charset can be read", get, &kvpb.GetKVRequest{Key: "A-1"}, nil},
+		{"an old key outside the charset can be deleted", kvpb.KeyValueStore_DeleteKeyValue_FullMethodName, &kvpb.DeleteKeyValueRequest{Key: "A-1"}, nil},
+		{"a value too long", set, &kvpb.SetKeyValueRequest{Key: "k", Value: "123456789"}, []string{"value"}},
+		{"a value that is not UTF-8", set, &kvpb.SetKeyValueRequest{Key: "k", Value: "\xff"}, []string{"value"}},
+		{"an unknown mode", set, &kvpb.SetKeyValueRequest{Key: "k", Mode: "replace"}, []string{"mode"}},
+		{"a version without a key to compare", set, &kvpb.SetKeyValueRequest{Key: "k", Mode: "create", ExpectedVersion: 3}, []string{"expectedVersion"}},
+		{"a version with the default mode", set, &kvpb.SetKeyValueRequest{Key: "k", ExpectedVersion: 3}, []string{"expectedVersion"}},
+		{"a negative TTL", set, &kvpb.SetKeyValueRequest{Key: "k", TtlMilliseconds: -1}, []string{"ttlMilliseconds"}},
+		{"every field at fault", set, &kvpb.SetKeyValueRequest{Value: "123456789", Mode: "replace", TtlMilliseconds: -1}, []string{"key", "value", "mode", "ttlMilliseconds"}},
+		{"an unknown bulk policy", kvpb.KeyValueStore_BulkSet_FullMethodName, &kvpb.BulkSetRequest{Key: "k", Policy: "merge"}, []string{"policy"}},
+		{"a valid counter", increment, &kvpb.CounterRequest{Key: "k", Delta: math.MinInt64, Min: &two, Max: &five}, nil},
+		{"a decrement that cannot be negated", decrement, &kvpb.CounterRequest{Key: "k", Delta: math.MinInt64}, []string{"delta"}},
+		{"bounds the wrong way round", increment, &kvpb.CounterRequest{Key: "k", Min: &five, Max: &two}, []string{"min"}},
+		{"a counter with a negative TTL", increment, &kvpb.CounterRequest{Key: "k", TtlSeconds: -1}, []string{"ttlSeconds"}},
+		{"no hash fields", kvpb.KeyValueStore_HashSet_FullMethodName, &kvpb.HashSetRequest{Key: "k"}, []string{"fields"}},
+		{"hash fields in order", kvpb.KeyValueStore_HashSet_FullMethodName, &kvpb.HashSetRequest{Key: "k", Fields: map[string]string{
+			"b": "123456789", strings.Repeat("a", 256): "v",
+		}}, []string{`fields["` + strings.Repeat("a", 256) + `"]`, `fields["b"].value`}},
+		{"no fields to delete", kvpb.KeyValueStore_HashDelete_FullMethodName, &kvpb.HashDeleteRequest{Key: "k"}, []string{"fields"}},
+		{"nothing to push", kvpb.KeyValueStore_ListPush_FullMethodName, &kvpb.ListPushRequest{Key: "k"}, []string{"values"}},
+		{"a pushed value too long", kvpb.KeyValueStore_ListPush_FullMethodName, &kvpb.ListPushRequest{Key: "k", Values: []string{"v", "123456789"}}, []string{"values[1]"}},
+		{"a negative pop", kvpb.KeyValueStore_ListPop_FullMethodName, &kvpb.ListPopRequest{Key: "k", Count: -1}, []string{"count"}},
+		{"no members", kvpb.KeyValueStore_SetAdd_FullMethodName, &kvpb.SetMembersRequest{Key: "k"}, []string{"members"}},
+		{"no scored members", kvpb.KeyValueStore_SortedSetAdd_FullMethodName, &kvpb.SortedSetAddRequest{Key: "k"}, []string{"members"}},
+		{"a scored member not UTF-8", kvpb.KeyValueStore_SortedSetAdd_FullMethodName, &kvpb.SortedSetAddRequest{Key: "k", Members: []*kvpb.ScoredMember{{Member: "\xff"}}}, []string{"members[0].member"}},
+		{"a negative range", kvpb.KeyValueStore_SortedSetRangeByScore_FullMethodName, &kvpb.SortedSetRangeRequest{Key: "k", Offset: -1, Limit: -1}, []string{"offset", "limit"}},
+		{"another service is not checked", kvpb.Locks_Lock_FullMethodName, &kvpb.LockRequest{}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := fields(t, v.Validate(test.method, test.request)); !slices.Equal(got, test.want) {
+				t.Fatalf("violations %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewValidator(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxKeyBytes int
+		keyCharset  string
+		wantErr     bool
+	}{
+		{"defaults", 255, "", false},
+		{"a charset", 64, `[A-Za-z0-9/_.:-]`, false},
+		{"no key fits", 0, "", true},
+		{"keys longer than the column", 256, "", true},
+		{"a charset that does not compile", 64, `[a-z`, true},
+	}
+	for _, test := range tests {
+		if _, err := NewValidator(test.maxKeyBytes, 0, test.keyCharset); (err != nil) != test.wantErr {
+			t.Errorf("%s: got %v, want an error %v", test.name, err, test.wantErr)
+		}
+	}
+}
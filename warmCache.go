@@ -0,0 +1,32 @@
+package main
+
+import (
+	cacheModule "github.com/kv-storage/cache"
+	"github.com/kv-storage/model"
+	"gorm.io/gorm"
+)
+
+// warmCache fills the cache with the newest string keys, so a restarted node
+// does not send its first wave of reads straight to the database.
+func warmCache(db *gorm.DB, limit int) error {
+	var keys []string
+	err := db.Model(&model.KV{}).
+		Where("type = ? AND expires_at IS NULL", model.TypeString).
+		Order("id DESC").Limit(limit).Pluck("key_name", &keys).Error
+	if err != nil || len(keys) == 0 {
+		return err
+	}
+	// Take the generations before reading, so a write racing with us wins over our fill
+	generations := make(map[string]uint64, len(keys))
+	for _, key := range keys {
+		generations[key] = cache.Generation(key)
+	}
+	var rows []model.KV
+	if err := db.Where("key_name IN ? AND type = ? AND expires_at IS NULL", keys, model.TypeString).Find(&rows).Error; err != nil {
+		return err
+	}
+	for _, kv := range rows {
+		cache.Fill(kv.Key, cacheModule.Entry{Value: kv.Value, Version: kv.Version, Flags: kv.Flags}, generations[kv.Key])
+	}
+	return nil
+}
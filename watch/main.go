@@ -0,0 +1,208 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	kvpb "github.com/kv-storage/proto/kv"
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const keepAliveInterval = 15 * time.Second
+
+// event is one key change as browsers receive it.
+type event struct {
+	Sequence  uint64 `json:"sequence"`
+	Op        string `json:"op"`
+	Key       string `json:"key"`
+	Value     string `json:"value,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// Handler serves the change feed to browsers, as Server-Sent Events or, when
+// the request asks to upgrade, over a WebSocket. Both resume after the
+// sequence in Last-Event-ID, or the lastEventId query parameter for
+// WebSocket clients that cannot set headers, and start from now without one.
+type Handler struct {
+	feed   kvpb.ChangeFeedClient
+	logger *zap.Logger
+
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+func NewHandler(feed kvpb.ChangeFeedClient, logger *zap.Logger) *Handler {
+	return &Handler{feed: feed, logger: logger, closing: make(chan struct{})}
+}
+
+// Close ends every open watch. Watches never go idle, so an HTTP server
+// shutting down would otherwise wait on them until its deadline.
+func (h *Handler) Close() {
+	h.closeOnce.Do(func() { close(h.closing) })
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	var after uint64
+	if lastEventID != "" {
+		var err error
+		if after, err = strconv.ParseUint(lastEventID, 10, 64); err != nil {
+			http.Error(w, "Last-Event-ID must be a change sequence number", http.StatusBadRequest)
+			return
+		}
+	}
+	request := &kvpb.ReadChangesRequest{
+		AfterSequence: after,
+		Prefix:        r.URL.Query().Get("prefix"),
+		Follow:        true,
+		FromNow:       after == 0,
+	}
+
+	// Authenticate as the browser: EventSource and WebSocket cannot set headers, hence access_token
+	authorization := r.Header.Get("Authorization")
+	if token := r.URL.Query().Get("access_token"); token != "" && authorization == "" {
+		authorization = "Bearer " + token
+	}
+	var credentials []string
+	if authorization != "" {
+		credentials = append(credentials, "authorization", authorization)
+	}
+	if apiKey := r.Header.Get("X-Api-Key"); apiKey != "" {
+		credentials = append(credentials, "x-api-key", apiKey)
+	}
+	r = r.WithContext(metadata.AppendToOutgoingContext(r.Context(), credentials...))
+
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		// A Server without a Handshake accepts any Origin, as the SSE route does
+		server := websocket.Server{Handler: func(conn *websocket.Conn) {
+			h.serveWebSocket(conn, request)
+		}}
+		server.ServeHTTP(w, r)
+		return
+	}
+	h.serveEvents(w, r, request)
+}
+
+// changes relays the feed from a goroutine, so callers can wait on it
+// alongside their own timers. The error channel reports why it ended.
+func (h *Handler) changes(ctx context.Context, request *kvpb.ReadChangesRequest) (<-chan *kvpb.ChangeEvent, <-chan error) {
+	events := make(chan *kvpb.ChangeEvent)
+	failed := make(chan error, 1)
+	go func() {
+		stream, err := h.feed.ReadChanges(ctx, request)
+		if err != nil {
+			failed <- err
+			return
+		}
+		for {
+			change, err := stream.Recv()
+			if err != nil {
+				failed <- err
+				return
+			}
+			select {
+			case events <- change:
+			case <-ctx.Done():
+				failed <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return events, failed
+}
+
+func toEvent(change *kvpb.ChangeEvent) event {
+	return event{
+		Sequence:  change.Sequence,
+		Op:        change.Op,
+		Key:       change.Key,
+		Value:     change.Value,
+		Timestamp: change.Timestamp,
+	}
+}
+
+func (h *Handler) serveEvents(w http.ResponseWriter, r *http.Request, request *kvpb.ReadChangesRequest) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Stops nginx from holding events back in its buffer
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	events, failed := h.changes(ctx, request)
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case change := <-events:
+			data, err := json.Marshal(toEvent(change))
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "id: %d\ndata: %s\n\n", change.Sequence, data)
+		case err := <-failed:
+			if ctx.Err() == nil {
+				// Most likely the resume point was pruned; the client has to start over
+				fmt.Fprintf(w, "event: error\ndata: %s\n\n", status.Convert(err).Message())
+				flusher.Flush()
+			}
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case <-h.closing:
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func (h *Handler) serveWebSocket(conn *websocket.Conn, request *kvpb.ReadChangesRequest) {
+	defer conn.Close()
+	ctx, cancel := context.WithCancel(conn.Request().Context())
+	defer cancel()
+	// Nothing is expected from the client; reading only notices that it left
+	go func() {
+		defer cancel()
+		var ignored string
+		for websocket.Message.Receive(conn, &ignored) == nil {
+		}
+	}()
+
+	events, failed := h.changes(ctx, request)
+	for {
+		select {
+		case change := <-events:
+			if err := websocket.JSON.Send(conn, toEvent(change)); err != nil {
+				return
+			}
+		case err := <-failed:
+			if ctx.Err() == nil {
+				websocket.JSON.Send(conn, map[string]string{"error": status.Convert(err).Message()})
+			} else {
+				h.logger.Debug("Watch closed", zap.Error(err))
+			}
+			return
+		case <-h.closing:
+			return
+		}
+	}
+}
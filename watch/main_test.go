@@ -0,0 +1,130 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	kvpb "github.com/kv-storage/proto/kv"
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// feed replays two changes after the requested sequence, then fails as a
+// pruned resume point does.
+type feed struct {
+	kvpb.ChangeFeedClient
+
+	mu      sync.Mutex
+	request *kvpb.ReadChangesRequest
+}
+
+func (f *feed) ReadChanges(ctx context.Context, request *kvpb.ReadChangesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[kvpb.ChangeEvent], error) {
+	f.mu.Lock()
+	f.request = request
+	f.mu.Unlock()
+	return &changes{events: []*kvpb.ChangeEvent{
+		{Sequence: request.AfterSequence + 1, Op: "set", Key: "a", Value: "1"},
+		{Sequence: request.AfterSequence + 2, Op: "delete", Key: "a"},
+	}}, nil
+}
+
+type changes struct {
+	grpc.ClientStream
+	events []*kvpb.ChangeEvent
+}
+
+func (c *changes) Recv() (*kvpb.ChangeEvent, error) {
+	if len(c.events) == 0 {
+		return nil, status.Error(codes.OutOfRange, "resume point pruned")
+	}
+	event := c.events[0]
+	c.events = c.events[1:]
+	return event, nil
+}
+
+func TestServerSentEvents(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		lastEventID string
+		wantCode    int
+		wantAfter   uint64
+		wantFromNow bool
+	}{
+		{"from now", "", "", http.StatusOK, 0, true},
+		{"resuming from the header", "", "5", http.StatusOK, 5, false},
+		{"resuming from the query", "?lastEventId=7&prefix=users/", "", http.StatusOK, 7, false},
+		{"the header wins", "?lastEventId=7", "5", http.StatusOK, 5, false},
+		{"a bad resume point", "", "five", http.StatusBadRequest, 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := &feed{}
+			server := httptest.NewServer(NewHandler(f, zap.NewNop()))
+			defer server.Close()
+			request, err := http.NewRequest(http.MethodGet, server.URL+test.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.lastEventID != "" {
+				request.Header.Set("Last-Event-ID", test.lastEventID)
+			}
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := io.ReadAll(response.Body)
+			response.Body.Close()
+			if err != nil || response.StatusCode != test.wantCode {
+				t.Fatalf("got %d, %v, want %d", response.StatusCode, err, test.wantCode)
+			}
+			if test.wantCode != http.StatusOK {
+				return
+			}
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if f.request.AfterSequence != test.wantAfter || f.request.FromNow != test.wantFromNow || !f.request.Follow {
+				t.Fatalf("read the feed with %v", f.request)
+			}
+			if strings.Contains(test.query, "prefix=users/") && f.request.Prefix != "users/" {
+				t.Fatalf("read the feed with %v, want the prefix", f.request)
+			}
+			want := fmt.Sprintf("id: %[1]d\ndata: {\"sequence\":%[1]d,\"op\":\"set\",\"key\":\"a\",\"value\":\"1\",\"timestamp\":0}\n\n"+
+				"id: %[2]d\ndata: {\"sequence\":%[2]d,\"op\":\"delete\",\"key\":\"a\",\"timestamp\":0}\n\n"+
+				"event: error\ndata: resume point pruned\n\n", test.wantAfter+1, test.wantAfter+2)
+			if string(body) != want {
+				t.Fatalf("got %q, want %q", body, want)
+			}
+		})
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	f := &feed{}
+	server := httptest.NewServer(NewHandler(f, zap.NewNop()))
+	defer server.Close()
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/?lastEventId=5&access_token=t", "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for _, want := range []uint64{6, 7} {
+		var got event
+		if err := websocket.JSON.Receive(conn, &got); err != nil || got.Sequence != want {
+			t.Fatalf("got %v, %v, want sequence %d", got, err, want)
+		}
+	}
+	var failure map[string]string
+	if err := websocket.JSON.Receive(conn, &failure); err != nil || failure["error"] != "resume point pruned" {
+		t.Fatalf("got %v, %v, want the feed's error", failure, err)
+	}
+}